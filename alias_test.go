@@ -0,0 +1,136 @@
+package god
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// timestamp marshals itself as a plain RFC 3339 string and leaves naming
+// its type to an alias.
+type timestamp struct{ time.Time }
+
+func (s timestamp) MarshalGOD() ([]byte, error) {
+	return MarshalValue(s.Format(time.RFC3339))
+}
+
+func (s *timestamp) UnmarshalGOD(data []byte) error {
+	var text string
+	if err := UnmarshalValue(data, &text); err != nil {
+		return err
+	}
+	t, err := time.Parse(time.RFC3339, text)
+	s.Time = t
+	return err
+}
+
+// userID is encoded as an ordinary integer.
+type userID int
+
+func (u *userID) UnmarshalGOD(data []byte) error {
+	n, err := strconv.Atoi(string(data))
+	*u = userID(n)
+	return err
+}
+
+func init() {
+	RegisterAlias("timestamp", reflect.TypeOf(timestamp{}))
+	RegisterAlias("uid", reflect.TypeOf(userID(0)))
+}
+
+type login struct {
+	At   timestamp `god:"at"`
+	User userID    `god:"user"`
+	Host string    `god:"host"`
+}
+
+var loginDay = timestamp{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+func TestUseAliases(t *testing.T) {
+	v := login{At: loginDay, User: 7, Host: "a"}
+	plain, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{at="2024-01-01T00:00:00Z";user=7;host="a"}`; string(plain) != want {
+		t.Errorf("without UseAliases got %s, want %s", plain, want)
+	}
+
+	data, err := MarshalWith(v, UseAliases())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{at=@timestamp"2024-01-01T00:00:00Z";user=@uid 7;host="a"}`
+	if string(data) != want {
+		t.Fatalf("got %s, want %s", data, want)
+	}
+
+	var back login
+	if err := Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !back.At.Equal(loginDay.Time) || back.User != 7 {
+		t.Errorf("typed decode = %+v", back)
+	}
+
+	var generic map[string]interface{}
+	if err := Unmarshal(data, &generic); err != nil {
+		t.Fatal(err)
+	}
+	if at, ok := generic["at"].(timestamp); !ok || !at.Equal(loginDay.Time) {
+		t.Errorf("at = %#v, want a timestamp", generic["at"])
+	}
+	if user, ok := generic["user"].(userID); !ok || user != 7 {
+		t.Errorf("user = %#v, want a userID", generic["user"])
+	}
+}
+
+func TestUseAliasesTableCells(t *testing.T) {
+	rows := []login{{At: loginDay, User: 1, Host: "a"}, {At: loginDay, User: 2, Host: "b"}}
+	data, err := MarshalWith(map[string]interface{}{"logins": rows}, UseAliases())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{logins=(at,user,host:@timestamp"2024-01-01T00:00:00Z",@uid 1,"a";@timestamp"2024-01-01T00:00:00Z",@uid 2,"b";)}`
+	if string(data) != want {
+		t.Fatalf("got %s, want %s", data, want)
+	}
+	var back struct {
+		Logins []login `god:"logins"`
+	}
+	if err := Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back.Logins, rows) {
+		t.Errorf("got %+v, want %+v", back.Logins, rows)
+	}
+}
+
+func TestAnnotatedMarshalerKeepsItsAnnotation(t *testing.T) {
+	data, err := MarshalWith(reading{At: day}, UseAliases())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{at=@time"2024-01-01T00:00:00Z";temp=;note=}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+func TestRegisterAliasPanics(t *testing.T) {
+	for name, f := range map[string]func(){
+		"taken name":     func() { RegisterAlias("time", reflect.TypeOf(celsius(0))) },
+		"second alias":   func() { RegisterAlias("moment", reflect.TypeOf(timestamp{})) },
+		"no unmarshaler": func() { RegisterAlias("plain", reflect.TypeOf(login{})) },
+		"invalid name":   func() { RegisterAlias("bad name", reflect.TypeOf(celsius(0))) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
@@ -0,0 +1,100 @@
+package god
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// writeTableRows writes the encoded cells of a beautified table, a row to
+// a line. With align each cell is padded so that the next one starts in
+// the same column on every row; cells that span lines neither count
+// towards the width of their column nor get padded. With a maximum width
+// set, a row that would run past it goes on after a separator on a new
+// line, indented one level deeper, which decoders read as whitespace. A
+// cell is never split, so a single wide cell may still overflow.
+func writeTableRows(b *encodeState, rows [][]string, level int, sep byte, align bool) {
+	var widths []int
+	for _, row := range rows {
+		for k, cell := range row {
+			if !align {
+				break
+			}
+			if k == len(widths) {
+				widths = append(widths, 0)
+			}
+			if w := cellWidth(cell); w > widths[k] {
+				widths[k] = w
+			}
+		}
+	}
+
+	for _, row := range rows {
+		b.WriteString(b.indent(level))
+		col := len(b.indent(level))
+		pad := 0 // spaces owed after the previous cell
+		for k, cell := range row {
+			w, fill := cellWidth(cell), 0
+			if align && w >= 0 {
+				fill = widths[k] - w
+			}
+			lead := 0 // spaces before the cell, for right-aligned numbers
+			if b.rightAlignNumbers && isNumericCell(cell) {
+				lead, fill = fill, 0
+			}
+			if k > 0 {
+				b.WriteByte(sep)
+				col++
+				if align {
+					pad++
+				}
+				// The cell is followed by a separator or the ';'
+				// that ends the row.
+				if b.maxWidth > 0 && col+pad+lead+firstLineWidth(cell)+1 > b.maxWidth {
+					b.WriteString("\n" + b.indent(level+1))
+					col, pad, lead = len(b.indent(level+1)), 0, 0
+				}
+			}
+			b.WriteString(strings.Repeat(" ", pad+lead))
+			b.WriteString(cell)
+			if i := strings.LastIndexByte(cell, '\n'); i >= 0 {
+				col = utf8.RuneCountInString(cell[i+1:])
+			} else {
+				col += pad + lead + w
+			}
+			pad = fill
+		}
+		b.WriteString(";\n")
+	}
+}
+
+// keyWidth returns the number of characters key takes once encoded.
+func (b *encodeState) keyWidth(key string) int {
+	if !b.isBareKey(key) {
+		key = b.quote(key)
+	}
+	return utf8.RuneCountInString(key)
+}
+
+// firstLineWidth returns the number of characters in the first line of an
+// encoded cell.
+func firstLineWidth(cell string) int {
+	if i := strings.IndexByte(cell, '\n'); i >= 0 {
+		cell = cell[:i]
+	}
+	return utf8.RuneCountInString(cell)
+}
+
+// cellWidth returns the number of characters in an encoded cell, or -1
+// if it spans lines.
+func cellWidth(cell string) int {
+	if strings.Contains(cell, "\n") {
+		return -1
+	}
+	return utf8.RuneCountInString(cell)
+}
+
+func isNumericCell(cell string) bool {
+	_, err := strconv.ParseFloat(cell, 64)
+	return err == nil
+}
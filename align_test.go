@@ -0,0 +1,154 @@
+package god
+
+import (
+	"reflect"
+	"testing"
+)
+
+type alignedRow struct {
+	ID     int     `god:"id"`
+	Name   string  `god:"name"`
+	Score  float64 `god:"score"`
+	Active bool    `god:"active"`
+	Note   *string `god:"note"`
+}
+
+func alignedRows() []alignedRow {
+	note := "on leave"
+	return []alignedRow{
+		{ID: 1, Name: "Al", Score: 9.5, Active: true},
+		{ID: 1024, Name: "Bartholomew", Score: 12.25, Note: &note},
+		{ID: 37, Name: "Zoë", Score: 100},
+	}
+}
+
+func TestAlignTableColumns(t *testing.T) {
+	data, err := MarshalWith(map[string]interface{}{"rows": alignedRows()}, Beautify(), AlignTableColumns())
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := `{
+  rows=(id,name,score,active,note:
+    1,    "Al",          9.5,   true, \0;
+    1024, "Bartholomew", 12.25, ,     "on leave";
+    37,   "Zoë",         100,   ,     \0;
+  );
+}`
+	if string(data) != golden {
+		t.Errorf("got:\n%s\nwant:\n%s", data, golden)
+	}
+
+	var out struct {
+		Rows []alignedRow `god:"rows"`
+	}
+	if err := Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out.Rows, alignedRows()) {
+		t.Errorf("round trip = %+v", out.Rows)
+	}
+}
+
+func TestAlignTableColumnsRightAlignNumbers(t *testing.T) {
+	data, err := MarshalWith(alignedRows(), Beautify(), AlignTableColumns(), RightAlignNumbers())
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := `{
+  (id,name,score,active,note:
+       1, "Al",            9.5, true, \0;
+    1024, "Bartholomew", 12.25, ,     "on leave";
+      37, "Zoë",           100, ,     \0;
+  )
+}`
+	if string(data) != golden {
+		t.Errorf("got:\n%s\nwant:\n%s", data, golden)
+	}
+	var out []alignedRow
+	if err := Unmarshal(data, &out); err != nil || !reflect.DeepEqual(out, alignedRows()) {
+		t.Errorf("round trip = %+v, %v", out, err)
+	}
+}
+
+func TestAlignTableColumnsCompact(t *testing.T) {
+	aligned, err := MarshalWith(alignedRows(), AlignTableColumns())
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, _ := Marshal(alignedRows())
+	if string(aligned) != string(plain) {
+		t.Errorf("compact output changed by alignment:\n%s\n%s", aligned, plain)
+	}
+}
+
+type alignedConfig struct {
+	Name      string            `god:"name"`
+	TimeoutMS int               `god:"timeout_ms"`
+	Labels    map[string]string `god:"labels"`
+	DB        struct {
+		Host string `god:"host"`
+		Port int    `god:"port"`
+	} `god:"db"`
+}
+
+func TestAlignAssignments(t *testing.T) {
+	var cfg alignedConfig
+	cfg.Name = "api"
+	cfg.TimeoutMS = 5000
+	cfg.Labels = map[string]string{"team": "core", "cost center": "42"}
+	cfg.DB.Host = "db.local"
+	cfg.DB.Port = 5432
+
+	data, err := MarshalWith(cfg, Beautify(), AlignAssignments())
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := `{
+  name       = "api";
+  timeout_ms = 5000;
+  labels     = {
+    "cost center" = "42";
+    team          = "core";
+  };
+  db         = {
+    host = "db.local";
+    port = 5432;
+  };
+}`
+	if string(data) != golden {
+		t.Errorf("got:\n%s\nwant:\n%s", data, golden)
+	}
+
+	var out alignedConfig
+	if err := Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, cfg) {
+		t.Errorf("round trip = %+v, want %+v", out, cfg)
+	}
+	var generic map[string]interface{}
+	if err := Unmarshal(data, &generic); err != nil || len(generic) != 4 {
+		t.Errorf("generic decode = %v, %v", generic, err)
+	}
+	if doc, err := ParseDocument(data); err != nil || doc.Root().Len() != 4 {
+		t.Errorf("ParseDocument = %v", err)
+	}
+
+	compact, _ := MarshalWith(cfg, AlignAssignments())
+	plain, _ := Marshal(cfg)
+	if string(compact) != string(plain) {
+		t.Errorf("compact output changed by alignment:\n%s\n%s", compact, plain)
+	}
+}
+
+func TestSpacesAroundAssignments(t *testing.T) {
+	src := "{name   =\"x\";\"quoted key\"\t= 1;\n  port\n  =\n  80}"
+	var m map[string]interface{}
+	if err := Unmarshal([]byte(src), &m); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"name": "x", "quoted key": int64(1), "port": int64(80)}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("got %v, want %v", m, want)
+	}
+}
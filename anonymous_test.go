@@ -0,0 +1,95 @@
+package god
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnonymousStructs(t *testing.T) {
+	in := map[string]interface{}{
+		"point": struct{ X, Y int }{1, 2},
+		"path":  []struct{ X, Y int }{{1, 2}, {3, 4}},
+	}
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{path=(x,y:1,2;3,4;);point={x=1;y=2}}`; string(data) != want {
+		t.Fatalf("got %s, want %s", data, want)
+	}
+
+	var out struct {
+		Point struct{ X, Y int }
+		Path  []struct {
+			X int `god:"x"`
+			Y int `god:"y"`
+		}
+	}
+	if err := Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Point.X != 1 || out.Point.Y != 2 || len(out.Path) != 2 || out.Path[1].X != 3 || out.Path[1].Y != 4 {
+		t.Errorf("got %+v", out)
+	}
+
+	var generic map[string]interface{}
+	if err := Unmarshal(data, &generic); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"point": map[string]interface{}{"x": int64(1), "y": int64(2)},
+		"path": []map[string]interface{}{
+			{"x": int64(1), "y": int64(2)},
+			{"x": int64(3), "y": int64(4)},
+		},
+	}
+	if !reflect.DeepEqual(generic, want) {
+		t.Errorf("generic decode = %#v, want %#v", generic, want)
+	}
+}
+
+func TestAnonymousStructRoot(t *testing.T) {
+	var out struct {
+		Name string `god:"name"`
+		Age  int    `god:"age"`
+	}
+	if err := Unmarshal([]byte(`{name="Ann";age=41}`), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "Ann" || out.Age != 41 {
+		t.Errorf("got %+v", out)
+	}
+
+	var rows []struct{ ID int }
+	if err := Unmarshal([]byte(`{(id:1;2;)}`), &rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[1].ID != 2 {
+		t.Errorf("got %+v", rows)
+	}
+}
+
+func TestDuplicateFieldKeys(t *testing.T) {
+	type clash struct {
+		ID int
+		Id int
+	}
+	for name, f := range map[string]func() error{
+		"object": func() error { _, err := Marshal(clash{1, 2}); return err },
+		"table":  func() error { _, err := Marshal([]clash{{1, 2}}); return err },
+		"decode": func() error { var v clash; return Unmarshal([]byte(`{id=1}`), &v) },
+		"decode table": func() error {
+			var v []struct {
+				A int
+				B int `god:"a"`
+			}
+			return Unmarshal([]byte(`{(a:1;)}`), &v)
+		},
+	} {
+		err := f()
+		if err == nil || !strings.Contains(err.Error(), `both have the key`) {
+			t.Errorf("%s: got error %v, want a duplicate key error", name, err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package god
+
+import (
+	"errors"
+	"reflect"
+	"sync/atomic"
+)
+
+// An atomic.Value is encoded as the value it holds, or grounded if it
+// holds none. Decoding stores a new value in it. What it holds is an
+// interface{}, so a struct loses its type on the way: decoding gives a
+// map unless the atomic.Value already holds a value, whose type is then
+// decoded into, or the struct was written with
+// MarshalOptions.TypeAnnotations and its name is in
+// UnmarshalOptions.TypeRegistry.
+
+var atomicValueType = reflect.TypeOf((*atomic.Value)(nil)).Elem()
+
+// atomicValueOf returns the *atomic.Value held in v, copying v first if
+// it is not addressable.
+func atomicValueOf(v reflect.Value) *atomic.Value {
+	if !v.CanAddr() {
+		tmp := reflect.New(atomicValueType)
+		tmp.Elem().Set(v)
+		v = tmp.Elem()
+	}
+	return v.Addr().Interface().(*atomic.Value)
+}
+
+// atomicLoad returns the value the atomic.Value in v holds, as an
+// interface, so that it is encoded as any value held in an interface{}
+// is, type annotation included.
+func atomicLoad(v reflect.Value) reflect.Value {
+	x := atomicValueOf(v).Load()
+	return reflect.ValueOf(&x).Elem()
+}
+
+// decodeAtomicValue decodes a value into a new value of the type the
+// atomic.Value target holds, or generically if it holds none, and stores
+// it in target.
+func decodeAtomicValue(p *parser, target reflect.Value) error {
+	if !target.CanAddr() {
+		return errors.New("cannot decode into an atomic.Value that is not addressable")
+	}
+	av := target.Addr().Interface().(*atomic.Value)
+	var v reflect.Value
+	if old := av.Load(); old != nil {
+		v = reflect.New(reflect.TypeOf(old)).Elem()
+	} else {
+		var x interface{}
+		v = reflect.ValueOf(&x).Elem()
+	}
+	if err := decodeValue(p, v); err != nil {
+		return err
+	}
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return errors.New("cannot store a grounded value in an atomic.Value")
+	}
+	av.Store(v.Interface())
+	return nil
+}
@@ -0,0 +1,122 @@
+package god
+
+import (
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+type atomicConfig struct {
+	Name    string
+	Retries int
+	Hosts   []string
+}
+
+type atomicHolder struct {
+	Version int
+	Current atomic.Value
+}
+
+func TestAtomicValueRoundTrip(t *testing.T) {
+	cfg := atomicConfig{Name: "prod", Retries: 3, Hosts: []string{"a", "b"}}
+	var in atomicHolder
+	in.Version = 2
+	in.Current.Store(cfg)
+
+	opts := []Option{TypeAnnotations(), WithTypeRegistry(map[string]reflect.Type{
+		"atomicConfig": reflect.TypeOf(atomicConfig{}),
+	})}
+	out, err := MarshalWith(&in, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{version=2;current={@type="atomicConfig";name="prod";retries=3;hosts=["a","b"]}}`; string(out) != want {
+		t.Errorf("got  %s\nwant %s", out, want)
+	}
+
+	var back atomicHolder
+	if err := UnmarshalWith(out, &back, opts...); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := back.Current.Load().(atomicConfig)
+	if !ok || !reflect.DeepEqual(got, cfg) || back.Version != 2 {
+		t.Errorf("got %#v, want %#v", back.Current.Load(), cfg)
+	}
+}
+
+func TestAtomicValueKeepsStoredType(t *testing.T) {
+	var v atomic.Value
+	v.Store(&atomicConfig{Name: "old"})
+	if err := Unmarshal([]byte(`{name="new";retries=1}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := v.Load().(*atomicConfig)
+	if !ok || got.Name != "new" || got.Retries != 1 {
+		t.Errorf("got %#v", v.Load())
+	}
+
+	out, err := Marshal(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{name="new";retries=1;hosts=}`; string(out) != want {
+		t.Errorf("got %s, want %s", out, want)
+	}
+}
+
+func TestAtomicValueGeneric(t *testing.T) {
+	var in atomicHolder
+	in.Current.Store([]int{1, 2})
+	out, err := Marshal(&in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back atomicHolder
+	if err := Unmarshal(out, &back); err != nil {
+		t.Fatal(err)
+	}
+	if got := back.Current.Load(); !reflect.DeepEqual(got, []interface{}{int64(1), int64(2)}) {
+		t.Errorf("got %#v", got)
+	}
+
+	// Without a type hint a struct comes back as a map.
+	in.Current = atomic.Value{}
+	in.Current.Store(atomicConfig{Name: "x"})
+	out, _ = Marshal(&in)
+	back = atomicHolder{}
+	if err := Unmarshal(out, &back); err != nil {
+		t.Fatal(err)
+	}
+	if m, ok := back.Current.Load().(map[string]interface{}); !ok || m["name"] != "x" {
+		t.Errorf("got %#v", back.Current.Load())
+	}
+}
+
+func TestAtomicValueEmpty(t *testing.T) {
+	var in atomicHolder
+	out, err := Marshal(&in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{version=;current=}`; string(out) != want {
+		t.Errorf("got %s, want %s", out, want)
+	}
+	var back atomicHolder
+	if err := Unmarshal(out, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.Current.Load() != nil {
+		t.Errorf("got %#v, want an empty atomic.Value", back.Current.Load())
+	}
+}
+
+func TestAtomicValueTypeMismatch(t *testing.T) {
+	var v atomic.Value
+	v.Store(5)
+	if err := Unmarshal([]byte(`{name="x"}`), &v); err == nil {
+		t.Error("expected an error decoding an object into an int")
+	}
+	if v.Load() != 5 {
+		t.Errorf("got %#v, want the old value kept", v.Load())
+	}
+}
@@ -0,0 +1,256 @@
+package god
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func benchPeople(n int) []Person {
+	people := make([]Person, n)
+	for i := range people {
+		people[i] = Person{
+			Name:    fmt.Sprintf("person-%d", i),
+			Age:     20 + i%50,
+			Address: fmt.Sprintf("%d Main Street", i),
+		}
+	}
+	return people
+}
+
+func benchCompany(n int) Company {
+	return Company{
+		Name:      "TechCorp",
+		Founded:   2020,
+		Employees: benchPeople(n),
+	}
+}
+
+func BenchmarkMarshalStruct(b *testing.B) {
+	cases := []struct {
+		name string
+		v    Company
+	}{
+		{"small", benchCompany(3)},
+		{"large", benchCompany(10000)},
+	}
+	for _, c := range cases {
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Marshal(c.v); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkMarshalTable(b *testing.B) {
+	cases := []struct {
+		name string
+		v    []Person
+	}{
+		{"small", benchPeople(3)},
+		{"large", benchPeople(10000)},
+	}
+	for _, c := range cases {
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Marshal(c.v); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkUnmarshalStruct(b *testing.B) {
+	cases := []struct {
+		name string
+		v    Company
+	}{
+		{"small", benchCompany(3)},
+		{"large", benchCompany(10000)},
+	}
+	for _, c := range cases {
+		data, err := Marshal(c.v)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				var out Company
+				if err := Unmarshal(data, &out); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkUnmarshalTable(b *testing.B) {
+	cases := []struct {
+		name string
+		v    []Person
+	}{
+		{"small", benchPeople(3)},
+		{"large", benchPeople(10000)},
+	}
+	for _, c := range cases {
+		data, err := Marshal(c.v)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				var out []Person
+				if err := Unmarshal(data, &out); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// benchDocument builds a document of at least size bytes shaped like an
+// event log: a list of objects with nested objects, lists and a table.
+func benchDocument(size int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("{events=[")
+	for i := 0; buf.Len() < size; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, `{id=%d;kind="click";user={name="user-%d";admin=%t};score=%d.5;tags=["a","b\\t"];hits=(path,n:"/home",%d;"/about",1)}`,
+			i, i%1000, i%7 == 0, i%100, i)
+	}
+	buf.WriteString("]}")
+	return buf.Bytes()
+}
+
+func BenchmarkGenericDecode(b *testing.B) {
+	data := benchDocument(10 << 20)
+	b.Run("map", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			var v interface{}
+			if err := Unmarshal(data, &v); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("document", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			if _, err := ParseDocument(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// benchStatusTable builds a table of rows rows whose string columns take
+// only a handful of values, like the status and region of an order log.
+func benchStatusTable(rows int) []byte {
+	statuses := []string{"pending", "shipped", "delivered", "cancelled"}
+	regions := []string{"eu-west", "us-east", "ap-south"}
+	var buf bytes.Buffer
+	buf.WriteString("{orders=(id,status,region,total:")
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&buf, "%d,%q,%q,%d.25;", i, statuses[i%len(statuses)], regions[i%len(regions)], i%500)
+	}
+	buf.WriteString(")}")
+	return buf.Bytes()
+}
+
+// BenchmarkInternStrings reports the heap held by a generically decoded
+// 100k-row table, with and without InternStrings.
+func BenchmarkInternStrings(b *testing.B) {
+	data := benchStatusTable(100000)
+	for _, intern := range []bool{false, true} {
+		opts := UnmarshalOptions{InternStrings: intern}
+		b.Run(fmt.Sprintf("intern=%v", intern), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+			var retained uint64
+			for i := 0; i < b.N; i++ {
+				var before, after runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+				var v interface{}
+				if err := opts.Unmarshal(data, &v); err != nil {
+					b.Fatal(err)
+				}
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				retained += after.HeapAlloc - before.HeapAlloc
+				runtime.KeepAlive(v)
+			}
+			b.ReportMetric(float64(retained)/float64(b.N), "heap-B/op")
+		})
+	}
+}
+
+func BenchmarkIsZeroValueStruct(b *testing.B) {
+	cases := []struct {
+		name string
+		v    interface{}
+	}{
+		{"nonzero", Person{Name: "Alice", Age: 30}},
+		{"zero", Person{}},
+		{"nested", Company{Employees: benchPeople(1)}},
+	}
+	for _, c := range cases {
+		v := reflect.ValueOf(c.v)
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				isZeroValue(v)
+			}
+		})
+	}
+}
+
+// wideDocument returns a document with n keys, the wanted ones first.
+func wideDocument(n int) []byte {
+	var sb strings.Builder
+	sb.WriteString(`{method="GET";path="/x";status=200;`)
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "k%d={a=%d;b=[\"x\",\"y\"];c=(p,q:1,2;3,4;)};", i, i)
+	}
+	sb.WriteString("}")
+	return []byte(sb.String())
+}
+
+func BenchmarkUnmarshalFields(b *testing.B) {
+	doc := wideDocument(80)
+	b.Run("Unmarshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var h wideHeader
+			if err := Unmarshal(doc, &h); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("UnmarshalFields", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var h wideHeader
+			if err := UnmarshalFields(doc, &h, "method", "path", "status"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
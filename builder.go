@@ -0,0 +1,263 @@
+package god
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// A Builder writes a GOD document from method calls instead of a Go value,
+// for documents whose shape is only known at run time, such as those
+// filled in from a template:
+//
+//	data, err := god.Build().Object(func(b *god.ObjectBuilder) {
+//		b.Str("name", "Alice").Int("age", 30).Object("address", func(b *god.ObjectBuilder) {
+//			b.Str("city", "NYC")
+//		})
+//	}).Bytes()
+//
+// The output is the same as Marshal writes for the equivalent map: keys are
+// sorted, a key set twice keeps its last value, and zero values are written
+// empty.
+type Builder struct {
+	opts MarshalOptions
+	data []byte
+	err  error
+}
+
+// Build returns a Builder configured by opts, such as Beautify().
+func Build(opts ...Option) *Builder {
+	return &Builder{opts: newOptions(opts).marshal}
+}
+
+// Object sets the document to the object built by f.
+func (b *Builder) Object(f func(*ObjectBuilder)) *Builder {
+	o := &ObjectBuilder{b: b, level: 1}
+	f(o)
+	var sb strings.Builder
+	e := b.opts.newEncodeState(&sb)
+	if err := o.write(e); err != nil {
+		b.fail(err)
+	}
+	if b.opts.TrailingNewline {
+		sb.WriteByte('\n')
+	}
+	b.data = []byte(sb.String())
+	return b
+}
+
+// Bytes returns the document, or the first error met while building it.
+func (b *Builder) Bytes() ([]byte, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.data == nil {
+		return nil, errors.New("builder has no root object")
+	}
+	return b.data, nil
+}
+
+func (b *Builder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// encode returns the text written by f.
+func (b *Builder) encode(f func(e *encodeState) error) string {
+	var sb strings.Builder
+	if err := f(b.opts.newEncodeState(&sb)); err != nil {
+		b.fail(err)
+	}
+	return sb.String()
+}
+
+func (b *Builder) compact() bool {
+	return !b.opts.Beautify
+}
+
+// An ObjectBuilder adds the key-value pairs of an object.
+type ObjectBuilder struct {
+	b     *Builder
+	level int
+	keys  []string
+	vals  []string
+}
+
+// set records the value of key, as written by f.
+func (o *ObjectBuilder) set(key string, f func(e *encodeState) error) *ObjectBuilder {
+	o.keys = append(o.keys, key)
+	o.vals = append(o.vals, o.b.encode(f))
+	return o
+}
+
+func (o *ObjectBuilder) value(key string, v interface{}) *ObjectBuilder {
+	return o.set(key, func(e *encodeState) error {
+		return encodeValue(e, reflect.ValueOf(&v).Elem(), o.level+1, o.b.compact())
+	})
+}
+
+// Str sets key to a string.
+func (o *ObjectBuilder) Str(key, v string) *ObjectBuilder { return o.value(key, v) }
+
+// Int sets key to an integer.
+func (o *ObjectBuilder) Int(key string, v int64) *ObjectBuilder { return o.value(key, v) }
+
+// Float sets key to a floating-point number.
+func (o *ObjectBuilder) Float(key string, v float64) *ObjectBuilder { return o.value(key, v) }
+
+// Bool sets key to a boolean.
+func (o *ObjectBuilder) Bool(key string, v bool) *ObjectBuilder { return o.value(key, v) }
+
+// Null sets key to the grounded null \0.
+func (o *ObjectBuilder) Null(key string) *ObjectBuilder {
+	return o.set(key, func(e *encodeState) error {
+		e.WriteString(`\0`)
+		return nil
+	})
+}
+
+// Object sets key to the object built by f.
+func (o *ObjectBuilder) Object(key string, f func(*ObjectBuilder)) *ObjectBuilder {
+	sub := &ObjectBuilder{b: o.b, level: o.level + 1}
+	f(sub)
+	return o.set(key, func(e *encodeState) error {
+		if len(sub.keys) == 0 {
+			return nil // Rule 18: an empty object is grounded
+		}
+		return sub.write(e)
+	})
+}
+
+// List sets key to the list built by f.
+func (o *ObjectBuilder) List(key string, f func(*ListBuilder)) *ObjectBuilder {
+	l := &ListBuilder{b: o.b, level: o.level + 1}
+	f(l)
+	return o.set(key, func(e *encodeState) error {
+		if len(l.elems) > 0 {
+			e.WriteString("[" + strings.Join(l.elems, ",") + "]")
+		}
+		return nil
+	})
+}
+
+// Table sets key to the table built by f.
+func (o *ObjectBuilder) Table(key string, f func(*TableBuilder)) *ObjectBuilder {
+	t := &TableBuilder{b: o.b, level: o.level + 1}
+	f(t)
+	return o.set(key, func(e *encodeState) error {
+		if len(t.rows) > 0 {
+			t.write(e)
+		}
+		return nil
+	})
+}
+
+// write writes the object with its keys sorted, keeping the last value of
+// a key set more than once.
+func (o *ObjectBuilder) write(e *encodeState) error {
+	order := make([]int, len(o.keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return o.keys[order[i]] < o.keys[order[j]] })
+	var keys, vals []string
+	for n, i := range order {
+		if n+1 < len(order) && o.keys[order[n+1]] == o.keys[i] {
+			continue
+		}
+		keys = append(keys, o.keys[i])
+		vals = append(vals, o.vals[i])
+	}
+	return writeObject(e, keys, o.level, o.b.compact(), "", func(i int) error {
+		e.WriteString(vals[i])
+		return nil
+	})
+}
+
+// A ListBuilder adds the elements of a list.
+type ListBuilder struct {
+	b     *Builder
+	level int
+	elems []string
+}
+
+// Add appends v, encoded as Marshal would encode it in a []interface{}.
+func (l *ListBuilder) Add(v interface{}) *ListBuilder {
+	elem := reflect.ValueOf(&v).Elem()
+	l.elems = append(l.elems, l.b.encode(func(e *encodeState) error {
+		if isZeroElem(elem) {
+			e.WriteString(`\0`)
+			return nil
+		}
+		return encodeValue(e, elem, l.level, l.b.compact())
+	}))
+	return l
+}
+
+// AddAll appends each of vs.
+func (l *ListBuilder) AddAll(vs ...interface{}) *ListBuilder {
+	for _, v := range vs {
+		l.Add(v)
+	}
+	return l
+}
+
+// A TableBuilder adds the header and rows of a table.
+type TableBuilder struct {
+	b      *Builder
+	level  int
+	header []string
+	rows   [][]string
+}
+
+// Headers sets the column names.
+func (t *TableBuilder) Headers(cols ...string) *TableBuilder {
+	t.header = append(t.header[:0], cols...)
+	return t
+}
+
+// Row appends a row with one value per column, encoded as the cells of a
+// struct table are.
+func (t *TableBuilder) Row(vals ...interface{}) *TableBuilder {
+	if len(vals) != len(t.header) {
+		t.b.fail(fmt.Errorf("table row has %d values for %d columns", len(vals), len(t.header)))
+		return t
+	}
+	row := make([]string, len(vals))
+	for i := range vals {
+		cell := reflect.ValueOf(&vals[i]).Elem()
+		row[i] = t.b.encode(func(e *encodeState) error {
+			return encodeTableCell(e, cell, t.level+1, t.b.compact())
+		})
+	}
+	t.rows = append(t.rows, row)
+	return t
+}
+
+func (t *TableBuilder) write(e *encodeState) {
+	compact := t.b.compact()
+	e.WriteString("(" + strings.Join(t.header, ",") + ":")
+	if !compact {
+		e.WriteByte('\n')
+	}
+	if !compact && (e.alignColumns || e.maxWidth > 0) {
+		writeTableRows(e, t.rows, t.level, ',', e.alignColumns)
+	} else {
+		for _, row := range t.rows {
+			if !compact {
+				e.WriteString(e.indent(t.level))
+			}
+			e.WriteString(strings.Join(row, ",") + ";")
+			if !compact {
+				e.WriteByte('\n')
+			}
+		}
+	}
+	if !compact {
+		e.WriteString(e.indent(t.level - 1))
+	}
+	e.WriteByte(')')
+}
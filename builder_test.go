@@ -0,0 +1,85 @@
+package god
+
+import (
+	"testing"
+)
+
+func TestBuilderMatchesMarshal(t *testing.T) {
+	type row struct {
+		ID   int    `god:"id"`
+		Name string `god:"name"`
+		OK   bool   `god:"ok"`
+	}
+	build := func(opts ...Option) ([]byte, error) {
+		return Build(opts...).Object(func(b *ObjectBuilder) {
+			b.Str("name", "Alice").Int("age", 30).Float("score", 9.5).Bool("admin", false)
+			b.Object("address", func(b *ObjectBuilder) {
+				b.Str("city", "NYC").Str("zip", "10001")
+			})
+			b.List("tags", func(l *ListBuilder) {
+				l.Add("a").AddAll(1, 2.5, nil, map[string]int{"x": 1})
+			})
+			b.Table("rows", func(t *TableBuilder) {
+				t.Headers("id", "name", "ok").Row(1, "a", true).Row(22, "", false)
+			})
+			b.Object("empty", func(*ObjectBuilder) {})
+			b.List("none", func(*ListBuilder) {})
+			b.Str("name", "Bob")
+			b.Str("key with spaces", "x")
+		}).Bytes()
+	}
+	equivalent := map[string]interface{}{
+		"name":    "Bob",
+		"age":     30,
+		"score":   9.5,
+		"admin":   false,
+		"address": map[string]interface{}{"city": "NYC", "zip": "10001"},
+		"tags":    []interface{}{"a", 1, 2.5, nil, map[string]int{"x": 1}},
+		"rows":    []row{{1, "a", true}, {22, "", false}},
+		"empty":   map[string]interface{}{},
+		"none":    []interface{}{},
+
+		"key with spaces": "x",
+	}
+	for _, opts := range [][]Option{nil, {Beautify()}, {Beautify(), AlignAssignments(), AlignTableColumns()}} {
+		got, err := build(opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := MarshalWith(equivalent, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("builder output differs from Marshal:\ngot\n%s\nwant\n%s", got, want)
+		}
+	}
+}
+
+func TestBuilderNull(t *testing.T) {
+	data, err := Build().Object(func(b *ObjectBuilder) { b.Null("n").Int("i", 0) }).Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{i=;n=\0}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	if _, err := Build().Bytes(); err == nil {
+		t.Error("expected an error for a document without a root")
+	}
+	_, err := Build().Object(func(b *ObjectBuilder) {
+		b.Table("t", func(t *TableBuilder) { t.Headers("a", "b").Row(1) })
+	}).Bytes()
+	if err == nil {
+		t.Error("expected an error for a short table row")
+	}
+	_, err = Build().Object(func(b *ObjectBuilder) {
+		b.List("l", func(l *ListBuilder) { l.Add(func() {}) })
+	}).Bytes()
+	if err == nil {
+		t.Error("expected an error for a value that cannot be encoded")
+	}
+}
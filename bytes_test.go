@@ -0,0 +1,88 @@
+package god
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodeBase64Root(t *testing.T) {
+	var b []byte
+	if err := Unmarshal([]byte(`{"aGVsbG8="}`), &b); err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "hello" {
+		t.Errorf("expected hello, got %q", b)
+	}
+
+	// A list of numbers still decodes byte by byte.
+	if err := Unmarshal([]byte(`{[104,105]}`), &b); err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "hi" {
+		t.Errorf("expected hi, got %q", b)
+	}
+
+	if err := Unmarshal([]byte(`{"not base64!"}`), &b); err == nil {
+		t.Error("expected an error for invalid base64")
+	}
+}
+
+func TestDecodeBase64Field(t *testing.T) {
+	type blob []byte
+	var v struct {
+		Key  []byte `god:"key"`
+		Data blob   `god:"data"`
+	}
+	if err := Unmarshal([]byte(`{key="AAEC";data=""}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(v.Key, []byte{0, 1, 2}) {
+		t.Errorf("key: got %v", v.Key)
+	}
+	if v.Data == nil || len(v.Data) != 0 {
+		t.Errorf("data: expected empty blob, got %#v", v.Data)
+	}
+}
+
+func TestEncodeBase64(t *testing.T) {
+	type row struct {
+		ID  int    `god:"id"`
+		Sum []byte `god:"sum"`
+	}
+	type doc struct {
+		Key  []byte            `god:"key"`
+		Rows []row             `god:"rows"`
+		Tags map[string][]byte `god:"tags"`
+		Arr  [2]byte           `god:"arr"`
+	}
+	in := doc{
+		Key:  []byte{0, 1, 2},
+		Rows: []row{{ID: 1, Sum: []byte("hi")}, {ID: 2}},
+		Tags: map[string][]byte{"a": []byte("hello")},
+		Arr:  [2]byte{1, 2},
+	}
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{key="AAEC";rows=(id,sum:1,"aGk=";2,;);tags={a="aGVsbG8="};arr=[1,2]}`
+	if string(data) != want {
+		t.Errorf("got  %s\nwant %s", data, want)
+	}
+	var out doc
+	if err := Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Key, in.Key) || !bytes.Equal(out.Rows[0].Sum, in.Rows[0].Sum) ||
+		out.Rows[1].Sum != nil || !bytes.Equal(out.Tags["a"], in.Tags["a"]) || out.Arr != in.Arr {
+		t.Errorf("got %+v, want %+v", out, in)
+	}
+
+	data, err = Marshal([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"aGVsbG8="}` {
+		t.Errorf("root: got %s", data)
+	}
+}
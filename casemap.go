@@ -0,0 +1,64 @@
+package god
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SnakeCase maps a Go field name to snake_case, for
+// MarshalOptions.FieldNameMapper: UserID becomes user_id and HTTPServer
+// http_server.
+func SnakeCase(name string) string {
+	return strings.ToLower(strings.Join(splitWords(name), "_"))
+}
+
+// KebabCase maps a Go field name to kebab-case, for
+// MarshalOptions.FieldNameMapper: UserID becomes user-id and HTTPServer
+// http-server.
+func KebabCase(name string) string {
+	return strings.ToLower(strings.Join(splitWords(name), "-"))
+}
+
+// CamelCase maps a Go field name to camelCase, for
+// MarshalOptions.FieldNameMapper, by lowercasing its first word: UserID
+// becomes userID and HTTPServer httpServer. Later words keep their case,
+// so initialisms stay whole.
+func CamelCase(name string) string {
+	words := splitWords(name)
+	if len(words) == 0 {
+		return ""
+	}
+	words[0] = strings.ToLower(words[0])
+	return strings.Join(words, "")
+}
+
+// splitWords splits a Go identifier into its words. A word starts at an
+// upper-case letter after a lower-case letter or digit, and at the last
+// letter of a run of upper-case letters followed by a lower-case one, so
+// that the initialism in HTTPServer is a word of its own. Underscores
+// separate words and are dropped.
+func splitWords(name string) []string {
+	var words []string
+	runes := []rune(name)
+	start := 0
+	for i, r := range runes {
+		switch {
+		case r == '_':
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+		case i > start && unicode.IsUpper(r):
+			prev := runes[i-1]
+			next := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && next {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
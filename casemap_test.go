@@ -0,0 +1,117 @@
+package god
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCaseMappers(t *testing.T) {
+	tests := []struct {
+		name, snake, kebab, camel string
+	}{
+		{"UserID", "user_id", "user-id", "userID"},
+		{"HTTPServer", "http_server", "http-server", "httpServer"},
+		{"CreatedAt", "created_at", "created-at", "createdAt"},
+		{"ID", "id", "id", "id"},
+		{"Name", "name", "name", "name"},
+		{"APIKeyV2", "api_key_v2", "api-key-v2", "apiKeyV2"},
+		{"Address2Line", "address2_line", "address2-line", "address2Line"},
+		{"Snake_Field", "snake_field", "snake-field", "snakeField"},
+	}
+	for _, tt := range tests {
+		if got := SnakeCase(tt.name); got != tt.snake {
+			t.Errorf("SnakeCase(%q) = %q, want %q", tt.name, got, tt.snake)
+		}
+		if got := KebabCase(tt.name); got != tt.kebab {
+			t.Errorf("KebabCase(%q) = %q, want %q", tt.name, got, tt.kebab)
+		}
+		if got := CamelCase(tt.name); got != tt.camel {
+			t.Errorf("CamelCase(%q) = %q, want %q", tt.name, got, tt.camel)
+		}
+	}
+}
+
+type mappedServer struct {
+	UserID     int
+	HTTPServer string
+	Label      string `god:"LABEL"`
+}
+
+type mappedConfig struct {
+	Servers   []mappedServer
+	MaxConns  int
+	DebugMode bool `god:"debug"`
+}
+
+func TestFieldNameMapper(t *testing.T) {
+	in := mappedConfig{
+		Servers:   []mappedServer{{UserID: 7, HTTPServer: "a", Label: "x"}},
+		MaxConns:  10,
+		DebugMode: true,
+	}
+	tests := []struct {
+		mapper func(string) string
+		want   string
+	}{
+		{SnakeCase, `{servers=(user_id,http_server,LABEL:7,"a","x";);max_conns=10;debug=true}`},
+		{KebabCase, `{servers=(user-id,http-server,LABEL:7,"a","x";);max-conns=10;debug=true}`},
+		{CamelCase, `{servers=(userID,httpServer,LABEL:7,"a","x";);maxConns=10;debug=true}`},
+	}
+	for _, tt := range tests {
+		out, err := MarshalWith(in, WithFieldNameMapper(tt.mapper))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != tt.want {
+			t.Errorf("got  %s\nwant %s", out, tt.want)
+		}
+		var back mappedConfig
+		if err := UnmarshalWith(out, &back, WithFieldNameMapper(tt.mapper)); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(back, in) {
+			t.Errorf("got %+v, want %+v", back, in)
+		}
+	}
+
+	// Without the mapper the mapped keys match no field.
+	var plain mappedConfig
+	if err := Unmarshal([]byte(tests[0].want), &plain); err != nil {
+		t.Fatal(err)
+	}
+	if plain.MaxConns != 0 || len(plain.Servers) != 1 || plain.Servers[0].UserID != 0 || plain.Servers[0].Label != "x" {
+		t.Errorf("got %+v", plain)
+	}
+}
+
+func TestFieldNameMapperDuplicate(t *testing.T) {
+	in := struct {
+		UserID  int
+		User_ID int
+	}{}
+	_, err := MarshalWith(in, WithFieldNameMapper(SnakeCase))
+	if err == nil || !strings.Contains(err.Error(), `both have the key "user_id"`) {
+		t.Errorf("got %v, want a duplicate key error", err)
+	}
+}
+
+func TestFieldNameMapperTableReader(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`{(user_id,http_server:7,"a";)}`))
+	d.opts.FieldNameMapper = SnakeCase
+	tr := &TableReader{d: d}
+	if err := tr.readHeader(); err != nil {
+		t.Fatal(err)
+	}
+	var got []mappedServer
+	for tr.Next() {
+		var s mappedServer
+		if err := tr.ScanInto(&s); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, s)
+	}
+	if want := []mappedServer{{UserID: 7, HTTPServer: "a"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
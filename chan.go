@@ -0,0 +1,220 @@
+package god
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// EncodeTableFrom writes a document holding one table, {(...)}, whose rows
+// are the values received from ch, a channel of structs or of pointers to
+// structs, as they arrive. Each row is written to the stream as soon as it
+// is received, and the table is closed once ch is closed, so a producer
+// goroutine sets the pace and no slice of rows is ever held.
+//
+// The columns are the fields of the struct, in order, or, if header is
+// given, the fields with those keys. Since the rows are not known in
+// advance, omitempty drops no column, and flatten and remain fields are
+// left out.
+//
+// A row that cannot be encoded, or a nil pointer row, ends the table early:
+// the table and document are closed after the rows already written, so the
+// stream stays readable, and the error is returned. The document written
+// is then truncated.
+func (e *Encoder) EncodeTableFrom(ch interface{}, header ...string) error {
+	if e.inObject {
+		return errors.New("cannot encode a document while an object is open")
+	}
+	if err := checkIndent(e.state.indentUnit); err != nil {
+		return err
+	}
+	cv := reflect.ValueOf(ch)
+	if cv.Kind() != reflect.Chan || cv.Type().ChanDir()&reflect.RecvDir == 0 {
+		return fmt.Errorf("EncodeTableFrom needs a channel to receive from, got %T", ch)
+	}
+	rowType := cv.Type().Elem()
+	if rowType.Kind() == reflect.Ptr {
+		rowType = rowType.Elem()
+	}
+	if rowType.Kind() != reflect.Struct {
+		return fmt.Errorf("EncodeTableFrom needs a channel of structs, got %T", ch)
+	}
+	names, columns, err := streamColumns(rowType, header, e.state.fieldNameMapper)
+	if err != nil {
+		return err
+	}
+
+	b, level := &e.state, 2
+	e.buf.Reset()
+	e.buf.WriteByte('{')
+	if !e.compact {
+		e.buf.WriteString("\n" + b.indent(1))
+	}
+	e.buf.WriteByte('(')
+	for i, name := range names {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+		e.buf.WriteString(name)
+	}
+	e.buf.WriteByte(':')
+	if !e.compact {
+		e.buf.WriteByte('\n')
+	}
+	if err := e.flush(); err != nil {
+		return err
+	}
+
+	// end closes the table and the document, after the last row or in
+	// place of one that failed to encode.
+	end := func() error {
+		e.buf.Reset()
+		if !e.compact {
+			e.buf.WriteString(b.indent(1) + ")\n}\n")
+		} else {
+			e.buf.WriteString(")}\n")
+		}
+		return e.flush()
+	}
+	truncate := func(err error) error {
+		end()
+		return err
+	}
+
+	for {
+		row, ok := cv.Recv()
+		if !ok {
+			break
+		}
+		if row.Kind() == reflect.Ptr {
+			if row.IsNil() {
+				return truncate(errors.New("EncodeTableFrom received a nil row"))
+			}
+			row = row.Elem()
+		}
+		e.buf.Reset()
+		if !e.compact && b.maxWidth > 0 {
+			// Wrapping needs the width of each cell, and the rows
+			// before are gone, so columns are not aligned.
+			cells := make([]string, len(columns))
+			for k, i := range columns {
+				var sb strings.Builder
+				sub := *b
+				sub.writer = &sb
+				if err := encodeTableCell(&sub, row.Field(i), level+1, false); err != nil {
+					return truncate(err)
+				}
+				cells[k] = sb.String()
+			}
+			writeTableRows(b, [][]string{cells}, level, ',', false)
+		} else {
+			if !e.compact {
+				e.buf.WriteString(b.indent(level))
+			}
+			for k, i := range columns {
+				if k > 0 {
+					e.buf.WriteByte(',')
+				}
+				if err := encodeTableCell(b, row.Field(i), level+1, e.compact); err != nil {
+					return truncate(err)
+				}
+			}
+			e.buf.WriteByte(';')
+			if !e.compact {
+				e.buf.WriteByte('\n')
+			}
+		}
+		if err := e.flush(); err != nil {
+			return err
+		}
+	}
+	return end()
+}
+
+// streamColumns returns the keys and field indexes of the columns of a
+// streamed table of struct type t: those named in header, or else every
+// regular field. Untagged fields are named by mapName.
+func streamColumns(t reflect.Type, header []string, mapName func(string) string) ([]string, []int, error) {
+	flatIdx, err := flattenField(t)
+	if err != nil {
+		return nil, nil, err
+	}
+	remainIdx, err := remainField(t)
+	if err != nil {
+		return nil, nil, err
+	}
+	var names []string
+	var columns []int
+	byName := make(map[string]int)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || i == flatIdx || i == remainIdx {
+			continue
+		}
+		name := parseFieldTag(field, mapName).name
+		if j, dup := byName[name]; dup {
+			return nil, nil, duplicateKeyError(t, j, i, name)
+		}
+		byName[name] = i
+		names = append(names, name)
+		columns = append(columns, i)
+	}
+	if len(header) == 0 {
+		return names, columns, nil
+	}
+	columns = columns[:0]
+	for _, name := range header {
+		i, ok := byName[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("%v has no field for column %q", t, name)
+		}
+		columns = append(columns, i)
+	}
+	return header, columns, nil
+}
+
+// DecodeTableTo reads the next document, which must hold a table as
+// written by EncodeTableFrom, and sends its rows to ch, a channel of
+// structs or of pointers to structs, one at a time as they are read. Rows
+// are decoded as by TableReader.ScanInto. ch is closed when the table
+// ends, and also on error, which is returned.
+func (d *Decoder) DecodeTableTo(ch interface{}) error {
+	cv := reflect.ValueOf(ch)
+	if cv.Kind() != reflect.Chan || cv.Type().ChanDir()&reflect.SendDir == 0 {
+		return fmt.Errorf("DecodeTableTo needs a channel to send to, got %T", ch)
+	}
+	defer cv.Close()
+	rowType := cv.Type().Elem()
+	ptr := rowType.Kind() == reflect.Ptr
+	if ptr {
+		rowType = rowType.Elem()
+	}
+	if rowType.Kind() != reflect.Struct {
+		return fmt.Errorf("DecodeTableTo needs a channel of structs, got %T", ch)
+	}
+
+	t := &TableReader{d: d}
+	if err := t.readHeader(); err != nil {
+		return err
+	}
+	for t.Next() {
+		row := reflect.New(rowType)
+		if err := t.ScanInto(row.Interface()); err != nil {
+			return err
+		}
+		if !ptr {
+			row = row.Elem()
+		}
+		cv.Send(row)
+	}
+	if err := t.Err(); err != nil {
+		return err
+	}
+	if t.rooted {
+		if c, err := t.skipSpaces(); err != nil || c != '}' {
+			return errors.New("expected '}' after root table")
+		}
+	}
+	return nil
+}
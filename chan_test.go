@@ -0,0 +1,168 @@
+package god
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type sensorRow struct {
+	Sensor string
+	Value  float64
+	OK     bool `god:"ok"`
+}
+
+func TestChannelPipeline(t *testing.T) {
+	for _, beautify := range []bool{false, true} {
+		pr, pw := io.Pipe()
+
+		// producer -> encoder
+		src := make(chan sensorRow)
+		go func() {
+			for i := 0; i < 100; i++ {
+				src <- sensorRow{Sensor: fmt.Sprintf("s%d", i%7), Value: float64(i) / 4, OK: i%3 == 0}
+			}
+			close(src)
+		}()
+		encErr := make(chan error, 1)
+		go func() {
+			enc := NewEncoder(pw)
+			enc.SetBeautify(beautify)
+			err := enc.EncodeTableFrom(src)
+			if err == nil {
+				err = enc.Encode(map[string]int{"after": 1})
+			}
+			pw.CloseWithError(err)
+			encErr <- err
+		}()
+
+		// decoder -> consumer
+		dec := NewDecoder(pr)
+		dst := make(chan *sensorRow)
+		decErr := make(chan error, 1)
+		go func() { decErr <- dec.DecodeTableTo(dst) }()
+		var got []sensorRow
+		for r := range dst {
+			got = append(got, *r)
+		}
+		if err := <-decErr; err != nil {
+			t.Fatal(err)
+		}
+		var after map[string]int
+		if err := dec.Decode(&after); err != nil || after["after"] != 1 {
+			t.Errorf("document after the table: got %v, %v", after, err)
+		}
+		if err := <-encErr; err != nil {
+			t.Fatal(err)
+		}
+
+		if len(got) != 100 {
+			t.Fatalf("got %d rows", len(got))
+		}
+		for i, r := range got {
+			want := sensorRow{Sensor: fmt.Sprintf("s%d", i%7), Value: float64(i) / 4, OK: i%3 == 0}
+			if r != want {
+				t.Fatalf("row %d: got %+v, want %+v", i, r, want)
+			}
+		}
+	}
+}
+
+func TestEncodeTableFromHeader(t *testing.T) {
+	var sb strings.Builder
+	ch := make(chan sensorRow, 2)
+	ch <- sensorRow{Sensor: "a", Value: 1.5}
+	ch <- sensorRow{Sensor: "b", OK: true}
+	close(ch)
+	if err := NewEncoder(&sb).EncodeTableFrom(ch, "ok", "sensor"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{(ok,sensor:,\"a\";true,\"b\";)}\n"; sb.String() != want {
+		t.Errorf("got %q, want %q", sb.String(), want)
+	}
+
+	// The same rows decode with Unmarshal.
+	var rows []sensorRow
+	if err := Unmarshal([]byte(sb.String()), &rows); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rows, []sensorRow{{Sensor: "a"}, {Sensor: "b", OK: true}}) {
+		t.Errorf("got %+v", rows)
+	}
+
+	empty := make(chan sensorRow)
+	close(empty)
+	if err := NewEncoder(io.Discard).EncodeTableFrom(empty, "nope"); err == nil {
+		t.Error("unknown column: want error")
+	}
+	if err := NewEncoder(io.Discard).EncodeTableFrom(make(chan int)); err == nil {
+		t.Error("channel of ints: want error")
+	}
+}
+
+type failingCell struct{ n int }
+
+func (failingCell) MarshalGOD() ([]byte, error) { return nil, errors.New("no") }
+
+type badRow struct {
+	Name string      `god:"name"`
+	Cell failingCell `god:"cell"`
+}
+
+func TestEncodeTableFromError(t *testing.T) {
+	var sb strings.Builder
+	enc := NewEncoder(&sb)
+	ch := make(chan *sensorRow, 3)
+	ch <- &sensorRow{Sensor: "a"}
+	ch <- nil
+	ch <- &sensorRow{Sensor: "c"}
+	close(ch)
+	if err := enc.EncodeTableFrom(ch); err == nil {
+		t.Fatal("nil row: want error")
+	}
+	bad := make(chan badRow, 1)
+	bad <- badRow{Name: "x", Cell: failingCell{1}}
+	close(bad)
+	if err := enc.EncodeTableFrom(bad); err == nil {
+		t.Fatal("failing cell: want error")
+	}
+	if err := enc.Encode(map[string]int{"n": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The stream holds the rows before each error, then the next document.
+	dec := NewDecoder(strings.NewReader(sb.String()))
+	var rows []sensorRow
+	if err := dec.Decode(&rows); err != nil {
+		t.Fatalf("%v in %q", err, sb.String())
+	}
+	if !reflect.DeepEqual(rows, []sensorRow{{Sensor: "a"}}) {
+		t.Errorf("got %+v", rows)
+	}
+	var empty []badRow
+	if err := dec.Decode(&empty); err != nil || len(empty) != 0 {
+		t.Errorf("got %+v, %v", empty, err)
+	}
+	var m map[string]int
+	if err := dec.Decode(&m); err != nil || m["n"] != 1 {
+		t.Errorf("got %v, %v", m, err)
+	}
+}
+
+func TestDecodeTableToError(t *testing.T) {
+	ch := make(chan sensorRow, 4)
+	err := NewDecoder(strings.NewReader(`{(sensor,value:"a",1;"b",x;)}`)).DecodeTableTo(ch)
+	if err == nil {
+		t.Fatal("want error")
+	}
+	var got []sensorRow
+	for r := range ch {
+		got = append(got, r)
+	}
+	if len(got) != 1 || got[0].Sensor != "a" {
+		t.Errorf("got %+v before the error", got)
+	}
+}
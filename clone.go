@@ -0,0 +1,29 @@
+package god
+
+import "reflect"
+
+// Clone returns a deep copy of v made by encoding it and decoding the
+// result into a new T. It is slower than copying by hand but needs no code
+// per type. The copy has what GOD keeps: unexported fields are left out,
+// and empty slices and maps come back as nil, as they are grounded.
+func Clone[T any](v T) (T, error) {
+	var out T
+	if reflect.ValueOf(&v).Elem().IsZero() {
+		return out, nil
+	}
+	data, err := Marshal(v)
+	if err != nil {
+		return out, err
+	}
+	err = Unmarshal(data, &out)
+	return out, err
+}
+
+// CloneSlice returns a deep copy of the elements of v, as Clone does. A
+// slice of structs travels as a table.
+func CloneSlice[T any](v []T) ([]T, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return Clone(v)
+}
@@ -0,0 +1,83 @@
+package god
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	orig := Company{
+		Name:      "TechCorp",
+		Founded:   2020,
+		Employees: []Person{{Name: "Alice", Age: 30}, {Name: "Bob", Address: "LA"}},
+	}
+	clone, err := Clone(orig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(clone, orig) {
+		t.Fatalf("Clone = %+v, want %+v", clone, orig)
+	}
+	clone.Employees[0].Name = "Mallory"
+	clone.Name = "Other"
+	if orig.Employees[0].Name != "Alice" || orig.Name != "TechCorp" {
+		t.Errorf("modifying the clone changed the original: %+v", orig)
+	}
+
+	ptr, err := Clone(&orig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ptr == &orig || !reflect.DeepEqual(*ptr, orig) {
+		t.Errorf("Clone(&orig) = %p %+v", ptr, ptr)
+	}
+
+	m := map[string][]int{"a": {1, 2}}
+	mc, err := Clone(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mc["a"][0] = 9
+	if m["a"][0] != 1 {
+		t.Errorf("modifying the map clone changed the original: %v", m)
+	}
+}
+
+func TestCloneZero(t *testing.T) {
+	var nilPtr *Company
+	if c, err := Clone(nilPtr); err != nil || c != nil {
+		t.Errorf("Clone(nil) = %v, %v", c, err)
+	}
+	if c, err := Clone(Person{}); err != nil || c != (Person{}) {
+		t.Errorf("Clone(Person{}) = %+v, %v", c, err)
+	}
+	if c, err := CloneSlice([]Person(nil)); err != nil || c != nil {
+		t.Errorf("CloneSlice(nil) = %v, %v", c, err)
+	}
+}
+
+func TestCloneSlice(t *testing.T) {
+	orig := []Person{{Name: "Alice", Age: 30}, {Name: "Bob"}}
+	clone, err := CloneSlice(orig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(clone, orig) {
+		t.Fatalf("CloneSlice = %+v, want %+v", clone, orig)
+	}
+	clone[1].Age = 99
+	if orig[1].Age != 0 {
+		t.Errorf("modifying the clone changed the original: %+v", orig)
+	}
+
+	strs, err := CloneSlice([]string{"a", "b"})
+	if err != nil || !reflect.DeepEqual(strs, []string{"a", "b"}) {
+		t.Errorf("CloneSlice(strings) = %v, %v", strs, err)
+	}
+}
+
+func TestCloneError(t *testing.T) {
+	if _, err := Clone(map[string]interface{}{"f": func() {}}); err == nil {
+		t.Error("cloning a func succeeded")
+	}
+}
@@ -8,7 +8,8 @@ import (
 )
 
 func testBareTable() {
-	fmt.Println("\n=== Bare Table Test ===\n")
+	fmt.Println("\n=== Bare Table Test ===")
+	fmt.Println()
 
 	// Test 1: Encode struct slice as bare table
 	fmt.Println("1. Encoding []Person as bare table:")
@@ -27,13 +28,13 @@ func testBareTable() {
 	// Test 2: Decode bare table back to []Person
 	fmt.Println("\n2. Decoding bare table to []Person:")
 	godData := []byte(`{(name,age,addr:"John",12,;"Alice",25,"Boston";"Bob",30,"Chicago";)}`)
-	
+
 	var decodedPeople []Person
 	err = god.Unmarshal(godData, &decodedPeople)
 	if err != nil {
 		log.Fatal(err)
 	}
-	
+
 	for i, p := range decodedPeople {
 		fmt.Printf("  Person %d: %+v\n", i+1, p)
 	}
@@ -55,7 +56,7 @@ func testBareTable() {
 	}
 	fmt.Printf("  Original: %+v\n", people)
 	fmt.Printf("  Decoded:  %+v\n", roundTrip)
-	
+
 	// Verify
 	match := true
 	if len(people) != len(roundTrip) {
@@ -8,7 +8,8 @@ import (
 )
 
 func example2() {
-	fmt.Println("\n=== Additional Examples ===\n")
+	fmt.Println("\n=== Additional Examples ===")
+	fmt.Println()
 
 	// Example 1: Struct with slice of structs (nested table)
 	fmt.Println("1. Company with Employees (Nested Structure):")
@@ -48,14 +49,14 @@ func example2() {
 	// Example 4: Decoding the company structure
 	// TODO: Fix table decoding issue
 	/*
-	fmt.Println("\n4. Decoding Company with Employee Table:")
-	godCompany := []byte(`{name="MegaCorp";founded=2015;employees=(name,age,addr:"John",28,"Boston";"Jane",32,"Seattle";)}`)
-
-	var result map[string]interface{}
-	err = god.Unmarshal(godCompany, &result)
-	if err != nil {
-		log.Fatal(err)
-	}
-	fmt.Printf("Decoded: %+v\n", result)
+		fmt.Println("\n4. Decoding Company with Employee Table:")
+		godCompany := []byte(`{name="MegaCorp";founded=2015;employees=(name,age,addr:"John",28,"Boston";"Jane",32,"Seattle";)}`)
+
+		var result map[string]interface{}
+		err = god.Unmarshal(godCompany, &result)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Decoded: %+v\n", result)
 	*/
 }
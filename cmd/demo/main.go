@@ -2,8 +2,8 @@ package main
 
 import (
 	"fmt"
-	"log"
 	"github.com/vinayakgupta29/god"
+	"log"
 )
 
 // Example struct definitions
@@ -14,7 +14,8 @@ type Person struct {
 }
 
 func main() {
-	fmt.Println("=== GOD (Grounded Object Data) Encoder/Decoder Demo ===\n")
+	fmt.Println("=== GOD (Grounded Object Data) Encoder/Decoder Demo ===")
+	fmt.Println()
 
 	// Example 1: Single struct encoding
 	fmt.Println("1. Single Person Struct:")
@@ -108,4 +109,3 @@ func main() {
 
 	fmt.Println("\n=== Demo Complete ===")
 }
-
@@ -9,7 +9,8 @@ import (
 
 func testRule5Examples() {
 	fmt.Println("\n=== Grammar Rule 5 Examples ===")
-	fmt.Println("Rule: Root can have EITHER single raw value OR key-value pairs, but NOT both\n")
+	fmt.Println("Rule: Root can have EITHER single raw value OR key-value pairs, but NOT both")
+	fmt.Println()
 
 	// Valid: Single raw string
 	fmt.Println("1. Single raw string: {\"John\"}")
@@ -44,7 +45,8 @@ func testRule5Examples() {
 	fmt.Printf("   Encoded: %s\n", string(encoded))
 
 	// Demonstrate decoding
-	fmt.Println("\n=== Decoding Examples ===\n")
+	fmt.Println("\n=== Decoding Examples ===")
+	fmt.Println()
 
 	// Decode single string
 	fmt.Println("1. Decoding {\"Hello World\"}")
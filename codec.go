@@ -0,0 +1,53 @@
+package god
+
+// ContentType is the media type of GOD documents.
+const ContentType = "application/god"
+
+// Codec marshals and unmarshals messages as GOD. Its method set matches
+// the codec interfaces of common message buses, such as go-micro,
+// Watermill and NATS encoded connections, so it can be plugged in
+// directly:
+//
+//	codec := god.Codec{UnmarshalOptions: god.UnmarshalOptions{UseNumber: true}}
+//
+// The zero Codec writes compact documents and decodes like Unmarshal.
+type Codec struct {
+	// Beautify writes documents in the indented form of MarshalBeautify.
+	Beautify bool
+
+	// UnmarshalOptions configures decoding, e.g. DisallowTrailingData for
+	// strict input or UseNumber.
+	UnmarshalOptions UnmarshalOptions
+}
+
+// Marshal returns the GOD encoding of v.
+func (c Codec) Marshal(v interface{}) ([]byte, error) {
+	return marshalWithCompact(v, !c.Beautify)
+}
+
+// Unmarshal decodes the GOD document in data into v.
+func (c Codec) Unmarshal(data []byte, v interface{}) error {
+	return c.UnmarshalOptions.Unmarshal(data, v)
+}
+
+// ContentType returns "application/god".
+func (Codec) ContentType() string {
+	return ContentType
+}
+
+// String returns the codec's name, "god".
+func (Codec) String() string {
+	return "god"
+}
+
+// Encode is Marshal for interfaces that pass the message subject along,
+// such as NATS's Encoder. The subject is ignored.
+func (c Codec) Encode(subject string, v interface{}) ([]byte, error) {
+	return c.Marshal(v)
+}
+
+// Decode is Unmarshal for interfaces that pass the message subject along.
+// The subject is ignored.
+func (c Codec) Decode(subject string, data []byte, v interface{}) error {
+	return c.Unmarshal(data, v)
+}
@@ -0,0 +1,180 @@
+package god
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// messageCodec is the shape message buses expect of a codec.
+type messageCodec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	ContentType() string
+}
+
+// subjectCodec is the shape of NATS's Encoder.
+type subjectCodec interface {
+	Encode(subject string, v interface{}) ([]byte, error)
+	Decode(subject string, data []byte, vPtr interface{}) error
+}
+
+var (
+	_ messageCodec = Codec{}
+	_ subjectCodec = Codec{}
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	in := Company{Name: "TechCorp", Founded: 2020, Employees: []Person{{Name: "Alice", Age: 30}}}
+	for _, c := range []messageCodec{Codec{}, Codec{Beautify: true}} {
+		data, err := c.Marshal(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out Company
+		if err := c.Unmarshal(data, &out); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(in, out) {
+			t.Errorf("round trip mismatch: %+v", out)
+		}
+		if c.ContentType() != "application/god" {
+			t.Errorf("unexpected content type %q", c.ContentType())
+		}
+	}
+
+	var sc subjectCodec = Codec{}
+	data, err := sc.Encode("orders.created", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out Company
+	if err := sc.Decode("orders.created", data, &out); err != nil || !reflect.DeepEqual(in, out) {
+		t.Errorf("Encode/Decode mismatch: %+v, %v", out, err)
+	}
+}
+
+func TestCodecOptions(t *testing.T) {
+	strict := Codec{UnmarshalOptions: UnmarshalOptions{DisallowTrailingData: true}}
+	var p Person
+	if err := strict.Unmarshal([]byte(`{name="x"} extra`), &p); err == nil {
+		t.Error("expected strict codec to reject trailing data")
+	}
+
+	numbers := Codec{UnmarshalOptions: UnmarshalOptions{UseNumber: true}}
+	var m map[string]interface{}
+	if err := numbers.Unmarshal([]byte(`{id=12345678901234567890;ratio=0.5;rows=(n:7;)}`), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["id"] != Number("12345678901234567890") || m["ratio"] != Number("0.5") {
+		t.Errorf("expected Number values, got %#v", m)
+	}
+	if rows := m["rows"].([]map[string]interface{}); rows[0]["n"] != Number("7") {
+		t.Errorf("expected Number table cell, got %#v", rows[0]["n"])
+	}
+
+	// Numbers are written back verbatim.
+	data, err := numbers.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{id=12345678901234567890;ratio=0.5;rows=[{n=7}]}`; string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+}
+
+func TestNumber(t *testing.T) {
+	if i, err := Number("42").Int64(); err != nil || i != 42 {
+		t.Errorf("Int64: %v, %v", i, err)
+	}
+	if f, err := Number("2.5").Float64(); err != nil || f != 2.5 {
+		t.Errorf("Float64: %v, %v", f, err)
+	}
+	if _, err := Marshal(map[string]Number{"n": "abc"}); err == nil {
+		t.Error("expected error for invalid number literal")
+	}
+
+	type reading struct {
+		Value Number `god:"value"`
+	}
+	var r reading
+	if err := Unmarshal([]byte(`{value=3.14159265358979323846}`), &r); err != nil || r.Value != "3.14159265358979323846" {
+		t.Errorf("decode into Number field: %q, %v", r.Value, err)
+	}
+}
+
+func TestJSONNumber(t *testing.T) {
+	type quote struct {
+		Price json.Number   `god:"price"`
+		Qty   json.Number   `god:"qty"`
+		Ticks []json.Number `god:"ticks"`
+	}
+	in := quote{Price: "123456789012345678901234.5", Qty: "-7", Ticks: []json.Number{"1e3", "0.25"}}
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{price=123456789012345678901234.5;qty=-7;ticks=[1e3,0.25]}`; string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+	var out quote
+	if err := Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("round trip: got %+v, want %+v", out, in)
+	}
+
+	// Table cells are numbers too.
+	var rows []quote
+	if err := Unmarshal([]byte(`{(price,qty:1.50,2;)}`), &rows); err != nil || rows[0].Price != "1.50" || rows[0].Qty != "2" {
+		t.Errorf("table: %+v, %v", rows, err)
+	}
+
+	for _, doc := range []string{`{price="1"}`, `{price=abc}`, `{(price:"abc";)}`} {
+		if err := Unmarshal([]byte(doc), &out); err == nil {
+			t.Errorf("%s: expected an error for a json.Number that is not a number", doc)
+		}
+	}
+	if _, err := Marshal(quote{Price: "abc"}); err == nil {
+		t.Error("expected error for invalid number literal")
+	}
+}
+
+func TestNormalizeNumber(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"3", "3"},
+		{"3.0", "3"},
+		{"3e0", "3"},
+		{"+3", "3"},
+		{"-0", "0"},
+		{"-0.0", "0"},
+		{"007", "7"},
+		{"0.50", "0.5"},
+		{"5e-1", "0.5"},
+		{"1E3", "1000"},
+		{"0.1", "0.1"},
+		{"9223372036854775807", "9223372036854775807"},
+		{"-9223372036854775808", "-9223372036854775808"},
+		{"1e21", "1e+21"},
+		{"1.5e-7", "1.5e-07"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeNumber(tt.in)
+		if err != nil {
+			t.Errorf("NormalizeNumber(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeNumber(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if again, _ := NormalizeNumber(got); again != got {
+			t.Errorf("NormalizeNumber(%q) = %q, not stable", got, again)
+		}
+	}
+	for _, bad := range []string{"", "abc", "1e400", "NaN", "Inf"} {
+		if _, err := NormalizeNumber(bad); err == nil {
+			t.Errorf("NormalizeNumber(%q) accepted an invalid number", bad)
+		}
+	}
+}
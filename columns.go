@@ -0,0 +1,119 @@
+package god
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// A ColumnPolicy says what decoding does when the header of a table does
+// not match the fields of the structs its rows are decoded into, as when
+// an export gains or loses columns over time.
+type ColumnPolicy int
+
+const (
+	// IgnoreColumns skips extra columns and grounds the fields of missing
+	// ones, without a word. It is the default.
+	IgnoreColumns ColumnPolicy = iota
+
+	// WarnColumns decodes as IgnoreColumns does, and appends each mismatch
+	// to UnmarshalOptions.ColumnMismatches.
+	WarnColumns
+
+	// ErrorColumns fails with a *ColumnMismatchError, naming every
+	// mismatch of the first table that has any, before decoding its rows.
+	ErrorColumns
+)
+
+// A ColumnMismatchKind says how a column fails to match.
+type ColumnMismatchKind int
+
+const (
+	// ColumnMissing is a field whose column, under its name or one of its
+	// aliases, is not in the header.
+	ColumnMissing ColumnMismatchKind = iota + 1
+
+	// ColumnExtra is a column of the header that matches no field. With a
+	// flatten or remain field, which takes such columns, there are none.
+	ColumnExtra
+)
+
+func (k ColumnMismatchKind) String() string {
+	switch k {
+	case ColumnMissing:
+		return "missing"
+	case ColumnExtra:
+		return "extra"
+	}
+	return "ColumnMismatchKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// A ColumnMismatch is a column of a table that does not match the fields
+// of its rows.
+type ColumnMismatch struct {
+	// Table locates the table, as in "reports.rows"; it is empty for the
+	// root.
+	Table string
+
+	// Column is the name of the column: the key of the field for a
+	// missing column, as written in the header for an extra one.
+	Column string
+
+	Kind ColumnMismatchKind
+}
+
+// ColumnMismatchError is returned with ErrorColumns when a table does not
+// match the fields of its rows.
+type ColumnMismatchError struct {
+	// Mismatches lists the extra columns of the table in header order,
+	// then its missing ones in field order.
+	Mismatches []ColumnMismatch
+}
+
+func (e *ColumnMismatchError) Error() string {
+	table := e.Mismatches[0].Table
+	if table == "" {
+		table = "(root)"
+	}
+	parts := make([]string, len(e.Mismatches))
+	for i, m := range e.Mismatches {
+		parts[i] = m.Kind.String() + " column " + strconv.Quote(m.Column)
+	}
+	return "table columns do not match at " + table + ": " + strings.Join(parts, ", ")
+}
+
+// checkColumns compares the header of a table with the fields of its
+// struct rows, of type t, as OnColumnMismatch says. fieldMap maps the keys
+// of the fields to their indexes, as from decodeKeys.
+func (p *parser) checkColumns(t reflect.Type, headers []string, fieldMap map[string]int, flatIdx, remainIdx int) error {
+	if p.onColumnMismatch == IgnoreColumns {
+		return nil
+	}
+	var mismatches []ColumnMismatch
+	matched := make(map[int]bool)
+	for _, h := range headers {
+		if i, ok := fieldMap[h]; ok {
+			matched[i] = true
+		} else if flatIdx < 0 && remainIdx < 0 {
+			mismatches = append(mismatches, ColumnMismatch{Table: string(p.path), Column: h, Kind: ColumnExtra})
+		}
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || i == flatIdx || i == remainIdx || matched[i] {
+			continue
+		}
+		name := parseFieldTag(field, p.fieldNameMapper).name
+		mismatches = append(mismatches, ColumnMismatch{Table: string(p.path), Column: name, Kind: ColumnMissing})
+	}
+	if len(mismatches) == 0 {
+		return nil
+	}
+	if p.onColumnMismatch == ErrorColumns {
+		return &ColumnMismatchError{Mismatches: mismatches}
+	}
+	if p.columnMismatches != nil {
+		*p.columnMismatches = append(*p.columnMismatches, mismatches...)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package god
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type exportRow struct {
+	ID    int    `god:"id"`
+	Name  string `god:"name,alias=full_name"`
+	Email string `god:"email"`
+	Phone string `god:"phone"`
+}
+
+type exportDoc struct {
+	Rows []exportRow `god:"rows"`
+}
+
+// exportData has an extra column, fax, and lacks email and phone; name
+// comes under its alias.
+var exportData = []byte(`{rows=(id,full_name,fax:1,"Alice","555";2,"Bob",;)}`)
+
+var exportRows = []exportRow{{ID: 1, Name: "Alice"}, {ID: 2, Name: "Bob"}}
+
+func TestColumnMismatchIgnore(t *testing.T) {
+	var doc exportDoc
+	if err := Unmarshal(exportData, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(doc.Rows, exportRows) {
+		t.Errorf("got %+v, want %+v", doc.Rows, exportRows)
+	}
+}
+
+func TestColumnMismatchWarn(t *testing.T) {
+	var mismatches []ColumnMismatch
+	var doc exportDoc
+	if err := UnmarshalWith(exportData, &doc, OnColumnMismatch(WarnColumns, &mismatches)); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(doc.Rows, exportRows) {
+		t.Errorf("got %+v, want %+v", doc.Rows, exportRows)
+	}
+	want := []ColumnMismatch{
+		{Table: "rows", Column: "fax", Kind: ColumnExtra},
+		{Table: "rows", Column: "email", Kind: ColumnMissing},
+		{Table: "rows", Column: "phone", Kind: ColumnMissing},
+	}
+	if !reflect.DeepEqual(mismatches, want) {
+		t.Errorf("got %+v, want %+v", mismatches, want)
+	}
+
+	// A matching table reports nothing.
+	mismatches = nil
+	data := []byte(`{(id,name,email,phone:1,"A","a@x","1";)}`)
+	var rows []exportRow
+	if err := UnmarshalWith(data, &rows, OnColumnMismatch(WarnColumns, &mismatches)); err != nil {
+		t.Fatal(err)
+	}
+	if len(mismatches) != 0 {
+		t.Errorf("got %+v, want none", mismatches)
+	}
+}
+
+func TestColumnMismatchError(t *testing.T) {
+	var doc exportDoc
+	err := UnmarshalWith(exportData, &doc, OnColumnMismatch(ErrorColumns, nil))
+	var cme *ColumnMismatchError
+	if !errors.As(err, &cme) {
+		t.Fatalf("got %v, want a *ColumnMismatchError", err)
+	}
+	if len(cme.Mismatches) != 3 {
+		t.Errorf("got %+v", cme.Mismatches)
+	}
+	want := `table columns do not match at rows: extra column "fax", missing column "email", missing column "phone"`
+	if err.Error() != want {
+		t.Errorf("got  %s\nwant %s", err, want)
+	}
+	if doc.Rows != nil {
+		t.Errorf("rows were decoded: %+v", doc.Rows)
+	}
+
+	var rows []exportRow
+	err = UnmarshalWith([]byte(`{(id,name,email,phone,fax:1;)}`), &rows, OnColumnMismatch(ErrorColumns, nil))
+	if !errors.As(err, &cme) || cme.Mismatches[0].Table != "" || err.Error() != `table columns do not match at (root): extra column "fax"` {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestColumnMismatchRemain(t *testing.T) {
+	type row struct {
+		ID    int                   `god:"id"`
+		Name  string                `god:"name"`
+		Other map[string]RawMessage `god:",remain"`
+	}
+	var mismatches []ColumnMismatch
+	var rows []row
+	if err := UnmarshalWith([]byte(`{(id,fax:1,"555";)}`), &rows, OnColumnMismatch(WarnColumns, &mismatches)); err != nil {
+		t.Fatal(err)
+	}
+	want := []ColumnMismatch{{Column: "name", Kind: ColumnMissing}}
+	if !reflect.DeepEqual(mismatches, want) {
+		t.Errorf("got %+v, want %+v", mismatches, want)
+	}
+}
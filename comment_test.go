@@ -0,0 +1,118 @@
+package god
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+type commentAddr struct {
+	City string `god:"city"`
+}
+
+type commentUser struct {
+	Name  string        `god:"name"`
+	Home  commentAddr   `god:"home"`
+	Trips []commentAddr `god:"trips"`
+	Tags  struct {
+		A int `god:"a"`
+	} `god:"tags"`
+}
+
+func TestEmitTypeComments(t *testing.T) {
+	v := commentUser{Name: "Ann", Home: commentAddr{"Oslo"}, Trips: []commentAddr{{"Rome"}, {"Lima"}}}
+	v.Tags.A = 1
+	data, err := MarshalWith(v, Beautify(), EmitTypeComments())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  // github.com/vinayakgupta29/god.commentUser
+  name="Ann";
+  home={
+    // github.com/vinayakgupta29/god.commentAddr
+    city="Oslo";
+  };
+  trips=// []github.com/vinayakgupta29/god.commentAddr
+  (city:
+    "Rome";
+    "Lima";
+  );
+  tags={
+    a=1;
+  };
+}`
+	if string(data) != want {
+		t.Fatalf("got\n%s\nwant\n%s", data, want)
+	}
+
+	var back commentUser
+	if err := Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.Name != "Ann" || back.Home.City != "Oslo" || len(back.Trips) != 2 || back.Tags.A != 1 {
+		t.Errorf("got %+v", back)
+	}
+
+	compact, err := MarshalWith(v, EmitTypeComments())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(compact), "//") {
+		t.Errorf("compact output has comments: %s", compact)
+	}
+}
+
+func TestEmitTypeCommentsRootTable(t *testing.T) {
+	data, err := MarshalWith([]commentAddr{{"Oslo"}}, Beautify(), EmitTypeComments())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  // []github.com/vinayakgupta29/god.commentAddr\n  (city:\n    \"Oslo\";\n  )\n}"
+	if string(data) != want {
+		t.Fatalf("got\n%s\nwant\n%s", data, want)
+	}
+	tr, err := NewTableReader(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tr.Next() || tr.Row()[0] != "Oslo" {
+		t.Errorf("table reader: %v %v", tr.Row(), tr.Err())
+	}
+}
+
+func TestDecodeComments(t *testing.T) {
+	src := `// leading
+{ // after brace
+  name = "Ann"; // trailing "quote" and {brace}
+  // own line
+  trips = ( // before header
+    city:
+    // between rows
+    "Rome";
+  );
+  home = {city="x//y"}
+}
+// done`
+	var v commentUser
+	if err := Unmarshal([]byte(src), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "Ann" || len(v.Trips) != 1 || v.Trips[0].City != "Rome" || v.Home.City != "x//y" {
+		t.Errorf("got %+v", v)
+	}
+
+	d := NewDecoder(strings.NewReader(src + "\n" + src))
+	for i := 0; i < 2; i++ {
+		var v commentUser
+		if err := d.Decode(&v); err != nil {
+			t.Fatalf("document %d: %v", i, err)
+		}
+		if v.Name != "Ann" {
+			t.Errorf("document %d: got %+v", i, v)
+		}
+	}
+	if err := d.Decode(&v); err != io.EOF {
+		t.Errorf("after last document: got %v, want io.EOF", err)
+	}
+}
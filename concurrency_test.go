@@ -0,0 +1,141 @@
+package god
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type parallelRow struct {
+	ID   int
+	Name string            `god:"name,alias=n"`
+	Tags map[string]string `god:",flatten"`
+}
+
+type parallelDoc struct {
+	Rows  []parallelRow
+	Extra interface{}
+	When  timestamp
+}
+
+// TestParallelMarshalUnmarshal runs many encodes and decodes at once, with
+// annotations being registered alongside, so that go test -race catches
+// shared mutable state.
+func TestParallelMarshalUnmarshal(t *testing.T) {
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for g := 0; g < 32; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			// Each name is registered once, also under go test -count.
+			if name := fmt.Sprintf("@parallel%d", g); g%8 == 0 {
+				if _, ok := lookupAnnotation(name); !ok {
+					RegisterAnnotation(name, new(timestamp))
+				}
+			}
+			in := parallelDoc{
+				Rows:  []parallelRow{{ID: g, Name: "a", Tags: map[string]string{"k": "v"}}, {ID: g + 1, Name: "b"}},
+				Extra: map[string]interface{}{"n": []interface{}{"x", true}},
+			}
+			for i := 0; i < 50; i++ {
+				data, err := MarshalWith(in, Beautify(), AlignTableColumns(), UseAliases())
+				if err != nil {
+					errs <- err
+					return
+				}
+				var out parallelDoc
+				if err := UnmarshalWith(data, &out, UseNumber(), InternStrings()); err != nil {
+					errs <- err
+					return
+				}
+				if !reflect.DeepEqual(out.Rows, in.Rows) {
+					errs <- fmt.Errorf("goroutine %d: got %+v, want %+v", g, out.Rows, in.Rows)
+					return
+				}
+				var generic interface{}
+				if err := Unmarshal(data, &generic); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+type (
+	parallelUnit0 int
+	parallelUnit1 int
+	parallelUnit2 int
+	parallelUnit3 int
+)
+
+type parallelUnits struct {
+	A parallelUnit0 `god:"a"`
+	B parallelUnit1 `god:"b"`
+	C parallelUnit2 `god:"c"`
+	D parallelUnit3 `god:"d"`
+}
+
+// parseParallelUnit parses a number of thousands such as "3k".
+func parseParallelUnit[T ~int](s string) (T, error) {
+	var n int
+	if _, err := fmt.Sscanf(s, "%dk", &n); err != nil {
+		return 0, err
+	}
+	return T(n * 1000), nil
+}
+
+// registerParallelUnit registers the parser for T once, also under go test
+// -count.
+func registerParallelUnit[T ~int]() {
+	unitsMu.RLock()
+	_, ok := units[reflect.TypeOf(T(0))]
+	unitsMu.RUnlock()
+	if !ok {
+		RegisterUnit(parseParallelUnit[T])
+	}
+}
+
+// TestParallelRegisterUnit decodes values with unit suffixes while their
+// parsers are being registered, so that go test -race catches unguarded
+// access to the unit registry.
+func TestParallelRegisterUnit(t *testing.T) {
+	registers := []func(){
+		registerParallelUnit[parallelUnit0],
+		registerParallelUnit[parallelUnit1],
+		registerParallelUnit[parallelUnit2],
+		registerParallelUnit[parallelUnit3],
+	}
+	data := []byte(`{a=1k;b=2k;c=3k;d=4k}`)
+	want := parallelUnits{1000, 2000, 3000, 4000}
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			if g < len(registers) {
+				registers[g]()
+			}
+			for i := 0; i < 50; i++ {
+				// Parsers not yet registered make decoding fail.
+				var v parallelUnits
+				if err := Unmarshal(data, &v); err == nil && v != want {
+					t.Errorf("goroutine %d: got %+v, want %+v", g, v, want)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	var v parallelUnits
+	if err := Unmarshal(data, &v); err != nil || v != want {
+		t.Errorf("got %+v, %v, want %+v", v, err, want)
+	}
+}
@@ -0,0 +1,284 @@
+// Package conformance is a test suite for implementations of the GOD
+// format. RunAll checks a marshal and unmarshal pair against documents
+// whose meaning is fixed by GRAMMAR_SPEC.md, so an alternative
+// implementation, or a wrapper around this one, can show that it reads and
+// writes the same format:
+//
+//	func TestConformance(t *testing.T) {
+//		conformance.RunAll(t, mygod.Marshal, mygod.Unmarshal)
+//	}
+//
+// The suite only checks what the format guarantees. Encoders are free in
+// layout, so their output is checked by decoding it again rather than
+// byte for byte.
+package conformance
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// MarshalFunc encodes a Go value as a GOD document.
+type MarshalFunc func(v interface{}) ([]byte, error)
+
+// UnmarshalFunc decodes a GOD document into the value v points to.
+type UnmarshalFunc func(data []byte, v interface{}) error
+
+// RunAll runs every conformance test as a subtest of t.
+func RunAll(t *testing.T, marshal MarshalFunc, unmarshal UnmarshalFunc) {
+	t.Run("Decode", func(t *testing.T) { RunDecode(t, unmarshal) })
+	t.Run("RoundTrip", func(t *testing.T) { RunRoundTrip(t, marshal, unmarshal) })
+	t.Run("Errors", func(t *testing.T) { RunErrors(t, unmarshal) })
+}
+
+// Scalars holds one field of every scalar kind.
+type Scalars struct {
+	S   string  `god:"s"`
+	I   int     `god:"i"`
+	I8  int8    `god:"i8"`
+	I64 int64   `god:"i64"`
+	U   uint    `god:"u"`
+	U64 uint64  `god:"u64"`
+	F32 float32 `god:"f32"`
+	F64 float64 `god:"f64"`
+	B   bool    `god:"b"`
+}
+
+// Row is a table row.
+type Row struct {
+	ID   int    `god:"id"`
+	Name string `god:"name"`
+	OK   bool   `god:"ok"`
+}
+
+// Collections holds lists, maps and tables.
+type Collections struct {
+	List  []int            `god:"list"`
+	Words []string         `god:"words"`
+	Map   map[string]int   `god:"map"`
+	Rows  []Row            `god:"rows"`
+	Any   map[string]Inner `god:"any"`
+}
+
+// Inner is a nested object.
+type Inner struct {
+	Name  string `god:"name"`
+	Inner *Inner `god:"inner"`
+}
+
+// Optional holds pointer fields, which tell \0 apart from a zero value.
+type Optional struct {
+	S *string `god:"s"`
+	I *int    `god:"i"`
+}
+
+// decodeCase is a document and the value it must decode to.
+type decodeCase struct {
+	name string
+	doc  string
+	want interface{}
+}
+
+func str(s string) *string { return &s }
+func num(i int) *int       { return &i }
+
+var decodeCases = []decodeCase{
+	// Scalars
+	{"scalars", `{s="x";i=-1;i8=127;i64=9223372036854775807;u=1;u64=18446744073709551615;f32=1.5;f64=-0.25;b=true}`,
+		Scalars{S: "x", I: -1, I8: 127, I64: math.MaxInt64, U: 1, U64: math.MaxUint64, F32: 1.5, F64: -0.25, B: true}},
+	{"scientific", `{f64=1.5e3;f32=-2E-2}`, Scalars{F64: 1500, F32: -0.02}},
+	{"float integral", `{f64=3}`, Scalars{F64: 3}},
+	{"bool false", `{b=false}`, Scalars{}},
+
+	// Grounding (rule 18)
+	{"empty values", `{s=;i=;b=;f64=}`, Scalars{}},
+	{"null values", `{s=\0;i=\0;b=\0;f64=\0}`, Scalars{}},
+	{"missing keys", `{}`, Scalars{}},
+	{"unknown keys", `{zzz=1;s="x";other=[1,2]}`, Scalars{S: "x"}},
+	{"null pointer", `{s=\0;i=\0}`, Optional{}},
+	{"zero pointer", `{s="";i=0}`, Optional{S: str(""), I: num(0)}},
+
+	// Separators and whitespace (rule 17)
+	{"no trailing semicolon", `{s="a";i=1}`, Scalars{S: "a", I: 1}},
+	{"trailing semicolon", `{s="a";i=1;}`, Scalars{S: "a", I: 1}},
+	{"whitespace", "\n\t{ s = \"a\" ;\n  i = 1 ;\n}\n", Scalars{S: "a", I: 1}},
+
+	// Strings
+	{"unicode", `{s="héllo, 世界 🌍"}`, Scalars{S: "héllo, 世界 🌍"}},
+	{"escapes", `{s="q\"b\\n\nt\t"}`, Scalars{S: "q\"b\\n\nt\t"}},
+	{"unicode escape", `{s="\u00e9\U0001F30D"}`, Scalars{S: "é🌍"}},
+	{"separators in string", `{s="a;b=c{d}[e](f),g:h"}`, Scalars{S: "a;b=c{d}[e](f),g:h"}},
+	{"raw string", "{s=\"\"\"line 1\n\"quoted\" \\n\"\"\"}", Scalars{S: "line 1\n\"quoted\" \\n"}},
+
+	// Collections
+	{"empty collections", `{list=[];words=[];map={};rows=()}`,
+		Collections{List: []int{}, Words: []string{}, Map: map[string]int{}, Rows: []Row{}}},
+	{"lists", `{list=[1,-2,3];words=["a","b,c"]}`, Collections{List: []int{1, -2, 3}, Words: []string{"a", "b,c"}}},
+	{"map", `{map={a=1;b=2}}`, Collections{Map: map[string]int{"a": 1, "b": 2}}},
+	{"table", `{rows=(id,name,ok:1,"a",true;2,"b",false;)}`,
+		Collections{Rows: []Row{{ID: 1, Name: "a", OK: true}, {ID: 2, Name: "b"}}}},
+	{"table without trailing semicolon", `{rows=(id,name:1,"a";2,"b")}`,
+		Collections{Rows: []Row{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}}},
+	{"table grounded cells", `{rows=(id,name,ok:,\0,;3,,true;)}`,
+		Collections{Rows: []Row{{}, {ID: 3, OK: true}}}},
+	{"table column order", `{rows=(name,id:"a",1;)}`, Collections{Rows: []Row{{ID: 1, Name: "a"}}}},
+	{"nested objects", `{any={x={name="a";inner={name="b";inner={name="c"}}}}}`,
+		Collections{Any: map[string]Inner{"x": {Name: "a", Inner: &Inner{Name: "b", Inner: &Inner{Name: "c"}}}}}},
+
+	// Root values (rule 5)
+	{"root string", `{"hello"}`, "hello"},
+	{"root list", `{[1,2,3]}`, []int{1, 2, 3}},
+	{"root table", `{(id,name:1,"a";)}`, []Row{{ID: 1, Name: "a"}}},
+	{"root map", `{a=1;b=2}`, map[string]int{"a": 1, "b": 2}},
+	{"empty key", `{""=1;a=2}`, map[string]int{"": 1, "a": 2}},
+}
+
+// RunDecode checks that fixed documents decode to the expected values.
+func RunDecode(t *testing.T, unmarshal UnmarshalFunc) {
+	for _, c := range decodeCases {
+		t.Run(c.name, func(t *testing.T) {
+			got := reflect.New(reflect.TypeOf(c.want))
+			if err := unmarshal([]byte(c.doc), got.Interface()); err != nil {
+				t.Fatalf("%s: %v", c.doc, err)
+			}
+			if !reflect.DeepEqual(got.Elem().Interface(), c.want) {
+				t.Errorf("%s:\ngot  %#v\nwant %#v", c.doc, got.Elem().Interface(), c.want)
+			}
+		})
+	}
+}
+
+// deep returns an object nested n levels deep.
+func deep(n int) *Inner {
+	v := &Inner{Name: "leaf"}
+	for i := 0; i < n; i++ {
+		v = &Inner{Name: fmt.Sprint(i), Inner: v}
+	}
+	return v
+}
+
+var roundTripCases = []struct {
+	name string
+	v    interface{}
+}{
+	{"scalars", Scalars{S: "x", I: -1, I8: -128, I64: math.MinInt64, U: 7, U64: math.MaxUint64, F32: 0.1, F64: 1e-300, B: true}},
+	{"large float", Scalars{F64: 1.7976931348623157e308}},
+	{"zero scalars", Scalars{}},
+	{"strings", []string{"", "a\"b", "line\nbreak", "tab\t", `back\slash`, "ü世🌍", "a;b=c{}[](),:", `"""`, "\x00\x7f"}},
+	{"collections", Collections{
+		List:  []int{0, 1, -1},
+		Words: []string{"a", ""},
+		Map:   map[string]int{"a": 1, "key with spaces": 2, "k=v;": 0},
+		Rows:  []Row{{ID: 1, Name: "a,b;c", OK: true}, {}},
+		Any:   map[string]Inner{"x": {Name: "y"}},
+	}},
+	{"empty collections", Collections{List: []int{}, Words: []string{}, Map: map[string]int{}, Rows: []Row{}}},
+	{"optional", Optional{S: str(""), I: num(0)}},
+	{"optional null", Optional{}},
+	{"deep", *deep(50)},
+	{"root list", []float64{1.5, -2, 3e20}},
+	{"root table", []Row{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}},
+	{"root map", map[string][]int{"a": {1}, "b": {}}},
+	{"empty key", map[string]int{"": 42}},
+}
+
+// RunRoundTrip checks that values survive marshal and unmarshal intact.
+// An empty list or map may come back nil, as encoders are free to ground
+// it.
+func RunRoundTrip(t *testing.T, marshal MarshalFunc, unmarshal UnmarshalFunc) {
+	for _, c := range roundTripCases {
+		t.Run(c.name, func(t *testing.T) {
+			data, err := marshal(c.v)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			got := reflect.New(reflect.TypeOf(c.v))
+			if err := unmarshal(data, got.Interface()); err != nil {
+				t.Fatalf("unmarshal %s: %v", data, err)
+			}
+			if !groundedEqual(got.Elem(), reflect.ValueOf(c.v)) {
+				t.Errorf("%s:\ngot  %#v\nwant %#v", data, got.Elem().Interface(), c.v)
+			}
+		})
+	}
+}
+
+// groundedEqual is reflect.DeepEqual, except that empty and nil lists and
+// maps are equal.
+func groundedEqual(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Slice, reflect.Array:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !groundedEqual(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for _, k := range a.MapKeys() {
+			bv := b.MapIndex(k)
+			if !bv.IsValid() || !groundedEqual(a.MapIndex(k), bv) {
+				return false
+			}
+		}
+		return true
+	case reflect.Ptr, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return groundedEqual(a.Elem(), b.Elem())
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if !groundedEqual(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+var errorCases = []struct {
+	name   string
+	doc    string
+	target interface{}
+}{
+	{"empty input", ``, &Scalars{}},
+	{"not an object", `s="x"`, &Scalars{}},
+	{"unclosed object", `{s="x"`, &Scalars{}},
+	{"unclosed string", `{s="x}`, &Scalars{}},
+	{"missing equals", `{s "x"}`, &Scalars{}},
+	{"bare empty key", `{=1}`, &map[string]int{}},
+	{"unclosed list", `{list=[1,2}`, &Collections{}},
+	{"unclosed table", `{rows=(id,name:1,"a";`, &Collections{}},
+	{"unclosed table header", `{rows=(id,name`, &Collections{}},
+	{"bad integer", `{i=abc}`, &Scalars{}},
+	{"integer overflow", `{i8=300}`, &Scalars{}},
+	{"negative unsigned", `{u=-1}`, &Scalars{}},
+	{"bad bool", `{b=yes}`, &Scalars{}},
+	{"string into int", `{i="1"}`, &Scalars{}},
+	{"list into string", `{s=[1]}`, &Scalars{}},
+	{"object into list", `{list={a=1}}`, &Collections{}},
+	{"bad table cell", `{rows=(id:x;)}`, &Collections{}},
+	{"non-pointer target", `{s="x"}`, Scalars{}},
+}
+
+// RunErrors checks that malformed documents and mismatched types are
+// rejected.
+func RunErrors(t *testing.T, unmarshal UnmarshalFunc) {
+	for _, c := range errorCases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := unmarshal([]byte(c.doc), c.target); err == nil {
+				t.Errorf("%s: expected an error", c.doc)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package conformance
+
+import (
+	"testing"
+
+	"github.com/vinayakgupta29/god"
+)
+
+func TestGod(t *testing.T) {
+	RunAll(t, god.Marshal, god.Unmarshal)
+}
+
+func TestGodBeautify(t *testing.T) {
+	t.Run("RoundTrip", func(t *testing.T) { RunRoundTrip(t, god.MarshalBeautify, god.Unmarshal) })
+}
@@ -0,0 +1,67 @@
+package god
+
+import (
+	"context"
+	"fmt"
+)
+
+// checkpointInterval is how many key, row and element boundaries pass
+// between checks of the context and progress reports, which keeps the
+// cost of cancellation negligible.
+const checkpointInterval = 64
+
+// UnmarshalContext is Unmarshal that gives up once ctx is done, returning
+// ctx.Err() wrapped with the offset reached. The context is checked at key,
+// row and list element boundaries, so even a huge table stops promptly.
+func UnmarshalContext(ctx context.Context, data []byte, v interface{}, opts ...Option) error {
+	return newOptions(opts).unmarshal.UnmarshalContext(ctx, data, v)
+}
+
+// UnmarshalContext is UnmarshalContext using the options in o.
+func (o UnmarshalOptions) UnmarshalContext(ctx context.Context, data []byte, v interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p := o.newParser(data)
+	p.ctx = ctx
+	return o.unmarshal(p, v)
+}
+
+// DecodeContext is Decode that gives up once ctx is done, as
+// UnmarshalContext does. The rest of the interrupted document is skipped,
+// so the next call starts with the following one.
+func (d *Decoder) DecodeContext(ctx context.Context, v interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	data, err := d.readDocument()
+	if err != nil {
+		return err
+	}
+	p := d.opts.newParser(data)
+	p.headers = d.headers
+	p.ctx = ctx
+	return unmarshal(p, v)
+}
+
+// checkpoint is called at every key, row and list element. Every
+// checkpointInterval calls it reports progress and fails if the context is
+// done.
+func (p *parser) checkpoint() error {
+	if p.ctx == nil && p.progress == nil {
+		return nil
+	}
+	p.checks++
+	if p.checks%checkpointInterval != 0 {
+		return nil
+	}
+	if p.progress != nil {
+		p.progress(int64(p.pos), int64(len(p.src)))
+	}
+	if p.ctx != nil {
+		if err := p.ctx.Err(); err != nil {
+			return fmt.Errorf("decoding stopped at pos %d: %w", p.pos, err)
+		}
+	}
+	return nil
+}
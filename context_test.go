@@ -0,0 +1,109 @@
+package god
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// bigTable returns a document with a table of n rows.
+func bigTable(n int) []byte {
+	var sb strings.Builder
+	sb.WriteString("{rows=(name,age:")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "\"p%d\",%d;", i, i%100)
+	}
+	sb.WriteString(")}")
+	return []byte(sb.String())
+}
+
+type bigDoc struct {
+	Rows []Person `god:"rows"`
+}
+
+func TestUnmarshalContextCancelMidTable(t *testing.T) {
+	data := bigTable(100000)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var stoppedAt int64
+	progress := func(done, total int64) {
+		if total != int64(len(data)) {
+			t.Errorf("total: got %d, want %d", total, len(data))
+		}
+		if stoppedAt == 0 && done > total/10 {
+			stoppedAt = done
+			cancel()
+		}
+	}
+	var v bigDoc
+	err := UnmarshalContext(ctx, data, &v, WithProgress(progress))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "pos") {
+		t.Errorf("error should carry the offset: %v", err)
+	}
+	// The next check comes within checkpointInterval rows.
+	var pos int64
+	fmt.Sscanf(err.Error(), "decoding stopped at pos %d", &pos)
+	if pos-stoppedAt > 64*16 {
+		t.Errorf("stopped at %d, long after cancel at %d", pos, stoppedAt)
+	}
+}
+
+func TestUnmarshalContextCompletes(t *testing.T) {
+	data := bigTable(1000)
+	var last, calls int64
+	var v bigDoc
+	err := UnmarshalContext(context.Background(), data, &v, WithProgress(func(done, total int64) {
+		if done < last {
+			t.Errorf("progress went backwards: %d after %d", done, last)
+		}
+		last = done
+		calls++
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Rows) != 1000 || v.Rows[999].Name != "p999" {
+		t.Errorf("decoded %d rows", len(v.Rows))
+	}
+	if last != int64(len(data)) || calls < 2 {
+		t.Errorf("progress: %d calls, last %d of %d", calls, last, len(data))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := UnmarshalContext(ctx, data, &v); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled context: got %v", err)
+	}
+}
+
+func TestDecodeContext(t *testing.T) {
+	stream := string(bigTable(10000)) + `{rows=(name,age:"last",1;)}`
+	d := NewDecoder(strings.NewReader(stream))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var v bigDoc
+	if err := d.DecodeContext(ctx, &v); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	// A document interrupted mid-table is skipped as a whole.
+	ctx, cancel = context.WithCancel(context.Background())
+	d.opts.Progress = func(done, total int64) { cancel() }
+	if err := d.DecodeContext(ctx, &v); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled mid-table, got %v", err)
+	}
+	d.opts.Progress = nil
+	if err := d.DecodeContext(context.Background(), &v); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Rows) != 1 || v.Rows[0].Name != "last" {
+		t.Errorf("next document: got %+v", v.Rows)
+	}
+}
@@ -0,0 +1,86 @@
+package god
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// A DecodeHookFunc converts a value of a document into a Go value of a
+// type that does not decode it by itself, such as a string into a
+// time.Duration. from is the type the value has when decoded into an
+// interface{}: string, float64 or int64 (Number with UseNumber), bool,
+// map[string]interface{}, []interface{} or, for a table,
+// []map[string]interface{}. to is the type of the target and raw the text
+// of the value, annotation included.
+//
+// A hook that handles the value returns it with true; the value must be
+// assignable or convertible to to, and nil sets the zero value. A hook
+// that does not returns false, and the next hook, or the default
+// decoding, is tried. An error stops decoding.
+type DecodeHookFunc func(from, to reflect.Type, raw []byte) (interface{}, bool, error)
+
+// AddHook adds h to the hooks consulted for every value the Decoder
+// decodes, after those added before; see UnmarshalOptions.DecodeHooks.
+func (d *Decoder) AddHook(h DecodeHookFunc) {
+	d.opts.DecodeHooks = append(d.opts.DecodeHooks, h)
+}
+
+// decodeHooked offers the value at p to the decode hooks, setting target
+// to the result of the first that handles it. If none does, it reports
+// false and leaves p where it was.
+func (p *parser) decodeHooked(target reflect.Value) (bool, error) {
+	start := p.pos
+	var stats UnmarshalStats
+	if p.stats != nil {
+		stats = *p.stats
+	}
+	v, err := parseGenericValue(p)
+	if err != nil {
+		return false, err
+	}
+	ok, err := p.runDecodeHooks(target, reflect.TypeOf(v), p.src[start:p.pos])
+	if !ok && err == nil {
+		p.pos = start
+		if p.stats != nil {
+			*p.stats = stats
+		}
+	}
+	return ok, err
+}
+
+// decodeHookedCell is decodeHooked for a plain table cell, already read as
+// s; raw is its text.
+func (p *parser) decodeHookedCell(target reflect.Value, s string, quoted bool, raw []byte) (bool, error) {
+	var from interface{} = s
+	if !quoted {
+		from = p.genericCell(s)
+	}
+	return p.runDecodeHooks(target, reflect.TypeOf(from), raw)
+}
+
+// runDecodeHooks tries the decode hooks in order and stores the value of
+// the first that handles the value in target.
+func (p *parser) runDecodeHooks(target reflect.Value, from reflect.Type, raw []byte) (bool, error) {
+	for _, h := range p.decodeHooks {
+		v, ok, err := h(from, target.Type(), raw)
+		if err != nil {
+			return true, err
+		}
+		if !ok {
+			continue
+		}
+		rv := reflect.ValueOf(v)
+		switch {
+		case !rv.IsValid():
+			target.Set(reflect.Zero(target.Type()))
+		case rv.Type().AssignableTo(target.Type()):
+			target.Set(rv)
+		case rv.Type().ConvertibleTo(target.Type()):
+			target.Set(rv.Convert(target.Type()))
+		default:
+			return true, fmt.Errorf("decode hook returned %v, which cannot be stored in %v", rv.Type(), target.Type())
+		}
+		return true, nil
+	}
+	return false, nil
+}
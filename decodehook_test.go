@@ -0,0 +1,81 @@
+package god
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// durationHook reads strings such as "1m30s" into time.Durations.
+func durationHook(from, to reflect.Type, raw []byte) (interface{}, bool, error) {
+	if from.Kind() != reflect.String || to != durationType {
+		return nil, false, nil
+	}
+	var s string
+	if err := UnmarshalValue(raw, &s); err != nil {
+		return nil, true, err
+	}
+	d, err := time.ParseDuration(s)
+	return d, true, err
+}
+
+type job struct {
+	Name    string
+	Timeout time.Duration
+	Retry   time.Duration
+}
+
+func TestDecoderAddHook(t *testing.T) {
+	var calls []string
+	dec := NewDecoder(strings.NewReader(`{name="build";timeout="1m30s";retry=5}{jobs=(name,timeout:"a","2s";"b",;)}`))
+	dec.AddHook(func(from, to reflect.Type, raw []byte) (interface{}, bool, error) {
+		calls = append(calls, from.String()+">"+to.String())
+		return nil, false, nil
+	})
+	dec.AddHook(durationHook)
+	dec.AddHook(func(from, to reflect.Type, raw []byte) (interface{}, bool, error) {
+		if from.Kind() == reflect.String && to == durationType {
+			return nil, false, errors.New("earlier hook should have won")
+		}
+		return nil, false, nil
+	})
+
+	var j job
+	if err := dec.Decode(&j); err != nil {
+		t.Fatal(err)
+	}
+	if j != (job{Name: "build", Timeout: 90 * time.Second, Retry: 5}) {
+		t.Errorf("got %+v", j)
+	}
+	want := []string{"string>string", "string>time.Duration", "int64>time.Duration"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("hooks saw %v, want %v", calls, want)
+	}
+
+	var doc struct{ Jobs []job }
+	if err := dec.Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Jobs) != 2 || doc.Jobs[0].Timeout != 2*time.Second || doc.Jobs[1].Timeout != 0 {
+		t.Errorf("got %+v", doc.Jobs)
+	}
+}
+
+func TestDecodeHookErrors(t *testing.T) {
+	o := UnmarshalOptions{DecodeHooks: []DecodeHookFunc{durationHook}}
+	var j job
+	if err := o.Unmarshal([]byte(`{timeout="soon"}`), &j); err == nil || !strings.Contains(err.Error(), "soon") {
+		t.Errorf("got %v, want parse error", err)
+	}
+
+	o.DecodeHooks = []DecodeHookFunc{func(from, to reflect.Type, raw []byte) (interface{}, bool, error) {
+		return "x", to == durationType, nil
+	}}
+	if err := o.Unmarshal([]byte(`{timeout=1}`), &j); err == nil {
+		t.Error("want error for a value of the wrong type")
+	}
+}
@@ -0,0 +1,187 @@
+package god
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// A Decoder reads a stream of GOD documents, such as the output of an
+// Encoder. Documents are delimited by their root braces, so any whitespace
+// (including none) may separate them.
+type Decoder struct {
+	r       *bufio.Reader
+	buf     []byte
+	headers map[int][]string
+	opts    UnmarshalOptions
+}
+
+// NewDecoder returns a Decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Decode reads the next document from the stream and decodes it into v. It
+// returns io.EOF when the stream ends cleanly between documents and
+// io.ErrUnexpectedEOF when it ends inside one.
+func (d *Decoder) Decode(v interface{}) error {
+	data, err := d.readDocument()
+	if err != nil {
+		return err
+	}
+	p := d.opts.newParser(data)
+	p.headers = d.headers
+	return unmarshal(p, v)
+}
+
+// SetMaxTokenSize bounds the length of any single key, string or bare value
+// in the stream, as UnmarshalOptions.MaxTokenSize does for Unmarshal.
+func (d *Decoder) SetMaxTokenSize(n int) {
+	d.opts.MaxTokenSize = n
+}
+
+// SetIncludeResolver resolves the @include directives of the documents
+// read with resolve, see UnmarshalOptions.IncludeResolver.
+func (d *Decoder) SetIncludeResolver(resolve func(path string) ([]byte, error)) {
+	d.opts.IncludeResolver = resolve
+}
+
+// SetTimeFormat sets the layout time.Time values are read in, see
+// UnmarshalOptions.TimeFormat.
+func (d *Decoder) SetTimeFormat(layout string) {
+	d.opts.TimeFormat = layout
+}
+
+// maxTokenSize returns the effective token limit, or 0 for none.
+func (d *Decoder) maxTokenSize() int {
+	switch {
+	case d.opts.MaxTokenSize == 0:
+		return DefaultMaxTokenSize
+	case d.opts.MaxTokenSize < 0:
+		return 0
+	}
+	return d.opts.MaxTokenSize
+}
+
+// readDocument returns the bytes of the next root object, tracking brace
+// depth outside of string literals and comments.
+func (d *Decoder) readDocument() ([]byte, error) {
+	for {
+		c, err := d.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if c == '/' && d.atComment() {
+			if _, err := d.r.ReadBytes('\n'); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if c != ' ' && c != '\n' && c != '\r' && c != '\t' {
+			if c != '{' {
+				return nil, errors.New("root must be an object '{...}'")
+			}
+			break
+		}
+	}
+
+	d.buf = append(d.buf[:0], '{')
+	depth := 1
+	for depth > 0 {
+		c, err := d.r.ReadByte()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		d.buf = append(d.buf, c)
+		if d.opts.MaxInputSize > 0 && len(d.buf) > d.opts.MaxInputSize {
+			return nil, fmt.Errorf("%w: document is longer than %d bytes", ErrInputTooLarge, d.opts.MaxInputSize)
+		}
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case '"':
+			if err := d.readString(); err != nil {
+				return nil, unexpectedEOF(err)
+			}
+		case '/':
+			if d.atComment() {
+				line, err := d.r.ReadBytes('\n')
+				d.buf = append(d.buf, line...)
+				if err != nil {
+					return nil, unexpectedEOF(err)
+				}
+			}
+		}
+	}
+	return d.buf, nil
+}
+
+// atComment reports whether the '/' just read starts a comment.
+func (d *Decoder) atComment() bool {
+	next, _ := d.r.Peek(1)
+	return len(next) == 1 && next[0] == '/'
+}
+
+// readString copies the rest of a string literal whose opening quote has
+// already been read. Strings longer than the token limit are rejected here,
+// before they are buffered in full.
+func (d *Decoder) readString() error {
+	start, limit := len(d.buf), d.maxTokenSize()
+	tooLarge := func() error {
+		if limit > 0 && len(d.buf)-start > limit {
+			return fmt.Errorf("%w: string is longer than %d bytes", ErrTokenTooLarge, limit)
+		}
+		return nil
+	}
+	if next, _ := d.r.Peek(2); string(next) == `""` {
+		d.buf = append(d.buf, '"', '"')
+		d.r.Discard(2)
+		quotes := 0
+		for quotes < 3 {
+			c, err := d.r.ReadByte()
+			if err != nil {
+				return err
+			}
+			d.buf = append(d.buf, c)
+			if c == '"' {
+				quotes++
+			} else {
+				quotes = 0
+			}
+			if err := tooLarge(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for {
+		c, err := d.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		d.buf = append(d.buf, c)
+		if err := tooLarge(); err != nil {
+			return err
+		}
+		switch c {
+		case '\\':
+			c, err = d.r.ReadByte()
+			if err != nil {
+				return err
+			}
+			d.buf = append(d.buf, c)
+		case '"':
+			return nil
+		}
+	}
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,193 @@
+package god
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// DefaultMaxMsgSize is the largest message a DelimitedReader accepts unless
+// its MaxMsgSize is changed.
+const DefaultMaxMsgSize = 4 << 20
+
+// ErrMsgTooLarge is returned by DelimitedReader.ReadMsg when a message exceeds
+// the reader's MaxMsgSize.
+var ErrMsgTooLarge = errors.New("message exceeds maximum size")
+
+// DelimitedWriter writes a sequence of GOD documents to a single stream, such
+// as a TCP connection, so that a DelimitedReader can split them apart again.
+type DelimitedWriter struct {
+	w     io.Writer
+	lines bool
+	buf   []byte
+}
+
+// NewDelimitedWriter returns a writer that frames each compact document with
+// a uvarint length prefix.
+func NewDelimitedWriter(w io.Writer) *DelimitedWriter {
+	return &DelimitedWriter{w: w}
+}
+
+// NewLineDelimitedWriter returns a writer that emits one compact document per
+// line. Multiline strings are re-quoted with escapes so that every document is
+// guaranteed to fit on a single line.
+func NewLineDelimitedWriter(w io.Writer) *DelimitedWriter {
+	return &DelimitedWriter{w: w, lines: true}
+}
+
+// WriteMsg encodes v and writes it as one framed message.
+func (dw *DelimitedWriter) WriteMsg(v interface{}) error {
+	data, err := Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	dw.buf = dw.buf[:0]
+	if dw.lines {
+		data, err = singleLine(data)
+		if err != nil {
+			return err
+		}
+		dw.buf = append(dw.buf, data...)
+		dw.buf = append(dw.buf, '\n')
+	} else {
+		dw.buf = binary.AppendUvarint(dw.buf, uint64(len(data)))
+		dw.buf = append(dw.buf, data...)
+	}
+	// A single Write per message keeps frames intact on shared connections.
+	_, err = dw.w.Write(dw.buf)
+	return err
+}
+
+// DelimitedReader reads GOD documents written by a DelimitedWriter.
+type DelimitedReader struct {
+	// MaxMsgSize bounds the size of a single message in bytes.
+	MaxMsgSize int
+
+	r     *bufio.Reader
+	lines bool
+	buf   []byte
+}
+
+// NewDelimitedReader returns a reader for uvarint length-prefixed documents.
+func NewDelimitedReader(r io.Reader) *DelimitedReader {
+	return &DelimitedReader{MaxMsgSize: DefaultMaxMsgSize, r: bufio.NewReader(r)}
+}
+
+// NewLineDelimitedReader returns a reader for newline-delimited documents.
+// Blank lines between documents are ignored.
+func NewLineDelimitedReader(r io.Reader) *DelimitedReader {
+	return &DelimitedReader{MaxMsgSize: DefaultMaxMsgSize, r: bufio.NewReader(r), lines: true}
+}
+
+// ReadMsg reads the next message and decodes it into v. It returns io.EOF when
+// the stream ends cleanly between messages and io.ErrUnexpectedEOF when it
+// ends inside one.
+func (dr *DelimitedReader) ReadMsg(v interface{}) error {
+	var data []byte
+	var err error
+	if dr.lines {
+		data, err = dr.readLine()
+	} else {
+		data, err = dr.readFrame()
+	}
+	if err != nil {
+		return err
+	}
+	return Unmarshal(data, v)
+}
+
+func (dr *DelimitedReader) readFrame() ([]byte, error) {
+	n, err := binary.ReadUvarint(dr.r)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(dr.MaxMsgSize) {
+		return nil, fmt.Errorf("%w: %d > %d bytes", ErrMsgTooLarge, n, dr.MaxMsgSize)
+	}
+	if cap(dr.buf) < int(n) {
+		dr.buf = make([]byte, n)
+	}
+	dr.buf = dr.buf[:n]
+	if _, err := io.ReadFull(dr.r, dr.buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return dr.buf, nil
+}
+
+func (dr *DelimitedReader) readLine() ([]byte, error) {
+	for {
+		dr.buf = dr.buf[:0]
+		for {
+			chunk, err := dr.r.ReadSlice('\n')
+			dr.buf = append(dr.buf, chunk...)
+			if len(dr.buf) > dr.MaxMsgSize+1 {
+				return nil, fmt.Errorf("%w: more than %d bytes", ErrMsgTooLarge, dr.MaxMsgSize)
+			}
+			if err == bufio.ErrBufferFull {
+				continue
+			}
+			if err == io.EOF && len(dr.buf) > 0 {
+				// Accept a final document without a trailing newline.
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+		line := bytes.TrimSpace(dr.buf)
+		if len(line) > 0 {
+			return line, nil
+		}
+	}
+}
+
+// singleLine rewrites the triple-quoted strings in compact GOD output as
+// regular escaped strings, leaving the rest of the document untouched.
+func singleLine(data []byte) ([]byte, error) {
+	if bytes.IndexByte(data, '\n') < 0 {
+		return data, nil
+	}
+	out := make([]byte, 0, len(data)+16)
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case bytes.HasPrefix(data[i:], []byte(`"""`)):
+			end := bytes.Index(data[i+3:], []byte(`"""`))
+			if end < 0 {
+				return nil, errors.New("unterminated triple-quoted string")
+			}
+			out = append(out, strconv.Quote(string(data[i+3:i+3+end]))...)
+			i += 3 + end + 3
+		case c == '"':
+			// Copy a regular string through, honouring escapes.
+			j := i + 1
+			for j < len(data) && data[j] != '"' {
+				if data[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(data) {
+				return nil, errors.New("unterminated string")
+			}
+			out = append(out, data[i:j+1]...)
+			i = j + 1
+		default:
+			out = append(out, c)
+			i++
+		}
+	}
+	if bytes.IndexByte(out, '\n') >= 0 {
+		return nil, errors.New("document cannot be encoded on a single line")
+	}
+	return out, nil
+}
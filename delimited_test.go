@@ -0,0 +1,119 @@
+package god
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+)
+
+func testDelimitedPipe(t *testing.T, newWriter func(io.Writer) *DelimitedWriter, newReader func(io.Reader) *DelimitedReader) {
+	const n = 3000
+	client, server := net.Pipe()
+	defer server.Close()
+
+	errc := make(chan error, 1)
+	go func() {
+		w := newWriter(client)
+		for i := 0; i < n; i++ {
+			p := Person{Name: fmt.Sprintf("user-%d", i), Age: i, Address: "line one\nline two"}
+			if err := w.WriteMsg(p); err != nil {
+				errc <- err
+				client.Close()
+				return
+			}
+		}
+		errc <- client.Close()
+	}()
+
+	r := newReader(server)
+	for i := 0; i < n; i++ {
+		var p Person
+		if err := r.ReadMsg(&p); err != nil {
+			t.Fatalf("message %d: %v", i, err)
+		}
+		want := Person{Name: fmt.Sprintf("user-%d", i), Age: i, Address: "line one\nline two"}
+		if p != want {
+			t.Fatalf("message %d: expected %+v, got %+v", i, want, p)
+		}
+	}
+	var p Person
+	if err := r.ReadMsg(&p); err != io.EOF {
+		t.Errorf("expected io.EOF at end of stream, got %v", err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("writer error: %v", err)
+	}
+}
+
+func TestDelimitedRoundTrip(t *testing.T) {
+	testDelimitedPipe(t, NewDelimitedWriter, NewDelimitedReader)
+}
+
+func TestLineDelimitedRoundTrip(t *testing.T) {
+	testDelimitedPipe(t, NewLineDelimitedWriter, NewLineDelimitedReader)
+}
+
+func TestLineDelimitedSingleLine(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewLineDelimitedWriter(&buf)
+	if err := w.WriteMsg(map[string]interface{}{"text": "a\nb \"quoted\" end", "other": "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Count(buf.Bytes(), []byte("\n")) != 1 {
+		t.Fatalf("expected exactly one line, got %q", buf.String())
+	}
+
+	var m map[string]interface{}
+	if err := NewLineDelimitedReader(&buf).ReadMsg(&m); err != nil {
+		t.Fatal(err)
+	}
+	if m["text"] != "a\nb \"quoted\" end" || m["other"] != "x" {
+		t.Errorf("unexpected decode: %q", m)
+	}
+}
+
+func TestDelimitedTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewDelimitedWriter(&buf)
+	for i := 0; i < 2; i++ {
+		if err := w.WriteMsg(Person{Name: "Alice", Age: 30}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data := buf.Bytes()[:buf.Len()-3]
+
+	r := NewDelimitedReader(bytes.NewReader(data))
+	var p Person
+	if err := r.ReadMsg(&p); err != nil {
+		t.Fatalf("first message: %v", err)
+	}
+	if err := r.ReadMsg(&p); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestDelimitedMaxMsgSize(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewDelimitedWriter(&buf).WriteMsg(Person{Name: "a long enough name"}); err != nil {
+		t.Fatal(err)
+	}
+	r := NewDelimitedReader(bytes.NewReader(buf.Bytes()))
+	r.MaxMsgSize = 8
+	var p Person
+	if err := r.ReadMsg(&p); !errors.Is(err, ErrMsgTooLarge) {
+		t.Errorf("expected ErrMsgTooLarge, got %v", err)
+	}
+
+	buf.Reset()
+	if err := NewLineDelimitedWriter(&buf).WriteMsg(Person{Name: "a long enough name"}); err != nil {
+		t.Fatal(err)
+	}
+	lr := NewLineDelimitedReader(bytes.NewReader(buf.Bytes()))
+	lr.MaxMsgSize = 8
+	if err := lr.ReadMsg(&p); !errors.Is(err, ErrMsgTooLarge) {
+		t.Errorf("expected ErrMsgTooLarge in line mode, got %v", err)
+	}
+}
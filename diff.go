@@ -0,0 +1,111 @@
+package god
+
+import (
+	"fmt"
+	"sort"
+)
+
+// A Difference is a place where two documents disagree, as found by Diff.
+type Difference struct {
+	// Path locates the value, as in "employees[1].age"; it is empty for
+	// the root.
+	Path string
+
+	// Want and Got are the generically decoded values at Path. Want is nil
+	// for a key only Got has and Got is nil for a key it lacks. For lists
+	// of different lengths, both are the whole lists.
+	Want, Got interface{}
+}
+
+// String describes the difference on one line, for example
+// "employees[1].age: expected 25, got 26".
+func (d Difference) String() string {
+	path := d.Path
+	if path == "" {
+		path = "(root)"
+	}
+	switch {
+	case d.Got == nil:
+		return path + ": missing in actual"
+	case d.Want == nil:
+		return fmt.Sprintf("%s: unexpected key with value %s", path, showGeneric(d.Got))
+	}
+	want, wok := d.Want.([]interface{})
+	got, gok := d.Got.([]interface{})
+	if wok && gok {
+		return fmt.Sprintf("%s: expected %d elements, got %d", path, len(want), len(got))
+	}
+	return fmt.Sprintf("%s: expected %s, got %s", path, showGeneric(d.Want), showGeneric(d.Got))
+}
+
+// Diff decodes want and got generically and returns the differing leaves,
+// in path order. Key order and layout do not matter, numbers compare by
+// value (3 equals 3.0), and a table equals the list of objects it stands
+// for. Diff returns an error if either document is invalid.
+func Diff(want, got []byte) ([]Difference, error) {
+	var w, g interface{}
+	if err := Unmarshal(want, &w); err != nil {
+		return nil, fmt.Errorf("want: %w", err)
+	}
+	if err := Unmarshal(got, &g); err != nil {
+		return nil, fmt.Errorf("got: %w", err)
+	}
+	var out []Difference
+	diffGeneric("", w, g, &out)
+	return out, nil
+}
+
+func diffGeneric(path string, want, got interface{}, out *[]Difference) {
+	want, got = normalizeGeneric(want), normalizeGeneric(got)
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(w)+len(g))
+		for k := range w {
+			keys = append(keys, k)
+		}
+		for k := range g {
+			if _, ok := w[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			diffGeneric(joinPath(path, k), w[k], g[k], out)
+		}
+		return
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok {
+			break
+		}
+		if len(w) != len(g) {
+			*out = append(*out, Difference{Path: path, Want: w, Got: g})
+		}
+		for i := 0; i < len(w) && i < len(g); i++ {
+			diffGeneric(fmt.Sprintf("%s[%d]", path, i), w[i], g[i], out)
+		}
+		return
+	}
+	if want == nil || got == nil || !(EqualOptions{Strict: true}).equal(want, got) {
+		*out = append(*out, Difference{Path: path, Want: want, Got: got})
+	}
+}
+
+// showGeneric renders a leaf for a Difference, summarizing collections.
+func showGeneric(v interface{}) string {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "list"
+	case string:
+		return fmt.Sprintf("%q", v)
+	case float64:
+		return formatFloat(v, 64)
+	}
+	return fmt.Sprint(v)
+}
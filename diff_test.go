@@ -0,0 +1,34 @@
+package god
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	diffs, err := Diff([]byte(`{a=1;b=[1,2];c={d="x"}}`), []byte(`{c={d="y"};b=[1.0,3];a=1.0}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Difference{
+		{Path: "b[1]", Want: int64(2), Got: int64(3)},
+		{Path: "c.d", Want: "x", Got: "y"},
+	}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("got %+v, want %+v", diffs, want)
+	}
+
+	diffs, err = Diff([]byte(`{(a:1;)}`), []byte(`{[{a=1}]}`))
+	if err != nil || len(diffs) != 0 {
+		t.Errorf("table and list of objects: got %v, %v", diffs, err)
+	}
+
+	diffs, err = Diff([]byte(`{n=[3,0.5,1e3]}`), []byte(`{n=[3e0,5e-1,1000.0]}`))
+	if err != nil || len(diffs) != 0 {
+		t.Errorf("number formats: got %v, %v", diffs, err)
+	}
+
+	if _, err := Diff([]byte(`{`), []byte(`{}`)); err == nil {
+		t.Error("expected an error for an invalid document")
+	}
+}
@@ -0,0 +1,625 @@
+package god
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A Kind is the type of a value in a Document.
+type Kind uint8
+
+const (
+	// KindNull is a grounded value: \0, or a key or cell with nothing after it.
+	KindNull Kind = iota
+	KindString
+	KindNumber
+	KindBool
+	KindObject
+	KindList
+	// KindTable is a list of objects sharing a header. Its elements are objects
+	// whose keys are the column names.
+	KindTable
+)
+
+var kindNames = [...]string{"null", "string", "number", "bool", "object", "list", "table"}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "Kind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// A Document is a parsed GOD document held in a few flat slices rather
+// than in maps and boxed values. Keys and scalars are ranges of the source,
+// or of a shared buffer for strings with escapes, and containers refer to a
+// range of their children, so parsing allocates little beyond the slices
+// themselves. Values are converted only when asked for.
+//
+// A Document keeps the data it was parsed from; the caller must not modify
+// it afterwards.
+type Document struct {
+	src   []byte
+	text  []byte // unescaped strings
+	nodes []docNode
+	kids  []int32 // children of containers, each container's contiguous
+}
+
+// docNode is one value. Scalars use val; containers use first and n to
+// name their children in Document.kids. key is set for object members.
+type docNode struct {
+	kind     Kind
+	key, val span
+	first, n int32
+}
+
+// A span is a range of the source, or of Document.text when off is
+// negative, in which case it starts at ^off.
+type span struct {
+	off, n int32
+}
+
+// ParseDocument parses data into a Document. It accepts what Unmarshal
+// accepts for an interface{} target, except for @include directives,
+// which are an error.
+func ParseDocument(data []byte) (*Document, error) {
+	if len(data) > 1<<31-1 {
+		return nil, errors.New("document too large")
+	}
+	d := &Document{
+		src: data,
+		// A value averages well over 8 bytes of source.
+		nodes: make([]docNode, 0, len(data)/8+1),
+	}
+	dp := &docParser{d: d, p: UnmarshalOptions{}.newParser(data)}
+	dp.p.skipSpaces()
+	if dp.p.peek() != '{' {
+		return nil, fmt.Errorf("expected '{' at start of document, got '%c'", dp.p.peek())
+	}
+	if _, err := dp.value(span{}); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Root returns the top-level value: an object for a document of key-value
+// pairs, or the value itself for a document with a single naked value.
+func (d *Document) Root() Node {
+	return Node{d: d, i: 0}
+}
+
+// Interface converts the whole document to the values Unmarshal would store
+// in an interface{}.
+func (d *Document) Interface() interface{} {
+	return d.Root().Interface()
+}
+
+func (d *Document) bytes(s span) []byte {
+	if s.off < 0 {
+		off := ^s.off
+		return d.text[off : off+s.n]
+	}
+	return d.src[s.off : s.off+s.n]
+}
+
+// A Node is a value in a Document. The zero Node is invalid and reports
+// KindNull.
+type Node struct {
+	d *Document
+	i int32
+}
+
+func (n Node) node() *docNode {
+	if n.d == nil {
+		return &docNode{}
+	}
+	return &n.d.nodes[n.i]
+}
+
+// Kind returns the type of the value.
+func (n Node) Kind() Kind {
+	return n.node().kind
+}
+
+// Len returns the number of members of an object or elements of a list or
+// table, and 0 for scalars.
+func (n Node) Len() int {
+	return int(n.node().n)
+}
+
+// Index returns the i'th element of a list or table, or the value of the
+// i'th member of an object in document order. It panics if i is out of
+// range.
+func (n Node) Index(i int) Node {
+	nd := n.node()
+	if i < 0 || i >= int(nd.n) {
+		panic(fmt.Sprintf("god: index %d out of range for %s of length %d", i, nd.kind, nd.n))
+	}
+	return Node{d: n.d, i: n.d.kids[int(nd.first)+i]}
+}
+
+// Key returns the key of an object member, or "" for other values.
+func (n Node) Key() string {
+	if n.d == nil {
+		return ""
+	}
+	return string(n.d.bytes(n.node().key))
+}
+
+// Get returns the value of the object member named key. If the key
+// appears more than once, the last one wins, as with Unmarshal.
+func (n Node) Get(key string) (Node, bool) {
+	nd := n.node()
+	if nd.kind != KindObject {
+		return Node{}, false
+	}
+	for j := int(nd.n) - 1; j >= 0; j-- {
+		c := n.d.kids[int(nd.first)+j]
+		if string(n.d.bytes(n.d.nodes[c].key)) == key {
+			return Node{d: n.d, i: c}, true
+		}
+	}
+	return Node{}, false
+}
+
+// Bytes returns the text of a scalar without copying it: the content of a
+// string, or the token of a number or bool. It is nil for null and
+// containers. The result must not be modified.
+func (n Node) Bytes() []byte {
+	nd := n.node()
+	switch nd.kind {
+	case KindString, KindNumber, KindBool:
+		return n.d.bytes(nd.val)
+	}
+	return nil
+}
+
+// Text returns the text of a scalar as Bytes does, as a string.
+func (n Node) Text() string {
+	return string(n.Bytes())
+}
+
+// Int returns a number as an int64.
+func (n Node) Int() (int64, error) {
+	if k := n.Kind(); k != KindNumber {
+		return 0, fmt.Errorf("cannot read %s as int", k)
+	}
+	return strconv.ParseInt(string(n.Bytes()), 10, 64)
+}
+
+// Float returns a number as a float64.
+func (n Node) Float() (float64, error) {
+	if k := n.Kind(); k != KindNumber {
+		return 0, fmt.Errorf("cannot read %s as float", k)
+	}
+	return strconv.ParseFloat(string(n.Bytes()), 64)
+}
+
+// Bool returns the value of a bool.
+func (n Node) Bool() (bool, error) {
+	if k := n.Kind(); k != KindBool {
+		return false, fmt.Errorf("cannot read %s as bool", k)
+	}
+	return n.Bytes()[0] == 't', nil
+}
+
+// Interface converts the value and everything below it to the values
+// Unmarshal would store in an interface{}: map[string]interface{} for an
+// object, []interface{} for a list, []map[string]interface{} for a table,
+// int64 or float64 for a number and "" for null.
+func (n Node) Interface() interface{} {
+	nd := n.node()
+	switch nd.kind {
+	case KindString:
+		return n.Text()
+	case KindNumber:
+		return (&parser{}).genericCell(n.Text())
+	case KindBool:
+		return nd.val.n == 4
+	case KindObject:
+		m := make(map[string]interface{}, nd.n)
+		n.fill(m)
+		return m
+	case KindList:
+		l := make([]interface{}, nd.n)
+		for j := range l {
+			l[j] = n.Index(j).Interface()
+		}
+		return l
+	case KindTable:
+		rows := make([]map[string]interface{}, nd.n)
+		for j := range rows {
+			row := n.Index(j)
+			rows[j] = make(map[string]interface{}, row.Len())
+			row.fill(rows[j])
+		}
+		return rows
+	}
+	return ""
+}
+
+func (n Node) fill(m map[string]interface{}) {
+	for j := 0; j < n.Len(); j++ {
+		c := n.Index(j)
+		m[c.Key()] = c.Interface()
+	}
+}
+
+// docParser builds a Document, borrowing the scanning helpers of parser.
+type docParser struct {
+	d *Document
+	p *parser
+	// stack collects the children of the containers being parsed until
+	// each is complete and its children can be copied out contiguously.
+	stack []int32
+	// header holds the column names of the table being parsed.
+	header []span
+}
+
+// add appends a node and returns its index.
+func (dp *docParser) add(nd docNode) int32 {
+	dp.d.nodes = append(dp.d.nodes, nd)
+	return int32(len(dp.d.nodes) - 1)
+}
+
+// close moves the children pushed since base into the node at i.
+func (dp *docParser) close(i int32, base int) {
+	nd := &dp.d.nodes[i]
+	nd.first = int32(len(dp.d.kids))
+	nd.n = int32(len(dp.stack) - base)
+	dp.d.kids = append(dp.d.kids, dp.stack[base:]...)
+	dp.stack = dp.stack[:base]
+}
+
+func (dp *docParser) value(key span) (int32, error) {
+	p := dp.p
+	p.skipSpaces()
+	if err := p.checkpoint(); err != nil {
+		return 0, err
+	}
+	switch c := p.peek(); {
+	case p.eof() || c == ';' || c == ',' || c == '}' || c == ']' || c == ')':
+		return dp.add(docNode{kind: KindNull, key: key}), nil
+	case c == '{':
+		return dp.object(key)
+	case c == '[':
+		return dp.list(key)
+	case c == '(':
+		return dp.table(key)
+	case c == '"':
+		s, err := dp.str()
+		if err != nil {
+			return 0, err
+		}
+		return dp.add(docNode{kind: KindString, key: key, val: s}), nil
+	case c == '\\' && p.peekAhead(2) == `\0`:
+		p.pos += 2
+		return dp.add(docNode{kind: KindNull, key: key}), nil
+	}
+	s, err := dp.token()
+	if err != nil {
+		return 0, err
+	}
+	tok := dp.d.bytes(s)
+	switch {
+	case string(tok) == "true" || string(tok) == "false":
+		return dp.add(docNode{kind: KindBool, key: key, val: s}), nil
+	case len(tok) == 0:
+		return 0, fmt.Errorf("unexpected '%c' at pos %d", p.peek(), p.pos)
+	}
+	if _, err := strconv.ParseFloat(string(tok), 64); err != nil {
+		return 0, err
+	}
+	return dp.add(docNode{kind: KindNumber, key: key, val: s}), nil
+}
+
+func (dp *docParser) object(key span) (int32, error) {
+	p := dp.p
+	start := p.pos
+	p.next() // consume '{'
+	p.skipSpaces()
+
+	// Rule 5: an object holds either key-value pairs or one naked value.
+	if p.peek() != '}' {
+		p.skipSpaces()
+		at, quoted := p.pos, p.peek() == '"'
+		k, err := dp.key()
+		if err != nil {
+			return 0, err
+		}
+		if !quoted && string(dp.d.bytes(k)) == includeDirective {
+			return 0, fmt.Errorf("%s not supported by ParseDocument at pos %d", includeDirective, at)
+		}
+		p.skipSpaces()
+		naked := p.peek() != '='
+		p.pos = start + 1
+		if naked {
+			i, err := dp.value(key)
+			if err != nil {
+				return 0, err
+			}
+			return i, p.endNaked()
+		}
+	}
+
+	i := dp.add(docNode{kind: KindObject, key: key})
+	base := len(dp.stack)
+	for {
+		p.skipSpaces()
+		if p.eof() {
+			return 0, errors.New("expected '}' at end of map")
+		}
+		if p.peek() == '}' {
+			p.next()
+			break
+		}
+		if p.peek() == ';' {
+			p.next()
+			continue
+		}
+		start, quoted := p.pos, p.peek() == '"'
+		k, err := dp.key()
+		if err != nil {
+			return 0, err
+		}
+		if !quoted && string(dp.d.bytes(k)) == includeDirective {
+			return 0, fmt.Errorf("%s not supported by ParseDocument at pos %d", includeDirective, start)
+		}
+		p.skipSpaces()
+		if p.peek() != '=' {
+			if err := p.nakedInPairs(string(dp.d.bytes(k)), quoted, start); err != nil {
+				return 0, err
+			}
+			return 0, fmt.Errorf("expected '=' after key '%s', got '%c' at position %d", dp.d.bytes(k), p.peek(), p.pos)
+		}
+		p.next() // consume '='
+		c, err := dp.value(k)
+		if err != nil {
+			return 0, err
+		}
+		dp.stack = append(dp.stack, c)
+		p.skipSpaces()
+		if p.peek() == ';' {
+			p.next()
+		}
+	}
+	dp.close(i, base)
+	return i, nil
+}
+
+func (dp *docParser) list(key span) (int32, error) {
+	p := dp.p
+	p.next() // consume '['
+	i := dp.add(docNode{kind: KindList, key: key})
+	base := len(dp.stack)
+	p.skipSpaces()
+	if p.peek() == ']' {
+		p.next()
+		dp.close(i, base)
+		return i, nil
+	}
+	for {
+		c, err := dp.value(span{})
+		if err != nil {
+			return 0, err
+		}
+		dp.stack = append(dp.stack, c)
+		p.skipSpaces()
+		if p.peek() == ']' {
+			p.next()
+			break
+		}
+		if p.peek() != ',' {
+			return 0, errors.New("expected ',' or ']' in list")
+		}
+		p.next()
+	}
+	dp.close(i, base)
+	return i, nil
+}
+
+func (dp *docParser) table(key span) (int32, error) {
+	p := dp.p
+	p.next() // consume '('
+	i := dp.add(docNode{kind: KindTable, key: key})
+	base := len(dp.stack)
+
+	start := p.pos
+	header, err := p.readUntilAny(":)")
+	if err != nil {
+		return 0, err
+	}
+	p.pos = start
+	sep := headerSeparator(header)
+	defer p.setCellSeparator(sep)()
+
+	dp.header = dp.header[:0]
+	for {
+		p.skipSpaces()
+		if p.eof() {
+			return 0, errUnterminatedTable
+		}
+		if p.peek() == ':' {
+			p.next()
+			break
+		}
+		if p.peek() == ')' {
+			p.next()
+			dp.close(i, base)
+			return i, nil
+		}
+		if h := dp.until(string(sep) + ":)"); h.n > 0 {
+			dp.header = append(dp.header, h)
+		}
+		if p.peek() == sep {
+			p.next()
+		}
+	}
+
+	for {
+		p.skipSpaces()
+		if p.eof() {
+			return 0, errUnterminatedTable
+		}
+		if p.peek() == ')' {
+			p.next()
+			break
+		}
+		if err := p.checkpoint(); err != nil {
+			return 0, err
+		}
+		row := dp.add(docNode{kind: KindObject})
+		rowBase := len(dp.stack)
+		cell := 0
+		for {
+			p.skipSpaces()
+			if p.eof() {
+				return 0, errUnterminatedTable
+			}
+			if p.peek() == ';' {
+				p.next()
+				break
+			}
+			if p.peek() == ')' {
+				break
+			}
+			c, err := dp.cell()
+			if err != nil {
+				return 0, err
+			}
+			if cell < len(dp.header) {
+				dp.d.nodes[c].key = dp.header[cell]
+				dp.stack = append(dp.stack, c)
+			}
+			cell++
+			p.skipSpaces()
+			if p.peek() == sep {
+				p.next()
+			}
+		}
+		// A trailing empty cell leaves no token; ground it like the others.
+		for ; cell < len(dp.header); cell++ {
+			dp.stack = append(dp.stack, dp.add(docNode{kind: KindNull, key: dp.header[cell]}))
+		}
+		dp.close(row, rowBase)
+		dp.stack = append(dp.stack, row)
+	}
+	dp.close(i, base)
+	return i, nil
+}
+
+// cell reads a table cell, which is typed as genericCell types it.
+func (dp *docParser) cell() (int32, error) {
+	// Annotations are type hints a Document does not keep, but a quoted
+	// string after one is still read whole.
+	if dp.p.readAnnotation() != "" {
+		dp.p.skipSpaces()
+	}
+	if isNestedStart(dp.p.peek()) {
+		// A nested table must not reuse the header of this one.
+		header := dp.header
+		dp.header = nil
+		c, err := dp.value(span{})
+		dp.header = header
+		return c, err
+	}
+	if dp.p.peek() == '"' {
+		s, err := dp.str()
+		if err != nil {
+			return 0, err
+		}
+		return dp.add(docNode{kind: KindString, val: s}), nil
+	}
+	s := dp.until(dp.p.cellSeparators())
+	kind := KindNumber
+	switch tok := dp.d.bytes(s); string(tok) {
+	case "", `\0`:
+		kind = KindNull
+	case "true", "false":
+		kind = KindBool
+	default:
+		if _, err := strconv.ParseFloat(string(tok), 64); err != nil {
+			kind = KindString
+		}
+	}
+	return dp.add(docNode{kind: kind, val: s}), nil
+}
+
+// key reads a bare or quoted object key.
+func (dp *docParser) key() (span, error) {
+	dp.p.skipSpaces()
+	if dp.p.peek() == '"' {
+		return dp.str()
+	}
+	return dp.token()
+}
+
+// token reads a bare token as readBareToken does.
+func (dp *docParser) token() (span, error) {
+	p := dp.p
+	p.skipSpaces()
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(" \n\r\t=;{}[](),:", rune(p.peek())) {
+		p.pos++
+	}
+	if err := p.checkTokenSize(start, p.pos); err != nil {
+		return span{}, err
+	}
+	return span{off: int32(start), n: int32(p.pos - start)}, nil
+}
+
+// until reads up to the next byte in seps, trimming surrounding spaces.
+func (dp *docParser) until(seps string) span {
+	p := dp.p
+	start := p.pos
+	for !p.eof() && strings.IndexByte(seps, p.peek()) < 0 {
+		p.pos++
+	}
+	end := p.pos
+	for start < end && isSpace(p.src[start]) {
+		start++
+	}
+	for end > start && isSpace(p.src[end-1]) {
+		end--
+	}
+	return span{off: int32(start), n: int32(end - start)}
+}
+
+// str reads a quoted or triple-quoted string. Strings without escapes are
+// left in the source; the others are unescaped into Document.text.
+func (dp *docParser) str() (span, error) {
+	p := dp.p
+	if p.peekAhead(3) == `"""` {
+		start := p.pos + 3
+		if _, err := parseTripleString(p); err != nil {
+			return span{}, err
+		}
+		return span{off: int32(start), n: int32(p.pos - 3 - start)}, nil
+	}
+	start := p.pos + 1
+	for j := start; j < len(p.src); j++ {
+		switch p.src[j] {
+		case '"':
+			if err := p.checkTokenSize(start, j); err != nil {
+				return span{}, err
+			}
+			p.pos = j + 1
+			return span{off: int32(start), n: int32(j - start)}, nil
+		case '\\':
+			s, err := parseString(p)
+			if err != nil {
+				return span{}, err
+			}
+			off := len(dp.d.text)
+			dp.d.text = append(dp.d.text, s...)
+			return span{off: ^int32(off), n: int32(len(s))}, nil
+		}
+	}
+	return span{}, errors.New("unterminated string")
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t'
+}
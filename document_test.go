@@ -0,0 +1,137 @@
+package god
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDocumentMatchesUnmarshal(t *testing.T) {
+	docs := []string{
+		`{}`,
+		`{"x"}`,
+		`{[1,2]}`,
+		`{a=;b=1;c=\0;d=[1,,2];e=(x,y:1,;"q",\0)}`,
+		`{name="Alice";age=30;score=9.5;ok=true;tags=["a","b\n"]}`,
+		`{"key with spaces"=1;nested={deep={x=-2e3}};raw="""a "quoted" line"""}`,
+		`{people=(name,age:"Bob",25;"Carol",;bare,1.5)}`,
+		`{a=1;a=2}`,
+		`{empty={};list=[];t=()}`,
+		`{(id,items,tags:1,(sku,qty:"a)",1;"b",2;),["x",(k:1;)];2,{n=1},;)}`,
+	}
+	for _, src := range docs {
+		var want interface{}
+		if err := Unmarshal([]byte(src), &want); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", src, err)
+		}
+		d, err := ParseDocument([]byte(src))
+		if err != nil {
+			t.Fatalf("ParseDocument(%s): %v", src, err)
+		}
+		if got := d.Interface(); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s:\n got %#v\nwant %#v", src, got, want)
+		}
+	}
+}
+
+func TestDocumentAccessors(t *testing.T) {
+	src := `{name="Al\"ice";age=30;ratio=0.5;admin=true;gone=\0;tags=["x","y"];people=(name,age:"Bob",25)}`
+	d, err := ParseDocument([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := d.Root()
+	if root.Kind() != KindObject || root.Len() != 7 {
+		t.Fatalf("root is %v of length %d", root.Kind(), root.Len())
+	}
+	if k := root.Index(1).Key(); k != "age" {
+		t.Errorf("second key = %q, want age", k)
+	}
+
+	name, _ := root.Get("name")
+	if name.Kind() != KindString || name.Text() != `Al"ice` {
+		t.Errorf("name = %v %q", name.Kind(), name.Text())
+	}
+	age, _ := root.Get("age")
+	if i, err := age.Int(); err != nil || i != 30 {
+		t.Errorf("age.Int() = %d, %v", i, err)
+	}
+	ratio, _ := root.Get("ratio")
+	if f, err := ratio.Float(); err != nil || f != 0.5 {
+		t.Errorf("ratio.Float() = %v, %v", f, err)
+	}
+	admin, _ := root.Get("admin")
+	if b, err := admin.Bool(); err != nil || !b {
+		t.Errorf("admin.Bool() = %v, %v", b, err)
+	}
+	gone, _ := root.Get("gone")
+	if gone.Kind() != KindNull || gone.Bytes() != nil {
+		t.Errorf("gone = %v %q", gone.Kind(), gone.Bytes())
+	}
+	if _, err := name.Int(); err == nil {
+		t.Error("name.Int() succeeded")
+	}
+	if _, ok := root.Get("missing"); ok {
+		t.Error("Get(missing) found a value")
+	}
+
+	tags, _ := root.Get("tags")
+	if tags.Kind() != KindList || tags.Len() != 2 || tags.Index(1).Text() != "y" {
+		t.Errorf("tags = %v", tags.Interface())
+	}
+	people, _ := root.Get("people")
+	if people.Kind() != KindTable || people.Len() != 1 {
+		t.Fatalf("people = %v", people.Interface())
+	}
+	bob := people.Index(0)
+	if age, _ := bob.Get("age"); age.Text() != "25" {
+		t.Errorf("bob's age = %q", age.Text())
+	}
+}
+
+func TestParseDocumentErrors(t *testing.T) {
+	for _, src := range []string{
+		`name=1`,
+		`{a=1`,
+		`{a "x"}`,
+		`{a=[1,2}`,
+		`{a=(x:1`,
+		`{a="open}`,
+		`{a=abc}`,
+	} {
+		if _, err := ParseDocument([]byte(src)); err == nil {
+			t.Errorf("ParseDocument(%s) succeeded", src)
+		}
+	}
+}
+
+func TestParseDocumentLarge(t *testing.T) {
+	data := benchDocument(1 << 16)
+	d, err := ParseDocument(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want interface{}
+	if err := Unmarshal(data, &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(d.Interface(), want) {
+		t.Error("Interface() differs from Unmarshal")
+	}
+}
+
+func TestParseDocumentInclude(t *testing.T) {
+	for _, src := range []string{`{@include "base.god";a=1}`, `{a=1;@include "base.god"}`, `{@include "base.god"}`} {
+		_, err := ParseDocument([]byte(src))
+		if err == nil || !strings.Contains(err.Error(), "@include not supported by ParseDocument") {
+			t.Errorf("%s: got %v", src, err)
+		}
+	}
+	d, err := ParseDocument([]byte(`{"@include"="x"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := d.Root().Get("@include"); !ok || n.Text() != "x" {
+		t.Errorf("quoted key: got %v", d.Interface())
+	}
+}
@@ -0,0 +1,151 @@
+package god
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// An Encoder writes GOD documents to an output stream.
+//
+// Whole values are written with Encode. Objects whose keys only become known
+// at run time, such as database rows, can instead be built incrementally:
+//
+//	enc.BeginObject()
+//	for i, col := range columns {
+//		enc.EncodeKeyValue(col, values[i])
+//	}
+//	enc.EndObject()
+//
+// Each document is followed by a newline.
+type Encoder struct {
+	w       io.Writer
+	compact bool
+	buf     strings.Builder
+	state   encodeState
+
+	inObject bool
+	first    bool
+	err      error
+}
+
+// NewEncoder returns an Encoder that writes compact documents to w.
+func NewEncoder(w io.Writer) *Encoder {
+	e := &Encoder{w: w, compact: true}
+	e.state.writer = &e.buf
+	return e
+}
+
+// SetBeautify controls whether documents are written in the indented form
+// produced by MarshalBeautify.
+func (e *Encoder) SetBeautify(on bool) {
+	e.compact = !on
+}
+
+// SetEscapeUnicode controls whether non-ASCII text is written as escapes,
+// see MarshalOptions.EscapeUnicode.
+func (e *Encoder) SetEscapeUnicode(on bool) {
+	e.state.escapeUnicode = on
+}
+
+// SetTimeFormat sets the layout time.Time values are written in, see
+// MarshalOptions.TimeFormat.
+func (e *Encoder) SetTimeFormat(layout string) {
+	e.state.timeFormat = layout
+}
+
+// SetMaxWidth wraps the rows of beautified tables at n characters, see
+// MarshalOptions.MaxWidth. Zero turns wrapping off.
+func (e *Encoder) SetMaxWidth(n int) {
+	e.state.maxWidth = n
+}
+
+// SetIndent sets what each level of beautified documents is indented by,
+// see MarshalOptions.Indent. "" restores the default of two spaces.
+func (e *Encoder) SetIndent(unit string) {
+	e.state.indentUnit = unit
+}
+
+// Encode writes the GOD encoding of v to the stream.
+func (e *Encoder) Encode(v interface{}) error {
+	if e.inObject {
+		return errors.New("cannot encode a document while an object is open")
+	}
+	e.buf.Reset()
+	mark := len(e.state.headers)
+	if err := encodeRoot(&e.state, v, e.compact); err != nil {
+		e.state.forgetHeaders(mark)
+		return err
+	}
+	e.buf.WriteByte('\n')
+	return e.flush()
+}
+
+// BeginObject starts a root object whose pairs are written with
+// EncodeKeyValue.
+func (e *Encoder) BeginObject() error {
+	if e.inObject {
+		return errors.New("an object is already open")
+	}
+	if err := checkIndent(e.state.indentUnit); err != nil {
+		return err
+	}
+	e.inObject = true
+	e.first = true
+	e.buf.Reset()
+	e.buf.WriteByte('{')
+	if !e.compact {
+		e.buf.WriteByte('\n')
+	}
+	return e.flush()
+}
+
+// EncodeKeyValue writes one key = value pair into the object opened by
+// BeginObject. Keys that are not valid bare tokens are quoted.
+func (e *Encoder) EncodeKeyValue(key string, value interface{}) error {
+	if !e.inObject {
+		return errors.New("no object is open")
+	}
+	e.buf.Reset()
+	if !e.first && e.compact {
+		e.buf.WriteByte(';')
+	}
+	e.first = false
+	if !e.compact {
+		e.buf.WriteString(e.state.indent(1))
+	}
+	encodeKey(&e.state, key)
+	e.buf.WriteByte('=')
+	// A nil value is grounded like any other zero value: key=
+	if value != nil {
+		mark := len(e.state.headers)
+		if err := encodeValue(&e.state, reflect.ValueOf(value), 2, e.compact); err != nil {
+			e.state.forgetHeaders(mark)
+			return err
+		}
+	}
+	if !e.compact {
+		e.buf.WriteString(";\n")
+	}
+	return e.flush()
+}
+
+// EndObject closes the object opened by BeginObject.
+func (e *Encoder) EndObject() error {
+	if !e.inObject {
+		return errors.New("no object is open")
+	}
+	e.inObject = false
+	e.buf.Reset()
+	e.buf.WriteString("}\n")
+	return e.flush()
+}
+
+func (e *Encoder) flush() error {
+	if e.err != nil {
+		return e.err
+	}
+	_, e.err = io.WriteString(e.w, e.buf.String())
+	return e.err
+}
@@ -0,0 +1,103 @@
+package god
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEncoderKeyValue(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+
+	columns := []string{"id", "name", "created at", "tags", "note"}
+	values := []interface{}{42, "Alice", "2024-01-02", []string{"a", "b"}, nil}
+
+	if err := enc.BeginObject(); err != nil {
+		t.Fatal(err)
+	}
+	for i, col := range columns {
+		if err := enc.EncodeKeyValue(col, values[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.EndObject(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{id=42;name="Alice";"created at"="2024-01-02";tags=["a","b"];note=}` + "\n"
+	if buf.String() != want {
+		t.Errorf("expected %q, got %q", want, buf.String())
+	}
+
+	var m map[string]interface{}
+	if err := Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if m["created at"] != "2024-01-02" || m["name"] != "Alice" {
+		t.Errorf("unexpected decode: %v", m)
+	}
+}
+
+func TestEncoderKeyValueBeautify(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetBeautify(true)
+	enc.BeginObject()
+	enc.EncodeKeyValue("key", "value")
+	enc.EncodeKeyValue("n", 1)
+	enc.EndObject()
+
+	want := "{\n  key=\"value\";\n  n=1;\n}\n"
+	if buf.String() != want {
+		t.Errorf("expected %q, got %q", want, buf.String())
+	}
+}
+
+func TestEncoderMisuse(t *testing.T) {
+	enc := NewEncoder(&bytes.Buffer{})
+	if err := enc.EncodeKeyValue("a", 1); err == nil {
+		t.Error("expected error for EncodeKeyValue without BeginObject")
+	}
+	if err := enc.EndObject(); err == nil {
+		t.Error("expected error for EndObject without BeginObject")
+	}
+	enc.BeginObject()
+	if err := enc.BeginObject(); err == nil {
+		t.Error("expected error for nested BeginObject")
+	}
+	if err := enc.Encode(Person{}); err == nil {
+		t.Error("expected error for Encode inside an open object")
+	}
+}
+
+func TestEncoderEncode(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	for _, p := range []Person{{Name: "A", Age: 1}, {Name: "B", Age: 2}} {
+		if err := enc.Encode(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || lines[0] != `{name="A";age=1;addr=}` {
+		t.Errorf("unexpected stream: %q", buf.String())
+	}
+}
+
+func TestQuotedMapKeysRoundTrip(t *testing.T) {
+	in := map[string]int{"plain": 1, "with space": 2, "a=b": 3, "@include": 4, `q"uote`: 5}
+	encoded, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]int
+	if err := Unmarshal(encoded, &out); err != nil {
+		t.Fatalf("Unmarshal error: %v\n%s", err, encoded)
+	}
+	for k, v := range in {
+		if out[k] != v {
+			t.Errorf("key %q: expected %d, got %d (%s)", k, v, out[k], encoded)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package god
+
+import "reflect"
+
+// Equal reports whether two GOD documents hold the same data. It is
+// equivalent to EqualOptions{}.Equal(a, b).
+func Equal(a, b []byte) (bool, error) {
+	return EqualOptions{}.Equal(a, b)
+}
+
+// EqualOptions configures the structural comparison of Equal.
+type EqualOptions struct {
+	// Strict tells grounded values, such as key= and \0, and absent keys
+	// apart from explicit zeros such as 0, false or []. By default every
+	// zero value is equal to every other, as all of them decode to the
+	// zero value of a typed field.
+	Strict bool
+}
+
+// Equal decodes a and b generically and compares them structurally, so
+// whitespace, key order, number formats (3 and 3.0) and tables versus
+// lists of objects make no difference. It returns an error if either
+// document is invalid.
+func (o EqualOptions) Equal(a, b []byte) (bool, error) {
+	var va, vb interface{}
+	if err := Unmarshal(a, &va); err != nil {
+		return false, err
+	}
+	if err := Unmarshal(b, &vb); err != nil {
+		return false, err
+	}
+	return o.equal(va, vb), nil
+}
+
+func (o EqualOptions) equal(x, y interface{}) bool {
+	x, y = normalizeGeneric(x), normalizeGeneric(y)
+	if !o.Strict && isGenericZero(x) && isGenericZero(y) {
+		return true
+	}
+
+	switch x := x.(type) {
+	case map[string]interface{}:
+		y, ok := y.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for k, xv := range x {
+			yv, ok := y[k]
+			if !ok && o.Strict {
+				return false
+			}
+			if !o.equal(xv, yv) {
+				return false
+			}
+		}
+		for k, yv := range y {
+			if _, ok := x[k]; !ok && (o.Strict || !isGenericZero(normalizeGeneric(yv))) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		y, ok := y.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !o.equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case int64, float64, Number:
+		xs, xok := canonicalNumber(x)
+		ys, yok := canonicalNumber(y)
+		return xok && yok && xs == ys
+	}
+	return x == y
+}
+
+// normalizeGeneric turns a generically decoded table into the list of
+// objects it stands for.
+func normalizeGeneric(v interface{}) interface{} {
+	rows, ok := v.([]map[string]interface{})
+	if !ok {
+		return v
+	}
+	list := make([]interface{}, len(rows))
+	for i, row := range rows {
+		list[i] = row
+	}
+	return list
+}
+
+// isGenericZero reports whether a generically decoded value is a zero
+// value. Absent map entries are nil.
+func isGenericZero(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return reflect.ValueOf(v).IsZero()
+}
@@ -0,0 +1,45 @@
+package god
+
+import "testing"
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		equal  bool
+		strict bool
+	}{
+		{`{a=1;b="x"}`, "{\n  b = \"x\";\n  a = 1;\n}", true, true},
+		{`{n=3}`, `{n=3.0}`, true, true},
+		{`{n=3}`, `{n=3.5}`, false, false},
+		{`{n=[3,3.0,3e0,30e-1]}`, `{n=[3e0,3,3.00,3]}`, true, true},
+		{`{n=9007199254740993}`, `{n=9007199254740992.0}`, false, false},
+		{`{list=[1,2]}`, `{list=[2,1]}`, false, false},
+		{`{rows=(a,b:1,"x";)}`, `{rows=[{a=1;b="x"}]}`, true, true},
+		{`{a=;b=1}`, `{a=0;b=1}`, true, false},
+		{`{a=\0}`, `{a=false}`, true, false},
+		{`{a=1}`, `{a=1;b=}`, true, false},
+		{`{a=1}`, `{a=1;b=2}`, false, false},
+		{`{m={}}`, `{m=[]}`, true, false},
+		{`{"x"}`, `{ "x" }`, true, true},
+	}
+	for _, tt := range tests {
+		got, err := Equal([]byte(tt.a), []byte(tt.b))
+		if err != nil {
+			t.Fatalf("Equal(%s, %s): %v", tt.a, tt.b, err)
+		}
+		if got != tt.equal {
+			t.Errorf("Equal(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.equal)
+		}
+		got, err = EqualOptions{Strict: true}.Equal([]byte(tt.b), []byte(tt.a))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.strict {
+			t.Errorf("strict Equal(%s, %s) = %v, want %v", tt.b, tt.a, got, tt.strict)
+		}
+	}
+
+	if _, err := Equal([]byte(`{a=1}`), []byte(`not god`)); err == nil {
+		t.Error("expected an error for an invalid document")
+	}
+}
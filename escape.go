@@ -0,0 +1,38 @@
+package god
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// EscapeKey returns key as it may be written in a GOD document: as it is
+// if it holds only ASCII letters, digits and underscores, and otherwise
+// quoted, with '"', '\' and non-printable characters escaped. It is meant
+// for programs that write documents by hand from keys they do not control;
+// Marshal and Builder escape keys themselves. UnescapeKey reverses it.
+func EscapeKey(key string) string {
+	if key == "" {
+		return `""`
+	}
+	for i := 0; i < len(key); i++ {
+		if c := key[i]; !(c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
+			return strconv.Quote(key)
+		}
+	}
+	return key
+}
+
+// UnescapeKey returns the key written as token, a bare or quoted key as
+// read from a GOD document or returned by EscapeKey. A token that is not a
+// single key, or is empty, is an error.
+func UnescapeKey(token string) (string, error) {
+	p := UnmarshalOptions{}.newParser([]byte(token))
+	key, quoted, err := p.readKey()
+	if err != nil {
+		return "", fmt.Errorf("invalid key %q: %w", token, err)
+	}
+	if key == "" && !quoted || !p.eof() {
+		return "", fmt.Errorf("invalid key %q", token)
+	}
+	return key, nil
+}
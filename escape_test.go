@@ -0,0 +1,41 @@
+package god
+
+import "testing"
+
+func TestEscapeKey(t *testing.T) {
+	tests := []struct{ key, want string }{
+		{"user_id", "user_id"},
+		{"Name2", "Name2"},
+		{"", `""`},
+		{"first name", `"first name"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{"a=b;c", `"a=b;c"`},
+		{"@include", `"@include"`},
+		{"tab\there", `"tab\there"`},
+		{"naïve", `"naïve"`},
+	}
+	for _, tt := range tests {
+		got := EscapeKey(tt.key)
+		if got != tt.want {
+			t.Errorf("EscapeKey(%q) = %s, want %s", tt.key, got, tt.want)
+		}
+		back, err := UnescapeKey(got)
+		if err != nil || back != tt.key {
+			t.Errorf("UnescapeKey(%s) = %q, %v, want %q", got, back, err, tt.key)
+		}
+
+		// The escaped key works in a document.
+		var m map[string]int
+		if err := Unmarshal([]byte("{"+got+"=1}"), &m); err != nil || m[tt.key] != 1 {
+			t.Errorf("decoding key %s: got %v, %v", got, m, err)
+		}
+	}
+}
+
+func TestUnescapeKeyErrors(t *testing.T) {
+	for _, token := range []string{``, `"open`, `a=b`, `"a"b`, `a;`} {
+		if key, err := UnescapeKey(token); err == nil {
+			t.Errorf("UnescapeKey(%q) = %q, want error", token, key)
+		}
+	}
+}
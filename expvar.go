@@ -0,0 +1,92 @@
+package god
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"sync"
+)
+
+var (
+	publishedMu sync.RWMutex
+	published   = make(map[string]func() interface{})
+)
+
+// Publish registers a value to be served by ExpvarHandler under name. f is
+// called on every request, so it should be cheap and safe for concurrent
+// use. Like expvar.Publish, it panics if name is already in use, either by
+// an earlier Publish or by an expvar variable.
+func Publish(name string, f func() interface{}) {
+	publishedMu.Lock()
+	defer publishedMu.Unlock()
+	if _, dup := published[name]; dup || expvar.Get(name) != nil {
+		panic("reuse of published var name: " + name)
+	}
+	published[name] = f
+}
+
+// ExpvarHandler returns an http.Handler that serves a snapshot of all
+// expvar variables, including the default cmdline and memstats, and of the
+// values registered with Publish, as a beautified GOD object. It is the GOD
+// counterpart of expvar.Handler:
+//
+//	http.Handle("/debug/vars.god", god.ExpvarHandler())
+func ExpvarHandler() http.Handler {
+	return http.HandlerFunc(serveExpvar)
+}
+
+func serveExpvar(w http.ResponseWriter, r *http.Request) {
+	data, err := MarshalBeautify(expvarSnapshot())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType+"; charset=utf-8")
+	w.Write(data)
+}
+
+// expvarSnapshot collects the current values of all variables.
+func expvarSnapshot() map[string]interface{} {
+	vars := make(map[string]interface{})
+	expvar.Do(func(kv expvar.KeyValue) {
+		vars[kv.Key] = expvarValue(kv.Value)
+	})
+
+	// Copy the callbacks so that they run without holding the lock.
+	publishedMu.RLock()
+	funcs := make(map[string]func() interface{}, len(published))
+	for name, f := range published {
+		funcs[name] = f
+	}
+	publishedMu.RUnlock()
+	for name, f := range funcs {
+		vars[name] = f()
+	}
+	return vars
+}
+
+// expvarValue returns the Go value of an expvar variable. Variables of
+// unknown types are decoded from their JSON form.
+func expvarValue(v expvar.Var) interface{} {
+	switch v := v.(type) {
+	case *expvar.Int:
+		return v.Value()
+	case *expvar.Float:
+		return v.Value()
+	case *expvar.String:
+		return v.Value()
+	case expvar.Func:
+		return v.Value()
+	case *expvar.Map:
+		m := make(map[string]interface{})
+		v.Do(func(kv expvar.KeyValue) {
+			m[kv.Key] = expvarValue(kv.Value)
+		})
+		return m
+	}
+	var x interface{}
+	if err := json.Unmarshal([]byte(v.String()), &x); err != nil {
+		return v.String()
+	}
+	return x
+}
@@ -0,0 +1,65 @@
+package god
+
+import (
+	"expvar"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// The variables are registered once per process, as expvar names cannot
+// be reused, so that the tests can run with -count greater than 1.
+var (
+	expvarOnce  sync.Once
+	publishOnce sync.Once
+)
+
+func TestExpvarHandler(t *testing.T) {
+	expvarOnce.Do(func() {
+		expvar.NewInt("god_test_requests").Set(42)
+		expvar.NewFloat("god_test_ratio").Set(0.1)
+		expvar.NewMap("god_test_codes").Add("200", 7)
+		Publish("god_test_build", func() interface{} {
+			return map[string]interface{}{"version": "1.2.3", "load": float32(0.3)}
+		})
+	})
+
+	rec := httptest.NewRecorder()
+	ExpvarHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/vars", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/god; charset=utf-8" {
+		t.Errorf("content type: got %q", ct)
+	}
+
+	var got map[string]interface{}
+	if err := Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v\n%s", err, rec.Body)
+	}
+	if got["god_test_requests"] != int64(42) {
+		t.Errorf("requests: got %#v", got["god_test_requests"])
+	}
+	if got["god_test_ratio"] != 0.1 {
+		t.Errorf("ratio: got %#v", got["god_test_ratio"])
+	}
+	if codes, ok := got["god_test_codes"].(map[string]interface{}); !ok || codes["200"] != int64(7) {
+		t.Errorf("codes: got %#v", got["god_test_codes"])
+	}
+	build, ok := got["god_test_build"].(map[string]interface{})
+	if !ok || build["version"] != "1.2.3" || build["load"] != 0.3 {
+		t.Errorf("build: got %#v", got["god_test_build"])
+	}
+	if _, ok := got["memstats"].(map[string]interface{}); !ok {
+		t.Errorf("memstats: got %T", got["memstats"])
+	}
+}
+
+func TestPublishDuplicate(t *testing.T) {
+	publishOnce.Do(func() {
+		Publish("god_test_dup", func() interface{} { return 1 })
+	})
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a reused name")
+		}
+	}()
+	Publish("god_test_dup", func() interface{} { return 2 })
+}
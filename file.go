@@ -0,0 +1,111 @@
+package god
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// ReadFile reads the GOD document at path and decodes it into v.
+// @include directives are resolved relative to the file's directory.
+func ReadFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	opts := UnmarshalOptions{IncludeFS: os.DirFS(filepath.Dir(path))}
+	if err := opts.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("decode %s: %w", path, err)
+	}
+	return nil
+}
+
+// ReadFS reads the GOD document name from fsys and decodes it into v.
+// It is intended for configs shipped with embed.FS; @include directives are
+// resolved within fsys, relative to name.
+func ReadFS(fsys fs.FS, name string, v interface{}) error {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	dir, err := fs.Sub(fsys, path.Dir(name))
+	if err != nil {
+		return err
+	}
+	opts := UnmarshalOptions{IncludeFS: dir}
+	if err := opts.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("decode %s: %w", name, err)
+	}
+	return nil
+}
+
+// UnmarshalFile decodes the GOD document at path in fsys into v, as
+// ReadFS does. It takes an fstest.MapFS in tests and an embed.FS for
+// embedded configs alike.
+func UnmarshalFile(fsys fs.FS, path string, v interface{}) error {
+	return ReadFS(fsys, path, v)
+}
+
+// MarshalToFile encodes v compactly into the file at path in the OS
+// filesystem, with mode 0644, as WriteFile does. An fs.FS cannot be
+// written to, so there is no fs.FS counterpart of UnmarshalFile.
+func MarshalToFile(path string, v interface{}) error {
+	return WriteFile(path, v, 0o644, false)
+}
+
+// WriteFile encodes v and atomically replaces the file at path with the result.
+// The document is written to a temporary file in the same directory, synced to
+// disk and then renamed over path, so readers only ever observe the old or the
+// new contents, never a partial write. If pretty is true the output is
+// beautified. The file ends with a newline.
+func WriteFile(path string, v interface{}, perm fs.FileMode, pretty bool) error {
+	data, err := MarshalOptions{Beautify: pretty, TrailingNewline: true}.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", path, err)
+	}
+
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	// Clean up the temp file on any failure before the rename.
+	committed := false
+	defer func() {
+		if !committed {
+			tmp.Close()
+			os.Remove(tmpName)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("sync %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		return err
+	}
+	committed = true
+
+	// Persist the rename itself. Not every platform allows syncing a
+	// directory, so failures here are ignored.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package god
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+//go:embed testdata
+var testdataFS embed.FS
+
+func TestWriteFileReadFileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "person.god")
+	want := Person{Name: "Alice", Age: 30, Address: "NYC"}
+
+	for _, pretty := range []bool{false, true} {
+		if err := WriteFile(path, want, 0o644, pretty); err != nil {
+			t.Fatalf("WriteFile(pretty=%v) error: %v", pretty, err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(string(data), "}\n") {
+			t.Errorf("pretty=%v: expected the file to end with one newline, got %q", pretty, data)
+		}
+		var got Person
+		if err := ReadFile(path, &got); err != nil {
+			t.Fatalf("ReadFile error: %v", err)
+		}
+		if got != want {
+			t.Errorf("pretty=%v: expected %+v, got %+v", pretty, want, got)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o644 {
+		t.Errorf("expected mode 0644, got %v", info.Mode().Perm())
+	}
+}
+
+func TestWriteFileNoPartialFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "people.god")
+
+	small := benchPeople(1)
+	large := benchPeople(2000)
+	if err := WriteFile(path, small, 0o644, false); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Errorf("read during write: %v", err)
+				return
+			}
+			var got []Person
+			if err := Unmarshal(data, &got); err != nil {
+				t.Errorf("observed partial file (%d bytes): %v", len(data), err)
+				return
+			}
+			if len(got) != len(small) && len(got) != len(large) {
+				t.Errorf("observed %d rows, expected %d or %d", len(got), len(small), len(large))
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 50; i++ {
+		v := small
+		if i%2 == 0 {
+			v = large
+		}
+		if err := WriteFile(path, v, 0o644, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("expected only the target file to remain, got %v", names)
+	}
+}
+
+func TestWriteFileEncodeErrorKeepsOldFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "person.god")
+	if err := WriteFile(path, Person{Name: "Bob"}, 0o644, false); err != nil {
+		t.Fatal(err)
+	}
+
+	err := WriteFile(path, map[string]interface{}{"ch": make(chan int, 1)}, 0o644, false)
+	if err == nil {
+		t.Fatal("expected an error for an unsupported type")
+	}
+	if !strings.Contains(err.Error(), path) {
+		t.Errorf("expected error to mention %q, got %v", path, err)
+	}
+
+	var got Person
+	if err := ReadFile(path, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "Bob" {
+		t.Errorf("expected old contents to survive, got %+v", got)
+	}
+}
+
+func TestReadFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing.god")
+	var p Person
+	err := ReadFile(missing, &p)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+
+	bad := filepath.Join(dir, "bad.god")
+	if err := os.WriteFile(bad, []byte(`name="x"`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err = ReadFile(bad, &p)
+	if err == nil || !strings.Contains(err.Error(), bad) {
+		t.Errorf("expected decode error mentioning %q, got %v", bad, err)
+	}
+}
+
+func TestReadFS(t *testing.T) {
+	var p Person
+	if err := ReadFS(testdataFS, "testdata/config.god", &p); err != nil {
+		t.Fatalf("ReadFS error: %v", err)
+	}
+	want := Person{Name: "embedded", Age: 7, Address: "Config Lane"}
+	if p != want {
+		t.Errorf("expected %+v, got %+v", want, p)
+	}
+
+	if err := ReadFS(testdataFS, "testdata/nope.god", &p); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestUnmarshalFileMapFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"configs/app.god":  {Data: []byte(`{@include "base.god"; age=31}`)},
+		"configs/base.god": {Data: []byte(`{name="Alice"; age=30; addr="NYC"}`)},
+	}
+	var p Person
+	if err := UnmarshalFile(fsys, "configs/app.god", &p); err != nil {
+		t.Fatal(err)
+	}
+	if want := (Person{Name: "Alice", Age: 31, Address: "NYC"}); p != want {
+		t.Errorf("expected %+v, got %+v", want, p)
+	}
+	if err := UnmarshalFile(fsys, "configs/nope.god", &p); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestMarshalToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "person.god")
+	want := Person{Name: "Bob", Age: 40}
+	if err := MarshalToFile(path, want); err != nil {
+		t.Fatal(err)
+	}
+	var got Person
+	if err := UnmarshalFile(os.DirFS(filepath.Dir(path)), filepath.Base(path), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
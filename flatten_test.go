@@ -0,0 +1,131 @@
+package god
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type flatService struct {
+	Name  string                 `god:"name"`
+	Port  int                    `god:"port"`
+	Extra map[string]interface{} `god:"extra,flatten"`
+}
+
+func TestFlattenEncode(t *testing.T) {
+	v := flatService{
+		Name:  "api",
+		Port:  80,
+		Extra: map[string]interface{}{"region": "eu", "debug": true, "name": "shadowed"},
+	}
+	data, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{name="api";port=80;debug=true;region="eu"}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+
+	// A nil map adds nothing.
+	data, err = Marshal(flatService{Name: "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{name="api";port=}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+func TestFlattenDecode(t *testing.T) {
+	var v flatService
+	src := `{name="api";region="eu";port=80;retries=3}`
+	if err := Unmarshal([]byte(src), &v); err != nil {
+		t.Fatal(err)
+	}
+	want := flatService{
+		Name:  "api",
+		Port:  80,
+		Extra: map[string]interface{}{"region": "eu", "retries": int64(3)},
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("got %#v, want %#v", v, want)
+	}
+}
+
+func TestFlattenRoundTrip(t *testing.T) {
+	type limits struct {
+		Name   string         `god:"name"`
+		Limits map[string]int `god:"limits,flatten"`
+	}
+	in := limits{Name: "a", Limits: map[string]int{"cpu": 2, "mem": 512}}
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out limits
+	if err := Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("got %+v, want %+v", out, in)
+	}
+}
+
+func TestFlattenNonMap(t *testing.T) {
+	type bad struct {
+		Extra string `god:"extra,flatten"`
+	}
+	_, err := Marshal(bad{})
+	if err == nil || !strings.Contains(err.Error(), "must be a map") {
+		t.Errorf("expected flatten error, got %v", err)
+	}
+	if err := Unmarshal([]byte(`{extra="x"}`), &bad{}); err == nil {
+		t.Error("expected flatten error on decode")
+	}
+}
+
+func TestFlattenTableColumns(t *testing.T) {
+	rows := []flatService{
+		{Name: "api", Port: 80, Extra: map[string]interface{}{"region": "eu", "tags": []interface{}{"a", "b"}}},
+		{Name: "db", Port: 5432, Extra: map[string]interface{}{"debug": true}},
+	}
+	data, err := Marshal(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{(name,port,debug,region,tags:"api",80,,"eu",["a","b"];"db",5432,true,,;)}`
+	if string(data) != want {
+		t.Fatalf("got %s, want %s", data, want)
+	}
+
+	var back []flatService
+	if err := Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, rows) {
+		t.Errorf("got %#v, want %#v", back, rows)
+	}
+}
+
+func TestFlattenTableTypedMap(t *testing.T) {
+	type metric struct {
+		Host   string             `god:"host"`
+		Values map[string]float64 `god:",flatten"`
+	}
+	var got []metric
+	doc := `{(cpu,host,mem,disk:0.5,"a",1.25,;\0,"b",2,0.75;)}`
+	if err := Unmarshal([]byte(doc), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []metric{
+		{Host: "a", Values: map[string]float64{"cpu": 0.5, "mem": 1.25}},
+		{Host: "b", Values: map[string]float64{"cpu": 0, "mem": 2, "disk": 0.75}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if err := Unmarshal([]byte(`{(host,cpu:"a","high";)}`), &got); err == nil {
+		t.Error("expected error for a cell that does not fit the map")
+	}
+}
@@ -0,0 +1,103 @@
+package god
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type box[T any] struct {
+	Item T `god:"item"`
+}
+
+type pair[K comparable, V any] struct {
+	Key   K `god:"key"`
+	Value V `god:"value"`
+}
+
+type result[T any] struct {
+	Value T     `god:"value"`
+	Err   error `god:"err"`
+}
+
+func TestGenericStructs(t *testing.T) {
+	cases := []struct {
+		name string
+		in   interface{}
+		want string
+	}{
+		{"int", box[int]{Item: 3}, `{item=3}`},
+		{"string", box[string]{Item: "x"}, `{item="x"}`},
+		{"table", box[[]Person]{Item: []Person{{Name: "Alice", Age: 30}}}, `{item=(name,age,addr:"Alice",30,;)}`},
+		{"nested", box[box[[]int]]{Item: box[[]int]{Item: []int{1, 2}}}, `{item={item=[1,2]}}`},
+		{"map", box[map[string]box[bool]]{Item: map[string]box[bool]{"a": {Item: true}}}, `{item={a={item=true}}}`},
+		{"pair", pair[string, *box[float64]]{Key: "k", Value: &box[float64]{Item: 1.5}}, `{key="k";value={item=1.5}}`},
+		{"slice", []box[string]{{Item: "a"}, {Item: "b"}}, `{(item:"a";"b";)}`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			data, err := Marshal(c.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != c.want {
+				t.Errorf("Marshal = %s, want %s", data, c.want)
+			}
+			out := reflect.New(reflect.TypeOf(c.in))
+			if err := Unmarshal(data, out.Interface()); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(out.Elem().Interface(), c.in) {
+				t.Errorf("round trip = %+v, want %+v", out.Elem().Interface(), c.in)
+			}
+		})
+	}
+}
+
+func TestResultWithError(t *testing.T) {
+	ok := result[[]int]{Value: []int{1, 2}}
+	data, err := Marshal(ok)
+	if err != nil || string(data) != `{value=[1,2];err=}` {
+		t.Errorf("Marshal(ok) = %s, %v", data, err)
+	}
+	var out result[[]int]
+	if err := Unmarshal(data, &out); err != nil || !reflect.DeepEqual(out, ok) {
+		t.Errorf("Unmarshal(ok) = %+v, %v", out, err)
+	}
+
+	failed := result[int]{Err: fmt.Errorf("lookup: %w", errors.New("not found"))}
+	data, err = Marshal(failed)
+	if err != nil || string(data) != `{value=;err="lookup: not found"}` {
+		t.Errorf("Marshal(failed) = %s, %v", data, err)
+	}
+	var back result[int]
+	if err := Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.Err == nil || back.Err.Error() != "lookup: not found" {
+		t.Errorf("decoded error = %v", back.Err)
+	}
+
+	table, err := Marshal([]result[string]{{Value: "a"}, {Err: errors.New("b, failed")}})
+	if err != nil || string(table) != `{(value,err:"a",;,"b, failed";)}` {
+		t.Errorf("Marshal(table) = %s, %v", table, err)
+	}
+	var rows []result[string]
+	if err := Unmarshal(table, &rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].Value != "a" || rows[0].Err != nil || rows[1].Err.Error() != "b, failed" {
+		t.Errorf("Unmarshal(table) = %+v", rows)
+	}
+
+	var stringer struct {
+		S fmt.Stringer `god:"s"`
+	}
+	if err := Unmarshal([]byte(`{s="x"}`), &stringer); err == nil {
+		t.Error("decoding a string into fmt.Stringer succeeded")
+	}
+	if err := Unmarshal([]byte(`{s=}`), &stringer); err != nil || stringer.S != nil {
+		t.Errorf("grounded fmt.Stringer = %v, %v", stringer.S, err)
+	}
+}
@@ -2,11 +2,19 @@ package god
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 /*
@@ -51,18 +59,30 @@ Zero values (grounded data):
 	bool: false
 	array: []
 	object: {}
-*/
 
-// Table represents the key = (header:rows;...) syntax.
-type Table struct {
-	Header []string
-	Rows   [][]string
-}
+Concurrency: Marshal, Unmarshal and their variants may be called from many
+goroutines at once, as long as no two calls share a value being decoded
+into or one is modified while it is being encoded. Each call keeps its
+state to itself, and the only package state, the annotations, aliases
+and unit parsers registered with RegisterAnnotation, RegisterAlias and
+RegisterUnit and the variables published with Publish, is guarded by
+locks. Registering while other goroutines encode and decode is safe too,
+though registration usually belongs in init.
+*/
 
 // ===================== ENCODING =====================
 
 // Marshal encodes any Go value into GOD format (compact, no extra whitespace).
 // Rule 2: Root must always be an object. Non-object types are wrapped with a default key.
+//
+// Anonymous structs encode like named ones, as objects or, in slices, as
+// tables, with untagged fields keyed by their lowercased names. Two fields
+// of one struct with the same key, such as ID and Id, are an error. Decoding
+// back needs a typed target, which may itself be an anonymous struct; an
+// interface{} gets a map[string]interface{} or a []map[string]interface{}.
+//
+// Byte slices are written as standard base64 strings, which decode back
+// into them, as lists of numbers still do; byte arrays are lists.
 func Marshal(v interface{}) ([]byte, error) {
 	return marshalWithCompact(v, true)
 }
@@ -73,67 +93,241 @@ func MarshalBeautify(v interface{}) ([]byte, error) {
 	return marshalWithCompact(v, false)
 }
 
+// MarshalMinimal encodes v like Marshal, but leaves out every struct field
+// that would be written as an empty value, and every table column that
+// would be empty in all rows, as if each field were tagged omitempty.
+// Sparse documents shrink accordingly. Decoding the result into a zero
+// value gives back v, since absent keys and columns are grounded; decoding
+// into a value that is already set leaves the fields that were left out as
+// they were. Map entries are kept, since a missing key decodes
+// differently from an empty one. See MarshalOptions.OmitZero.
+func MarshalMinimal(v interface{}) ([]byte, error) {
+	return MarshalOptions{OmitZero: true}.Marshal(v)
+}
+
+// MarshalValue encodes v as a single bare value, such as a list, table or
+// string, without wrapping it in a root object. It is the counterpart of
+// UnmarshalValue. Zero values encode as nothing at all.
+func MarshalValue(v interface{}) ([]byte, error) {
+	var sb strings.Builder
+	// Going through the interface grounds a nil v instead of panicking.
+	if err := encodeValue(&encodeState{writer: &sb}, reflect.ValueOf(&v).Elem(), 1, true); err != nil {
+		return nil, err
+	}
+	return []byte(sb.String()), nil
+}
+
 func marshalWithCompact(v interface{}, compact bool) ([]byte, error) {
-	var b strings.Builder
+	var sb strings.Builder
+	if err := encodeRoot(&encodeState{writer: &sb}, v, compact); err != nil {
+		return nil, err
+	}
+	return []byte(sb.String()), nil
+}
+
+// writer is the sink the encoder writes to. strings.Builder and bufio.Writer
+// both satisfy it, which lets the same encoder buffer or stream its output.
+type writer interface {
+	io.Writer
+	io.ByteWriter
+	io.StringWriter
+}
+
+// encodeState carries the output and any per-stream state through the
+// encoder.
+type encodeState struct {
+	writer
+
+	// headers interns table headers for Encoder streams; nil when disabled.
+	headers map[string]int
+
+	// fieldHook and path implement MarshalOptions.FieldHook.
+	fieldHook FieldHook
+	path      fieldPath
+
+	// alignColumns and rightAlignNumbers implement
+	// MarshalOptions.AlignTableColumns and RightAlignNumbers.
+	alignColumns      bool
+	rightAlignNumbers bool
+
+	// alignAssignments implements MarshalOptions.AlignAssignments.
+	alignAssignments bool
+
+	// escapeUnicode implements MarshalOptions.EscapeUnicode.
+	escapeUnicode bool
+
+	// stats is filled in by MarshalWithStats; nil otherwise.
+	stats *MarshalStats
+
+	// lossless implements MarshalOptions.Lossless.
+	lossless bool
+
+	// useAliases implements MarshalOptions.UseAliases.
+	useAliases bool
+
+	// typeComments implements MarshalOptions.EmitTypeComments.
+	typeComments bool
+
+	// jsonRaw implements MarshalOptions.JSONRawMessages.
+	jsonRaw JSONRawMode
+
+	// typeAnnotations implements MarshalOptions.TypeAnnotations.
+	typeAnnotations bool
+
+	// timeFormat implements MarshalOptions.TimeFormat.
+	timeFormat string
+
+	// fieldNameMapper implements MarshalOptions.FieldNameMapper.
+	fieldNameMapper func(string) string
+
+	// maxWidth implements MarshalOptions.MaxWidth.
+	maxWidth int
+
+	// omitZero implements MarshalOptions.OmitZero.
+	omitZero bool
+
+	// tableSep is the sep tag option of the field being encoded, for the
+	// table it holds, or 0. See separator.go.
+	tableSep byte
+
+	// indentUnit implements MarshalOptions.Indent.
+	indentUnit string
+}
+
+func encodeRoot(b *encodeState, v interface{}, compact bool) error {
+	if err := checkIndent(b.indentUnit); err != nil {
+		return err
+	}
 	rv := reflect.ValueOf(v)
-	
+
 	// Handle pointers
 	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
 		rv = rv.Elem()
 	}
-	
+	if rv.IsValid() && rv.Type() == atomicValueType {
+		rv = atomicLoad(rv)
+		if name := b.annotatedType(rv); name != "" {
+			return encodeStructAs(b, reflect.Indirect(rv.Elem()), name, 1, compact)
+		}
+		if rv = rv.Elem(); rv.Kind() == reflect.Ptr && !rv.IsNil() {
+			rv = rv.Elem()
+		}
+	}
+	if rv.IsValid() && rv.Type() == syncMapType {
+		rv = syncMapAsMap(rv)
+	}
+	if rv.IsValid() && rv.Type() == urlValuesType {
+		rv = urlValuesAsMap(rv)
+	}
+	if rv.IsValid() && rv.Type() == orderedMapType {
+		return encodeOrderedMap(b, rv, 1, compact)
+	}
+
 	// Rule 2: Root must always be an object {}
 	// Rule 5: Root can contain either:
 	//   - A single raw value: {"string"}, {[...]}, {(table)}, etc.
 	//   - Key-value pairs: {key=value;key2=value2}
 	//   - But NOT both mixed together
-	
+
 	// If it's already a map or struct, encode normally (key-value pairs)
-	if rv.Kind() == reflect.Map || rv.Kind() == reflect.Struct {
-		if err := encodeValue(&b, rv, 1, compact); err != nil {
-			return nil, err
-		}
-		return []byte(b.String()), nil
+	// The root object is written even when it is empty. Types that marshal
+	// themselves are naked values.
+	custom := rv.IsValid() && (marshalerFor(rv).IsValid() || textMarshalerFor(rv).IsValid())
+	if rv.Kind() == reflect.Struct && rv.Type() != tableType && !custom {
+		return encodeStruct(b, rv, 1, compact)
+	}
+	if rv.Kind() == reflect.Map && !isSetType(rv.Type()) && !custom {
+		return encodeMap(b, rv, 1, compact)
 	}
-	
+
 	// Otherwise, wrap as single raw value in {}
+	b.noteDepth(1)
 	b.WriteByte('{')
 	if !compact {
 		b.WriteByte('\n')
-		b.WriteString("  ")
+		b.WriteString(b.indent(1))
 	}
-	
-	if err := encodeValue(&b, rv, 1, compact); err != nil {
-		return nil, err
+
+	// The value sits at level 1, so its contents are at level 2.
+	if err := encodeValue(b, rv, 2, compact); err != nil {
+		return err
 	}
-	
+
 	if !compact {
 		b.WriteByte('\n')
 	}
 	b.WriteByte('}')
-	
-	return []byte(b.String()), nil
-}
-
 
+	return nil
+}
 
+func encodeValue(b *encodeState, v reflect.Value, level int, compact bool) error {
+	if err := b.checkLossless(v); err != nil {
+		return err
+	}
 
-func encodeValue(b *strings.Builder, v reflect.Value, level int, compact bool) error {
-	// Handle pointers
+	// Pointers are three-valued: nil is written as \0, and a non-nil
+	// pointer keeps its target even when that is the zero value. Pointers
+	// to pointers, as in generated code, follow the same rules.
 	if v.Kind() == reflect.Ptr {
-		if v.IsNil() {
-			return nil
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				b.WriteString(`\0`)
+				return nil
+			}
+			v = v.Elem()
 		}
-		v = v.Elem()
+		if isZeroValue(v) {
+			return encodeExplicitZero(b, v, level, compact)
+		}
+	}
+
+	if v.Type() == atomicValueType {
+		return encodeValue(b, atomicLoad(v), level, compact)
+	}
+
+	// A struct with its type name is not empty even when it is zero.
+	if name := b.annotatedType(v); name != "" {
+		return encodeStructAs(b, reflect.Indirect(v.Elem()), name, level, compact)
 	}
 
 	// Rule 18: Zero values are empty fields
-	if isZeroValue(v) {
+	if b.grounds(v) {
 		return nil
 	}
 
+	if b.jsonRaw != JSONRawBytes && v.Type() == jsonRawMessageType {
+		return encodeJSONRaw(b, v.Bytes(), level, compact, b.jsonRaw)
+	}
+	if m := marshalerFor(v); m.IsValid() {
+		return encodeMarshaler(b, m)
+	}
+	b.writeAlias(v)
+	if b.timeFormat != "" && v.Type() == timeType {
+		return encodeTime(b, v.Interface().(time.Time), b.timeFormat)
+	}
+	if m := textMarshalerFor(v); m.IsValid() {
+		return encodeText(b, m)
+	}
+
+	if isNumberType(v.Type()) {
+		return encodeNumber(b, Number(v.String()))
+	}
+	if v.Type() == syncMapType {
+		return encodeMap(b, syncMapAsMap(v), level, compact)
+	}
+	if v.Type() == urlValuesType {
+		return encodeMap(b, urlValuesAsMap(v), level, compact)
+	}
+	if v.Type() == orderedMapType {
+		return encodeOrderedMap(b, v, level, compact)
+	}
+
 	switch v.Kind() {
 	case reflect.Struct:
+		if v.Type() == tableType {
+			return encodeTable(b, v.Interface().(Table), level, compact)
+		}
 		return encodeStruct(b, v, level, compact)
 	case reflect.Map:
 		return encodeMap(b, v, level, compact)
@@ -146,12 +340,7 @@ func encodeValue(b *strings.Builder, v reflect.Value, level int, compact bool) e
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		b.WriteString(fmt.Sprintf("%d", v.Uint()))
 	case reflect.Float32, reflect.Float64:
-		f := v.Float()
-		if float64(int64(f)) == f {
-			b.WriteString(fmt.Sprintf("%d", int64(f)))
-		} else {
-			b.WriteString(fmt.Sprintf("%v", f))
-		}
+		b.WriteString(formatFloat(v.Float(), v.Type().Bits()))
 	case reflect.Bool:
 		if v.Bool() {
 			b.WriteString("true")
@@ -162,6 +351,9 @@ func encodeValue(b *strings.Builder, v reflect.Value, level int, compact bool) e
 		if v.IsNil() {
 			return nil
 		}
+		if v.Type() == errorType {
+			return encodeString(b, v.Interface().(error).Error(), compact)
+		}
 		return encodeValue(b, v.Elem(), level, compact)
 	default:
 		return fmt.Errorf("unsupported type: %v", v.Kind())
@@ -169,118 +361,280 @@ func encodeValue(b *strings.Builder, v reflect.Value, level int, compact bool) e
 	return nil
 }
 
-func encodeStruct(b *strings.Builder, v reflect.Value, level int, compact bool) error {
+// encodeExplicitZero writes the zero value v as a literal rather than an
+// empty value, so that a pointer to it decodes as non-nil.
+func encodeExplicitZero(b *encodeState, v reflect.Value, level int, compact bool) error {
+	switch v.Kind() {
+	case reflect.String:
+		b.WriteString(`""`)
+	case reflect.Bool:
+		b.WriteString("false")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		b.WriteByte('0')
+	case reflect.Struct:
+		return encodeStruct(b, v, level, compact)
+	case reflect.Map:
+		if isSetType(v.Type()) {
+			b.WriteString("[]")
+			return nil
+		}
+		return encodeMap(b, v, level, compact)
+	case reflect.Slice, reflect.Array:
+		b.WriteString("[]")
+	case reflect.Ptr, reflect.Interface:
+		b.WriteString(`\0`)
+	default:
+		return fmt.Errorf("unsupported type: %v", v.Kind())
+	}
+	return nil
+}
+
+// formatFloat formats f with the fewest digits that parse back to the same
+// float of the given bit size. Integral values are written without a
+// fraction or exponent.
+func formatFloat(f float64, bits int) string {
+	if f == math.Trunc(f) && math.Abs(f) < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, bits)
+	}
+	return strconv.FormatFloat(f, 'g', -1, bits)
+}
+
+func encodeStruct(b *encodeState, v reflect.Value, level int, compact bool) error {
+	return encodeStructAs(b, v, "", level, compact)
+}
+
+// encodeStructAs encodes the struct v, first writing typeName under
+// typeKey if it is not empty.
+func encodeStructAs(b *encodeState, v reflect.Value, typeName string, level int, compact bool) error {
 	t := v.Type()
-	
-	b.WriteByte('{')
-	if !compact {
-		b.WriteByte('\n')
+	b.tableSep = 0
+
+	flatIdx, err := flattenField(t)
+	if err != nil {
+		return err
+	}
+	remainIdx, err := remainField(t)
+	if err != nil {
+		return err
+	}
+
+	// Collect the pairs first, so that their keys can be aligned. Field
+	// hooks run as each pair is written, in document order.
+	var pairs []structPair
+	names := make(map[string]int)
+	if typeName != "" {
+		pairs = append(pairs, structPair{key: typeKey, val: reflect.ValueOf(typeName)})
 	}
-	
-	first := true
 	for i := 0; i < v.NumField(); i++ {
 		field := t.Field(i)
 		fieldValue := v.Field(i)
-		
+
 		// Skip unexported fields
-		if !field.IsExported() {
+		if !field.IsExported() || i == flatIdx || i == remainIdx {
 			continue
 		}
-		
+
 		// Get field name from tag or use field name
-		fieldName := field.Tag.Get("god")
-		if fieldName == "" {
-			fieldName = strings.ToLower(field.Name)
+		tag := parseFieldTag(field, b.fieldNameMapper)
+		if j, dup := names[tag.name]; dup {
+			return duplicateKeyError(t, j, i, tag.name)
 		}
-		
-		if !first && compact {
-			b.WriteByte(';')
+		if typeName != "" && tag.name == typeKey {
+			return fmt.Errorf("%v: field %s has the key %s, which holds the type name", t, field.Name, typeKey)
 		}
-		first = false
-		
-		if !compact {
-			b.WriteString(indent(level))
+		names[tag.name] = i
+		if (tag.omitEmpty || b.omitZero) && b.grounds(fieldValue) {
+			continue
+		}
+		pairs = append(pairs, structPair{key: tag.name, val: fieldValue, tag: &tag})
+	}
+
+	// The entries of a flatten map follow the regular fields. A key that
+	// names a regular field is dropped, since it would decode into that
+	// field rather than the map.
+	if flatIdx >= 0 {
+		m := v.Field(flatIdx)
+		for _, key := range sortedKeys(m) {
+			name, err := mapKeyString(key)
+			if err != nil {
+				return err
+			}
+			if _, dup := names[name]; !dup {
+				pairs = append(pairs, structPair{key: name, val: m.MapIndex(key)})
+			} else if b.lossless {
+				return b.droppedKey(name)
+			}
+		}
+	}
+
+	// So do the raw values of a remain map, written as they were read.
+	if remainIdx >= 0 {
+		m := v.Field(remainIdx)
+		for _, key := range sortedKeys(m) {
+			name := key.String()
+			if _, dup := names[name]; !dup {
+				pairs = append(pairs, structPair{key: name, val: remainValue(m.MapIndex(key))})
+			} else if b.lossless {
+				return b.droppedKey(name)
+			}
+		}
+	}
+
+	keys := make([]string, len(pairs))
+	for i, pair := range pairs {
+		keys[i] = pair.key
+	}
+	return writeObject(b, keys, level, compact, b.typeComment(t), func(i int) error {
+		pair := pairs[i]
+		mark := b.pushKey(pair.key)
+		val := pair.val
+		if pair.tag != nil {
+			val = b.hookField(*pair.tag, val)
+			if err := b.setSeparator(pair.tag.sep); err != nil {
+				return fmt.Errorf("field %s: %w", pair.key, err)
+			}
 		}
-		
-		b.WriteString(fieldName)
-		b.WriteByte('=')
-		
-		if err := encodeValue(b, fieldValue, level+1, compact); err != nil {
+		err := encodeValue(b, val, level+1, compact)
+		b.tableSep = 0
+		b.pop(mark)
+		return err
+	})
+}
+
+// structPair is a key-value pair of an encoded struct. tag is set for
+// regular fields, whose field hook runs when the pair is written.
+type structPair struct {
+	key string
+	val reflect.Value
+	tag *fieldTag
+}
+
+func encodeMap(b *encodeState, v reflect.Value, level int, compact bool) error {
+	if isSetType(v.Type()) {
+		return encodeSet(b, v, level, compact)
+	}
+
+	// Keys are sorted so that the same map always encodes the same way.
+	keys := sortedKeys(v)
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		name, err := mapKeyString(key)
+		if err != nil {
 			return err
 		}
-		
-		if !compact {
-			b.WriteString(";\n")
+		names[i] = name
+	}
+	if v.Type().Key().Kind() == reflect.Interface {
+		if err := sortInterfaceKeys(keys, names); err != nil {
+			return err
 		}
 	}
-	
-	if !compact {
-		b.WriteString(indent(level - 1))
+	return writeObject(b, names, level, compact, "", func(i int) error {
+		mark := b.pushKey(names[i])
+		err := encodeValue(b, v.MapIndex(keys[i]), level+1, compact)
+		b.pop(mark)
+		return err
+	})
+}
+
+// typeComment returns the name of struct type t for EmitTypeComments, or
+// "" if the option is off or t is anonymous.
+func (b *encodeState) typeComment(t reflect.Type) string {
+	if !b.typeComments || t.Name() == "" {
+		return ""
 	}
-	b.WriteByte('}')
-	return nil
+	return t.PkgPath() + "." + t.Name()
 }
 
-func encodeMap(b *strings.Builder, v reflect.Value, level int, compact bool) error {
+// writeObject writes an object with the given keys in order, calling value
+// to encode the value of the i-th key. A comment, if not empty, is its
+// first line when beautified.
+func writeObject(b *encodeState, keys []string, level int, compact bool, comment string, value func(i int) error) error {
+	if b.stats != nil {
+		b.noteDepth(level)
+		b.stats.Fields += len(keys)
+	}
 	b.WriteByte('{')
 	if !compact {
 		b.WriteByte('\n')
+		if comment != "" {
+			b.WriteString(b.indent(level) + "// " + comment + "\n")
+		}
+	}
+
+	width := 0
+	if b.alignAssignments && !compact {
+		for _, key := range keys {
+			width = max(width, b.keyWidth(key))
+		}
 	}
-	
-	first := true
-	iter := v.MapRange()
-	for iter.Next() {
-		key := iter.Key()
-		val := iter.Value()
-		
-		if !first && compact {
+	for i, key := range keys {
+		if i > 0 && compact {
 			b.WriteByte(';')
 		}
-		first = false
-		
 		if !compact {
-			b.WriteString(indent(level))
-		}
-		
-		// Key must be string
-		b.WriteString(fmt.Sprintf("%v", key.Interface()))
-		b.WriteByte('=')
-		
-		if err := encodeValue(b, val, level+1, compact); err != nil {
+			b.WriteString(b.indent(level))
+		}
+
+		encodeKey(b, key)
+		if width > 0 {
+			b.WriteString(strings.Repeat(" ", width-b.keyWidth(key)) + " = ")
+		} else {
+			b.WriteByte('=')
+		}
+
+		if err := value(i); err != nil {
 			return err
 		}
-		
+
 		if !compact {
 			b.WriteString(";\n")
 		}
 	}
-	
+
 	if !compact {
-		b.WriteString(indent(level - 1))
+		b.WriteString(b.indent(level - 1))
 	}
 	b.WriteByte('}')
 	return nil
 }
 
-func encodeSlice(b *strings.Builder, v reflect.Value, level int, compact bool) error {
+func encodeSlice(b *encodeState, v reflect.Value, level int, compact bool) error {
 	if v.Len() == 0 {
 		b.WriteString("[]")
 		return nil
 	}
-	
+
+	// A byte slice is a base64 blob, see decodeBase64
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		return encodeString(b, base64.StdEncoding.EncodeToString(v.Bytes()), compact)
+	}
+
 	// Check if slice of structs -> use table format
 	elemType := v.Type().Elem()
-	if elemType.Kind() == reflect.Struct {
+	if elemType.Kind() == reflect.Struct && !marshalsItself(elemType) {
 		return encodeStructSliceAsTable(b, v, level, compact)
 	}
-	
+
 	// Regular list
 	b.WriteByte('[')
 	for i := 0; i < v.Len(); i++ {
 		if i > 0 {
 			b.WriteByte(',')
 		}
-		if err := encodeValue(b, v.Index(i), level, compact); err != nil {
+		// A zero element would otherwise leave an empty slot, which is
+		// ambiguous in [] and at the end of a list; ground it explicitly.
+		elem := v.Index(i)
+		if isZeroElem(elem) && !(b.lossless && holdsEmptyCollection(elem)) && b.annotatedType(elem) == "" {
+			b.WriteString(`\0`)
+			continue
+		}
+		mark := b.pushIndex(i)
+		err := encodeValue(b, elem, level, compact)
+		b.pop(mark)
+		if err != nil {
 			return err
 		}
 	}
@@ -288,85 +642,193 @@ func encodeSlice(b *strings.Builder, v reflect.Value, level int, compact bool) e
 	return nil
 }
 
-func encodeStructSliceAsTable(b *strings.Builder, v reflect.Value, level int, compact bool) error {
+func encodeStructSliceAsTable(b *encodeState, v reflect.Value, level int, compact bool) error {
 	if v.Len() == 0 {
 		b.WriteString("()")
 		return nil
 	}
-	
+
 	elemType := v.Type().Elem()
-	
-	// Build header from struct fields
+	sep := b.takeSeparator()
+	if b.stats != nil {
+		b.noteDepth(level)
+		b.stats.TableRows += v.Len()
+	}
+
+	// Build header from struct fields. Tables have fixed columns, so
+	// omitempty drops a column only when it is zero in every row.
 	var headers []string
+	var columns []int
+	var tags []fieldTag
+	remainIdx, err := remainField(elemType)
+	if err != nil {
+		return err
+	}
+	flatIdx, err := flattenField(elemType)
+	if err != nil {
+		return err
+	}
+	names := make(map[string]int)
 	for i := 0; i < elemType.NumField(); i++ {
 		field := elemType.Field(i)
-		if !field.IsExported() {
+		if !field.IsExported() || i == remainIdx || i == flatIdx {
+			continue
+		}
+		tag := parseFieldTag(field, b.fieldNameMapper)
+		if j, dup := names[tag.name]; dup {
+			return duplicateKeyError(elemType, j, i, tag.name)
+		}
+		names[tag.name] = i
+		if (tag.omitEmpty || b.omitZero) && isZeroColumn(v, i) {
 			continue
 		}
-		fieldName := field.Tag.Get("god")
-		if fieldName == "" {
-			fieldName = strings.ToLower(field.Name)
+		headers = append(headers, tag.name)
+		columns = append(columns, i)
+		tags = append(tags, tag)
+	}
+
+	// Columns kept in remain or flatten maps follow the regular ones.
+	extraIdx := max(remainIdx, flatIdx)
+	var extra []string
+	if extraIdx >= 0 {
+		if extra, err = extraColumns(v, extraIdx, headers); err != nil {
+			return err
 		}
-		headers = append(headers, fieldName)
+		headers = append(headers, extra...)
+	}
+
+	if c := b.typeComment(elemType); c != "" && !compact {
+		b.WriteString("// []" + c + "\n" + b.indent(level-1))
 	}
-	
 	b.WriteByte('(')
-	
-	// Write header
-	for i, h := range headers {
-		if i > 0 {
-			b.WriteByte(',')
+
+	// Write header, or a reference to one already sent on this stream.
+	// References do not record a separator, so only ',' tables use them.
+	if sep != ',' || !b.writeHeaderRef(headers) {
+		for i, h := range headers {
+			if i > 0 {
+				b.WriteByte(sep)
+			}
+			b.WriteString(h)
 		}
-		b.WriteString(h)
 	}
 	b.WriteByte(':')
-	
+
 	if !compact {
 		b.WriteByte('\n')
 	}
-	
-	// Write rows
+
+	// Write rows. Aligned columns need the width of every cell first, and
+	// wrapped rows the width of each, so their cells are encoded
+	// separately and written out at the end.
+	collect := !compact && (b.alignColumns || b.maxWidth > 0)
+	var cells [][]string
 	for i := 0; i < v.Len(); i++ {
-		if !compact {
-			b.WriteString(indent(level))
+		out := b
+		var sb strings.Builder
+		if collect {
+			sub := *b
+			sub.writer = &sb
+			out = &sub
+		} else if !compact {
+			b.WriteString(b.indent(level))
+		}
+		var rowCells []string
+		nextCell := func(k int) {
+			if collect && k > 0 {
+				rowCells = append(rowCells, sb.String())
+				sb.Reset()
+			} else if k > 0 {
+				b.WriteByte(sep)
+			}
 		}
-		
+
 		structVal := v.Index(i)
-		exportedIdx := 0
-		for j := 0; j < elemType.NumField(); j++ {
-			field := elemType.Field(j)
-			if !field.IsExported() {
-				continue
-			}
-			
-			if exportedIdx > 0 {
-				b.WriteByte(',')
+		row := out.pushIndex(i)
+		for k, j := range columns {
+			nextCell(k)
+			mark := out.pushKey(tags[k].name)
+			err := encodeTableCell(out, out.hookField(tags[k], structVal.Field(j)), level+1, compact)
+			out.pop(mark)
+			if err != nil {
+				return err
 			}
-			exportedIdx++
-			
-			fieldVal := structVal.Field(j)
-			if err := encodeTableCell(b, fieldVal, level+1, compact); err != nil {
+		}
+		for k, name := range extra {
+			nextCell(len(columns) + k)
+			m := structVal.Field(extraIdx)
+			key := reflect.New(m.Type().Key()).Elem()
+			if err := setMapKey(key, name); err != nil {
 				return err
 			}
+			cell := m.MapIndex(key)
+			if !cell.IsValid() {
+				continue
+			}
+			if extraIdx == flatIdx {
+				mark := out.pushKey(name)
+				err := encodeTableCell(out, cell, level+1, compact)
+				out.pop(mark)
+				if err != nil {
+					return err
+				}
+			} else if err := writeRawCell(out, remainValue(cell).Bytes()); err != nil {
+				return fmt.Errorf("column %s: %w", name, err)
+			}
+		}
+		out.pop(row)
+		if collect {
+			cells = append(cells, append(rowCells, sb.String()))
+			continue
 		}
 		b.WriteByte(';')
 		if !compact {
 			b.WriteByte('\n')
 		}
 	}
-	
+	if collect {
+		writeTableRows(b, cells, level, sep, b.alignColumns)
+	}
+
 	if !compact {
-		b.WriteString(indent(level - 1))
+		b.WriteString(b.indent(level - 1))
 	}
 	b.WriteByte(')')
 	return nil
 }
 
-func encodeTableCell(b *strings.Builder, v reflect.Value, level int, compact bool) error {
-	if !v.IsValid() || isZeroValue(v) {
+// isZeroColumn reports whether field i is zero in every row of table v.
+func isZeroColumn(v reflect.Value, i int) bool {
+	for r := 0; r < v.Len(); r++ {
+		if !isZeroValue(v.Index(r).Field(i)) {
+			return false
+		}
+	}
+	return true
+}
+
+func encodeTableCell(b *encodeState, v reflect.Value, level int, compact bool) error {
+	if err := b.checkLossless(v); err != nil {
+		return err
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			b.WriteString(`\0`)
+			return nil
+		}
+		if isZeroValue(v.Elem()) {
+			return encodeExplicitZero(b, v.Elem(), level, compact)
+		}
+	}
+	if !v.IsValid() || b.grounds(v) {
 		return nil // Rule 18: empty cell for zero values
 	}
 
+	if v.Type() == errorType {
+		b.WriteString(b.quote(v.Interface().(error).Error()))
+		return nil
+	}
+
 	// Handle pointers/interfaces
 	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
 		if v.IsNil() {
@@ -375,24 +837,34 @@ func encodeTableCell(b *strings.Builder, v reflect.Value, level int, compact boo
 		v = v.Elem()
 	}
 
+	if m := marshalerFor(v); m.IsValid() {
+		return encodeMarshaler(b, m)
+	}
+	b.writeAlias(v)
+	if b.timeFormat != "" && v.Type() == timeType {
+		return encodeTime(b, v.Interface().(time.Time), b.timeFormat)
+	}
+	if m := textMarshalerFor(v); m.IsValid() {
+		return encodeText(b, m)
+	}
+
+	if isNumberType(v.Type()) {
+		return encodeNumber(b, Number(v.String()))
+	}
+
 	switch v.Kind() {
 	case reflect.String:
 		s := v.String()
 		if s == "" {
 			return nil
 		}
-		b.WriteString(strconv.Quote(s))
+		b.WriteString(b.quote(s))
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		b.WriteString(fmt.Sprintf("%d", v.Int()))
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		b.WriteString(fmt.Sprintf("%d", v.Uint()))
 	case reflect.Float32, reflect.Float64:
-		f := v.Float()
-		if float64(int64(f)) == f {
-			b.WriteString(fmt.Sprintf("%d", int64(f)))
-		} else {
-			b.WriteString(fmt.Sprintf("%v", f))
-		}
+		b.WriteString(formatFloat(v.Float(), v.Type().Bits()))
 	case reflect.Bool:
 		if v.Bool() {
 			b.WriteString("true")
@@ -403,36 +875,126 @@ func encodeTableCell(b *strings.Builder, v reflect.Value, level int, compact boo
 		// Rule 19: Tables can contain nested structures
 		return encodeValue(b, v, level, compact)
 	default:
-		b.WriteString(strconv.Quote(fmt.Sprintf("%v", v.Interface())))
+		b.WriteString(b.quote(fmt.Sprintf("%v", v.Interface())))
 	}
 	return nil
 }
 
-func encodeString(b *strings.Builder, s string, compact bool) error {
-	if strings.Contains(s, "\n") {
+// encodeKey writes key as a bare token, quoting it when it contains characters
+// the parser would treat as delimiters or when it could be read as a directive.
+func encodeKey(b *encodeState, key string) {
+	if b.isBareKey(key) {
+		b.WriteString(key)
+	} else {
+		b.WriteString(b.quote(key))
+	}
+}
+
+func isBareKey(key string) bool {
+	if key == "" || key[0] == '@' {
+		return false
+	}
+	if strings.ContainsAny(key, "=;{}[](),:\"\\") {
+		return false
+	}
+	// Bare tokens are trimmed of Unicode spaces, and control characters
+	// are easier to read escaped.
+	return strings.IndexFunc(key, func(r rune) bool {
+		return unicode.IsSpace(r) || !unicode.IsPrint(r)
+	}) < 0
+}
+
+// isZeroElem reports whether a list element encodes as an empty value.
+func isZeroElem(v reflect.Value) bool {
+	for v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return true
+		}
+		v = v.Elem()
+	}
+	// encodeValue writes nil as \0 and grounds what a pointer points to.
+	return v.Kind() != reflect.Ptr && isZeroValue(v)
+}
+
+func encodeString(b *encodeState, s string, compact bool) error {
+	// Triple quotes are raw, so they can only hold text that neither
+	// contains """ nor ends in a quote that would run into the closing one,
+	// and, when escaping Unicode, only ASCII.
+	if strings.Contains(s, "\n") && !strings.Contains(s, `"""`) && !strings.HasSuffix(s, `"`) && !(b.escapeUnicode && !isASCII(s)) {
 		b.WriteString(`"""`)
 		b.WriteString(s)
 		b.WriteString(`"""`)
 	} else {
-		b.WriteString(strconv.Quote(s))
+		b.WriteString(b.quote(s))
 	}
 	return nil
 }
 
-func indent(level int) string {
-	if level <= 0 {
-		return ""
+// quote returns s as a quoted string. Printable Unicode is written as is,
+// unless escapeUnicode is set.
+func (b *encodeState) quote(s string) string {
+	if b.escapeUnicode {
+		return strconv.QuoteToASCII(s)
 	}
-	return strings.Repeat("  ", level)
+	return strconv.Quote(s)
 }
 
-func isZeroValue(v reflect.Value) bool {
-	switch v.Kind() {
-	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
-		return v.Len() == 0
-	case reflect.Bool:
-		return !v.Bool()
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+// isBareKey reports whether key is written without quotes. Keys with
+// non-ASCII letters are quoted when escaping Unicode.
+func (b *encodeState) isBareKey(key string) bool {
+	if key == typeKey && b.typeAnnotations {
+		return true
+	}
+	return isBareKey(key) && !(b.escapeUnicode && !isASCII(key))
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// defaultIndent is what each level of beautified output is indented by
+// unless MarshalOptions.Indent says otherwise.
+const defaultIndent = "  "
+
+// indent returns the indentation of level. Levels count the nesting of
+// contents: the keys of an object, or the rows of a table, written at
+// level n are indented n times, and the bracket that closes it n-1 times,
+// so that it lines up with the line its opener is on.
+func (b *encodeState) indent(level int) string {
+	return indentBy(b.indentUnit, level)
+}
+
+func indentBy(unit string, level int) string {
+	if level <= 0 {
+		return ""
+	}
+	if unit == "" {
+		unit = defaultIndent
+	}
+	return strings.Repeat(unit, level)
+}
+
+// checkIndent reports an error if unit would not be read back as
+// whitespace.
+func checkIndent(unit string) error {
+	if strings.Trim(unit, " \t") != "" {
+		return fmt.Errorf("invalid indent %q: only spaces and tabs are allowed", unit)
+	}
+	return nil
+}
+
+func isZeroValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		return v.Int() == 0
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		return v.Uint() == 0
@@ -440,147 +1002,474 @@ func isZeroValue(v reflect.Value) bool {
 		return v.Float() == 0
 	case reflect.Interface, reflect.Ptr:
 		return v.IsNil()
+	case reflect.Struct:
+		if v.Type() == syncMapType {
+			return isEmptySyncMap(v)
+		}
+		if v.Type() == orderedMapType {
+			return isEmptyOrderedMap(v)
+		}
+		// A struct is zero when every field is, so an empty nested struct
+		// is grounded like any other zero value. Unexported fields count too,
+		// which keeps types like time.Time from looking empty.
+		for i := 0; i < v.NumField(); i++ {
+			if !isZeroValue(v.Field(i)) {
+				return false
+			}
+		}
+		return true
 	}
 	return false
 }
 
 // ===================== DECODING =====================
 
+// Unmarshal decodes the GOD document in data into the value pointed to by v.
+// It is equivalent to UnmarshalOptions{}.Unmarshal(data, v).
+//
+// When decoding into an interface{} value, objects become
+// map[string]interface{}, lists []interface{}, strings string, booleans bool,
+// integers int64 and numbers with a fraction or exponent float64, or Number
+// for every number when UnmarshalOptions.UseNumber is set.
+//
+// A document decoded into a value that already holds data is merged into
+// it, as with encoding/json, so that decoding successive documents into one
+// struct overlays them. Keys the document leaves out keep their values, at
+// any depth of nested structs; keys it has overwrite, a grounded key with
+// the zero value. Maps, sets included, are merged key by key, but each
+// value stored is decoded afresh. Slices, arrays and tables are replaced
+// whole. Non-nil pointers, also when held in an interface{}, are decoded
+// into.
 func Unmarshal(data []byte, v interface{}) error {
+	return UnmarshalOptions{}.Unmarshal(data, v)
+}
+
+// UnmarshalOptions configures how a GOD document is decoded.
+type UnmarshalOptions struct {
+	// IncludeFS resolves @include directives. If neither it nor
+	// IncludeResolver is set, documents may not include others, and
+	// @include fails with ErrIncludeDisabled, so that untrusted input
+	// cannot read local files. There is deliberately no default such as
+	// os.DirFS("."); pass that to read includes from the working
+	// directory. ReadFile and ReadFS set it.
+	//
+	// Includes are only decoded. Encoding never writes @include: the
+	// pairs of included files are written inline, like any others.
+	IncludeFS fs.FS
+
+	// IncludeResolver, if set, resolves @include directives in place of
+	// IncludeFS: it returns the contents of the file at path, already
+	// joined to the directory of the including file, so that the host
+	// controls, and can sandbox, what a document may read. Include cycles
+	// are detected by path either way.
+	IncludeResolver func(path string) ([]byte, error)
+
+	// DisallowTrailingData makes it an error for anything but whitespace to
+	// follow the root object, which catches concatenated or truncated and
+	// appended files. Trailing whitespace, such as the final newline of a
+	// file, is always allowed. By default the rest of the input is ignored.
+	DisallowTrailingData bool
+
+	// UseNumber decodes numbers into interface{} values as a Number rather
+	// than an int64 or float64, keeping their original text.
+	UseNumber bool
+
+	// MaxTokenSize bounds the length in bytes of any single key, string or
+	// bare value, so that untrusted input cannot force huge allocations.
+	// Zero means DefaultMaxTokenSize and a negative value means no limit.
+	MaxTokenSize int
+
+	// FieldHook is called with the decoded value of every struct field,
+	// before it is assigned; a replacement must be assignable to the field.
+	FieldHook FieldHook
+
+	// Progress, if set, is called from time to time during decoding with
+	// the number of bytes decoded so far and the size of the input.
+	Progress func(bytesDone, total int64)
+
+	// InternStrings makes identical keys and short strings decoded by one
+	// call share their memory, which shrinks documents with many repeated
+	// keys or low-cardinality values, such as generically decoded tables.
+	// It trades some decoding speed for memory and is off by default.
+	InternStrings bool
+
+	// JSONRawMessages says how values are decoded into json.RawMessage
+	// targets; by default they must be lists of bytes like for any
+	// []byte. See JSONRawMode.
+	JSONRawMessages JSONRawMode
+
+	// DecodeHooks are offered every value before it is decoded into a
+	// target other than a pointer, plain table cells included, and the
+	// first that handles it decodes it. See DecodeHookFunc.
+	DecodeHooks []DecodeHookFunc
+
+	// TypeRegistry maps the type names written by
+	// MarshalOptions.TypeAnnotations to types. An object whose first key
+	// is @type, decoded into an interface, becomes a value of the type
+	// registered under its name, or a pointer to one if only the pointer
+	// implements the interface; a name that is not registered is an
+	// error. Without a registry @type is an ordinary key.
+	TypeRegistry map[string]reflect.Type
+
+	// OrderedMaps decodes objects into interface{} values as
+	// *OrderedMap, which keeps their keys in document order, rather than
+	// map[string]interface{}.
+	OrderedMaps bool
+
+	// TimeFormat is the layout time.Time values are read in, see
+	// MarshalOptions.TimeFormat. By default they are read by their
+	// UnmarshalText method, in RFC 3339 format.
+	TimeFormat string
+
+	// FieldNameMapper gives the keys that struct fields without a name in
+	// their god tag are decoded from, see MarshalOptions.FieldNameMapper.
+	FieldNameMapper func(string) string
+
+	// SkipValidation decodes without calling the ValidateGOD methods of
+	// the structs decoded, see Validator, as for raw ingestion.
+	SkipValidation bool
+
+	// FlattenKeys decodes objects nested in a map from strings to lists
+	// of strings, such as url.Values, into its keys joined with '.', as
+	// {user={name="Ann"}} into user.name. By default they are an error.
+	FlattenKeys bool
+
+	// OnColumnMismatch says what to do when the header of a table lacks
+	// columns for fields of its struct rows or has columns that match
+	// none, see ColumnPolicy. By default nothing is done.
+	OnColumnMismatch ColumnPolicy
+
+	// ColumnMismatches, if set, collects the mismatches found with
+	// WarnColumns, across every table decoded.
+	ColumnMismatches *[]ColumnMismatch
+
+	// DisallowUnknownFields makes it an error for an object decoded into
+	// a struct to have a key, or a table of structs a column, that matches
+	// no field and that no flatten or remain field takes. By default such
+	// keys are skipped.
+	DisallowUnknownFields bool
+
+	// DisallowDuplicateKeys makes it an error for an object decoded into
+	// a struct or map to have a key twice, or two names of one field. By
+	// default the last one wins.
+	DisallowDuplicateKeys bool
+
+	// RequireFields makes it an error for an object decoded into a
+	// struct, or the header of a table of structs, to lack the key of an
+	// exported field not tagged omitempty. Objects that @include others
+	// are not checked.
+	RequireFields bool
+
+	// MaxDepth, if positive, bounds how deeply values may nest, the
+	// values of the root object being at depth 1, so that untrusted input
+	// cannot exhaust the stack. Deeper values fail with ErrTooDeep.
+	MaxDepth int
+
+	// MaxInputSize, if positive, bounds the length in bytes of a
+	// document. Longer ones fail with ErrInputTooLarge without being
+	// decoded; a Decoder stops reading them at the limit.
+	MaxInputSize int
+}
+
+// DefaultMaxTokenSize is the token size limit used when
+// UnmarshalOptions.MaxTokenSize is zero.
+const DefaultMaxTokenSize = 1 << 20
+
+// ErrTokenTooLarge is returned when a token exceeds the decoder's
+// MaxTokenSize.
+var ErrTokenTooLarge = errors.New("token exceeds maximum size")
+
+// Unmarshal decodes the GOD document in data into the value pointed to by v
+// using the options in o.
+func (o UnmarshalOptions) Unmarshal(data []byte, v interface{}) error {
+	return o.unmarshal(o.newParser(data), v)
+}
+
+func (o UnmarshalOptions) unmarshal(p *parser, v interface{}) error {
+	if err := unmarshal(p, v); err != nil {
+		return err
+	}
+	if o.DisallowTrailingData {
+		p.skipSpaces()
+		if !p.eof() {
+			return fmt.Errorf("unexpected trailing data after root object at pos %d", p.pos)
+		}
+	}
+	if o.Progress != nil {
+		o.Progress(int64(len(p.src)), int64(len(p.src)))
+	}
+	return nil
+}
+
+// UnmarshalValue decodes a single bare value, such as a list, table or
+// string, that is not wrapped in a root object. data must hold exactly one
+// value, optionally surrounded by whitespace. It is equivalent to
+// UnmarshalOptions{}.UnmarshalValue(data, v).
+func UnmarshalValue(data []byte, v interface{}) error {
+	return UnmarshalOptions{}.UnmarshalValue(data, v)
+}
+
+// UnmarshalValue decodes a single bare value using the options in o.
+func (o UnmarshalOptions) UnmarshalValue(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("unmarshal target must be a non-nil pointer")
+	}
+	p := o.newParser(data)
+	p.skipSpaces()
+	if p.eof() {
+		return errors.New("expected a value, got end of input")
+	}
+	if err := decodeValue(p, rv.Elem()); err != nil {
+		return err
+	}
+	p.skipSpaces()
+	if !p.eof() {
+		return fmt.Errorf("unexpected trailing data after value at pos %d", p.pos)
+	}
+	return nil
+}
+
+func unmarshal(p *parser, v interface{}) error {
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Ptr || rv.IsNil() {
 		return errors.New("unmarshal target must be a non-nil pointer")
 	}
-	
-	p := &parser{src: data, pos: 0}
+	if p.maxInputSize > 0 && len(p.src) > p.maxInputSize {
+		return fmt.Errorf("%w: document is longer than %d bytes", ErrInputTooLarge, p.maxInputSize)
+	}
+
 	p.skipSpaces()
-	
+
 	target := rv.Elem()
-	
+
 	// Rule 1: Root MUST be an object {}
 	if p.peek() != '{' {
 		return fmt.Errorf("root must be an object '{...}', got '%c'", p.peek())
 	}
+	// Remember where the root starts, after any leading whitespace, so
+	// that object targets can decode it from its opening brace.
+	root := p.pos
 	p.next() // consume '{'
 	p.skipSpaces()
-	
-	// Special case: Single raw table {(...)}
-	if target.Kind() == reflect.Slice && p.peek() == '(' {
-		if err := decodeTable(p, target); err != nil {
+
+	// Rule 18: a grounded root is the zero value, which for a pointer is
+	// nil, as for pointer fields.
+	if target.Kind() == reflect.Ptr && p.peekAhead(2) == `\0` {
+		p.pos += 2
+		if err := p.endNaked(); err != nil {
 			return err
 		}
-		p.skipSpaces()
-		if p.peek() != '}' {
-			return errors.New("expected '}' after root table")
-		}
-		p.next()
+		target.Set(reflect.Zero(target.Type()))
 		return nil
 	}
-	
-	// If it's a struct or map, let decodeValue/decodeStruct/decodeMap handle the braces (re-parsing)
-	// Actually, we already consumed '{'.
-	if target.Kind() == reflect.Struct {
-		// Back up to '{' so decodeStruct can handle it properly
-		p.pos--
-		for p.pos > 0 && p.src[p.pos] != '{' {
-			p.pos--
+
+	// A pointer root points at whatever the document decodes to, so
+	// dereference it before looking at the shape of the target.
+	for target.Kind() == reflect.Ptr {
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		target = target.Elem()
+	}
+
+	// Special case: Single raw table {(...)}
+	if (target.Kind() == reflect.Slice || target.Type() == tableType) && p.peek() == '(' {
+		if err := decodeValue(p, target); err != nil {
+			return err
 		}
+		return p.endNaked()
+	}
+
+	// Structs and maps decode the root object themselves, braces included,
+	// unless they decode themselves from a naked value.
+	custom := unmarshalerFor(target).IsValid() || textUnmarshalerFor(target).IsValid()
+	if target.Type() == syncMapType {
+		p.pos = root
+		return decodeSyncMap(p, target)
+	}
+	if target.Type() == atomicValueType {
+		p.pos = root
+		return decodeAtomicValue(p, target)
+	}
+	if target.Type() == orderedMapType {
+		p.pos = root
+		return decodeOrderedMap(p, target)
+	}
+	if target.Kind() == reflect.Struct && !custom {
+		p.pos = root
 		return decodeStruct(p, target)
 	}
-	
-	if target.Kind() == reflect.Map {
-		p.pos--
-		for p.pos > 0 && p.src[p.pos] != '{' {
-			p.pos--
-		}
+
+	if target.Kind() == reflect.Map && !custom && !(isSetType(target.Type()) && p.peek() == '[') {
+		p.pos = root
 		return decodeMap(p, target)
 	}
 
+	// An interface{} root holds whatever the document does: a map for
+	// key-value pairs, or the naked value itself.
+	if target.Kind() == reflect.Interface && target.NumMethod() == 0 {
+		p.pos = root
+		val, err := parseGenericValue(p)
+		if err != nil {
+			return err
+		}
+		target.Set(reflect.ValueOf(val))
+		return nil
+	}
+
 	// For other types (strings, non-struct slices, numbers), it's a naked value inside {}
 	if err := decodeValue(p, target); err != nil {
 		return err
 	}
-	
-	p.skipSpaces()
-	if p.peek() != '}' {
-		return fmt.Errorf("expected '}' at end of root object, got '%c'", p.peek())
-	}
-	p.next()
-	
-	return nil
+	return p.endNaked()
 }
 
 func decodeValue(p *parser, target reflect.Value) error {
 	p.skipSpaces()
-	
+	defer p.nestCells()()
+	if p.maxDepth > 0 {
+		if err := p.enter(); err != nil {
+			return err
+		}
+		defer p.leave()
+	}
+
 	// Rule 18: Empty values or \0 are zero-valued
-	if p.peek() == ';' || p.peek() == '}' || p.peek() == ',' || p.peek() == ']' || p.peek() == ')' || p.peek() == ':' {
+	if p.peek() == ';' || p.peek() == '}' || p.peek() == ',' || p.peek() == ']' || p.peek() == ')' || p.peek() == ':' || p.atCellSeparator() {
+		// An absent value leaves pointers as they are; only an explicit
+		// \0 grounds them to nil, and "" points at an empty string.
+		if target.Kind() == reflect.Ptr {
+			return nil
+		}
 		target.Set(reflect.Zero(target.Type()))
-		if target.Kind() == reflect.Interface {
+		if target.Kind() == reflect.Interface && target.NumMethod() == 0 {
 			target.Set(reflect.ValueOf(""))
 		}
 		return nil
 	}
-	
+
 	// Check for \0
 	if p.pos+1 < len(p.src) && p.src[p.pos] == '\\' && p.src[p.pos+1] == '0' {
 		p.pos += 2
 		target.Set(reflect.Zero(target.Type()))
 		// For Interface, use "" as grounded default
-		if target.Kind() == reflect.Interface {
+		if target.Kind() == reflect.Interface && target.NumMethod() == 0 {
 			target.Set(reflect.ValueOf(""))
 		}
 		return nil
 	}
-	
+
+	if len(p.decodeHooks) > 0 && target.Kind() != reflect.Ptr {
+		if ok, err := p.decodeHooked(target); ok || err != nil {
+			return err
+		}
+	}
+
+	// Annotations are type hints; only interface{} targets need them,
+	// see parseGenericValue.
+	if target.Kind() != reflect.Interface {
+		if p.readAnnotation() != "" {
+			p.skipSpaces()
+			if p.eof() || strings.IndexByte(";},])", p.peek()) >= 0 {
+				return errAnnotationWithoutValue
+			}
+		}
+		if u := unmarshalerFor(target); u.IsValid() {
+			return decodeUnmarshaler(p, u)
+		}
+		if p.timeFormat != "" && target.Type() == timeType {
+			return decodeTime(p, target, p.timeFormat)
+		}
+		if u := textUnmarshalerFor(target); u.IsValid() {
+			return decodeText(p, u)
+		}
+		if ok, err := p.decodeUnit(target); ok || err != nil {
+			return err
+		}
+		if p.jsonRaw != JSONRawBytes && target.Type() == jsonRawMessageType {
+			return decodeJSONRaw(p, target, p.jsonRaw)
+		}
+	}
+
 	switch target.Kind() {
 	case reflect.Ptr:
 		if target.IsNil() {
 			target.Set(reflect.New(target.Type().Elem()))
 		}
 		return decodeValue(p, target.Elem())
-		
+
 	case reflect.Struct:
+		if target.Type() == tableType {
+			return decodeTableValue(p, target)
+		}
+		if target.Type() == syncMapType {
+			return decodeSyncMap(p, target)
+		}
+		if target.Type() == atomicValueType {
+			return decodeAtomicValue(p, target)
+		}
+		if target.Type() == orderedMapType {
+			return decodeOrderedMap(p, target)
+		}
 		return decodeStruct(p, target)
-		
+
 	case reflect.Map:
+		if isSetType(target.Type()) && p.peek() == '[' {
+			return decodeSet(p, target)
+		}
 		return decodeMap(p, target)
-		
+
 	case reflect.Slice:
 		return decodeSlice(p, target)
-		
+
+	case reflect.Array:
+		return decodeArray(p, target)
+
 	case reflect.String:
+		if isNumberType(target.Type()) {
+			return decodeNumber(p, target)
+		}
 		val, err := parseStringValue(p)
 		if err != nil {
 			return err
 		}
 		target.SetString(val)
 		return nil
-		
+
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		val, err := parseNumber(p)
+		val, err := parseInt(p)
 		if err != nil {
 			return err
 		}
-		target.SetInt(int64(val))
+		if target.OverflowInt(val) {
+			return fmt.Errorf("value %d overflows %v", val, target.Type())
+		}
+		target.SetInt(val)
 		return nil
-		
+
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		val, err := parseNumber(p)
+		val, err := parseUint(p)
 		if err != nil {
 			return err
 		}
-		target.SetUint(uint64(val))
+		if target.OverflowUint(val) {
+			return fmt.Errorf("value %d overflows %v", val, target.Type())
+		}
+		target.SetUint(val)
 		return nil
-		
+
 	case reflect.Float32, reflect.Float64:
 		val, err := parseNumber(p)
 		if err != nil {
 			return err
 		}
+		if overflowsFloat32(target, val) {
+			return fmt.Errorf("value %g overflows %v", val, target.Type())
+		}
 		target.SetFloat(val)
 		return nil
-		
+
 	case reflect.Bool:
 		val, err := parseBool(p)
 		if err != nil {
@@ -588,8 +1477,16 @@ func decodeValue(p *parser, target reflect.Value) error {
 		}
 		target.SetBool(val)
 		return nil
-		
+
 	case reflect.Interface:
+		// A pointer the interface already holds is decoded into, as
+		// encoding/json does.
+		if e := target.Elem(); e.Kind() == reflect.Ptr && !e.IsNil() {
+			return decodeValue(p, e)
+		}
+		if target.NumMethod() > 0 {
+			return decodeInterface(p, target)
+		}
 		// Decode as generic value
 		val, err := parseGenericValue(p)
 		if err != nil {
@@ -601,59 +1498,136 @@ func decodeValue(p *parser, target reflect.Value) error {
 		}
 		target.Set(reflect.ValueOf(val))
 		return nil
-		
+
 	default:
 		return fmt.Errorf("unsupported target type: %v", target.Kind())
 	}
 }
 
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// decodeInterface decodes into an interface type with methods. Errors are
+// encoded as their message, so a string becomes an error with that
+// message; any other value must be of a type that implements the
+// interface, such as one chosen by an annotation.
+func decodeInterface(p *parser, target reflect.Value) error {
+	val, err := parseGenericValue(p)
+	if err != nil {
+		return err
+	}
+	if val == nil {
+		target.Set(reflect.Zero(target.Type()))
+		return nil
+	}
+	if s, ok := val.(string); ok && target.Type() == errorType {
+		target.Set(reflect.ValueOf(errors.New(s)))
+		return nil
+	}
+	return assignInterface(target, val)
+}
+
 func decodeStruct(p *parser, target reflect.Value) error {
 	if p.peek() != '{' {
 		return fmt.Errorf("expected '{' for struct, got '%c'", p.peek())
 	}
 	p.next() // consume '{'
 	p.skipSpaces()
-	
+	p.depth++
+	defer func() { p.depth-- }()
+
 	t := target.Type()
-	fieldMap := make(map[string]int) // field name -> field index
-	
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		if !field.IsExported() {
-			continue
-		}
-		fieldName := field.Tag.Get("god")
-		if fieldName == "" {
-			fieldName = strings.ToLower(field.Name)
-		}
-		fieldMap[fieldName] = i
+	// Keys that match no field go to the flatten or remain map, if any.
+	flatIdx, err := flattenField(t)
+	if err != nil {
+		return err
+	}
+	remainIdx, err := remainField(t)
+	if err != nil {
+		return err
 	}
-	
+	fieldMap, err := decodeKeys(t, flatIdx, remainIdx, p.fieldNameMapper)
+	if err != nil {
+		return err
+	}
+	keys := p.newObjectKeys()
+
 	for !p.eof() && p.peek() != '}' {
+		if err := p.checkpoint(); err != nil {
+			return err
+		}
+
 		// Parse key
-		key := p.readBareToken()
+		start := p.pos
+		key, quoted, err := p.readKey()
+		if err != nil {
+			return err
+		}
 		p.skipSpaces()
-		
+
+		if !quoted && key == includeDirective {
+			if err := p.include(target, decodeStruct); err != nil {
+				return err
+			}
+			keys.include()
+			continue
+		}
+
 		if p.peek() != '=' {
+			if err := p.nakedInPairs(key, quoted, start); err != nil {
+				return err
+			}
 			return fmt.Errorf("expected '=' after key '%s'", key)
 		}
 		p.next() // consume '='
 		p.skipSpaces()
-		
-		// Find field
+
+		// Find field; the type name of a registered type is not one.
 		fieldIdx, ok := fieldMap[key]
 		if !ok {
+			fieldIdx = -1
+		}
+		if err := keys.add(key, fieldIdx); err != nil {
+			return err
+		}
+		if !ok && key == typeKey && p.typeRegistry != nil {
+			if err := skipValue(p); err != nil {
+				return err
+			}
+		} else if !ok && flatIdx >= 0 {
+			mark := p.pushKey(key)
+			err := decodeMapEntry(p, target.Field(flatIdx), key)
+			p.pop(mark)
+			if err != nil {
+				return err
+			}
+		} else if !ok && remainIdx >= 0 {
+			raw, err := p.readRawValue()
+			if err != nil {
+				return err
+			}
+			setRemain(target.Field(remainIdx), key, raw)
+		} else if !ok {
+			if p.disallowUnknown {
+				return fmt.Errorf("%v: unknown key %q", t, key)
+			}
 			// Skip unknown field
+			if p.stats != nil {
+				p.stats.UnknownKeys++
+			}
 			if err := skipValue(p); err != nil {
 				return err
 			}
 		} else {
-			fieldVal := target.Field(fieldIdx)
-			if err := decodeValue(p, fieldVal); err != nil {
-				return err
+			mark := p.pushKey(key)
+			err := p.decodeField(target.Field(fieldIdx), func(v reflect.Value) error {
+				return decodeValue(p, v)
+			})
+			p.pop(mark)
+			if err != nil {
+				return atKey(err, key)
 			}
 		}
-		
+
 		p.skipSpaces()
 		// Optional semicolon (rule 17)
 		if p.peek() == ';' {
@@ -661,13 +1635,21 @@ func decodeStruct(p *parser, target reflect.Value) error {
 		}
 		p.skipSpaces()
 	}
-	
+
 	if p.peek() != '}' {
 		return errors.New("expected '}' at end of struct")
 	}
 	p.next() // consume '}'
-	
-	return nil
+
+	// The root of an included file fills in part of the struct it is
+	// spliced into, which is validated once whole.
+	if p.file != "" && p.depth == 1 {
+		return nil
+	}
+	if err := p.checkRequired(keys, t, flatIdx, remainIdx); err != nil {
+		return err
+	}
+	return p.validate(target)
 }
 
 func decodeMap(p *parser, target reflect.Value) error {
@@ -676,46 +1658,66 @@ func decodeMap(p *parser, target reflect.Value) error {
 	}
 	p.next() // consume '{'
 	p.skipSpaces()
-	
+	p.depth++
+	defer func() { p.depth-- }()
+
 	if target.IsNil() {
 		target.Set(reflect.MakeMap(target.Type()))
 	}
-	
-	keyType := target.Type().Key()
-	valType := target.Type().Elem()
-	
+	keys := p.newObjectKeys()
+
 	for !p.eof() && p.peek() != '}' {
+		if err := p.checkpoint(); err != nil {
+			return err
+		}
+
 		// Parse key
-		keyStr := p.readBareToken()
+		start := p.pos
+		keyStr, quoted, err := p.readKey()
+		if err != nil {
+			return err
+		}
 		p.skipSpaces()
-		
-		// Skip empty keys (can happen with extra whitespace/semicolons)
-		if keyStr == "" {
-			if p.peek() == ';' {
-				p.next()
-				p.skipSpaces()
+
+		// Skip stray semicolons. An empty key must be quoted, as "".
+		if keyStr == "" && !quoted {
+			if p.peek() != ';' {
+				if err := p.nakedInPairs(keyStr, quoted, start); err != nil {
+					return err
+				}
+				return fmt.Errorf("expected key at position %d, got '%c'", p.pos, p.peek())
 			}
+			p.next()
+			p.skipSpaces()
 			continue
 		}
-		
+
+		if !quoted && keyStr == includeDirective {
+			if err := p.include(target, decodeMap); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := keys.add(keyStr, -1); err != nil {
+			return err
+		}
+
 		if p.peek() != '=' {
+			if err := p.nakedInPairs(keyStr, quoted, start); err != nil {
+				return err
+			}
 			return fmt.Errorf("expected '=' after key '%s', got '%c' at position %d", keyStr, p.peek(), p.pos)
 		}
 		p.next() // consume '='
 		p.skipSpaces()
-		
-		// Create key value
-		keyVal := reflect.New(keyType).Elem()
-		keyVal.SetString(keyStr) // Assuming string keys
-		
-		// Parse value
-		val := reflect.New(valType).Elem()
-		if err := decodeValue(p, val); err != nil {
+
+		mark := p.pushKey(keyStr)
+		err = decodeMapEntry(p, target, keyStr)
+		p.pop(mark)
+		if err != nil {
 			return err
 		}
-		
-		target.SetMapIndex(keyVal, val)
-		
+
 		p.skipSpaces()
 		// Optional semicolon
 		if p.peek() == ';' {
@@ -723,124 +1725,311 @@ func decodeMap(p *parser, target reflect.Value) error {
 		}
 		p.skipSpaces()
 	}
-	
+
 	if p.peek() != '}' {
 		return errors.New("expected '}' at end of map")
 	}
 	p.next() // consume '}'
-	
+
+	return nil
+}
+
+// decodeMapEntry decodes the value at p and stores it in the map target
+// under key, allocating the map if it is nil.
+func decodeMapEntry(p *parser, target reflect.Value, key string) error {
+	if target.IsNil() {
+		target.Set(reflect.MakeMap(target.Type()))
+	}
+	if isValuesMap(target.Type()) {
+		return p.decodeValuesEntry(target, key)
+	}
+
+	keyVal := reflect.New(target.Type().Key()).Elem()
+	if err := setMapKey(keyVal, key); err != nil {
+		return err
+	}
+
+	val := reflect.New(target.Type().Elem()).Elem()
+	if err := decodeValue(p, val); err != nil {
+		return atKey(err, key)
+	}
+
+	target.SetMapIndex(keyVal, val)
+	return nil
+}
+
+// mapKeyString formats a map key as an object key. It is the inverse of
+// setMapKey.
+func mapKeyString(key reflect.Value) (string, error) {
+	switch key.Kind() {
+	case reflect.String:
+		return key.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(key.Uint(), 10), nil
+	case reflect.Bool:
+		return strconv.FormatBool(key.Bool()), nil
+	case reflect.Float32, reflect.Float64:
+		return formatFloat(key.Float(), key.Type().Bits()), nil
+	case reflect.Interface:
+		if key.IsNil() {
+			return "", errors.New("unsupported map key: nil")
+		}
+		return mapKeyString(key.Elem())
+	}
+	return "", fmt.Errorf("unsupported map key type: %v", key.Type())
+}
+
+// setMapKey parses a decoded object key into key, whose type may be any
+// string, integer, bool or float kind.
+func setMapKey(key reflect.Value, s string) error {
+	switch key.Kind() {
+	case reflect.String:
+		key.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid %v map key %q", key.Type(), s)
+		}
+		key.SetBool(b)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, key.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid %v map key %q", key.Type(), s)
+		}
+		key.SetFloat(f)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, key.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid %v map key %q", key.Type(), s)
+		}
+		key.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, key.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid %v map key %q", key.Type(), s)
+		}
+		key.SetUint(u)
+	case reflect.Interface:
+		if key.NumMethod() > 0 {
+			return fmt.Errorf("unsupported map key type: %v", key.Type())
+		}
+		key.Set(reflect.ValueOf(s))
+	default:
+		return fmt.Errorf("unsupported map key type: %v", key.Type())
+	}
+	return nil
+}
+
+// decodeBase64 decodes a standard base64 string into the byte slice target.
+func decodeBase64(p *parser, target reflect.Value) error {
+	start := p.pos
+	s, err := parseStringValue(p)
+	if err != nil {
+		return err
+	}
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return fmt.Errorf("invalid base64 string at pos %d: %v", start, err)
+	}
+	target.Set(reflect.ValueOf(data).Convert(target.Type()))
 	return nil
 }
 
 func decodeSlice(p *parser, target reflect.Value) error {
 	p.skipSpaces()
-	
+
 	// Check if it's a table format (for struct slices)
 	if p.peek() == '(' {
 		return decodeTable(p, target)
 	}
-	
+
+	// A string into a byte slice is a base64 blob
+	if target.Type().Elem().Kind() == reflect.Uint8 && p.peek() == '"' {
+		return decodeBase64(p, target)
+	}
+
 	// Regular list format
 	if p.peek() != '[' {
 		return fmt.Errorf("expected '[' or '(' for slice, got '%c'", p.peek())
 	}
 	p.next() // consume '['
 	p.skipSpaces()
-	
+
 	elemType := target.Type().Elem()
 	slice := reflect.MakeSlice(target.Type(), 0, 0)
-	
+
 	for !p.eof() && p.peek() != ']' {
-		elem := reflect.New(elemType).Elem()
-		if err := decodeValue(p, elem); err != nil {
+		if err := p.checkpoint(); err != nil {
 			return err
 		}
+		elem := reflect.New(elemType).Elem()
+		mark := p.pushIndex(slice.Len())
+		err := decodeValue(p, elem)
+		p.pop(mark)
+		if err != nil {
+			return atIndex(err, slice.Len())
+		}
 		slice = reflect.Append(slice, elem)
-		
+
 		p.skipSpaces()
 		if p.peek() == ',' {
 			p.next()
 			p.skipSpaces()
+		} else if p.peek() != ']' {
+			break
 		}
 	}
-	
+
 	if p.peek() != ']' {
 		return errors.New("expected ']' at end of list")
 	}
 	p.next() // consume ']'
-	
+
 	target.Set(slice)
 	return nil
 }
 
+// decodeArray decodes a list or table into a fixed-size array. Elements the
+// document does not provide are left at their zero value.
+func decodeArray(p *parser, target reflect.Value) error {
+	tmp := reflect.New(reflect.SliceOf(target.Type().Elem())).Elem()
+	if err := decodeSlice(p, tmp); err != nil {
+		return err
+	}
+	if tmp.Len() > target.Len() {
+		return fmt.Errorf("list of %d elements does not fit in %v", tmp.Len(), target.Type())
+	}
+	target.Set(reflect.Zero(target.Type()))
+	reflect.Copy(target, tmp)
+	return nil
+}
+
+var errUnterminatedTable = errors.New("expected ')' at end of table")
+
 func decodeTable(p *parser, target reflect.Value) error {
+	_, err := decodeTableHeader(p, target)
+	return err
+}
+
+// decodeTableHeader decodes a table into target and returns its header.
+func decodeTableHeader(p *parser, target reflect.Value) ([]string, error) {
 	if p.peek() != '(' {
-		return fmt.Errorf("expected '(' for table, got '%c'", p.peek())
+		return nil, fmt.Errorf("expected '(' for table, got '%c'", p.peek())
 	}
 	p.next() // consume '('
 	p.skipSpaces()
-	
+
 	elemType := target.Type().Elem()
-	if elemType.Kind() != reflect.Struct {
-		return errors.New("table format only supported for struct slices")
+	// Generic decoding turns each row into a map keyed by the header.
+	generic := elemType == reflect.TypeOf(map[string]interface{}{})
+	// A Table keeps each row as the text of its cells in header order.
+	positional := elemType == rowType
+	if elemType.Kind() != reflect.Struct && !generic && !positional {
+		return nil, errors.New("table format only supported for struct slices")
 	}
-	
-	// Parse header
-	var headers []string
-	for {
-		p.skipSpaces()
-		if p.peek() == ':' {
-			p.next()
-			break
+
+	// Parse header, which a Decoder stream may have interned as #n
+	id, headers, isRef, err := p.readHeaderID()
+	if err != nil {
+		return nil, err
+	}
+	// The header separates its columns as the rows separate their cells.
+	sep := byte(',')
+	if !isRef {
+		text, err := p.readUntilAny(":)")
+		if err != nil {
+			return nil, err
 		}
-		if p.peek() == ')' {
-			p.next()
-			return nil // Empty table
+		if p.eof() {
+			return nil, errUnterminatedTable
 		}
-		
-		token := p.readUntilAny(",:")
-		token = strings.TrimSpace(token)
-		if token != "" {
-			headers = append(headers, token)
+		sep = headerSeparator(text)
+		for _, token := range strings.Split(text, string(sep)) {
+			if token = strings.TrimSpace(token); token != "" {
+				headers = append(headers, token)
+			}
 		}
-		
-		p.skipSpaces()
-		if p.peek() == ',' {
-			p.next()
+		p.countTokens(len(headers))
+		if p.next() == ')' {
+			target.Set(reflect.MakeSlice(target.Type(), 0, 0))
+			return headers, nil // Empty table
+		}
+	}
+	defer p.setCellSeparator(sep)()
+	if id > 0 && !isRef {
+		p.headers[id] = headers
+	}
+
+	// Build field map; columns that match no field go to the remain or
+	// flatten map, if there is one
+	remainIdx, flatIdx := -1, -1
+	if elemType.Kind() == reflect.Struct {
+		if remainIdx, err = remainField(elemType); err != nil {
+			return nil, err
+		}
+		if flatIdx, err = flattenField(elemType); err != nil {
+			return nil, err
 		}
 	}
-	
-	// Build field map
 	fieldMap := make(map[string]int)
-	for i := 0; i < elemType.NumField(); i++ {
-		field := elemType.Field(i)
-		if !field.IsExported() {
-			continue
+	if elemType.Kind() == reflect.Struct {
+		if fieldMap, err = decodeKeys(elemType, flatIdx, remainIdx, p.fieldNameMapper); err != nil {
+			return nil, err
+		}
+		if err := p.checkColumns(elemType, headers, fieldMap, flatIdx, remainIdx); err != nil {
+			return nil, err
 		}
-		fieldName := field.Tag.Get("god")
-		if fieldName == "" {
-			fieldName = strings.ToLower(field.Name)
+		if err := p.checkStrictColumns(elemType, headers, fieldMap, flatIdx, remainIdx); err != nil {
+			return nil, err
+		}
+	}
+
+	// custom holds, per column, the field of a type that is decoded as a
+	// whole value rather than from the text of a cell, or -1.
+	custom := make([]int, len(headers))
+	for i, h := range headers {
+		custom[i] = -1
+		if fieldIdx, ok := fieldMap[h]; ok && p.decodesWhole(elemType.Field(fieldIdx).Type) {
+			custom[i] = fieldIdx
 		}
-		fieldMap[fieldName] = i
 	}
-	
+
 	// Parse rows
 	slice := reflect.MakeSlice(target.Type(), 0, 0)
-	
+
 	for {
 		p.skipSpaces()
+		if p.eof() {
+			return nil, errUnterminatedTable
+		}
 		if p.peek() == ')' {
 			p.next()
 			break
 		}
-		
+
+		if err := p.checkpoint(); err != nil {
+			return nil, err
+		}
+
 		// Create new struct
+		row := p.pushIndex(slice.Len())
 		structVal := reflect.New(elemType).Elem()
-		
+		if generic {
+			structVal.Set(reflect.MakeMap(elemType))
+		}
+		if positional {
+			structVal.Set(reflect.MakeSlice(elemType, len(headers), len(headers)))
+		}
+
 		// Parse cells
 		cellIdx := 0
 		for {
 			p.skipSpaces()
+			if p.eof() {
+				return nil, errUnterminatedTable
+			}
 			if p.peek() == ';' {
 				p.next()
 				break
@@ -848,42 +2037,227 @@ func decodeTable(p *parser, target reflect.Value) error {
 			if p.peek() == ')' {
 				break
 			}
-			
-			// Parse cell value
-			var cellStr string
-			if p.peek() == '"' {
-				val, err := parseStringValue(p)
+			if cellIdx == len(headers) {
+				return nil, fmt.Errorf("table row %d has more cells than its %d columns at pos %d", slice.Len(), len(headers), p.pos)
+			}
+
+			// Cells of types that decode themselves and nested lists,
+			// objects and tables are whole values.
+			fieldIdx := -1
+			flatNested := false
+			if cellIdx < len(custom) {
+				fieldIdx = custom[cellIdx]
+				i, ok := fieldMap[headers[cellIdx]]
+				if ok && isNestedStart(p.peek()) {
+					fieldIdx = i
+				}
+				flatNested = !ok && flatIdx >= 0 && isNestedStart(p.peek())
+			}
+			if fieldIdx >= 0 || flatNested || generic && cellIdx < len(headers) && isNestedStart(p.peek()) {
+				mark := p.pushKey(headers[cellIdx])
+				var err error
+				if flatNested {
+					err = decodeMapEntry(p, structVal.Field(flatIdx), headers[cellIdx])
+				} else if generic {
+					var cell interface{}
+					if cell, err = parseGenericValue(p); err == nil {
+						structVal.SetMapIndex(reflect.ValueOf(headers[cellIdx]), reflect.ValueOf(cell))
+					}
+				} else {
+					err = p.decodeField(structVal.Field(fieldIdx), func(v reflect.Value) error {
+						return decodeValue(p, v)
+					})
+				}
+				p.pop(mark)
 				if err != nil {
-					return err
+					return nil, atIndex(atKey(err, headers[cellIdx]), slice.Len())
 				}
-				cellStr = val
-			} else {
-				cellStr = p.readUntilAny(",;)")
-				cellStr = strings.TrimSpace(cellStr)
+				cellIdx++
+				p.skipSpaces()
+				if p.peek() == sep {
+					p.next()
+				}
+				continue
+			}
+
+			// Parse cell value
+			start := p.pos
+			cellStr, quoted, err := p.readCell()
+			if err != nil {
+				return nil, err
 			}
-			
+
 			// Set field value
 			if cellIdx < len(headers) {
 				headerName := headers[cellIdx]
-				if fieldIdx, ok := fieldMap[headerName]; ok {
-					field := structVal.Field(fieldIdx)
-					if err := setFieldFromString(field, cellStr); err != nil {
-						return err
+				if generic {
+					cell := p.internValue(cellStr)
+					if !quoted {
+						cell = p.genericCell(cellStr)
+					}
+					structVal.SetMapIndex(reflect.ValueOf(headerName), reflect.ValueOf(cell))
+				} else if positional {
+					if quoted || cellStr != `\0` {
+						structVal.Index(cellIdx).SetString(cellStr)
+					}
+				} else if fieldIdx, ok := fieldMap[headerName]; ok {
+					mark := p.pushKey(headerName)
+					raw := bytes.TrimSpace(p.src[start:p.pos])
+					err := p.decodeField(structVal.Field(fieldIdx), func(v reflect.Value) error {
+						if len(p.decodeHooks) > 0 && v.Kind() != reflect.Ptr && (quoted || cellStr != "" && cellStr != `\0`) {
+							if ok, err := p.decodeHookedCell(v, cellStr, quoted, raw); ok || err != nil {
+								return err
+							}
+						}
+						return setCell(v, cellStr, quoted)
+					})
+					p.pop(mark)
+					if err != nil {
+						return nil, err
+					}
+				} else if remainIdx >= 0 {
+					raw := bytes.TrimSpace(p.src[start:p.pos])
+					setRemain(structVal.Field(remainIdx), headerName, raw)
+				} else if flatIdx >= 0 && (quoted || cellStr != "") {
+					mark := p.pushKey(headerName)
+					err := p.setFlattenCell(structVal.Field(flatIdx), headerName, cellStr, quoted)
+					p.pop(mark)
+					if err != nil {
+						return nil, err
 					}
 				}
 			}
-			
+
 			cellIdx++
 			p.skipSpaces()
-			if p.peek() == ',' {
+			if p.peek() == sep {
 				p.next()
 			}
 		}
-		
+
+		// A trailing empty cell leaves no token; ground it like the others.
+		for i := cellIdx; generic && i < len(headers); i++ {
+			structVal.SetMapIndex(reflect.ValueOf(headers[i]), reflect.ValueOf(""))
+		}
+
+		if !generic && !positional {
+			if err := p.validate(structVal); err != nil {
+				return nil, atIndex(err, slice.Len())
+			}
+		}
 		slice = reflect.Append(slice, structVal)
+		if p.stats != nil {
+			p.stats.Rows++
+		}
+		p.pop(row)
 	}
-	
+
 	target.Set(slice)
+	return headers, nil
+}
+
+// readCell reads one table cell, returning the content of a quoted string
+// or the trimmed text of any other value, including a whole nested list,
+// object or table.
+func (p *parser) readCell() (string, bool, error) {
+	// Whitespace or an annotation before a quoted string must not hide
+	// it, or the cell would end at the first separator in the string.
+	p.skipSpaces()
+	if p.readAnnotation() != "" {
+		p.skipSpaces()
+	}
+	if p.peek() == '"' {
+		val, err := parseStringValue(p)
+		return val, true, err
+	}
+	if isNestedStart(p.peek()) {
+		start := p.pos
+		if err := skipValue(p); err != nil {
+			return "", false, err
+		}
+		return string(p.src[start:p.pos]), false, nil
+	}
+	val, err := p.readUntilAny(p.cellSeparators())
+	if err != nil {
+		return "", false, err
+	}
+	p.countToken()
+	return strings.TrimSpace(val), false, nil
+}
+
+// isNestedStart reports whether c opens a list, object or table.
+func isNestedStart(c byte) bool {
+	return c == '[' || c == '{' || c == '('
+}
+
+// genericCell converts an unquoted table cell for generic decoding, using
+// the same types as parseGenericValue. Empty cells are grounded to "".
+func (p *parser) genericCell(s string) interface{} {
+	switch s {
+	case "", `\0`:
+		return ""
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if p.useNumber {
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			return Number(s)
+		}
+		return s
+	}
+	if !strings.ContainsAny(s, ".eE") {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
+
+// setCell stores a table cell in field. For pointer fields \0 is nil, an
+// empty cell leaves the field as it is and anything else, including "",
+// is a value to point at.
+func setCell(field reflect.Value, s string, quoted bool) error {
+	switch {
+	case !quoted && s == `\0`:
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	case field.Kind() != reflect.Ptr:
+		return setFieldFromString(field, s)
+	case !quoted && s == "":
+		return nil
+	}
+	if field.IsNil() {
+		field.Set(reflect.New(field.Type().Elem()))
+	}
+	return setFieldFromString(field.Elem(), s)
+}
+
+// setFlattenCell stores a plain table cell in the flatten map m under key.
+// Cells for interface{} values are converted as in generic tables.
+func (p *parser) setFlattenCell(m reflect.Value, key, s string, quoted bool) error {
+	if m.IsNil() {
+		m.Set(reflect.MakeMap(m.Type()))
+	}
+	keyVal := reflect.New(m.Type().Key()).Elem()
+	if err := setMapKey(keyVal, key); err != nil {
+		return err
+	}
+	val := reflect.New(m.Type().Elem()).Elem()
+	if val.Kind() == reflect.Interface && val.NumMethod() == 0 {
+		cell := p.internValue(s)
+		if !quoted {
+			cell = p.genericCell(s)
+		}
+		val.Set(reflect.ValueOf(cell))
+	} else if err := setCell(val, s, quoted); err != nil {
+		return err
+	}
+	m.SetMapIndex(keyVal, val)
 	return nil
 }
 
@@ -891,24 +2265,41 @@ func setFieldFromString(field reflect.Value, s string) error {
 	if s == "" {
 		return nil
 	}
-	
+	if u := textUnmarshalerFor(field); u.IsValid() {
+		return unmarshalText(u, s)
+	}
+	if ok, err := setUnit(field, s); ok {
+		return err
+	}
+
 	switch field.Kind() {
 	case reflect.String:
+		if isNumberType(field.Type()) {
+			if _, err := strconv.ParseFloat(s, 64); err != nil {
+				return fmt.Errorf("invalid number %q", s)
+			}
+		}
 		field.SetString(s)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		i, err := strconv.ParseInt(s, 10, 64)
+		i, err := parseIntToken(s)
 		if err != nil {
 			return err
 		}
+		if field.OverflowInt(i) {
+			return fmt.Errorf("value %d overflows %v", i, field.Type())
+		}
 		field.SetInt(i)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		u, err := strconv.ParseUint(s, 10, 64)
+		u, err := parseUintToken(s)
 		if err != nil {
 			return err
 		}
+		if field.OverflowUint(u) {
+			return fmt.Errorf("value %d overflows %v", u, field.Type())
+		}
 		field.SetUint(u)
 	case reflect.Float32, reflect.Float64:
-		f, err := strconv.ParseFloat(s, 64)
+		f, err := strconv.ParseFloat(s, field.Type().Bits())
 		if err != nil {
 			return err
 		}
@@ -919,6 +2310,21 @@ func setFieldFromString(field reflect.Value, s string) error {
 			return err
 		}
 		field.SetBool(b)
+	case reflect.Interface:
+		if field.Type() != errorType {
+			return fmt.Errorf("unsupported field type: %v", field.Type())
+		}
+		field.Set(reflect.ValueOf(errors.New(s)))
+	case reflect.Slice:
+		// A byte slice is a base64 blob, as in decodeBase64
+		if field.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported field type: %v", field.Type())
+		}
+		data, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return fmt.Errorf("invalid base64 string %q: %v", s, err)
+		}
+		field.SetBytes(data)
 	default:
 		return fmt.Errorf("unsupported field type: %v", field.Kind())
 	}
@@ -930,6 +2336,124 @@ func setFieldFromString(field reflect.Value, s string) error {
 type parser struct {
 	src []byte
 	pos int
+
+	// depth counts the objects currently being decoded; the root is 1.
+	depth int
+
+	// Include state, see include.go. opts are the options p was made
+	// with, which the parsers of included files are made with too.
+	opts      UnmarshalOptions
+	file      string
+	including map[string]bool
+
+	// headers holds interned table headers for Decoder streams; nil when
+	// interning is disabled. See intern.go.
+	headers map[int][]string
+
+	useNumber bool
+
+	// jsonRaw implements UnmarshalOptions.JSONRawMessages.
+	jsonRaw JSONRawMode
+
+	// decodeHooks implements UnmarshalOptions.DecodeHooks, see
+	// decodehook.go.
+	decodeHooks []DecodeHookFunc
+
+	// typeRegistry implements UnmarshalOptions.TypeRegistry, see
+	// polymorph.go.
+	typeRegistry map[string]reflect.Type
+
+	// orderedMaps implements UnmarshalOptions.OrderedMaps, see
+	// ordered.go.
+	orderedMaps bool
+
+	// timeFormat implements UnmarshalOptions.TimeFormat, see time.go.
+	timeFormat string
+
+	// fieldNameMapper implements UnmarshalOptions.FieldNameMapper.
+	fieldNameMapper func(string) string
+
+	// skipValidation implements UnmarshalOptions.SkipValidation, see
+	// validate.go.
+	skipValidation bool
+
+	// flattenKeys implements UnmarshalOptions.FlattenKeys, see values.go.
+	flattenKeys bool
+
+	// These implement the options of the same names, see strict.go.
+	// nesting is the depth of the value being decoded.
+	disallowUnknown    bool
+	disallowDuplicates bool
+	requireFields      bool
+	maxDepth           int
+	maxInputSize       int
+	nesting            int
+
+	// onColumnMismatch and columnMismatches implement the options of the
+	// same names, see columns.go.
+	onColumnMismatch ColumnPolicy
+	columnMismatches *[]ColumnMismatch
+
+	// cellSep is the cell separator of the table being decoded when it
+	// is not ',', and 0 otherwise. See separator.go.
+	cellSep byte
+
+	// maxTokenSize bounds the length of a single token; 0 means no limit.
+	maxTokenSize int
+
+	// fieldHook and path implement UnmarshalOptions.FieldHook.
+	fieldHook FieldHook
+	path      fieldPath
+
+	// ctx, progress and checks implement cancellation and progress
+	// reporting, see context.go.
+	ctx      context.Context
+	progress func(bytesDone, total int64)
+	checks   int
+
+	// interned implements UnmarshalOptions.InternStrings; nil when
+	// disabled. See intern.go.
+	interned map[string]interface{}
+
+	// stats is filled in by UnmarshalWithStats; nil otherwise.
+	stats *UnmarshalStats
+
+	// tokenEnd is where the last token counted in stats ended, see
+	// countToken.
+	tokenEnd int
+}
+
+func (o UnmarshalOptions) newParser(data []byte) *parser {
+	p := &parser{
+		src:                data,
+		opts:               o,
+		useNumber:          o.UseNumber,
+		maxTokenSize:       o.MaxTokenSize,
+		fieldHook:          o.FieldHook,
+		progress:           o.Progress,
+		jsonRaw:            o.JSONRawMessages,
+		decodeHooks:        o.DecodeHooks,
+		typeRegistry:       o.TypeRegistry,
+		orderedMaps:        o.OrderedMaps,
+		timeFormat:         o.TimeFormat,
+		fieldNameMapper:    o.FieldNameMapper,
+		onColumnMismatch:   o.OnColumnMismatch,
+		columnMismatches:   o.ColumnMismatches,
+		skipValidation:     o.SkipValidation,
+		flattenKeys:        o.FlattenKeys,
+		disallowUnknown:    o.DisallowUnknownFields,
+		disallowDuplicates: o.DisallowDuplicateKeys,
+		requireFields:      o.RequireFields,
+		maxDepth:           o.MaxDepth,
+		maxInputSize:       o.MaxInputSize,
+	}
+	if p.maxTokenSize == 0 {
+		p.maxTokenSize = DefaultMaxTokenSize
+	}
+	if o.InternStrings {
+		p.interned = make(map[string]interface{})
+	}
+	return p
 }
 
 func (p *parser) eof() bool {
@@ -952,34 +2476,93 @@ func (p *parser) next() byte {
 	return c
 }
 
+// skipSpaces skips whitespace and comments, which run from // to the end
+// of the line.
 func (p *parser) skipSpaces() {
-	for !p.eof() && (p.peek() == ' ' || p.peek() == '\n' || p.peek() == '\r' || p.peek() == '\t') {
-		p.pos++
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\n', '\r', '\t':
+			p.pos++
+		case '/':
+			if p.pos+1 == len(p.src) || p.src[p.pos+1] != '/' {
+				return
+			}
+			if i := bytes.IndexByte(p.src[p.pos:], '\n'); i >= 0 {
+				p.pos += i + 1
+			} else {
+				p.pos = len(p.src)
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) readBareToken() (string, error) {
+	tok, err := p.scanBareToken()
+	return string(tok), err
+}
+
+// readBareKey reads a bare key like readBareToken, interning it.
+func (p *parser) readBareKey() (string, error) {
+	tok, err := p.scanBareToken()
+	if err != nil {
+		return "", err
 	}
+	return p.intern(tok), nil
 }
 
-func (p *parser) readBareToken() string {
+// scanBareToken consumes a bare token and returns its text in p.src.
+func (p *parser) scanBareToken() ([]byte, error) {
 	p.skipSpaces()
-	var buf bytes.Buffer
+	start := p.pos
 	for !p.eof() {
 		c := p.peek()
-		if c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '=' || c == ';' || c == '{' || c == '}' || c == '[' || c == ']' || c == '(' || c == ')' || c == ',' || c == ':' {
+		if c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '=' || c == ';' || c == '{' || c == '}' || c == '[' || c == ']' || c == '(' || c == ')' || c == ',' || c == ':' || p.cellSep != 0 && c == p.cellSep {
 			break
 		}
-		buf.WriteByte(p.next())
+		p.pos++
+		if err := p.checkTokenSize(start, p.pos); err != nil {
+			return nil, err
+		}
+	}
+	p.countToken()
+	return p.src[start:p.pos], nil
+}
+
+// checkTokenSize reports an error once the token that began at start has
+// grown past the parser's size limit by reaching end.
+func (p *parser) checkTokenSize(start, end int) error {
+	if p.maxTokenSize > 0 && end-start > p.maxTokenSize {
+		return fmt.Errorf("%w: token at pos %d is longer than %d bytes", ErrTokenTooLarge, start, p.maxTokenSize)
+	}
+	return nil
+}
+
+// readKey reads an object key, which is either a bare token or a quoted
+// string for keys that contain delimiters. quoted reports which form was used.
+func (p *parser) readKey() (key string, quoted bool, err error) {
+	p.skipSpaces()
+	if p.peek() == '"' {
+		key, err = parseString(p)
+		return key, true, err
 	}
-	return strings.TrimSpace(buf.String())
+	key, err = p.readBareKey()
+	return key, false, err
 }
 
-func (p *parser) readUntilAny(seps string) string {
+func (p *parser) readUntilAny(seps string) (string, error) {
 	start := p.pos
 	for !p.eof() {
 		if strings.ContainsRune(seps, rune(p.peek())) {
 			break
 		}
 		p.pos++
+		if err := p.checkTokenSize(start, p.pos); err != nil {
+			return "", err
+		}
 	}
-	return string(p.src[start:p.pos])
+	return string(p.src[start:p.pos]), nil
 }
 
 func parseStringValue(p *parser) (string, error) {
@@ -996,6 +2579,7 @@ func parseStringValue(p *parser) (string, error) {
 }
 
 func parseString(p *parser) (string, error) {
+	start := p.pos
 	if p.next() != '"' {
 		return "", errors.New("expected '\"' at start of string")
 	}
@@ -1008,6 +2592,19 @@ func parseString(p *parser) (string, error) {
 			}
 			nc := p.next()
 			switch nc {
+			case 'x', 'u', 'U', 'a', 'b', 'f', 'v':
+				// Escapes produced by strconv.Quote for non-printable text
+				p.pos -= 2
+				r, multibyte, tail, err := strconv.UnquoteChar(string(p.src[p.pos:]), '"')
+				if err != nil {
+					return "", fmt.Errorf("invalid escape in string at pos %d", p.pos)
+				}
+				if multibyte {
+					buf.WriteRune(r)
+				} else {
+					buf.WriteByte(byte(r))
+				}
+				p.pos = len(p.src) - len(tail)
 			case 'n':
 				buf.WriteByte('\n')
 			case 'r':
@@ -1024,9 +2621,13 @@ func parseString(p *parser) (string, error) {
 			continue
 		}
 		if c == '"' {
-			return buf.String(), nil
+			p.countToken()
+			return p.intern(buf.Bytes()), nil
 		}
 		buf.WriteByte(c)
+		if err := p.checkTokenSize(start, start+buf.Len()); err != nil {
+			return "", err
+		}
 	}
 	return "", errors.New("unterminated string")
 }
@@ -1039,11 +2640,15 @@ func parseTripleString(p *parser) (string, error) {
 	start := p.pos
 	for !p.eof() {
 		if p.peekAhead(3) == `"""` {
-			segment := string(p.src[start:p.pos])
+			segment := p.intern(p.src[start:p.pos])
 			p.pos += 3
+			p.countToken()
 			return segment, nil
 		}
 		p.pos++
+		if err := p.checkTokenSize(start, p.pos); err != nil {
+			return "", err
+		}
 	}
 	return "", errors.New("unterminated triple-quoted string")
 }
@@ -1056,15 +2661,77 @@ func (p *parser) peekAhead(n int) string {
 }
 
 func parseNumber(p *parser) (float64, error) {
-	token := p.readBareToken()
+	token, err := p.readBareToken()
+	if err != nil {
+		return 0, err
+	}
 	if token == "" {
 		return 0, errors.New("expected number")
 	}
 	return strconv.ParseFloat(token, 64)
 }
 
+// parseInt reads an integer exactly, falling back to a float form such as
+// 1e3. Going through float64 alone would corrupt values beyond 2^53.
+func parseInt(p *parser) (int64, error) {
+	token, err := p.readBareToken()
+	if err != nil {
+		return 0, err
+	}
+	if token == "" {
+		return 0, errors.New("expected number")
+	}
+	return parseIntToken(token)
+}
+
+// parseIntToken is parseInt for a token already read, such as a table
+// cell.
+func parseIntToken(token string) (int64, error) {
+	if i, err := strconv.ParseInt(token, 10, 64); err == nil {
+		return i, nil
+	}
+	// Integral floats such as 1e3 are accepted; anything else would be
+	// silently truncated.
+	f, err := strconv.ParseFloat(token, 64)
+	if err != nil {
+		return 0, err
+	}
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid integer %q", token)
+	}
+	return int64(f), nil
+}
+
+func parseUint(p *parser) (uint64, error) {
+	token, err := p.readBareToken()
+	if err != nil {
+		return 0, err
+	}
+	if token == "" {
+		return 0, errors.New("expected number")
+	}
+	return parseUintToken(token)
+}
+
+func parseUintToken(token string) (uint64, error) {
+	if u, err := strconv.ParseUint(token, 10, 64); err == nil {
+		return u, nil
+	}
+	f, err := strconv.ParseFloat(token, 64)
+	if err != nil {
+		return 0, err
+	}
+	if f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 {
+		return 0, fmt.Errorf("invalid unsigned integer %q", token)
+	}
+	return uint64(f), nil
+}
+
 func parseBool(p *parser) (bool, error) {
-	token := p.readBareToken()
+	token, err := p.readBareToken()
+	if err != nil {
+		return false, err
+	}
 	if token == "true" {
 		return true, nil
 	}
@@ -1076,26 +2743,51 @@ func parseBool(p *parser) (bool, error) {
 
 func parseGenericValue(p *parser) (interface{}, error) {
 	p.skipSpaces()
+	if annotation := p.readAnnotation(); annotation != "" {
+		p.skipSpaces()
+		if p.eof() || strings.IndexByte(";},])", p.peek()) >= 0 {
+			return nil, errAnnotationWithoutValue
+		}
+		if t, ok := lookupAnnotation(annotation); ok {
+			return decodeAnnotated(p, t)
+		}
+	}
+	defer p.nestCells()()
 	c := p.peek()
 	if c == '{' {
 		// Rule 5: Root (or object value) can have naked single value OR key-value pairs
 		savedPos := p.pos
 		p.next() // skip '{'
 		p.skipSpaces()
-		
+
 		if p.peek() == '}' {
 			p.next()
+			if p.orderedMaps {
+				return new(OrderedMap), nil
+			}
 			return make(map[string]interface{}), nil
 		}
-		
-		// Peek-ahead to see if it's a key-value or a naked value
-		p.readBareToken()
+
+		// Peek-ahead to see if it's a key-value or a naked value; an
+		// @include directive is object content too.
+		key, quoted, _ := p.readKey()
 		p.skipSpaces()
-		isMap := p.peek() == '='
-		
+		isMap := p.peek() == '=' || !quoted && key == includeDirective
+
 		// Reset and decode properly
 		p.pos = savedPos
 		if isMap {
+			if t, err := p.registeredType(); err != nil || t != nil {
+				if err != nil {
+					return nil, err
+				}
+				return decodeRegistered(p, t)
+			}
+			if p.orderedMaps {
+				m := new(OrderedMap)
+				err := decodeOrderedMap(p, reflect.ValueOf(m).Elem())
+				return m, err
+			}
 			m := make(map[string]interface{})
 			err := decodeMap(p, reflect.ValueOf(&m).Elem())
 			return m, err
@@ -1106,11 +2798,7 @@ func parseGenericValue(p *parser) (interface{}, error) {
 			if err != nil {
 				return nil, err
 			}
-			p.skipSpaces()
-			if p.peek() == '}' {
-				p.next()
-			}
-			return val, nil
+			return val, p.endNaked()
 		}
 	}
 	if c == '[' {
@@ -1125,62 +2813,73 @@ func parseGenericValue(p *parser) (interface{}, error) {
 		return s, err
 	}
 	if c == '"' {
-		return parseString(p)
+		s, err := parseStringValue(p)
+		if err != nil {
+			return nil, err
+		}
+		return p.internValue(s), nil
 	}
 	if c == 't' || c == 'f' {
 		return parseBool(p)
 	}
-	
+
 	// Check for \0
 	if p.pos+1 < len(p.src) && p.src[p.pos] == '\\' && p.src[p.pos+1] == '0' {
 		p.pos += 2
 		return "", nil // Return "" for \0 as grounded default
 	}
 
-	return parseNumber(p)
+	return parseGenericNumber(p)
+}
+
+// parseGenericNumber decodes a number for an interface{} target. Tokens
+// without a decimal point or exponent become int64 so that callers can
+// type-assert counts and ids naturally; everything else, including integers
+// too large for int64, becomes float64.
+func parseGenericNumber(p *parser) (interface{}, error) {
+	token, err := p.readBareToken()
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		return nil, errors.New("expected number")
+	}
+	if p.useNumber {
+		if _, err := strconv.ParseFloat(token, 64); err != nil {
+			return nil, err
+		}
+		return Number(token), nil
+	}
+	if !strings.ContainsAny(token, ".eE") {
+		if i, err := strconv.ParseInt(token, 10, 64); err == nil {
+			return i, nil
+		}
+	}
+	return strconv.ParseFloat(token, 64)
 }
 
 func skipValue(p *parser) error {
 	p.skipSpaces()
+	if p.readAnnotation() != "" {
+		p.skipSpaces()
+	}
 	c := p.peek()
-	
+
 	switch c {
-	case '{':
-		depth := 0
-		for !p.eof() {
-			if p.peek() == '{' {
-				depth++
-			} else if p.peek() == '}' {
-				depth--
-				p.next()
-				if depth == 0 {
-					return nil
-				}
-				continue
-			}
-			p.next()
-		}
-	case '[':
+	case '{', '[', '(':
+		// Strings are skipped whole, so that brackets inside them do
+		// not count.
 		depth := 0
 		for !p.eof() {
-			if p.peek() == '[' {
-				depth++
-			} else if p.peek() == ']' {
-				depth--
-				p.next()
-				if depth == 0 {
-					return nil
+			switch p.peek() {
+			case '"':
+				if _, err := parseStringValue(p); err != nil {
+					return err
 				}
 				continue
-			}
-			p.next()
-		}
-	case '(':
-		depth := 0
-		for !p.eof() {
-			if p.peek() == '(' {
+			case '{', '[', '(':
 				depth++
-			} else if p.peek() == ')' {
+			case '}', ']', ')':
 				depth--
 				p.next()
 				if depth == 0 {
@@ -1191,9 +2890,11 @@ func skipValue(p *parser) error {
 			p.next()
 		}
 	case '"':
-		parseStringValue(p)
+		_, err := parseStringValue(p)
+		return err
 	default:
-		p.readBareToken()
+		_, err := p.readBareToken()
+		return err
 	}
 	return nil
 }
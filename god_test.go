@@ -2,6 +2,7 @@ package god
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -27,7 +28,6 @@ type Response struct {
 	Data      map[string]interface{} `god:"data"`
 }
 
-
 func TestSinglePersonEncode(t *testing.T) {
 	person := Person{
 		Name:    "John",
@@ -150,6 +150,39 @@ func TestComplexStructEncode(t *testing.T) {
 	fmt.Println()
 }
 
+func TestComplexStructDecode(t *testing.T) {
+	want := Company{
+		Name:    "TechCorp",
+		Founded: 2020,
+		Employees: []Person{
+			{Name: "Alice", Age: 30, Address: "NYC"},
+			{Name: "Bob", Age: 25, Address: "LA"},
+		},
+	}
+	docs := []string{
+		`{name="TechCorp";founded=2020;employees=(name,age,addr:"Alice",30,"NYC";"Bob",25,"LA")}`,
+		`{employees=(name,age,addr:"Alice",30,"NYC";"Bob",25,"LA";);name="TechCorp";founded=2020}`,
+		"{\n  name = \"TechCorp\";\n  employees = (\n    name, age, addr:\n    \"Alice\", 30, \"NYC\";\n    \"Bob\", 25, \"LA\";\n  );\n  founded = 2020;\n}",
+	}
+	for _, doc := range docs {
+		var company Company
+		if err := Unmarshal([]byte(doc), &company); err != nil {
+			t.Fatalf("Unmarshal error: %v\n%s", err, doc)
+		}
+		if !reflect.DeepEqual(company, want) {
+			t.Errorf("Unmarshal(%s) = %+v, want %+v", doc, company, want)
+		}
+
+		var ptr *Company
+		if err := Unmarshal([]byte(doc), &ptr); err != nil {
+			t.Fatalf("Unmarshal into pointer error: %v\n%s", err, doc)
+		}
+		if ptr == nil || !reflect.DeepEqual(*ptr, want) {
+			t.Errorf("Unmarshal(%s) into pointer = %+v, want %+v", doc, ptr, want)
+		}
+	}
+}
+
 func TestMapEncode(t *testing.T) {
 	data := map[string]interface{}{
 		"status":  200,
@@ -275,9 +308,169 @@ func TestTableBeautify(t *testing.T) {
 	s := string(encoded)
 	fmt.Println("=== Table Beautify Test ===")
 	fmt.Println(s)
-	
-	expectedPart := "(name,age,addr:\n  \"John\",30,\"NYC\";\n  \"Alice\",25,\"Boston\";\n)"
+
+	expectedPart := "{\n  (name,age,addr:\n    \"John\",30,\"NYC\";\n    \"Alice\",25,\"Boston\";\n  )\n}"
 	if !strings.Contains(s, expectedPart) {
 		t.Errorf("Table beautify formatting incorrect. Expected part:\n%s\nGot:\n%s", expectedPart, s)
 	}
 }
+
+func TestResponseNestedTableBeautify(t *testing.T) {
+	resp := Response{
+		Status: 200,
+		Data: map[string]interface{}{
+			"count": 2,
+			"rows": []Person{
+				{Name: "Alice", Age: 30, Address: "NYC"},
+				{Name: "Bob", Age: 25},
+			},
+			"page": map[string]interface{}{
+				"next": []Person{{Name: "Carol", Age: 41}},
+			},
+		},
+	}
+	encoded, err := MarshalBeautify(resp)
+	if err != nil {
+		t.Fatalf("MarshalBeautify error: %v", err)
+	}
+	golden := `{
+  status=200;
+  request=;
+  error=;
+  errorCode=;
+  data={
+    count=2;
+    page={
+      next=(name,age,addr:
+        "Carol",41,;
+      );
+    };
+    rows=(name,age,addr:
+      "Alice",30,"NYC";
+      "Bob",25,;
+    );
+  };
+}`
+	if string(encoded) != golden {
+		t.Errorf("got:\n%s\nwant:\n%s", encoded, golden)
+	}
+
+	var decoded struct {
+		Data struct {
+			Rows []Person `god:"rows"`
+		} `god:"data"`
+	}
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Data.Rows, resp.Data["rows"]) {
+		t.Errorf("rows = %+v, want %+v", decoded.Data.Rows, resp.Data["rows"])
+	}
+}
+
+func TestGenericNumberTypes(t *testing.T) {
+	var m map[string]interface{}
+	err := Unmarshal([]byte(`{count=3;neg=-12;ratio=0.5;exp=1e3;big=99999999999999999999;list=[1,2.5]}`), &m)
+	if err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	expected := map[string]interface{}{
+		"count": int64(3),
+		"neg":   int64(-12),
+		"ratio": 0.5,
+		"exp":   float64(1000),
+		"big":   1e20,
+	}
+	for k, want := range expected {
+		if m[k] != want {
+			t.Errorf("%s: expected %T(%v), got %T(%v)", k, want, want, m[k], m[k])
+		}
+	}
+	list := m["list"].([]interface{})
+	if list[0] != int64(1) || list[1] != 2.5 {
+		t.Errorf("list: expected [int64(1) 2.5], got %#v", list)
+	}
+}
+
+func TestGenericTableDecode(t *testing.T) {
+	var m map[string]interface{}
+	err := Unmarshal([]byte(`{name="MegaCorp";employees=(name,age,addr,ok:"John",28,"Boston",true;"Jane",,"Seattle",;)}`), &m)
+	if err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	rows, ok := m["employees"].([]map[string]interface{})
+	if !ok || len(rows) != 2 {
+		t.Fatalf("expected 2 generic rows, got %#v", m["employees"])
+	}
+	if rows[0]["name"] != "John" || rows[0]["age"] != int64(28) || rows[0]["ok"] != true {
+		t.Errorf("unexpected first row: %#v", rows[0])
+	}
+	if rows[1]["age"] != "" || rows[1]["ok"] != "" || rows[1]["addr"] != "Seattle" {
+		t.Errorf("unexpected second row: %#v", rows[1])
+	}
+}
+
+type lineItem struct {
+	SKU  string `god:"sku"`
+	Note string `god:"note"`
+	Qty  int    `god:"qty"`
+}
+
+type order struct {
+	ID    int               `god:"id"`
+	Items []lineItem        `god:"items"`
+	Tags  []string          `god:"tags"`
+	Meta  map[string]string `god:"meta"`
+	Ship  lineItem          `god:"ship"`
+}
+
+func TestNestedTableCells(t *testing.T) {
+	in := []order{
+		{
+			ID:    1,
+			Items: []lineItem{{SKU: "a", Note: "x), (y", Qty: 1}, {SKU: "b", Qty: 2}},
+			Tags:  []string{"x", "y]"},
+			Meta:  map[string]string{"k": "}"},
+			Ship:  lineItem{SKU: "z", Qty: 3},
+		},
+		{ID: 2},
+	}
+	for _, beautify := range []bool{false, true} {
+		data, err := MarshalOptions{Beautify: beautify}.Marshal(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []order
+		if err := Unmarshal(data, &out); err != nil {
+			t.Fatalf("beautify=%v: %v\n%s", beautify, err, data)
+		}
+		if !reflect.DeepEqual(out, in) {
+			t.Errorf("beautify=%v: round trip\ngot  %+v\nwant %+v", beautify, out, in)
+		}
+	}
+
+	var generic interface{}
+	doc := `{(id,items,tags:1,(sku,qty:"a",1;"b",2;),["x"];2,(sku:"c";),;)}`
+	if err := Unmarshal([]byte(doc), &generic); err != nil {
+		t.Fatal(err)
+	}
+	rows := generic.([]map[string]interface{})
+	items := rows[0]["items"].([]map[string]interface{})
+	if len(items) != 2 || items[1]["sku"] != "b" || items[1]["qty"] != int64(2) {
+		t.Errorf("nested table decoded as %v", rows[0]["items"])
+	}
+	if !reflect.DeepEqual(rows[0]["tags"], []interface{}{"x"}) || rows[1]["tags"] != "" {
+		t.Errorf("nested lists decoded as %v and %v", rows[0]["tags"], rows[1]["tags"])
+	}
+
+	// Nested values in columns without a field are skipped whole.
+	var ids []struct {
+		ID int `god:"id"`
+	}
+	if err := Unmarshal([]byte(doc), &ids); err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[1].ID != 2 {
+		t.Errorf("got %+v", ids)
+	}
+}
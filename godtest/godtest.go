@@ -0,0 +1,59 @@
+// Package godtest provides test assertions for code that produces GOD.
+//
+// Comparing Marshal output as strings fails on any change of layout or key
+// order and says little about what went wrong. AssertEqual compares the
+// documents by meaning instead and reports only the leaves that differ,
+// one per line in path order, so failures stay readable and stable:
+//
+//	GOD documents differ:
+//	  employees[1].age: expected 25, got 26
+//	  founded: missing in actual
+package godtest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vinayakgupta29/god"
+	"github.com/vinayakgupta29/god/internal/roundtrip"
+)
+
+// AssertEqual reports an error on t unless the GOD documents want and got
+// hold the same data, as god.Diff sees it. It returns whether they do.
+func AssertEqual(t testing.TB, want, got []byte) bool {
+	t.Helper()
+	diffs, err := god.Diff(want, got)
+	if err != nil {
+		t.Errorf("cannot compare GOD documents: %v", err)
+		return false
+	}
+	if len(diffs) == 0 {
+		return true
+	}
+	t.Errorf("%s", Render(diffs))
+	return false
+}
+
+// AssertRoundTrip marshals v, unmarshals the result into a new value of
+// the same type and marshals that again, and reports an error on t unless
+// both encodings hold the same data. It returns whether they do.
+func AssertRoundTrip(t testing.TB, v interface{}) bool {
+	t.Helper()
+	first, second, err := roundtrip.Twice(v, "Marshal", god.Marshal)
+	if err != nil {
+		t.Errorf("%v", err)
+		return false
+	}
+	return AssertEqual(t, first, second)
+}
+
+// Render formats differences as AssertEqual reports them.
+func Render(diffs []god.Difference) string {
+	var sb strings.Builder
+	sb.WriteString("GOD documents differ:")
+	for _, d := range diffs {
+		sb.WriteString("\n  ")
+		sb.WriteString(d.String())
+	}
+	return sb.String()
+}
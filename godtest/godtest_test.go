@@ -0,0 +1,88 @@
+package godtest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/vinayakgupta29/god"
+)
+
+// recorder captures failures instead of failing the test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertEqualPasses(t *testing.T) {
+	r := &recorder{TB: t}
+	want := []byte(`{name="acme";staff=(name,age:"a",30;);tags=["x"]}`)
+	got := []byte("{\n  tags = [\"x\"];\n  staff = [{age=30.0;name=\"a\"}];\n  name = \"acme\";\n}")
+	if !AssertEqual(r, want, got) || len(r.errors) != 0 {
+		t.Errorf("expected equal documents, got %q", r.errors)
+	}
+}
+
+func TestAssertEqualRendering(t *testing.T) {
+	r := &recorder{TB: t}
+	want := []byte(`{name="acme";founded=2020;staff=(name,age:"a",25;"b",30;);tags=["x","y"];meta={v=1.5}}`)
+	got := []byte(`{name="acme";staff=(name,age:"a",26;"b",30;);tags=["x"];meta=[1];extra=true}`)
+	if AssertEqual(r, want, got) {
+		t.Fatal("expected a failure")
+	}
+	golden := `GOD documents differ:
+  extra: unexpected key with value true
+  founded: missing in actual
+  meta: expected object, got list
+  staff[0].age: expected 25, got 26
+  tags: expected 2 elements, got 1`
+	if len(r.errors) != 1 || r.errors[0] != golden {
+		t.Errorf("got:\n%s\nwant:\n%s", r.errors, golden)
+	}
+}
+
+func TestAssertEqualInvalid(t *testing.T) {
+	r := &recorder{TB: t}
+	if AssertEqual(r, []byte(`{a=1}`), []byte(`a=1`)) {
+		t.Fatal("expected a failure")
+	}
+	if len(r.errors) != 1 {
+		t.Errorf("expected one error, got %q", r.errors)
+	}
+}
+
+func TestRender(t *testing.T) {
+	got := Render([]god.Difference{
+		{Path: "", Want: "a", Got: int64(1)},
+		{Path: "x", Want: 1.5, Got: 2.0},
+	})
+	want := "GOD documents differ:\n  (root): expected \"a\", got 1\n  x: expected 1.5, got 2"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestAssertRoundTrip(t *testing.T) {
+	type row struct {
+		Name string `god:"name"`
+		Age  int    `god:"age"`
+	}
+	type doc struct {
+		Rows []row         `god:"rows"`
+		Tags map[int]bool  `god:"tags"`
+		Ptr  *string       `god:"ptr"`
+		Any  []interface{} `god:"any"`
+	}
+	s := ""
+	AssertRoundTrip(t, doc{Rows: []row{{"a", 1}}, Tags: map[int]bool{3: true}, Ptr: &s, Any: []interface{}{"x", int64(1)}})
+
+	r := &recorder{TB: t}
+	if AssertRoundTrip(r, make(chan int)) || len(r.errors) != 1 {
+		t.Errorf("expected a marshal failure, got %q", r.errors)
+	}
+}
@@ -0,0 +1,263 @@
+package god
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Redacted is written in place of the value of a field tagged
+// `god:"name,redact"`.
+const Redacted = "***"
+
+// A FieldHook can replace the value of a struct field as it is encoded or
+// decoded, for example to redact or encrypt personal data without touching
+// the original struct. path locates the field from the root, as in
+// "employees[2].ssn" or "meta.owner". If use is false, v is used as is.
+//
+// Hooks run for fields at any depth, including the cells of tables. A nil
+// replacement grounds the field.
+type FieldHook func(path string, v reflect.Value) (replacement interface{}, use bool)
+
+// MarshalOptions configures encoding. The zero value encodes like Marshal.
+type MarshalOptions struct {
+	// Beautify writes the indented form of MarshalBeautify.
+	Beautify bool
+
+	// FieldHook is called with every struct field before it is encoded.
+	// Fields tagged redact are written as Redacted unless the hook
+	// replaces them.
+	FieldHook FieldHook
+
+	// AlignTableColumns pads the cells of beautified tables with spaces
+	// so that each column starts at the same offset in every row. The
+	// padding is insignificant whitespace.
+	AlignTableColumns bool
+
+	// RightAlignNumbers right-aligns numeric cells within their column
+	// when AlignTableColumns is set.
+	RightAlignNumbers bool
+
+	// AlignAssignments pads the keys of each beautified object so that
+	// its '=' signs line up. Each object is aligned on its own.
+	AlignAssignments bool
+
+	// TrailingNewline ends the document with a single '\n', as POSIX
+	// tools expect of text files. WriteFile always sets it.
+	TrailingNewline bool
+
+	// EscapeUnicode writes every non-ASCII rune of a quoted string or key
+	// as a \u or \U escape, for consumers that only handle ASCII. By
+	// default printable Unicode is written as UTF-8, which is shorter and
+	// readable; control and other non-printable characters are always
+	// escaped.
+	EscapeUnicode bool
+
+	// Lossless makes Marshal return a *LossyEncodingError rather than
+	// write a value that would not decode back into an equal one, such as
+	// a NaN, an error (written as its message) or a flatten key that
+	// collides with a field. Empty but non-nil slices and maps are written
+	// as [] and {} instead of being grounded. Fields that are never encoded,
+	// those tagged "-" or unexported, and values changed by FieldHook or
+	// redact are not checked, nor are values held in an interface{}, which
+	// decode to the generic types.
+	Lossless bool
+
+	// UseAliases writes the alias registered with RegisterAlias in front
+	// of each value of an aliased type, as in @timestamp"2024-01-01", so
+	// that documents name their types. Types that annotate themselves
+	// with MarshalGODAnnotated keep their own annotation.
+	UseAliases bool
+
+	// EmitTypeComments writes the type of each named struct as a comment
+	// in beautified output: "// example.com/pkg.User" as the first line
+	// of the object, and "// []example.com/pkg.User" above the header of
+	// a table. Decoders skip comments. Compact output has none.
+	EmitTypeComments bool
+
+	// JSONRawMessages says how json.RawMessage values are written; by
+	// default they are lists of bytes like any []byte. See JSONRawMode.
+	JSONRawMessages JSONRawMode
+
+	// TypeAnnotations writes each named struct held in an interface, such
+	// as an element of a []Shape, with its type name under the key @type
+	// before its fields: {@type="Circle";radius=5}. Decode it back with
+	// UnmarshalOptions.TypeRegistry. The key @type is reserved for this
+	// and always written bare.
+	TypeAnnotations bool
+
+	// TimeFormat is the layout time.Time values are written in, as for
+	// time.Format, or TimeUnix or TimeUnixMilli for an integer epoch.
+	// Decode them with the same UnmarshalOptions.TimeFormat. By default
+	// times are written by their MarshalText method, in RFC 3339 format.
+	TimeFormat string
+
+	// FieldNameMapper, if set, gives the key of each struct field without
+	// a name in its god tag, in objects and table headers alike, in place
+	// of the lowercased field name. See SnakeCase, CamelCase and
+	// KebabCase. Decode with the same UnmarshalOptions.FieldNameMapper.
+	FieldNameMapper func(string) string
+
+	// MaxWidth, if positive, is the width in characters that beautified
+	// table rows are kept within: a row that would run past it is broken
+	// after a cell separator and goes on on the next line, indented one
+	// level deeper. The breaks are whitespace, so wrapped tables decode
+	// as they are. Compact output is not wrapped.
+	MaxWidth int
+
+	// OmitZero treats every struct field as tagged omitempty: fields that
+	// would be written as empty values, and table columns empty in every
+	// row, are left out. See MarshalMinimal.
+	OmitZero bool
+
+	// Indent is what each level of beautified output is indented by, such
+	// as "\t"; two spaces by default. It may only hold spaces and tabs,
+	// which decoders skip. Every object, list and table indents its
+	// contents one level deeper than the line it opens on and closes at
+	// that line's indentation.
+	Indent string
+}
+
+// Marshal returns the GOD encoding of v using the options in o.
+func (o MarshalOptions) Marshal(v interface{}) ([]byte, error) {
+	return o.marshal(v, nil)
+}
+
+// marshal encodes v, counting into stats if it is not nil.
+func (o MarshalOptions) marshal(v interface{}, stats *MarshalStats) ([]byte, error) {
+	var sb strings.Builder
+	b := o.newEncodeState(&sb)
+	b.stats = stats
+	if err := encodeRoot(b, v, !o.Beautify); err != nil {
+		return nil, err
+	}
+	if o.TrailingNewline {
+		b.WriteByte('\n')
+	}
+	return []byte(sb.String()), nil
+}
+
+// newEncodeState returns an encodeState writing to w with the options in o.
+func (o MarshalOptions) newEncodeState(w writer) *encodeState {
+	return &encodeState{
+		writer:            w,
+		fieldHook:         o.FieldHook,
+		alignColumns:      o.AlignTableColumns,
+		rightAlignNumbers: o.RightAlignNumbers,
+		alignAssignments:  o.AlignAssignments,
+		escapeUnicode:     o.EscapeUnicode,
+		lossless:          o.Lossless,
+		useAliases:        o.UseAliases,
+		typeComments:      o.EmitTypeComments,
+		jsonRaw:           o.JSONRawMessages,
+		typeAnnotations:   o.TypeAnnotations,
+		timeFormat:        o.TimeFormat,
+		fieldNameMapper:   o.FieldNameMapper,
+		maxWidth:          o.MaxWidth,
+		omitZero:          o.OmitZero,
+		indentUnit:        o.Indent,
+	}
+}
+
+// fieldPath is the location of the value being encoded or decoded, kept
+// only while a FieldHook is set or, when encoding, with Lossless or, when
+// decoding, with a ColumnPolicy.
+type fieldPath []byte
+
+// push appends an object key and returns the mark to truncate back to.
+func (fp *fieldPath) push(key string) int {
+	mark := len(*fp)
+	if mark > 0 {
+		*fp = append(*fp, '.')
+	}
+	*fp = append(*fp, key...)
+	return mark
+}
+
+// index appends a list index and returns the mark to truncate back to.
+func (fp *fieldPath) index(i int) int {
+	mark := len(*fp)
+	*fp = append(*fp, '[')
+	*fp = strconv.AppendInt(*fp, int64(i), 10)
+	*fp = append(*fp, ']')
+	return mark
+}
+
+func (b *encodeState) pushKey(key string) int {
+	if b.fieldHook == nil && !b.lossless {
+		return 0
+	}
+	return b.path.push(key)
+}
+
+func (b *encodeState) pushIndex(i int) int {
+	if b.fieldHook == nil && !b.lossless {
+		return 0
+	}
+	return b.path.index(i)
+}
+
+func (b *encodeState) pop(mark int) {
+	b.path = b.path[:mark]
+}
+
+// hookField returns the value to encode for the struct field v, whose key
+// has already been pushed.
+func (b *encodeState) hookField(tag fieldTag, v reflect.Value) reflect.Value {
+	if b.fieldHook != nil {
+		if r, use := b.fieldHook(string(b.path), v); use {
+			return reflect.ValueOf(&r).Elem()
+		}
+	}
+	if tag.redact {
+		return reflect.ValueOf(Redacted)
+	}
+	return v
+}
+
+func (p *parser) pushKey(key string) int {
+	if p.fieldHook == nil && p.onColumnMismatch == IgnoreColumns {
+		return 0
+	}
+	return p.path.push(key)
+}
+
+func (p *parser) pushIndex(i int) int {
+	if p.fieldHook == nil && p.onColumnMismatch == IgnoreColumns {
+		return 0
+	}
+	return p.path.index(i)
+}
+
+func (p *parser) pop(mark int) {
+	p.path = p.path[:mark]
+}
+
+// decodeField runs decode on the struct field v, whose key has already
+// been pushed, and passes the result through the field hook.
+func (p *parser) decodeField(v reflect.Value, decode func(reflect.Value) error) error {
+	if p.fieldHook == nil {
+		return decode(v)
+	}
+	tmp := reflect.New(v.Type()).Elem()
+	tmp.Set(v)
+	if err := decode(tmp); err != nil {
+		return err
+	}
+	r, use := p.fieldHook(string(p.path), tmp)
+	if !use {
+		v.Set(tmp)
+		return nil
+	}
+	rv := reflect.ValueOf(r)
+	switch {
+	case !rv.IsValid():
+		v.Set(reflect.Zero(v.Type()))
+	case rv.Type().AssignableTo(v.Type()):
+		v.Set(rv)
+	default:
+		return fmt.Errorf("field hook for %s returned %v, want %v", p.path, rv.Type(), v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package god
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type hookEmployee struct {
+	Name string `god:"name"`
+	SSN  string `god:"ssn,redact"`
+	Age  int    `god:"age"`
+}
+
+type hookCompany struct {
+	Name      string         `god:"name"`
+	Owner     hookEmployee   `god:"owner"`
+	Employees []hookEmployee `god:"employees"`
+}
+
+func TestRedactTag(t *testing.T) {
+	in := hookCompany{
+		Name:      "acme",
+		Owner:     hookEmployee{Name: "o", SSN: "000-11-2222", Age: 50},
+		Employees: []hookEmployee{{Name: "a", SSN: "123-45-6789", Age: 30}},
+	}
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{name="acme";owner={name="o";ssn="***";age=50};employees=(name,ssn,age:"a","***",30;)}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+	if in.Owner.SSN != "000-11-2222" || in.Employees[0].SSN != "123-45-6789" {
+		t.Errorf("original struct was modified: %+v", in)
+	}
+}
+
+func TestMarshalFieldHook(t *testing.T) {
+	in := hookCompany{
+		Owner:     hookEmployee{Name: "o", Age: 50},
+		Employees: []hookEmployee{{Name: "a", Age: 30}, {Name: "b", Age: 40}},
+	}
+	var paths []string
+	opts := MarshalOptions{FieldHook: func(path string, v reflect.Value) (interface{}, bool) {
+		paths = append(paths, path)
+		if strings.HasSuffix(path, ".name") {
+			return strings.ToUpper(v.String()), true
+		}
+		return nil, false
+	}}
+	data, err := opts.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{name=;owner={name="O";ssn="***";age=50};employees=(name,ssn,age:"A","***",30;"B","***",40;)}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+	wantPaths := []string{
+		"name", "owner", "owner.name", "owner.ssn", "owner.age", "employees",
+		"employees[0].name", "employees[0].ssn", "employees[0].age",
+		"employees[1].name", "employees[1].ssn", "employees[1].age",
+	}
+	if !reflect.DeepEqual(paths, wantPaths) {
+		t.Errorf("paths: got %q, want %q", paths, wantPaths)
+	}
+	if in.Employees[0].Name != "a" {
+		t.Errorf("original struct was modified: %+v", in)
+	}
+}
+
+func TestUnmarshalFieldHook(t *testing.T) {
+	src := `{name="acme";owner={name="enc:o";age=50};employees=(name,age:"enc:a",30;"b",40;)}`
+	var paths []string
+	opts := UnmarshalOptions{FieldHook: func(path string, v reflect.Value) (interface{}, bool) {
+		paths = append(paths, path)
+		if s, ok := v.Interface().(string); ok && strings.HasPrefix(s, "enc:") {
+			return strings.TrimPrefix(s, "enc:"), true
+		}
+		return nil, false
+	}}
+	var out hookCompany
+	if err := opts.Unmarshal([]byte(src), &out); err != nil {
+		t.Fatal(err)
+	}
+	want := hookCompany{
+		Name:      "acme",
+		Owner:     hookEmployee{Name: "o", Age: 50},
+		Employees: []hookEmployee{{Name: "a", Age: 30}, {Name: "b", Age: 40}},
+	}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("got %+v, want %+v", out, want)
+	}
+	wantPaths := []string{
+		"name", "owner.name", "owner.age", "owner",
+		"employees[0].name", "employees[0].age", "employees[1].name", "employees[1].age", "employees",
+	}
+	if !reflect.DeepEqual(paths, wantPaths) {
+		t.Errorf("paths: got %q, want %q", paths, wantPaths)
+	}
+
+	bad := UnmarshalOptions{FieldHook: func(path string, v reflect.Value) (interface{}, bool) {
+		return 1, path == "name"
+	}}
+	if err := bad.Unmarshal([]byte(src), &out); err == nil {
+		t.Error("expected an error for a replacement of the wrong type")
+	}
+}
@@ -0,0 +1,81 @@
+package god
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+)
+
+// includeDirective is the top-level statement that splices another GOD file
+// into the document being decoded:
+//
+//	{
+//	  @include "database.god";
+//	  name="service";
+//	}
+//
+// The included file must itself be a key-value object. Its pairs are merged
+// into the current target in document order, so keys that follow the
+// directive override included ones and vice versa. Paths are resolved against
+// UnmarshalOptions.IncludeFS, relative to the directory of the including file,
+// or handed to UnmarshalOptions.IncludeResolver if it is set. Marshal never
+// emits includes; they only exist in hand-written documents.
+const includeDirective = "@include"
+
+// ErrIncludeDisabled is returned for an @include directive decoded without
+// UnmarshalOptions.IncludeFS or IncludeResolver.
+var ErrIncludeDisabled = errors.New("@include needs UnmarshalOptions.IncludeFS or IncludeResolver")
+
+func (p *parser) include(target reflect.Value, decode func(*parser, reflect.Value) error) error {
+	if p.depth != 1 {
+		return fmt.Errorf("%s is only allowed at the top level", includeDirective)
+	}
+	name, err := parseStringValue(p)
+	if err != nil {
+		return fmt.Errorf("%s: %w", includeDirective, err)
+	}
+	p.skipSpaces()
+	if p.peek() == ';' {
+		p.next()
+	}
+	p.skipSpaces()
+
+	name = path.Join(path.Dir(p.file), name)
+	if p.including == nil {
+		p.including = make(map[string]bool)
+	}
+	if p.including[name] {
+		return fmt.Errorf("circular %s of %q", includeDirective, name)
+	}
+	var data []byte
+	switch {
+	case p.opts.IncludeResolver != nil:
+		data, err = p.opts.IncludeResolver(name)
+	case p.opts.IncludeFS != nil:
+		data, err = fs.ReadFile(p.opts.IncludeFS, name)
+	default:
+		err = ErrIncludeDisabled
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", includeDirective, err)
+	}
+
+	p.including[name] = true
+	defer delete(p.including, name)
+
+	// The included file is decoded with the same options, as a document
+	// of its own. It shares the files being included, the nesting of
+	// values against MaxDepth, the context and the statistics; progress
+	// is only reported through the including document.
+	child := p.opts.newParser(data)
+	child.file, child.including, child.nesting = name, p.including, p.nesting
+	child.ctx, child.stats, child.progress = p.ctx, p.stats, nil
+	child.skipSpaces()
+	err = decode(child, target)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
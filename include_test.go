@@ -0,0 +1,213 @@
+package god
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestIncludeStruct(t *testing.T) {
+	fsys := fstest.MapFS{
+		"base.god": {Data: []byte(`{name="Base";age=40;addr="Base Street"}`)},
+	}
+	data := []byte(`{@include "base.god"; name="Override"}`)
+
+	var p Person
+	if err := (UnmarshalOptions{IncludeFS: fsys}).Unmarshal(data, &p); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	want := Person{Name: "Override", Age: 40, Address: "Base Street"}
+	if p != want {
+		t.Errorf("expected %+v, got %+v", want, p)
+	}
+}
+
+func TestIncludeMapNested(t *testing.T) {
+	fsys := fstest.MapFS{
+		"conf/db.god":    {Data: []byte(`{@include "creds.god"; host="localhost"; port=5432}`)},
+		"conf/creds.god": {Data: []byte(`{user="admin"}`)},
+	}
+	data := []byte(`{name="svc"; @include "conf/db.god"}`)
+
+	var m map[string]interface{}
+	if err := (UnmarshalOptions{IncludeFS: fsys}).Unmarshal(data, &m); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if m["name"] != "svc" || m["host"] != "localhost" || m["port"] != int64(5432) || m["user"] != "admin" {
+		t.Errorf("unexpected merge result: %v", m)
+	}
+}
+
+func TestIncludeCircular(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.god": {Data: []byte(`{@include "b.god"; a=1}`)},
+		"b.god": {Data: []byte(`{@include "a.god"; b=2}`)},
+	}
+	var m map[string]interface{}
+	err := (UnmarshalOptions{IncludeFS: fsys}).Unmarshal([]byte(`{@include "a.god"}`), &m)
+	if err == nil || !strings.Contains(err.Error(), "circular") {
+		t.Errorf("expected circular include error, got %v", err)
+	}
+}
+
+func TestIncludeDiamondIsNotCircular(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.god":      {Data: []byte(`{@include "shared.god"; a=1}`)},
+		"b.god":      {Data: []byte(`{@include "shared.god"; b=2}`)},
+		"shared.god": {Data: []byte(`{shared=true}`)},
+	}
+	var m map[string]interface{}
+	err := (UnmarshalOptions{IncludeFS: fsys}).Unmarshal([]byte(`{@include "a.god"; @include "b.god"}`), &m)
+	if err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if m["a"] != int64(1) || m["b"] != int64(2) || m["shared"] != true {
+		t.Errorf("unexpected merge result: %v", m)
+	}
+}
+
+func TestIncludeOnlyAtTopLevel(t *testing.T) {
+	fsys := fstest.MapFS{"x.god": {Data: []byte(`{a=1}`)}}
+	var v struct {
+		Inner map[string]int `god:"inner"`
+	}
+	err := (UnmarshalOptions{IncludeFS: fsys}).Unmarshal([]byte(`{inner={@include "x.god"}}`), &v)
+	if err == nil || !strings.Contains(err.Error(), "top level") {
+		t.Errorf("expected top-level error, got %v", err)
+	}
+}
+
+func TestIncludeMissingFile(t *testing.T) {
+	var p Person
+	err := (UnmarshalOptions{IncludeFS: fstest.MapFS{}}).Unmarshal([]byte(`{@include "nope.god"}`), &p)
+	if err == nil || !strings.Contains(err.Error(), "nope.god") {
+		t.Errorf("expected missing file error, got %v", err)
+	}
+}
+
+func TestReadFSInclude(t *testing.T) {
+	var p Person
+	if err := ReadFS(testdataFS, "testdata/config_include.god", &p); err != nil {
+		t.Fatalf("ReadFS error: %v", err)
+	}
+	want := Person{Name: "embedded", Age: 40, Address: "Included Street"}
+	if p != want {
+		t.Errorf("expected %+v, got %+v", want, p)
+	}
+}
+
+func TestIncludeResolver(t *testing.T) {
+	files := map[string]string{
+		"conf/app.god":  `{@include "db.god"; name="svc"}`,
+		"conf/db.god":   `{@include "../secret.god"; host="db"}`,
+		"secret.god":    `{password="hunter2"}`,
+		"loop/a.god":    `{@include "b.god"}`,
+		"loop/b.god":    `{@include "a.god"}`,
+		"members.god":   `{employees=(name,age:"a",1;"b",0;)}`,
+		"partial.god":   `{name="x"}`,
+		"validated.god": `{@include "partial.god"; age=3}`,
+	}
+	var asked []string
+	resolve := func(path string) ([]byte, error) {
+		asked = append(asked, path)
+		if strings.HasPrefix(path, "..") || path == "secret.god" {
+			return nil, fs.ErrPermission
+		}
+		data, ok := files[path]
+		if !ok {
+			return nil, fs.ErrNotExist
+		}
+		return []byte(data), nil
+	}
+
+	var m map[string]interface{}
+	err := UnmarshalWith([]byte(`{@include "conf/app.god"}`), &m, WithIncludeResolver(resolve))
+	if !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("got %v, want a sandbox error", err)
+	}
+	if want := []string{"conf/app.god", "conf/db.god", "secret.god"}; !reflect.DeepEqual(asked, want) {
+		t.Errorf("resolver asked for %q, want %q", asked, want)
+	}
+
+	files["conf/db.god"] = `{host="db"}`
+	m = nil
+	if err := UnmarshalWith([]byte(`{@include "conf/app.god"; port=1}`), &m, WithIncludeResolver(resolve)); err != nil {
+		t.Fatal(err)
+	}
+	if m["host"] != "db" || m["name"] != "svc" || m["port"] != int64(1) {
+		t.Errorf("got %v", m)
+	}
+
+	err = UnmarshalWith([]byte(`{@include "loop/a.god"}`), &m, WithIncludeResolver(resolve))
+	if err == nil || !strings.Contains(err.Error(), "circular") {
+		t.Errorf("expected circular include error, got %v", err)
+	}
+
+	// Included files are decoded with the same options, and the struct
+	// they fill in is validated once whole.
+	var c staffCompany
+	d := NewDecoder(strings.NewReader(`{@include "members.god"}`))
+	d.SetIncludeResolver(resolve)
+	var ve *ValidationError
+	if err := d.Decode(&c); !errors.As(err, &ve) || ve.Path != "employees[1]" {
+		t.Errorf("got %v, want a validation error at employees[1]", err)
+	}
+	var s staffMember
+	if err := UnmarshalWith([]byte(`{@include "validated.god"}`), &s, WithIncludeResolver(resolve)); err != nil {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestIncludeGeneric(t *testing.T) {
+	fsys := fstest.MapFS{
+		"c.god":    {Data: []byte(`{host="localhost"}`)},
+		"base.god": {Data: []byte(`{name="base";port=80}`)},
+	}
+	opts := UnmarshalOptions{IncludeFS: fsys}
+
+	var v interface{}
+	if err := opts.Unmarshal([]byte(`{@include "c.god"}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]interface{}{"host": "localhost"}; !reflect.DeepEqual(v, want) {
+		t.Errorf("got %#v, want %#v", v, want)
+	}
+
+	v = nil
+	if err := opts.Unmarshal([]byte(`{@include "base.god";name="x"}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]interface{}{"name": "x", "port": int64(80)}; !reflect.DeepEqual(v, want) {
+		t.Errorf("got %#v, want %#v", v, want)
+	}
+
+	var m map[string]interface{}
+	if err := opts.Unmarshal([]byte(`{@include "base.god";name="x"}`), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["name"] != "x" || m["port"] != int64(80) {
+		t.Errorf("got %v", m)
+	}
+
+	for _, target := range []interface{}{new(interface{}), new(map[string]interface{})} {
+		err := opts.Unmarshal([]byte(`{sub={@include "c.god"}}`), target)
+		if err == nil || !strings.Contains(err.Error(), "only allowed at the top level") {
+			t.Errorf("nested include into %T: got %v", target, err)
+		}
+	}
+}
+
+func TestIncludeDisabledByDefault(t *testing.T) {
+	var m map[string]interface{}
+	err := Unmarshal([]byte(`{@include "go.mod"}`), &m)
+	if !errors.Is(err, ErrIncludeDisabled) {
+		t.Errorf("got %v, want ErrIncludeDisabled", err)
+	}
+	var v interface{}
+	if err := Unmarshal([]byte(`{@include "go.mod";a=1}`), &v); !errors.Is(err, ErrIncludeDisabled) {
+		t.Errorf("interface{}: got %v, want ErrIncludeDisabled", err)
+	}
+}
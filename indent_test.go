@@ -0,0 +1,186 @@
+package god
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type indentMember struct {
+	Name  string
+	Roles map[string]int
+	Tags  []string
+}
+
+type indentTeam struct {
+	Name    string
+	Members []indentMember
+}
+
+type indentCompany struct {
+	Name  string
+	Teams []indentTeam
+	Meta  map[string][]map[string]int
+}
+
+var indentSample = indentCompany{
+	Name: "Acme",
+	Teams: []indentTeam{
+		{Name: "core", Members: []indentMember{
+			{Name: "Ann", Roles: map[string]int{"lead": 1}, Tags: []string{"go"}},
+			{Name: "Ben"},
+		}},
+		{Name: "ops"},
+	},
+	Meta: map[string][]map[string]int{"floors": {{"n": 1}, {"n": 2}}},
+}
+
+func TestIndentGolden(t *testing.T) {
+	out, err := MarshalBeautify(indentSample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := `{
+  name="Acme";
+  teams=(name,members:
+    "core",(name,roles,tags:
+      "Ann",{
+        lead=1;
+      },["go"];
+      "Ben",,;
+    );
+    "ops",;
+  );
+  meta={
+    floors=[{
+      n=1;
+    },{
+      n=2;
+    }];
+  };
+}`
+	if string(out) != golden {
+		t.Errorf("got:\n%s\nwant:\n%s", out, golden)
+	}
+	checkIndentation(t, out, "  ")
+
+	var back indentCompany
+	if err := Unmarshal(out, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, indentSample) {
+		t.Errorf("got %+v, want %+v", back, indentSample)
+	}
+}
+
+func TestIndentTabs(t *testing.T) {
+	out, err := MarshalWith(indentSample, Beautify(), Indent("\t"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	spaces, _ := MarshalBeautify(indentSample)
+	if want := strings.ReplaceAll(string(spaces), "  ", "\t"); string(out) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+	checkIndentation(t, out, "\t")
+
+	for _, o := range []MarshalOptions{
+		{Beautify: true, Indent: "\t", AlignTableColumns: true},
+		{Beautify: true, Indent: "    ", MaxWidth: 20},
+	} {
+		out, err := o.Marshal(indentSample)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var back indentCompany
+		if err := Unmarshal(out, &back); err != nil || !reflect.DeepEqual(back, indentSample) {
+			t.Errorf("round trip of\n%s\ngot %+v, %v", out, back, err)
+		}
+	}
+
+	tr, err := Transcode(spaces, Beautify(), Indent("\t"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(tr, out) {
+		t.Errorf("transcode got:\n%s\nwant:\n%s", tr, out)
+	}
+}
+
+func TestIndentEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetBeautify(true)
+	enc.SetIndent("\t")
+	if err := enc.Encode(map[string][]int{"a": {1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.BeginObject(); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.EncodeKeyValue("b", map[string]int{"c": 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.EndObject(); err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n\ta=[1];\n}\n{\n\tb={\n\t\tc=2;\n\t};\n}\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestIndentInvalid(t *testing.T) {
+	if _, err := MarshalWith(indentSample, Beautify(), Indent("--")); err == nil {
+		t.Error("expected an error for a non-whitespace indent")
+	}
+	if _, err := Transcode([]byte(`{a=1}`), Indent("x")); err == nil {
+		t.Error("expected an error for a non-whitespace indent")
+	}
+	enc := NewEncoder(&bytes.Buffer{})
+	enc.SetIndent("//")
+	if err := enc.BeginObject(); err == nil {
+		t.Error("expected an error for a non-whitespace indent")
+	}
+}
+
+// checkIndentation checks that every line of a beautified document is
+// indented by whole units, one deeper than the line that opened the
+// construct it is in, and that each line starting with a closing bracket
+// is indented like the line that opened it. Strings in the output must not
+// hold brackets.
+func checkIndentation(t *testing.T, out []byte, unit string) {
+	t.Helper()
+	var open []int // indentation levels of the lines with unclosed openers
+	for n, line := range strings.Split(string(out), "\n") {
+		body := strings.TrimLeft(line, " \t")
+		prefix := line[:len(line)-len(body)]
+		level := strings.Count(prefix, unit)
+		if strings.Repeat(unit, level) != prefix {
+			t.Errorf("line %d: indentation %q is not whole units of %q", n+1, prefix, unit)
+		}
+		closes := 0
+		for closes < len(body) && strings.IndexByte("}])", body[closes]) >= 0 {
+			closes++
+		}
+		switch {
+		case len(open) == 0:
+		case closes > 0 && level != open[len(open)-closes]:
+			t.Errorf("line %d: %q closes at level %d, opened at level %d", n+1, line, level, open[len(open)-closes])
+		case closes == 0 && level != open[len(open)-1]+1:
+			t.Errorf("line %d: %q is at level %d, want %d", n+1, line, level, open[len(open)-1]+1)
+		}
+		for _, c := range body {
+			switch c {
+			case '{', '[', '(':
+				open = append(open, level)
+			case '}', ']', ')':
+				open = open[:len(open)-1]
+			}
+		}
+	}
+	if len(open) != 0 {
+		t.Errorf("%d constructs left open", len(open))
+	}
+}
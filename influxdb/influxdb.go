@@ -0,0 +1,349 @@
+// Package influxdb converts between InfluxDB line protocol and GOD. Each
+// line protocol record
+//
+//	cpu,host=a,region=eu usage=0.5,cores=8i,ok=true 1700000000000000000
+//
+// becomes a row of a table with a measurement, tags, fields and timestamp
+// column, held under the key points:
+//
+//	{points=(measurement,tags,fields,timestamp:"cpu",{host="a";region="eu"},{cores=8;ok=true;usage=0.5},1700000000000000000;)}
+//
+// Tag and field sets vary from record to record, so each is a nested
+// object. Tag values are strings. Field values keep their line protocol
+// types as far as GOD can tell them apart: integers are written without a
+// decimal point, floats always with one or an exponent, so that 8i and 8
+// survive a round trip as 8 and 8.0. Unsigned integers are read as
+// integers and written back as signed ones, with a u suffix only when
+// they do not fit an int64. A record without a timestamp has an empty
+// timestamp cell.
+//
+// Both objects are written with their keys sorted, which InfluxDB
+// recommends for tags and which does not change the meaning of a record.
+package influxdb
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vinayakgupta29/god"
+)
+
+// Key is the key FromLineProtocol writes the table of points under.
+const Key = "points"
+
+// point is a row of the points table.
+type point struct {
+	Measurement string                    `god:"measurement"`
+	Tags        map[string]string         `god:"tags"`
+	Fields      map[string]god.RawMessage `god:"fields"`
+	Timestamp   *int64                    `god:"timestamp"`
+}
+
+// FromLineProtocol parses the line protocol records in data, one per line,
+// and returns them as a GOD document holding a table of points
+// under Key. Blank lines and comment lines, starting with '#', are
+// skipped.
+func FromLineProtocol(data []byte) ([]byte, error) {
+	var points []point
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, len(data)+1)
+	for lineNo := 1; sc.Scan(); lineNo++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		pt, err := parseRecord(line)
+		if err != nil {
+			return nil, fmt.Errorf("influxdb: line %d: %w", lineNo, err)
+		}
+		points = append(points, pt)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return god.Marshal(map[string][]point{Key: points})
+}
+
+// ToLineProtocol converts the table of points under key in the GOD
+// document data, in the shape FromLineProtocol writes, back into line
+// protocol, one record per line. Fields that are grounded are left out,
+// as are tags with empty values, which line protocol cannot hold.
+func ToLineProtocol(data []byte, key string) ([]byte, error) {
+	var doc map[string]god.RawMessage
+	if err := god.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	raw, ok := doc[key]
+	if !ok {
+		return nil, fmt.Errorf("influxdb: document has no key %q", key)
+	}
+	var points []point
+	if err := god.UnmarshalValue(raw, &points); err != nil {
+		return nil, fmt.Errorf("influxdb: %s: %w", key, err)
+	}
+
+	var buf bytes.Buffer
+	for i, pt := range points {
+		if err := writeRecord(&buf, pt); err != nil {
+			return nil, fmt.Errorf("influxdb: point %d: %w", i, err)
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// parseRecord parses one line protocol record.
+func parseRecord(line string) (point, error) {
+	pt := point{Fields: make(map[string]god.RawMessage)}
+	s := &scanner{s: line}
+
+	pt.Measurement = s.token(", ")
+	if pt.Measurement == "" {
+		return pt, errors.New("missing measurement")
+	}
+	for s.peek() == ',' {
+		s.i++
+		k := s.token("=, ")
+		if !s.consume('=') {
+			return pt, fmt.Errorf("expected '=' after tag key %q", k)
+		}
+		v := s.token(", ")
+		if k == "" || v == "" {
+			return pt, fmt.Errorf("empty tag key or value at offset %d", s.i)
+		}
+		if pt.Tags == nil {
+			pt.Tags = make(map[string]string)
+		}
+		pt.Tags[k] = v
+	}
+
+	if !s.consume(' ') {
+		return pt, errors.New("missing field set")
+	}
+	for {
+		k := s.token("=, ")
+		if !s.consume('=') {
+			return pt, fmt.Errorf("expected '=' after field key %q", k)
+		}
+		if k == "" {
+			return pt, fmt.Errorf("empty field key at offset %d", s.i)
+		}
+		v, err := s.fieldValue()
+		if err != nil {
+			return pt, fmt.Errorf("field %q: %w", k, err)
+		}
+		pt.Fields[k] = v
+		if !s.consume(',') {
+			break
+		}
+	}
+
+	if s.consume(' ') {
+		ts, err := strconv.ParseInt(strings.TrimSpace(s.s[s.i:]), 10, 64)
+		if err != nil {
+			return pt, fmt.Errorf("invalid timestamp %q", strings.TrimSpace(s.s[s.i:]))
+		}
+		pt.Timestamp = &ts
+	} else if s.i < len(s.s) {
+		return pt, fmt.Errorf("unexpected %q at offset %d", s.s[s.i], s.i)
+	}
+	return pt, nil
+}
+
+// A scanner reads the parts of a record.
+type scanner struct {
+	s string
+	i int
+}
+
+func (s *scanner) peek() byte {
+	if s.i < len(s.s) {
+		return s.s[s.i]
+	}
+	return 0
+}
+
+// consume skips c, and any further spaces if c is a space, and reports
+// whether it was there.
+func (s *scanner) consume(c byte) bool {
+	if s.peek() != c {
+		return false
+	}
+	for s.i++; c == ' ' && s.peek() == ' '; s.i++ {
+	}
+	return true
+}
+
+// token reads up to the next unescaped byte in stops. A backslash escapes
+// a following stop byte or '='; before anything else it is kept.
+func (s *scanner) token(stops string) string {
+	var b strings.Builder
+	for ; s.i < len(s.s); s.i++ {
+		c := s.s[s.i]
+		if c == '\\' && s.i+1 < len(s.s) && strings.IndexByte(stops+"=", s.s[s.i+1]) >= 0 {
+			s.i++
+			b.WriteByte(s.s[s.i])
+			continue
+		}
+		if strings.IndexByte(stops, c) >= 0 {
+			break
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// fieldValue reads a field value and returns its GOD encoding.
+func (s *scanner) fieldValue() (god.RawMessage, error) {
+	if s.peek() == '"' {
+		var b strings.Builder
+		for s.i++; s.i < len(s.s); s.i++ {
+			c := s.s[s.i]
+			if c == '\\' && s.i+1 < len(s.s) && (s.s[s.i+1] == '"' || s.s[s.i+1] == '\\') {
+				s.i++
+				c = s.s[s.i]
+			} else if c == '"' {
+				s.i++
+				return god.MarshalValue(b.String())
+			}
+			b.WriteByte(c)
+		}
+		return nil, errors.New("unterminated string")
+	}
+
+	v := s.token(", ")
+	switch v {
+	case "t", "T", "true", "True", "TRUE":
+		return god.RawMessage("true"), nil
+	case "f", "F", "false", "False", "FALSE":
+		return god.RawMessage("false"), nil
+	case "":
+		return nil, errors.New("missing value")
+	}
+	switch v[len(v)-1] {
+	case 'i':
+		n, err := strconv.ParseInt(v[:len(v)-1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", v)
+		}
+		return god.RawMessage(strconv.FormatInt(n, 10)), nil
+	case 'u':
+		n, err := strconv.ParseUint(v[:len(v)-1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid unsigned integer %q", v)
+		}
+		return god.RawMessage(strconv.FormatUint(n, 10)), nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, fmt.Errorf("invalid float %q", v)
+	}
+	return god.RawMessage(formatFloat(f)), nil
+}
+
+// formatFloat writes f with a decimal point or an exponent, so that it
+// does not read back as an integer.
+func formatFloat(f float64) string {
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+// writeRecord writes pt as a line protocol record.
+func writeRecord(buf *bytes.Buffer, pt point) error {
+	if pt.Measurement == "" {
+		return errors.New("missing measurement")
+	}
+	buf.WriteString(escape(pt.Measurement, ", "))
+	for _, k := range sortedKeys(pt.Tags) {
+		if v := pt.Tags[k]; v != "" {
+			buf.WriteString("," + escape(k, ",= ") + "=" + escape(v, ",= "))
+		}
+	}
+
+	n := 0
+	for _, k := range sortedKeys(pt.Fields) {
+		raw := pt.Fields[k]
+		if len(raw) == 0 || string(raw) == `\0` {
+			continue
+		}
+		v, err := fieldText(raw)
+		if err != nil {
+			return fmt.Errorf("field %q: %w", k, err)
+		}
+		if n == 0 {
+			buf.WriteByte(' ')
+		} else {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(escape(k, ",= ") + "=" + v)
+		n++
+	}
+	if n == 0 {
+		return errors.New("no fields")
+	}
+	if pt.Timestamp != nil {
+		buf.WriteString(" " + strconv.FormatInt(*pt.Timestamp, 10))
+	}
+	return nil
+}
+
+// fieldText converts the GOD value raw to a line protocol field value.
+func fieldText(raw god.RawMessage) (string, error) {
+	switch s := string(raw); {
+	case s == "true" || s == "false":
+		return s, nil
+	case raw[0] == '"':
+		var v string
+		if err := god.UnmarshalValue(raw, &v); err != nil {
+			return "", err
+		}
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v) + `"`, nil
+	case strings.ContainsAny(s, ".eE"):
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return "", fmt.Errorf("cannot write %s as a field value", s)
+		}
+		return formatFloat(f), nil
+	}
+	s := string(raw)
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return s + "i", nil
+	}
+	if _, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return s + "u", nil
+	}
+	return "", fmt.Errorf("cannot write %s as a field value", s)
+}
+
+// escape puts a backslash before each byte of s in special.
+func escape(s, special string) string {
+	if !strings.ContainsAny(s, special) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(special, s[i]) >= 0 {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
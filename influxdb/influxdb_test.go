@@ -0,0 +1,84 @@
+package influxdb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFromLineProtocol(t *testing.T) {
+	in := "# cpu load\n" +
+		"cpu,host=a,region=eu usage=0.5,cores=8i,ok=true 1700000000000000000\n" +
+		"\n" +
+		`disk\ io,path=/var\,log free=12,msg="say \"hi\"" ` + "\n"
+	got, err := FromLineProtocol([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`(measurement,tags,fields,timestamp:`,
+		`"cpu",{host="a";region="eu"},{cores=8;ok=true;usage=0.5},1700000000000000000;`,
+		`"disk io",{path="/var,log"},{free=12.0;msg="say \"hi\""},\0;`,
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("missing %s in\n%s", want, got)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	in := "cpu,host=a,region=eu cores=8i,ok=true,usage=0.5 1700000000000000000\n" +
+		"disk\\ io,path=/var\\,log free=12.0,msg=\"say \\\"hi\\\" \\\\o/\"\n" +
+		"mem big=18446744073709551615u,small=3i,x=1e+21\n"
+	doc, err := FromLineProtocol([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ToLineProtocol(doc, Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != in {
+		t.Errorf("round trip through %s\ngot  %s\nwant %s", doc, out, in)
+	}
+}
+
+func TestToLineProtocol(t *testing.T) {
+	in := `{series=(measurement,tags,fields:
+		"weather",{city="Oslo";empty=""},{temp=-3.5;n=4;note=\0};
+		"weather",,{temp=1};
+	)}`
+	got, err := ToLineProtocol([]byte(in), "series")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "weather,city=Oslo n=4i,temp=-3.5\nweather temp=1i\n"
+	if string(got) != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, in := range []string{
+		"cpu",
+		",host=a x=1",
+		"cpu,host= x=1",
+		"cpu x=",
+		"cpu x=1,y",
+		`cpu x="open`,
+		"cpu x=12q",
+		"cpu x=1 noon",
+	} {
+		if _, err := FromLineProtocol([]byte(in)); err == nil || !strings.HasPrefix(err.Error(), "influxdb: line 1: ") {
+			t.Errorf("FromLineProtocol(%q): got error %v, want a line error", in, err)
+		}
+	}
+	for _, in := range []string{
+		`{other=1}`,
+		`{points=(measurement,fields:"cpu",{};)}`,
+		`{points=(measurement,fields:"cpu",{x=[1]};)}`,
+	} {
+		if _, err := ToLineProtocol([]byte(in), Key); err == nil {
+			t.Errorf("ToLineProtocol(%q): expected an error", in)
+		}
+	}
+}
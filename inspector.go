@@ -0,0 +1,214 @@
+package god
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+// An Inspector reads single top-level values of a GOD document without
+// decoding the rest of it, for the common case of wanting two or three
+// keys from a large document. The first lookup scans the root object once,
+// skipping over values, and indexes where each key's value lies in the
+// source; a value is decoded the first time it is asked for and remembered.
+//
+// An Inspector refers to the data it was created with rather than copying
+// it, so the caller must not modify data afterwards. Its methods may be
+// called from several goroutines at once.
+//
+// As with Unmarshal, the last of duplicate keys wins. @include directives
+// are not followed, so keys of included documents are not seen.
+type Inspector struct {
+	data []byte
+
+	once    sync.Once
+	entries map[string]*inspectorEntry
+	err     error
+}
+
+// inspectorEntry is the raw text of a top-level value and, once asked for,
+// its decoded form.
+type inspectorEntry struct {
+	raw []byte
+
+	once sync.Once
+	val  interface{}
+	err  error
+}
+
+// NewInspector returns an Inspector over the GOD document in data. The
+// document is not read until the first lookup.
+func NewInspector(data []byte) *Inspector {
+	return &Inspector{data: data}
+}
+
+// Err returns the syntax error, if any, that stopped the index of the
+// document from being built. Keys after the error are not found.
+func (in *Inspector) Err() error {
+	in.once.Do(in.index)
+	return in.err
+}
+
+// HasKey reports whether the document has the top-level key key, even if
+// its value is grounded.
+func (in *Inspector) HasKey(key string) bool {
+	_, ok := in.entry(key)
+	return ok
+}
+
+// RawAt returns the text of the value of key, as a RawMessage would hold
+// it. The result shares memory with the document and must not be
+// modified. A value left empty, as in "key=;", is returned as an empty
+// slice.
+func (in *Inspector) RawAt(key string) ([]byte, bool) {
+	e, ok := in.entry(key)
+	if !ok {
+		return nil, false
+	}
+	return e.raw, true
+}
+
+// StringAt returns the value of key if it is a quoted string. A grounded
+// value is "".
+func (in *Inspector) StringAt(key string) (string, bool) {
+	v, ok := in.value(key)
+	if v == nil {
+		return "", ok
+	}
+	s, ok := v.(string)
+	return s, ok
+}
+
+// IntAt returns the value of key if it is an integer that fits an int64.
+// A grounded value is 0.
+func (in *Inspector) IntAt(key string) (int64, bool) {
+	v, ok := in.value(key)
+	if v == nil {
+		return 0, ok
+	}
+	i, ok := v.(int64)
+	return i, ok
+}
+
+// FloatAt returns the value of key if it is a number, converting integers
+// to float64. A grounded value is 0.
+func (in *Inspector) FloatAt(key string) (float64, bool) {
+	v, ok := in.value(key)
+	switch v := v.(type) {
+	case nil:
+		return 0, ok
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func (in *Inspector) entry(key string) (*inspectorEntry, bool) {
+	in.once.Do(in.index)
+	e, ok := in.entries[key]
+	return e, ok
+}
+
+// value returns the decoded value of key, which is nil if the value is
+// grounded, and whether key is present and its value valid.
+func (in *Inspector) value(key string) (interface{}, bool) {
+	e, ok := in.entry(key)
+	if !ok {
+		return nil, false
+	}
+	e.once.Do(func() {
+		if len(e.raw) == 0 || string(e.raw) == `\0` {
+			return
+		}
+		p := UnmarshalOptions{}.newParser(e.raw)
+		e.val, e.err = parseGenericValue(p)
+	})
+	if e.err != nil {
+		return nil, false
+	}
+	return e.val, true
+}
+
+// index records where the value of each top-level key lies in in.data.
+// Bare keys are made into strings without copying, since the source is
+// neither copied nor modified; only quoted keys, which may have escapes,
+// are allocated.
+func (in *Inspector) index() {
+	in.entries = make(map[string]*inspectorEntry)
+	p := UnmarshalOptions{}.newParser(in.data)
+	p.skipSpaces()
+	if p.peek() != '{' {
+		in.err = fmt.Errorf("root must be an object '{...}', got '%c'", p.peek())
+		return
+	}
+	p.next()
+	p.depth++
+	for p.skipSpaces(); !p.eof() && p.peek() != '}'; p.skipSpaces() {
+		start := p.pos
+		key, quoted, err := readKeyInPlace(p)
+		if err != nil {
+			in.err = err
+			return
+		}
+		p.skipSpaces()
+		if p.peek() != '=' {
+			if err := p.nakedInPairs(key, quoted, start); err != nil {
+				in.err = err
+				return
+			}
+			in.err = fmt.Errorf("expected '=' after key '%s'", key)
+			return
+		}
+		p.next() // consume '='
+		if !quoted && key == includeDirective {
+			err = skipValue(p)
+		} else {
+			var raw []byte
+			raw, err = p.readRawSpan()
+			in.entries[key] = &inspectorEntry{raw: raw}
+		}
+		if err != nil {
+			in.err = err
+			return
+		}
+		p.skipSpaces()
+		if p.peek() == ';' {
+			p.next()
+		}
+	}
+	if p.peek() != '}' {
+		in.err = errors.New("expected '}' at end of root object")
+	}
+}
+
+// readKeyInPlace reads a key like parser.readKey, except that a bare key
+// is returned as a string sharing memory with p.src.
+func readKeyInPlace(p *parser) (key string, quoted bool, err error) {
+	p.skipSpaces()
+	if p.peek() == '"' {
+		key, err = parseString(p)
+		return key, true, err
+	}
+	tok, err := p.scanBareToken()
+	if err != nil || len(tok) == 0 {
+		return "", false, err
+	}
+	return unsafe.String(unsafe.SliceData(tok), len(tok)), false, nil
+}
+
+// readRawSpan skips one value, including any annotation, and returns its
+// text in p.src. An absent value is empty.
+func (p *parser) readRawSpan() ([]byte, error) {
+	p.skipSpaces()
+	start := p.pos
+	if p.eof() || p.peek() == ';' || p.peek() == '}' {
+		return p.src[start:start], nil
+	}
+	if err := skipValue(p); err != nil {
+		return nil, err
+	}
+	return p.src[start:p.pos], nil
+}
@@ -0,0 +1,128 @@
+package god
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestInspector(t *testing.T) {
+	doc := []byte(`{
+		name = "Alice \"A\"";
+		age = 30;
+		score = 4.5;
+		big = 1e3;
+		tags = ["x", "y; z"];
+		people = (name,age:"Bob",25;"Carol",41;);
+		nested = {a={b="}"}};
+		"quoted key" = "q";
+		empty = ;
+		none = \0;
+		// a comment
+		age = 31
+	}`)
+	in := NewInspector(doc)
+	if err := in.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if s, ok := in.StringAt("name"); !ok || s != `Alice "A"` {
+		t.Errorf("StringAt(name) = %q, %v", s, ok)
+	}
+	if i, ok := in.IntAt("age"); !ok || i != 31 {
+		t.Errorf("IntAt(age) = %d, %v; want the last duplicate", i, ok)
+	}
+	if f, ok := in.FloatAt("score"); !ok || f != 4.5 {
+		t.Errorf("FloatAt(score) = %v, %v", f, ok)
+	}
+	if f, ok := in.FloatAt("age"); !ok || f != 31 {
+		t.Errorf("FloatAt(age) = %v, %v", f, ok)
+	}
+	if _, ok := in.IntAt("big"); ok {
+		t.Error("IntAt(big) should fail for a float")
+	}
+	if _, ok := in.IntAt("name"); ok {
+		t.Error("IntAt(name) should fail for a string")
+	}
+	if s, ok := in.StringAt("quoted key"); !ok || s != "q" {
+		t.Errorf("StringAt(quoted key) = %q, %v", s, ok)
+	}
+	if s, ok := in.StringAt("none"); !ok || s != "" {
+		t.Errorf("StringAt(none) = %q, %v", s, ok)
+	}
+	if i, ok := in.IntAt("empty"); !ok || i != 0 {
+		t.Errorf("IntAt(empty) = %d, %v", i, ok)
+	}
+
+	for key, want := range map[string]string{
+		"tags":   `["x", "y; z"]`,
+		"people": `(name,age:"Bob",25;"Carol",41;)`,
+		"nested": `{a={b="}"}}`,
+		"none":   `\0`,
+		"empty":  ``,
+	} {
+		if raw, ok := in.RawAt(key); !ok || string(raw) != want {
+			t.Errorf("RawAt(%s) = %q, %v; want %q", key, raw, ok, want)
+		}
+	}
+	var people []map[string]interface{}
+	raw, _ := in.RawAt("people")
+	if err := UnmarshalValue(raw, &people); err != nil || len(people) != 2 {
+		t.Errorf("RawAt(people) decodes to %v, %v", people, err)
+	}
+
+	if !in.HasKey("tags") || !in.HasKey("empty") || in.HasKey("missing") {
+		t.Error("HasKey reports wrong presence")
+	}
+	if _, ok := in.StringAt("missing"); ok {
+		t.Error("StringAt(missing) should fail")
+	}
+}
+
+func TestInspectorDoesNotCopy(t *testing.T) {
+	doc := []byte(`{blob="abcdef"}`)
+	raw, ok := NewInspector(doc).RawAt("blob")
+	if !ok || &raw[0] != &doc[strings.IndexByte(string(doc), '"')] {
+		t.Error("RawAt should return a slice of the document")
+	}
+}
+
+func TestInspectorInvalid(t *testing.T) {
+	in := NewInspector([]byte(`{a=1;b "x";c=3}`))
+	if in.Err() == nil {
+		t.Error("expected a syntax error")
+	}
+	if i, ok := in.IntAt("a"); !ok || i != 1 {
+		t.Errorf("keys before the error should be found, got %d, %v", i, ok)
+	}
+	if in.HasKey("c") {
+		t.Error("keys after the error should not be found")
+	}
+	if NewInspector([]byte(`[1,2]`)).Err() == nil {
+		t.Error("expected an error for a non-object root")
+	}
+	if _, ok := NewInspector([]byte(`{a=[1}`)).IntAt("a"); ok {
+		t.Error("an invalid value should not be found")
+	}
+}
+
+func TestInspectorConcurrent(t *testing.T) {
+	in := NewInspector([]byte(`{a=1;b="two";c=3.5}`))
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, ok := in.IntAt("a"); !ok || v != 1 {
+				t.Errorf("IntAt(a) = %d, %v", v, ok)
+			}
+			if v, ok := in.StringAt("b"); !ok || v != "two" {
+				t.Errorf("StringAt(b) = %q, %v", v, ok)
+			}
+			if v, ok := in.FloatAt("c"); !ok || v != 3.5 {
+				t.Errorf("FloatAt(c) = %v, %v", v, ok)
+			}
+		}()
+	}
+	wg.Wait()
+}
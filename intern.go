@@ -0,0 +1,144 @@
+package god
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Table header interning is an opt-in stream mode for Encoder and Decoder.
+// The first table with a given header assigns it an id,
+//
+//	(#1=name,age,addr:"Alice",30,"NYC";)
+//
+// and later tables with the same header, in the same or a later document of
+// the stream, refer back to it:
+//
+//	(#1:"Bob",25,"LA";)
+//
+// Ids are scoped to one stream, so documents using them are not valid on
+// their own and Unmarshal rejects them.
+
+// SetInternHeaders enables or disables table header interning for the
+// documents subsequently written by e. The Decoder reading the stream must
+// enable it too.
+func (e *Encoder) SetInternHeaders(on bool) {
+	if on {
+		if e.state.headers == nil {
+			e.state.headers = make(map[string]int)
+		}
+	} else {
+		e.state.headers = nil
+	}
+}
+
+// writeHeaderRef writes the interned id for headers. It reports true when the
+// header was already sent and nothing else needs to be written; otherwise a
+// new id is defined and the caller writes the header names after it.
+func (b *encodeState) writeHeaderRef(headers []string) bool {
+	if b.headers == nil {
+		return false
+	}
+	key := strings.Join(headers, ",")
+	if id, ok := b.headers[key]; ok {
+		b.WriteString("#" + strconv.Itoa(id))
+		return true
+	}
+	id := len(b.headers) + 1
+	b.headers[key] = id
+	b.WriteString("#" + strconv.Itoa(id) + "=")
+	return false
+}
+
+// forgetHeaders drops the ids defined after the first mark of them, which
+// len(b.headers) gives, by output that failed to encode and was never
+// written, so that later tables define them again.
+func (b *encodeState) forgetHeaders(mark int) {
+	for key, id := range b.headers {
+		if id > mark {
+			delete(b.headers, key)
+		}
+	}
+}
+
+// SetInternHeaders enables or disables decoding of interned table headers
+// written by an Encoder with SetInternHeaders(true).
+func (d *Decoder) SetInternHeaders(on bool) {
+	if on {
+		if d.headers == nil {
+			d.headers = make(map[int][]string)
+		}
+	} else {
+		d.headers = nil
+	}
+}
+
+// readHeaderID consumes an interned header id at the start of a table. For
+// a definition (#n=) it returns the id and leaves the header names to the
+// caller; for a reference (#n:) it returns the stored header with isRef set.
+// id is 0 when the table has a plain header.
+func (p *parser) readHeaderID() (id int, headers []string, isRef bool, err error) {
+	p.skipSpaces()
+	if p.peek() != '#' {
+		return 0, nil, false, nil
+	}
+	if p.headers == nil {
+		return 0, nil, false, fmt.Errorf("interned table header at pos %d is only valid in a Decoder stream with SetInternHeaders(true)", p.pos)
+	}
+	p.next() // consume '#'
+	start := p.pos
+	for !p.eof() && p.peek() >= '0' && p.peek() <= '9' {
+		p.next()
+	}
+	id, err = strconv.Atoi(string(p.src[start:p.pos]))
+	if err != nil || id <= 0 {
+		return 0, nil, false, fmt.Errorf("invalid table header id at pos %d", start)
+	}
+	p.skipSpaces()
+	switch p.next() {
+	case '=':
+		return id, nil, false, nil
+	case ':':
+		headers, ok := p.headers[id]
+		if !ok {
+			return 0, nil, false, fmt.Errorf("reference to undefined table header #%d", id)
+		}
+		return id, headers, true, nil
+	}
+	return 0, nil, false, fmt.Errorf("expected '=' or ':' after table header #%d", id)
+}
+
+// String interning, enabled by UnmarshalOptions.InternStrings, makes the
+// keys and short strings of one decode share their memory. Only strings up
+// to maxInternLen bytes are interned and the table stops growing at
+// maxInterned entries, so documents full of distinct values cost no more
+// than a bounded map.
+const (
+	maxInternLen = 64
+	maxInterned  = 1 << 14
+)
+
+// intern returns b as a string, reusing an earlier copy of the same text
+// when interning is enabled.
+func (p *parser) intern(b []byte) string {
+	if p.interned == nil || len(b) > maxInternLen {
+		return string(b)
+	}
+	if v, ok := p.interned[string(b)]; ok {
+		return v.(string)
+	}
+	s := string(b)
+	if len(p.interned) < maxInterned {
+		p.interned[s] = s
+	}
+	return s
+}
+
+// internValue returns s as an interface{}. Interned strings are stored
+// boxed, so that the generic values holding them share the box too.
+func (p *parser) internValue(s string) interface{} {
+	if v, ok := p.interned[s]; ok {
+		return v
+	}
+	return s
+}
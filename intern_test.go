@@ -0,0 +1,197 @@
+package god
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+type telemetryBatch struct {
+	Host    string   `god:"host"`
+	Samples []Person `god:"samples"`
+	Owners  []Person `god:"owners"`
+}
+
+func telemetryBatches(n int) []telemetryBatch {
+	batches := make([]telemetryBatch, n)
+	for i := range batches {
+		batches[i] = telemetryBatch{
+			Host:    fmt.Sprintf("host-%d", i%7),
+			Samples: benchPeople(3),
+			Owners:  []Person{{Name: "ops", Age: i}},
+		}
+	}
+	return batches
+}
+
+func encodeStream(t *testing.T, batches []telemetryBatch, intern bool) []byte {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetInternHeaders(intern)
+	for _, b := range batches {
+		if err := enc.Encode(b); err != nil {
+			t.Fatalf("Encode error: %v", err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestInternHeadersStream(t *testing.T) {
+	batches := telemetryBatches(1000)
+	plain := encodeStream(t, batches, false)
+	interned := encodeStream(t, batches, true)
+
+	saved := len(plain) - len(interned)
+	t.Logf("plain=%d interned=%d saved=%d bytes (%.1f%%)", len(plain), len(interned), saved, 100*float64(saved)/float64(len(plain)))
+	if saved < 1000*len("name,age,addr") {
+		t.Errorf("expected interning to save at least one header per message, saved %d bytes", saved)
+	}
+	if !strings.HasPrefix(string(interned), `{host="host-0";samples=(#1=name,age,addr:`) {
+		t.Errorf("unexpected first document: %.80s", interned)
+	}
+
+	dec := NewDecoder(bytes.NewReader(interned))
+	dec.SetInternHeaders(true)
+	for i, want := range batches {
+		var got telemetryBatch
+		if err := dec.Decode(&got); err != nil {
+			t.Fatalf("document %d: Decode error: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("document %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+	var extra telemetryBatch
+	if err := dec.Decode(&extra); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestInternHeadersRejectedByUnmarshal(t *testing.T) {
+	interned := encodeStream(t, telemetryBatches(2), true)
+	docs := strings.SplitAfter(strings.TrimSpace(string(interned)), "\n")
+
+	var b telemetryBatch
+	for _, doc := range docs {
+		err := Unmarshal([]byte(doc), &b)
+		if err == nil || !strings.Contains(err.Error(), "SetInternHeaders") {
+			t.Errorf("expected Unmarshal to reject interned header, got %v", err)
+		}
+	}
+
+	dec := NewDecoder(strings.NewReader(docs[1]))
+	dec.SetInternHeaders(true)
+	if err := dec.Decode(&b); err == nil || !strings.Contains(err.Error(), "undefined table header #1") {
+		t.Errorf("expected undefined header error, got %v", err)
+	}
+}
+
+func TestInternHeadersAfterError(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetInternHeaders(true)
+	bad := struct {
+		Samples []Person `god:"samples"`
+		Bad     func()   `god:"bad"`
+	}{Samples: benchPeople(1), Bad: func() {}}
+	if err := enc.Encode(bad); err == nil {
+		t.Fatal("expected an error encoding a func")
+	}
+	batches := telemetryBatches(2)
+	for _, b := range batches {
+		if err := enc.Encode(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dec := NewDecoder(&buf)
+	dec.SetInternHeaders(true)
+	for i, want := range batches {
+		var got telemetryBatch
+		if err := dec.Decode(&got); err != nil {
+			t.Fatalf("document %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("document %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+}
+
+func TestDecoderStream(t *testing.T) {
+	stream := "{name=\"A\";addr=\"\"\"multi\nline }\"\"\"}{name=\"B\"}\n\n  {name=\"{C}\";age=3}\n"
+	dec := NewDecoder(strings.NewReader(stream))
+	want := []Person{{Name: "A", Address: "multi\nline }"}, {Name: "B"}, {Name: "{C}", Age: 3}}
+	for i, w := range want {
+		var p Person
+		if err := dec.Decode(&p); err != nil {
+			t.Fatalf("document %d: %v", i, err)
+		}
+		if p != w {
+			t.Errorf("document %d: expected %+v, got %+v", i, w, p)
+		}
+	}
+	var p Person
+	if err := dec.Decode(&p); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+
+	dec = NewDecoder(strings.NewReader(`{name="A"`))
+	if err := dec.Decode(&p); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestInternStrings(t *testing.T) {
+	long := strings.Repeat("x", maxInternLen+1)
+	doc := `{rows=(status,note:"ok","` + long + `";"ok","` + long + `";);status="ok";m={status="ok"}}`
+
+	for _, intern := range []bool{false, true} {
+		var v map[string]interface{}
+		if err := (UnmarshalOptions{InternStrings: intern}).Unmarshal([]byte(doc), &v); err != nil {
+			t.Fatal(err)
+		}
+		rows := v["rows"].([]map[string]interface{})
+		a, b := rows[0]["status"].(string), rows[1]["status"].(string)
+		c, d := rows[0]["note"].(string), rows[1]["note"].(string)
+		if a != "ok" || c != long || d != long || v["status"] != "ok" {
+			t.Fatalf("intern=%v: decoded %v", intern, v)
+		}
+		shared := unsafe.StringData(a) == unsafe.StringData(b) &&
+			unsafe.StringData(a) == unsafe.StringData(v["status"].(string))
+		if shared != intern {
+			t.Errorf("intern=%v: short strings shared = %v", intern, shared)
+		}
+		if unsafe.StringData(c) == unsafe.StringData(d) {
+			t.Errorf("intern=%v: strings over the size cap should not be interned", intern)
+		}
+	}
+
+	// Keys are interned too, including for typed maps.
+	var m []map[string]int
+	if err := UnmarshalWith([]byte(`{[{count=1},{count=2}]}`), &m, InternStrings()); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, row := range m {
+		for k := range row {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) != 2 || unsafe.StringData(keys[0]) != unsafe.StringData(keys[1]) {
+		t.Errorf("expected the key to be shared, got %q", keys)
+	}
+}
+
+func TestInternStringsCap(t *testing.T) {
+	p := UnmarshalOptions{InternStrings: true}.newParser(nil)
+	for i := 0; i < maxInterned+10; i++ {
+		p.intern([]byte(fmt.Sprint(i)))
+	}
+	if len(p.interned) != maxInterned {
+		t.Errorf("interned %d strings, want the cap of %d", len(p.interned), maxInterned)
+	}
+}
@@ -0,0 +1,312 @@
+// Package roundtrip generates random values that GOD can represent and checks
+// that they survive a round trip through the codec.
+//
+// Values are built from random types: scalars at their boundaries, strings
+// full of delimiters and escapes, lists, fixed-size arrays, string-keyed
+// maps, structs assembled with reflect.StructOf and slices of flat structs,
+// which GOD writes as tables.
+//
+// Check demands that encoding be a fixed point byte for byte; the public
+// godtest package builds on Twice to compare round trips by meaning.
+package roundtrip
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vinayakgupta29/god"
+)
+
+// A Generator produces random GOD-representable types and values.
+type Generator struct {
+	Rand *rand.Rand
+
+	// MaxDepth bounds how deeply composite types nest; MaxLen bounds the
+	// number of elements, keys and fields of each one.
+	MaxDepth int
+	MaxLen   int
+}
+
+// New returns a Generator drawing from r with modest size limits.
+func New(r *rand.Rand) *Generator {
+	return &Generator{Rand: r, MaxDepth: 3, MaxLen: 5}
+}
+
+var scalarTypes = []reflect.Type{
+	reflect.TypeOf(""),
+	reflect.TypeOf(false),
+	reflect.TypeOf(int(0)),
+	reflect.TypeOf(int8(0)),
+	reflect.TypeOf(int16(0)),
+	reflect.TypeOf(int32(0)),
+	reflect.TypeOf(int64(0)),
+	reflect.TypeOf(uint(0)),
+	reflect.TypeOf(uint8(0)),
+	reflect.TypeOf(uint16(0)),
+	reflect.TypeOf(uint32(0)),
+	reflect.TypeOf(uint64(0)),
+	reflect.TypeOf(float32(0)),
+	reflect.TypeOf(float64(0)),
+}
+
+// nasty holds fragments that exercise quoting and escaping.
+var nasty = []string{
+	`"`, `\`, "\n", "\r", "\t", ";", "=", "{", "}", "(", ")", "[", "]", ",", ":",
+	`\0`, `"""`, " ", "@include", "#1", "\x00", "\x7f", "\xff", "é", "世界", "😀",
+	" ", "true", "-1e9", "a", "key",
+}
+
+// Type returns a random type of at most MaxDepth levels of nesting. It is
+// never a pointer, since Marshal encodes what a root pointer points to.
+func (g *Generator) Type() reflect.Type {
+	t := g.typ(g.MaxDepth)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func (g *Generator) typ(depth int) reflect.Type {
+	if depth <= 0 || g.Rand.Intn(3) == 0 {
+		return g.scalarType()
+	}
+	switch g.Rand.Intn(6) {
+	case 0:
+		return reflect.SliceOf(g.elemType(depth - 1))
+	case 1:
+		return reflect.ArrayOf(1+g.Rand.Intn(g.MaxLen), g.elemType(depth-1))
+	case 2:
+		return reflect.MapOf(g.keyType(), g.typ(depth-1))
+	case 3:
+		return g.structType(depth - 1)
+	case 4:
+		// A slice of flat structs is encoded as a table.
+		return reflect.SliceOf(g.structType(0))
+	default:
+		return reflect.PtrTo(g.scalarType())
+	}
+}
+
+var keyTypes = []reflect.Type{
+	reflect.TypeOf(""),
+	reflect.TypeOf(int(0)),
+	reflect.TypeOf(int8(0)),
+	reflect.TypeOf(uint64(0)),
+}
+
+// keyType returns a map key type, usually string.
+func (g *Generator) keyType() reflect.Type {
+	if g.Rand.Intn(2) == 0 {
+		return keyTypes[0]
+	}
+	return keyTypes[g.Rand.Intn(len(keyTypes))]
+}
+
+// elemType returns a list element type. Lists of structs are tables, whose
+// cells cannot hold nested values, so such structs are kept flat.
+func (g *Generator) elemType(depth int) reflect.Type {
+	t := g.typ(depth)
+	if t.Kind() == reflect.Struct {
+		return g.structType(0)
+	}
+	return t
+}
+
+func (g *Generator) scalarType() reflect.Type {
+	return scalarTypes[g.Rand.Intn(len(scalarTypes))]
+}
+
+// structType builds a struct with 1 to MaxLen exported fields. At depth 0
+// every field is a scalar or a pointer to one, which is what table rows
+// require.
+func (g *Generator) structType(depth int) reflect.Type {
+	fields := make([]reflect.StructField, 1+g.Rand.Intn(g.MaxLen))
+	for i := range fields {
+		fields[i] = reflect.StructField{Name: fmt.Sprintf("F%d", i), Type: g.typ(depth)}
+		if depth == 0 {
+			fields[i].Type = g.scalarType()
+			if g.Rand.Intn(4) == 0 {
+				fields[i].Type = reflect.PtrTo(fields[i].Type)
+			}
+		}
+	}
+	return reflect.StructOf(fields)
+}
+
+// Value returns a random value of type t.
+func (g *Generator) Value(t reflect.Type) reflect.Value {
+	v := reflect.New(t).Elem()
+	// Leave some values zero, since grounding treats them specially.
+	if g.Rand.Intn(8) == 0 {
+		return v
+	}
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(g.String())
+	case reflect.Bool:
+		v.SetBool(g.Rand.Intn(2) == 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(g.int(t.Bits()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(g.uint(t.Bits()))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(g.float(t.Bits()))
+	case reflect.Ptr:
+		v.Set(reflect.New(t.Elem()))
+		v.Elem().Set(g.Value(t.Elem()))
+	case reflect.Slice:
+		n := g.Rand.Intn(g.MaxLen + 1)
+		v.Set(reflect.MakeSlice(t, n, n))
+		for i := 0; i < n; i++ {
+			v.Index(i).Set(g.Value(t.Elem()))
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			v.Index(i).Set(g.Value(t.Elem()))
+		}
+	case reflect.Map:
+		v.Set(reflect.MakeMap(t))
+		for i := g.Rand.Intn(g.MaxLen + 1); i > 0; i-- {
+			key := g.Value(t.Key())
+			// Empty keys are not representable yet.
+			if key.Kind() == reflect.String && key.String() == "" {
+				key.SetString("k")
+			}
+			v.SetMapIndex(key, g.Value(t.Elem()))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			v.Field(i).Set(g.Value(t.Field(i).Type))
+		}
+	default:
+		panic("roundtrip: cannot generate " + t.String())
+	}
+	return v
+}
+
+// Any returns a random value of a random type.
+func (g *Generator) Any() interface{} {
+	return g.Value(g.Type()).Interface()
+}
+
+// String returns a random string assembled from printable text and nasty
+// fragments.
+func (g *Generator) String() string {
+	var sb strings.Builder
+	for i := g.Rand.Intn(g.MaxLen + 1); i > 0; i-- {
+		if g.Rand.Intn(2) == 0 {
+			sb.WriteString(nasty[g.Rand.Intn(len(nasty))])
+		} else {
+			sb.WriteByte(byte('a' + g.Rand.Intn(26)))
+		}
+	}
+	return sb.String()
+}
+
+func (g *Generator) int(bits int) int64 {
+	max := int64(1)<<(bits-1) - 1
+	switch g.Rand.Intn(4) {
+	case 0:
+		return max
+	case 1:
+		return -max - 1
+	default:
+		return g.Rand.Int63n(201) - 100
+	}
+}
+
+func (g *Generator) uint(bits int) uint64 {
+	switch g.Rand.Intn(3) {
+	case 0:
+		return uint64(1)<<(bits-1)*2 - 1
+	default:
+		return uint64(g.Rand.Intn(1000))
+	}
+}
+
+func (g *Generator) float(bits int) float64 {
+	boundaries := []float64{math.MaxFloat64, math.SmallestNonzeroFloat64, -math.MaxFloat64, 1e21, 1e-7, 0.1, -2.5, 1 << 53, 1 << 63}
+	if bits == 32 {
+		boundaries = []float64{math.MaxFloat32, math.SmallestNonzeroFloat32, -math.MaxFloat32, 0.1, -2.5, 1 << 24}
+	}
+	if g.Rand.Intn(2) == 0 {
+		return boundaries[g.Rand.Intn(len(boundaries))]
+	}
+	f := g.Rand.NormFloat64() * 1000
+	if bits == 32 {
+		f = float64(float32(f))
+	}
+	return f
+}
+
+// Value wraps an arbitrary generated value so that it can be produced by
+// testing/quick, which calls its Generate method.
+type Value struct {
+	V interface{}
+}
+
+// Generate implements quick.Generator. The size hint bounds element counts.
+func (Value) Generate(r *rand.Rand, size int) reflect.Value {
+	g := New(r)
+	if size > 0 && size < g.MaxLen {
+		g.MaxLen = size
+	}
+	return reflect.ValueOf(Value{V: g.Any()})
+}
+
+// Check marshals v, decodes the result into a new value of v's type and
+// marshals that again, in both compact and beautified form. It returns an
+// error unless both encodings are byte-for-byte equal, i.e. unless encoding
+// is a fixed point of the round trip.
+func Check(v interface{}) error {
+	marshalers := []struct {
+		name string
+		fn   func(interface{}) ([]byte, error)
+	}{
+		{"Marshal", god.Marshal},
+		{"MarshalBeautify", god.MarshalBeautify},
+	}
+	for _, m := range marshalers {
+		first, second, err := Twice(v, m.name, m.fn)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(first, second) {
+			return fmt.Errorf("%s round trip of %T is not a fixed point\nvalue:  %#v\nfirst:  %s\nsecond: %s", m.name, v, v, first, second)
+		}
+	}
+	return nil
+}
+
+// Twice marshals v with marshal, named name in errors, decodes the result
+// into a new value of v's type and marshals that again. It returns both
+// encodings. The godtest package compares them by meaning.
+func Twice(v interface{}, name string, marshal func(interface{}) ([]byte, error)) (first, second []byte, err error) {
+	first, err = marshal(v)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s(%T): %v", name, v, err)
+	}
+	out := reflect.New(reflect.TypeOf(v))
+	if err := god.Unmarshal(first, out.Interface()); err != nil {
+		return nil, nil, fmt.Errorf("Unmarshal into %T: %v\nencoded: %s", v, err, first)
+	}
+	second, err = marshal(out.Elem().Interface())
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s of decoded %T: %v", name, v, err)
+	}
+	return first, second, nil
+}
+
+// RoundTrip fails t if v does not survive a Marshal, Unmarshal, Marshal
+// round trip unchanged. See Check.
+func RoundTrip(t testing.TB, v interface{}) {
+	t.Helper()
+	if err := Check(v); err != nil {
+		t.Error(err)
+	}
+}
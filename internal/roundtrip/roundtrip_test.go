@@ -0,0 +1,62 @@
+package roundtrip
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"testing/quick"
+)
+
+func TestRoundTripBoundaries(t *testing.T) {
+	cases := []interface{}{
+		int64(math.MaxInt64),
+		int64(math.MinInt64),
+		uint64(math.MaxUint64),
+		math.MaxFloat64,
+		math.SmallestNonzeroFloat64,
+		float32(0.1),
+		"line1\nends in a quote\"",
+		"contains \"\"\" and\nnewline",
+		"\x00\x7f\xff ",
+		[]string{""},
+		[]int{1, 0},
+		[2]bool{},
+		map[string]int{"b": 2, "a": 1, "a b": 0, "@include": 3},
+		map[string]interface{}{"note": "multi\nline"},
+	}
+	for _, v := range cases {
+		RoundTrip(t, v)
+	}
+}
+
+func TestRoundTripGenerated(t *testing.T) {
+	g := New(rand.New(rand.NewSource(1)))
+	for i := 0; i < 2000; i++ {
+		v := g.Any()
+		if err := Check(v); err != nil {
+			t.Fatalf("value %d: %v", i, err)
+		}
+	}
+}
+
+func TestQuick(t *testing.T) {
+	f := func(v Value) bool {
+		if err := Check(v.V); err != nil {
+			t.Log(err)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 500}); err != nil {
+		t.Error(err)
+	}
+}
+
+func FuzzRoundTrip(f *testing.F) {
+	for seed := int64(0); seed < 16; seed++ {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, seed int64) {
+		RoundTrip(t, New(rand.New(rand.NewSource(seed))).Any())
+	})
+}
@@ -0,0 +1,131 @@
+package god
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// A JSONRawMode says how values of type json.RawMessage, which hold JSON
+// text, are encoded and decoded. See WithJSONRawMessages.
+type JSONRawMode int
+
+const (
+	// JSONRawBytes treats a json.RawMessage as any other []byte, a list
+	// of byte values. It is the default.
+	JSONRawBytes JSONRawMode = iota
+
+	// JSONRawConvert writes the JSON as the equivalent GOD value, so that
+	// {"a":[1,2]} is written as {a=[1,2]}. Decoding converts the GOD value
+	// back to compact JSON with sorted keys; a GOD string becomes a JSON
+	// string literal. As everywhere in GOD, zero values such as false, 0
+	// and null are written empty and so come back as "".
+	JSONRawConvert
+
+	// JSONRawQuote writes the JSON text as a quoted GOD string, and
+	// decodes a string holding valid JSON back into its text.
+	JSONRawQuote
+
+	// JSONRawError makes encoding or decoding a json.RawMessage an error,
+	// for programs that must not pass JSON through unnoticed.
+	JSONRawError
+)
+
+var jsonRawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// errJSONRaw is returned for json.RawMessage values under JSONRawError.
+var errJSONRaw = errors.New("json.RawMessage is not allowed (JSONRawError)")
+
+// encodeJSONRaw writes the JSON text raw as mode says.
+func encodeJSONRaw(b *encodeState, raw []byte, level int, compact bool, mode JSONRawMode) error {
+	if mode == JSONRawError {
+		return errJSONRaw
+	}
+	if !json.Valid(raw) {
+		return fmt.Errorf("invalid JSON in json.RawMessage: %q", raw)
+	}
+	if mode == JSONRawQuote {
+		b.WriteString(b.quote(string(raw)))
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("converting json.RawMessage: %w", err)
+	}
+	if v == nil {
+		b.WriteString(`\0`)
+		return nil
+	}
+	return encodeValue(b, reflect.ValueOf(v), level, compact)
+}
+
+// decodeJSONRaw reads a value into target, a json.RawMessage, as mode
+// says.
+func decodeJSONRaw(p *parser, target reflect.Value, mode JSONRawMode) error {
+	switch mode {
+	case JSONRawError:
+		return errJSONRaw
+	case JSONRawQuote:
+		start := p.pos
+		s, err := parseStringValue(p)
+		if err != nil {
+			return err
+		}
+		if !json.Valid([]byte(s)) {
+			return fmt.Errorf("invalid JSON in string at pos %d", start)
+		}
+		target.SetBytes([]byte(s))
+		return nil
+	}
+
+	useNumber := p.useNumber
+	p.useNumber = true
+	v, err := parseGenericValue(p)
+	p.useNumber = useNumber
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(toJSONValue(v)); err != nil {
+		return fmt.Errorf("converting to json.RawMessage: %w", err)
+	}
+	target.SetBytes(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	return nil
+}
+
+// toJSONValue returns the generically decoded value v with its Numbers
+// replaced by json.Numbers, which encoding/json writes bare.
+func toJSONValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case Number:
+		return json.Number(v)
+	case map[string]interface{}:
+		for k, elem := range v {
+			v[k] = toJSONValue(elem)
+		}
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = toJSONValue(elem)
+		}
+	case []map[string]interface{}:
+		for _, row := range v {
+			toJSONValue(row)
+		}
+	}
+	return v
+}
+
+// decodesJSONRaw reports whether p decodes values of type t, or what t
+// points to, as JSON rather than as a list of bytes.
+func (p *parser) decodesJSONRaw(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return p.jsonRaw != JSONRawBytes && t == jsonRawMessageType
+}
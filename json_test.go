@@ -0,0 +1,90 @@
+package god
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type webhook struct {
+	Name    string
+	Payload json.RawMessage
+}
+
+func TestJSONRawConvert(t *testing.T) {
+	in := webhook{Name: "push", Payload: json.RawMessage(`{"ref":"main","commits":[{"id":1,"tags":["a","b"]}],"forced":false,"by":null}`)}
+	data, err := MarshalWith(in, WithJSONRawMessages(JSONRawConvert))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{name="push";payload={by=;commits=[{id=1;tags=["a","b"]}];forced=;ref="main"}}`
+	if string(data) != want {
+		t.Fatalf("got %s, want %s", data, want)
+	}
+
+	var out webhook
+	if err := UnmarshalWith(data, &out, WithJSONRawMessages(JSONRawConvert)); err != nil {
+		t.Fatal(err)
+	}
+	if !json.Valid(out.Payload) {
+		t.Fatalf("invalid JSON %s", out.Payload)
+	}
+	// Grounded values, null and false alike, decode as "".
+	if want := `{"by":"","commits":[{"id":1,"tags":["a","b"]}],"forced":"","ref":"main"}`; string(out.Payload) != want {
+		t.Errorf("got %s, want %s", out.Payload, want)
+	}
+}
+
+func TestJSONRawConvertScalars(t *testing.T) {
+	tests := []struct{ doc, want string }{
+		{`{payload="x<y"}`, `"x<y"`},
+		{`{payload=12.50}`, `12.50`},
+		{`{payload=true}`, `true`},
+		{`{payload=(a,b:1,"x";)}`, `[{"a":1,"b":"x"}]`},
+	}
+	for _, tt := range tests {
+		var out webhook
+		if err := UnmarshalWith([]byte(tt.doc), &out, WithJSONRawMessages(JSONRawConvert)); err != nil {
+			t.Fatalf("%s: %v", tt.doc, err)
+		}
+		if string(out.Payload) != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.doc, out.Payload, tt.want)
+		}
+	}
+}
+
+func TestJSONRawQuote(t *testing.T) {
+	in := []webhook{{Name: "a", Payload: json.RawMessage(`{"n":[1,{"m":"q\""}]}`)}}
+	data, err := MarshalWith(map[string][]webhook{"hooks": in}, WithJSONRawMessages(JSONRawQuote))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{hooks=(name,payload:"a","{\"n\":[1,{\"m\":\"q\\\"\"}]}";)}`
+	if string(data) != want {
+		t.Fatalf("got %s, want %s", data, want)
+	}
+	var out map[string][]webhook
+	if err := UnmarshalWith(data, &out, WithJSONRawMessages(JSONRawQuote)); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(out["hooks"][0].Payload); got != string(in[0].Payload) {
+		t.Errorf("got %s, want %s", got, in[0].Payload)
+	}
+
+	var bad webhook
+	if err := UnmarshalWith([]byte(`{payload="{oops"}`), &bad, WithJSONRawMessages(JSONRawQuote)); err == nil {
+		t.Error("want error for invalid JSON")
+	}
+}
+
+func TestJSONRawError(t *testing.T) {
+	_, err := MarshalWith(webhook{Payload: json.RawMessage(`1`)}, WithJSONRawMessages(JSONRawError))
+	if !errors.Is(err, errJSONRaw) {
+		t.Errorf("Marshal: got %v", err)
+	}
+	var out webhook
+	err = UnmarshalWith([]byte(`{payload=1}`), &out, WithJSONRawMessages(JSONRawError))
+	if !errors.Is(err, errJSONRaw) {
+		t.Errorf("Unmarshal: got %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package god
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// jsonSchemaAnnotations are keywords that carry no validation meaning and
+// are dropped silently.
+var jsonSchemaAnnotations = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true, "title": true,
+	"description": true, "default": true, "examples": true,
+}
+
+// SchemaFromJSONSchema converts a draft-07 JSON Schema into a Schema. The
+// supported keywords are type, properties, required, items, enum, minimum,
+// maximum, minLength, maxLength and additionalProperties (false makes the
+// object Strict). Other keywords are recorded in the returned schema's
+// Warnings instead of failing the conversion.
+func SchemaFromJSONSchema(data []byte) (*Schema, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	var warnings []string
+	s, err := convertJSONSchema(raw, "", &warnings)
+	if err != nil {
+		return nil, err
+	}
+	s.Warnings = warnings
+	return s, nil
+}
+
+func convertJSONSchema(raw map[string]interface{}, ptr string, warnings *[]string) (*Schema, error) {
+	s := &Schema{}
+	warn := func(format string, args ...interface{}) {
+		*warnings = append(*warnings, ptrOrRoot(ptr)+": "+fmt.Sprintf(format, args...))
+	}
+
+	keys := make([]string, 0, len(raw))
+	for k := range raw {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		val := raw[k]
+		switch k {
+		case "type":
+			switch t := val.(type) {
+			case string:
+				if t == "null" {
+					warn("type %q has no GOD equivalent", t)
+				} else {
+					s.Type = t
+				}
+			case []interface{}:
+				// GOD has no null, so ["string","null"] is just "string".
+				var types []string
+				for _, item := range t {
+					if name, ok := item.(string); ok && name != "null" {
+						types = append(types, name)
+					}
+				}
+				if len(types) == 1 {
+					s.Type = types[0]
+				} else {
+					warn("union type %v is not supported", t)
+				}
+			default:
+				return nil, fmt.Errorf("%s: type must be a string or array", ptrOrRoot(ptr))
+			}
+		case "properties":
+			props, ok := val.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s: properties must be an object", ptrOrRoot(ptr))
+			}
+			s.Properties = make(map[string]*Schema, len(props))
+			for name, sub := range props {
+				subMap, ok := sub.(map[string]interface{})
+				if !ok {
+					warn("property %q schema is not an object", name)
+					continue
+				}
+				prop, err := convertJSONSchema(subMap, ptr+"/properties/"+name, warnings)
+				if err != nil {
+					return nil, err
+				}
+				s.Properties[name] = prop
+			}
+		case "required":
+			list, ok := val.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s: required must be an array", ptrOrRoot(ptr))
+			}
+			for _, item := range list {
+				if name, ok := item.(string); ok {
+					s.Required = append(s.Required, name)
+				}
+			}
+		case "items":
+			sub, ok := val.(map[string]interface{})
+			if !ok {
+				warn("tuple-form items is not supported")
+				continue
+			}
+			items, err := convertJSONSchema(sub, ptr+"/items", warnings)
+			if err != nil {
+				return nil, err
+			}
+			s.Items = items
+		case "enum":
+			list, ok := val.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s: enum must be an array", ptrOrRoot(ptr))
+			}
+			s.Enum = list
+		case "minimum", "maximum":
+			f, ok := val.(float64)
+			if !ok {
+				return nil, fmt.Errorf("%s: %s must be a number", ptrOrRoot(ptr), k)
+			}
+			if k == "minimum" {
+				s.Minimum = &f
+			} else {
+				s.Maximum = &f
+			}
+		case "minLength", "maxLength":
+			f, ok := val.(float64)
+			if !ok || f < 0 || f != float64(int(f)) {
+				return nil, fmt.Errorf("%s: %s must be a non-negative integer", ptrOrRoot(ptr), k)
+			}
+			n := int(f)
+			if k == "minLength" {
+				s.MinLength = &n
+			} else {
+				s.MaxLength = &n
+			}
+		case "additionalProperties":
+			switch ap := val.(type) {
+			case bool:
+				s.Strict = !ap
+			default:
+				warn("additionalProperties schema is not supported; extra keys are allowed")
+			}
+		default:
+			if !jsonSchemaAnnotations[k] {
+				warn("unsupported keyword %q", k)
+			}
+		}
+	}
+	return s, nil
+}
+
+func ptrOrRoot(ptr string) string {
+	if ptr == "" {
+		return "#"
+	}
+	return "#" + ptr
+}
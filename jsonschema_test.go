@@ -0,0 +1,137 @@
+package god
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSchemaFromJSONSchemaWarnings(t *testing.T) {
+	data, err := os.ReadFile("testdata/jsonschema/user.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := SchemaFromJSONSchema(data)
+	if err != nil {
+		t.Fatalf("SchemaFromJSONSchema error: %v", err)
+	}
+	want := []string{
+		`#/properties/address/properties/zip: unsupported keyword "pattern"`,
+		`#/properties/email: unsupported keyword "format"`,
+	}
+	got := strings.Join(s.Warnings, "\n")
+	for _, w := range want {
+		if !strings.Contains(got, w) {
+			t.Errorf("expected warning %q, got:\n%s", w, got)
+		}
+	}
+	if len(s.Warnings) != len(want) {
+		t.Errorf("expected %d warnings, got %d:\n%s", len(want), len(s.Warnings), got)
+	}
+}
+
+// TestSchemaFromJSONSchemaCorpus checks that every fixture schema accepts the
+// documents in its .valid.god file and rejects each one in its .invalid.god
+// file, mirroring what the JSON Schema does for the equivalent JSON.
+func TestSchemaFromJSONSchemaCorpus(t *testing.T) {
+	schemas, err := filepath.Glob("testdata/jsonschema/*.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range schemas {
+		base := strings.TrimSuffix(path, ".schema.json")
+		t.Run(filepath.Base(base), func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s, err := SchemaFromJSONSchema(data)
+			if err != nil {
+				t.Fatalf("SchemaFromJSONSchema error: %v", err)
+			}
+			for _, want := range []bool{true, false} {
+				file := base + ".valid.god"
+				if !want {
+					file = base + ".invalid.god"
+				}
+				f, err := os.Open(file)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer f.Close()
+
+				dec := NewDecoder(f)
+				for i := 1; ; i++ {
+					var v interface{}
+					if err := dec.Decode(&v); err == io.EOF {
+						break
+					} else if err != nil {
+						t.Fatalf("%s document %d: %v", file, i, err)
+					}
+					err := s.ValidateValue(v)
+					if want && err != nil {
+						t.Errorf("%s document %d: expected valid, got %v", file, i, err)
+					}
+					if !want && err == nil {
+						t.Errorf("%s document %d: expected a validation error", file, i)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSchemaErrorPath(t *testing.T) {
+	s := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"users": {Type: "array", Items: &Schema{
+				Type:       "object",
+				Properties: map[string]*Schema{"name": {Type: "string"}},
+			}},
+		},
+	}
+	err := s.Validate([]byte(`{users=[{name="a"},{name=3}]}`))
+	se, ok := err.(*SchemaError)
+	if !ok {
+		t.Fatalf("expected *SchemaError, got %v", err)
+	}
+	if se.Path != "users[1].name" {
+		t.Errorf("expected path users[1].name, got %q", se.Path)
+	}
+}
+
+func TestSchemaTables(t *testing.T) {
+	s := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"users": {Type: "array", Items: &Schema{
+				Type:       "object",
+				Required:   []string{"name"},
+				Properties: map[string]*Schema{"name": {Type: "string"}, "age": {Type: "integer"}},
+			}},
+		},
+	}
+	for _, doc := range []string{
+		`{users=(name,age:"a",30;"b",;)}`,
+		`{users=()}`,
+		`{users=}`,
+		`{users=[]}`,
+	} {
+		if err := s.Validate([]byte(doc)); err != nil {
+			t.Errorf("%s: %v", doc, err)
+		}
+	}
+
+	err := s.Validate([]byte(`{users=(name,age:"a",30;"b",1.5;)}`))
+	se, ok := err.(*SchemaError)
+	if !ok || se.Path != "users[1].age" {
+		t.Errorf("expected an error at users[1].age, got %v", err)
+	}
+	err = s.Validate([]byte(`{users="a"}`))
+	if err == nil || !strings.Contains(err.Error(), "expected array, got string") {
+		t.Errorf("expected an array error, got %v", err)
+	}
+}
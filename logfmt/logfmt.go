@@ -0,0 +1,218 @@
+// Package logfmt converts between logfmt records and GOD objects. A
+// logfmt record is a line of space-separated pairs:
+//
+//	level=info msg="request done" status=200 cached=false took=1.25
+//
+// which FromLogfmt turns into
+//
+//	{cached=false;level="info";msg="request done";status=200;took=1.25}
+//
+// Logfmt values are untyped text, so FromLogfmt reads unquoted numbers and
+// true or false as GOD numbers and booleans, and everything else, quoted
+// values included, as strings. ToLogfmt writes the other way round, quoting
+// strings that would otherwise read as numbers or booleans, so that
+// numbers, booleans and strings survive a round trip. Numbers keep the text
+// they were written with.
+package logfmt
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vinayakgupta29/god"
+)
+
+// FromLogfmt parses the logfmt record in data into a GOD object, with keys
+// sorted as god.Marshal sorts them. Line breaks separate pairs like spaces
+// do, so data is read as a single record. A key with no '=' is a flag and
+// becomes true, a key with an empty value is grounded, and a key given
+// twice keeps its last value. Dots in keys are kept, so dotted keys written
+// by ToLogfmt stay flat.
+func FromLogfmt(data []byte) ([]byte, error) {
+	obj := make(map[string]god.RawMessage)
+	s := string(data)
+	for i := 0; ; {
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+		if i == len(s) {
+			break
+		}
+		start := i
+		for i < len(s) && !isSpace(s[i]) && s[i] != '=' && s[i] != '"' {
+			i++
+		}
+		key := s[start:i]
+		if key == "" {
+			return nil, fmt.Errorf("logfmt: expected key at offset %d", start)
+		}
+		if i == len(s) || s[i] != '=' {
+			if i < len(s) && s[i] == '"' {
+				return nil, fmt.Errorf("logfmt: unexpected '\"' in key at offset %d", i)
+			}
+			obj[key] = god.RawMessage("true")
+			continue
+		}
+		i++ // consume '='
+
+		if i < len(s) && s[i] == '"' {
+			end, err := quotedEnd(s, i)
+			if err != nil {
+				return nil, err
+			}
+			text, err := strconv.Unquote(s[i:end])
+			if err != nil {
+				return nil, fmt.Errorf("logfmt: invalid quoted value at offset %d: %v", i, err)
+			}
+			if obj[key], err = god.MarshalValue(text); err != nil {
+				return nil, err
+			}
+			i = end
+			continue
+		}
+		start = i
+		for i < len(s) && !isSpace(s[i]) {
+			if s[i] == '"' || s[i] == '=' {
+				return nil, fmt.Errorf("logfmt: unexpected '%c' in value at offset %d", s[i], i)
+			}
+			i++
+		}
+		val, err := fromBare(s[start:i])
+		if err != nil {
+			return nil, err
+		}
+		obj[key] = val
+	}
+	return god.Marshal(obj)
+}
+
+// ToLogfmt writes the GOD object in data as a logfmt record, on one line
+// without a line break. Nested objects and lists are flattened, joining
+// keys and list indexes with dots: {db={host="x"};tags=["a","b"]} becomes
+// db.host=x tags.0=a tags.1=b. Grounded values are written empty. Keys
+// that logfmt cannot hold, with spaces, '=' or '"' in them, are an error.
+func ToLogfmt(data []byte) ([]byte, error) {
+	var root interface{}
+	if err := god.UnmarshalWith(data, &root, god.UseNumber()); err != nil {
+		return nil, err
+	}
+	obj, ok := root.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("logfmt: document root must be key-value pairs, got %T", root)
+	}
+	var buf bytes.Buffer
+	if err := writePairs(&buf, "", obj); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writePairs(buf *bytes.Buffer, prefix string, v interface{}) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if k == "" || strings.ContainsAny(k, " \t\r\n=\"") {
+				return fmt.Errorf("logfmt: key %q cannot be written", prefix+k)
+			}
+			if err := writePairs(buf, prefix+k+".", v[k]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		for i, elem := range v {
+			if err := writePairs(buf, prefix+strconv.Itoa(i)+".", elem); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []map[string]interface{}:
+		for i, row := range v {
+			if err := writePairs(buf, prefix+strconv.Itoa(i)+".", row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if buf.Len() > 0 {
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(strings.TrimSuffix(prefix, ".") + "=")
+	switch v := v.(type) {
+	case string:
+		buf.WriteString(toValue(v))
+	case god.Number:
+		buf.WriteString(string(v))
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	default:
+		buf.WriteString(toValue(fmt.Sprint(v)))
+	}
+	return nil
+}
+
+// toValue writes s bare when FromLogfmt would read it back as the same
+// string, and quoted otherwise.
+func toValue(s string) string {
+	if s == "" {
+		return ""
+	}
+	if isNumber(s) || s == "true" || s == "false" || strings.ContainsAny(s, " =\"\\") || !isPrintable(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// fromBare converts an unquoted logfmt value to GOD.
+func fromBare(s string) (god.RawMessage, error) {
+	switch {
+	case s == "":
+		return nil, nil
+	case isNumber(s), s == "true", s == "false":
+		return god.RawMessage(s), nil
+	}
+	return god.MarshalValue(s)
+}
+
+// quotedEnd returns the offset just past the quoted value that starts at
+// s[i].
+func quotedEnd(s string, i int) (int, error) {
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("logfmt: unterminated quoted value at offset %d", i)
+}
+
+var numberPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+func isNumber(s string) bool {
+	return numberPattern.MatchString(s)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+func isPrintable(s string) bool {
+	for _, r := range s {
+		if !strconv.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
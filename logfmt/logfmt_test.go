@@ -0,0 +1,58 @@
+package logfmt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFromLogfmt(t *testing.T) {
+	in := `level=info msg="request done" status=200 took=1.25 cached=false path=/api/v1 id="42" retry empty=` + "\n"
+	got, err := FromLogfmt([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{cached=false;empty=;id="42";level="info";msg="request done";path="/api/v1";retry=true;status=200;took=1.25}`
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestToLogfmt(t *testing.T) {
+	in := `{msg="a \"quoted\" word";n=0;ok=false;id="42";db={host="db.internal";port=5432};tags=["x","y"];none=;price=1.50}`
+	got, err := ToLogfmt([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `db.host=db.internal db.port=5432 id="42" msg="a \"quoted\" word" n=0 none= ok=false price=1.50 tags.0=x tags.1=y`
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	in := `a=1 b="two words" c=true d="true" e=-3.5e2 f= g="tab\there"`
+	doc, err := FromLogfmt([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ToLogfmt(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != in {
+		t.Errorf("round trip through %s\ngot  %s\nwant %s", doc, out, in)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, in := range []string{`=1`, `a="open`, `a=b"c`, `a"b=1`, `a="\q"`} {
+		if _, err := FromLogfmt([]byte(in)); err == nil || !strings.HasPrefix(err.Error(), "logfmt: ") {
+			t.Errorf("FromLogfmt(%q): got error %v, want a logfmt error", in, err)
+		}
+	}
+	for _, in := range []string{`{"a b"=1}`, `{[1,2]}`} {
+		if _, err := ToLogfmt([]byte(in)); err == nil {
+			t.Errorf("ToLogfmt(%q): expected error", in)
+		}
+	}
+}
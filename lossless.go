@@ -0,0 +1,118 @@
+package god
+
+import (
+	"math"
+	"reflect"
+)
+
+// LossyEncodingError is returned by MarshalOptions.Marshal with Lossless
+// set when part of a value would not decode back to an equal value.
+type LossyEncodingError struct {
+	// Path locates the value, as in "employees[2].score"; it is empty for
+	// the root.
+	Path string
+
+	// Reason says what would be lost.
+	Reason string
+}
+
+func (e *LossyEncodingError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "(root)"
+	}
+	return "lossy encoding at " + path + ": " + e.Reason
+}
+
+// A lossyCheck returns why v would not decode back to an equal value once
+// encoded, or "" if it would.
+type lossyCheck func(v reflect.Value) string
+
+// lossyChecks run on every value encoded with MarshalOptions.Lossless.
+// Encodings that cannot round-trip some values register a check here, so
+// that Lossless reports them rather than letting them degrade.
+var lossyChecks = []lossyCheck{
+	nonFiniteCheck,
+	errorCheck,
+	unsupportedKindCheck,
+}
+
+func nonFiniteCheck(v reflect.Value) string {
+	if k := v.Kind(); (k == reflect.Float32 || k == reflect.Float64) && (math.IsNaN(v.Float()) || math.IsInf(v.Float(), 0)) {
+		return "NaN and infinities are not GOD numbers"
+	}
+	return ""
+}
+
+func errorCheck(v reflect.Value) string {
+	if v.Type() == errorType && !v.IsNil() {
+		return "an error is written as its message only"
+	}
+	return ""
+}
+
+func unsupportedKindCheck(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Complex64, reflect.Complex128, reflect.Chan, reflect.Func, reflect.Uintptr, reflect.UnsafePointer:
+		return "values of kind " + v.Kind().String() + " cannot be decoded"
+	}
+	return ""
+}
+
+// checkLossless runs the lossy checks on v when Lossless is set.
+func (b *encodeState) checkLossless(v reflect.Value) error {
+	if !b.lossless || !v.IsValid() {
+		return nil
+	}
+	for _, check := range lossyChecks {
+		if reason := check(v); reason != "" {
+			return b.lossy(reason)
+		}
+	}
+	return nil
+}
+
+// lossy returns a LossyEncodingError for the value being encoded.
+func (b *encodeState) lossy(reason string) error {
+	return &LossyEncodingError{Path: string(b.path), Reason: reason}
+}
+
+// droppedKey reports a flatten or remain key that is not written because
+// it names a regular field.
+func (b *encodeState) droppedKey(name string) error {
+	mark := b.pushKey(name)
+	err := b.lossy("the key is dropped because it names a regular field")
+	b.pop(mark)
+	return err
+}
+
+// grounds reports whether the zero value v is written as an empty value.
+// Empty values decode to nil slices and maps, so with Lossless a value
+// holding an empty but non-nil slice or map is written out in full.
+func (b *encodeState) grounds(v reflect.Value) bool {
+	return isZeroValue(v) && !(b.lossless && holdsEmptyCollection(v))
+}
+
+// holdsEmptyCollection reports whether v is, or has an exported field or
+// element that is, an empty but non-nil slice or map.
+func holdsEmptyCollection(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Interface:
+		return !v.IsNil() && holdsEmptyCollection(v.Elem())
+	case reflect.Slice, reflect.Map:
+		return !v.IsNil() && v.Len() == 0
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if holdsEmptyCollection(v.Index(i)) {
+				return true
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() && holdsEmptyCollection(v.Field(i)) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package god
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+type sample struct {
+	Sensor string  `god:"sensor"`
+	Value  float64 `god:"value"`
+}
+
+type station struct {
+	Name     string        `god:"name"`
+	Readings []sample      `god:"readings"`
+	Tags     []string      `god:"tags"`
+	Err      error         `god:"err"`
+	Any      []interface{} `god:"any"`
+}
+
+func TestLosslessErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		v    interface{}
+		path string
+	}{
+		{"NaN field", sample{Value: math.NaN()}, "value"},
+		{"infinite table cell", station{Readings: []sample{{}, {Value: math.Inf(1)}}}, "readings[1].value"},
+		{"NaN list element", map[string][]float64{"xs": {1, math.NaN()}}, "xs[1]"},
+		{"error field", station{Err: errors.New("boom")}, "err"},
+		{"complex in interface", station{Any: []interface{}{"x", complex(1, 2)}}, "any[1]"},
+		{"flatten key collision", flatService{Name: "a", Extra: map[string]interface{}{"port": 1}}, "port"},
+		{"remain key collision", userV1{Name: "a", Extra: map[string]string{"name": `"b"`}}, "name"},
+		{"naked root", math.Inf(-1), ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Without Lossless, the value is encoded or fails with some
+			// other error; with it, the loss is reported.
+			_, err := MarshalWith(c.v, Lossless())
+			var lossy *LossyEncodingError
+			if !errors.As(err, &lossy) {
+				t.Fatalf("expected a LossyEncodingError, got %v", err)
+			}
+			if lossy.Path != c.path {
+				t.Errorf("path = %q, want %q (%v)", lossy.Path, c.path, err)
+			}
+			if lossy.Reason == "" {
+				t.Error("expected a reason")
+			}
+		})
+	}
+}
+
+func TestLosslessRoundTrip(t *testing.T) {
+	in := station{
+		Name:     "north",
+		Readings: []sample{{Sensor: "t1", Value: 1.5}, {}},
+		Tags:     []string{},
+	}
+	if _, err := Marshal(in); err != nil {
+		t.Fatal(err)
+	}
+	for _, beautify := range []bool{false, true} {
+		data, err := MarshalOptions{Lossless: true, Beautify: beautify}.Marshal(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out station
+		if err := Unmarshal(data, &out); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(out, in) {
+			t.Errorf("round trip of %s:\ngot  %#v\nwant %#v", data, out, in)
+		}
+	}
+
+	// Empty collections are written out wherever they are, even in an
+	// otherwise zero value.
+	nested := map[string]station{"a": {Tags: []string{}}}
+	data, err := MarshalWith(nested, Lossless())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{a={name=;readings=;tags=[];err=;any=}}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+	var back map[string]station
+	if err := Unmarshal(data, &back); err != nil || !reflect.DeepEqual(back, nested) {
+		t.Errorf("round trip: %#v, %v", back, err)
+	}
+}
@@ -0,0 +1,273 @@
+package god
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMapOfSlicesDecode(t *testing.T) {
+	var m map[string][]string
+	if err := Unmarshal([]byte(`{fruits=["apple","banana"];vegs=["carrot"]}`), &m); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	want := map[string][]string{"fruits": {"apple", "banana"}, "vegs": {"carrot"}}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("expected %v, got %v", want, m)
+	}
+}
+
+func TestMapOfSlicesRoundTrip(t *testing.T) {
+	cases := []struct {
+		name string
+		in   interface{}
+	}{
+		{"strings", map[string][]string{"fruits": {"apple", "banana"}, "vegs": {"carrot"}}},
+		{"ints", map[string][]int{"a": {1, 2, 3}, "b": {-4}}},
+		{"bools", map[string][]bool{"flags": {true, false, true}}},
+		{"nested", map[string][][]string{"grid": {{"a", "b"}, {"c"}}}},
+		{"tables", map[string][]Person{"team": {{Name: "Alice", Age: 30}, {Name: "Bob", Address: "LA"}}}},
+		{"arrays", map[string][2]int{"pair": {1, 2}, "half": {3}}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			for _, marshal := range []func(interface{}) ([]byte, error){Marshal, MarshalBeautify} {
+				encoded, err := marshal(c.in)
+				if err != nil {
+					t.Fatalf("Marshal error: %v", err)
+				}
+				out := reflect.New(reflect.TypeOf(c.in))
+				if err := Unmarshal(encoded, out.Interface()); err != nil {
+					t.Fatalf("Unmarshal error: %v\n%s", err, encoded)
+				}
+				if !reflect.DeepEqual(out.Elem().Interface(), c.in) {
+					t.Errorf("round trip mismatch:\nencoded: %s\nexpected %v\ngot      %v", encoded, c.in, out.Elem().Interface())
+				}
+			}
+		})
+	}
+}
+
+type service struct {
+	Port int    `god:"port"`
+	Host string `god:"host"`
+}
+
+type serviceConfig struct {
+	Services map[string]service  `god:"services"`
+	Backends map[string]*service `god:"backends"`
+}
+
+func TestStructWithMapOfStructs(t *testing.T) {
+	var cfg serviceConfig
+	src := `{services={web={port=80};db={port=5432;host="db.local"};idle=};backends={api={port=8080}}}`
+	if err := Unmarshal([]byte(src), &cfg); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	want := serviceConfig{
+		Services: map[string]service{"web": {Port: 80}, "db": {Port: 5432, Host: "db.local"}, "idle": {}},
+		Backends: map[string]*service{"api": {Port: 8080}},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("expected %+v, got %+v", want, cfg)
+	}
+
+	for _, marshal := range []func(interface{}) ([]byte, error){Marshal, MarshalBeautify} {
+		encoded, err := marshal(want)
+		if err != nil {
+			t.Fatalf("Marshal error: %v", err)
+		}
+		var out serviceConfig
+		if err := Unmarshal(encoded, &out); err != nil {
+			t.Fatalf("Unmarshal error: %v\n%s", err, encoded)
+		}
+		if !reflect.DeepEqual(out, want) {
+			t.Errorf("round trip mismatch:\nencoded: %s\ngot %+v", encoded, out)
+		}
+	}
+}
+
+func TestArrayDecodeOverflow(t *testing.T) {
+	var a [2]int
+	if err := Unmarshal([]byte(`{[1,2,3]}`), &a); err == nil {
+		t.Errorf("expected an error decoding 3 elements into %T", a)
+	}
+}
+
+func TestNonStringKeyMaps(t *testing.T) {
+	cases := []struct {
+		name string
+		in   interface{}
+	}{
+		{"int struct", map[int]Person{42: {Name: "Alice", Age: 30}, -1: {Name: "Bob"}}},
+		{"int64 string", map[int64]string{1 << 40: "big", 0: "zero"}},
+		{"uint8 slice", map[uint8][]int{255: {1, 2}, 7: nil}},
+		{"int map", map[int]map[string]int{1: {"a": 1}, 2: {}}},
+		{"int32 pointer", map[int32]*Person{3: {Name: "Carol"}, 4: nil}},
+		{"uint table", map[uint][]Person{10: {{Name: "Dan", Age: 40}, {Name: "Eve"}}}},
+		{"int set", map[int]map[int]struct{}{1: {3: {}, 2: {}}}},
+		{"bool string", map[bool]string{true: "yes", false: "no"}},
+		{"float64 int", map[float64]int{1.5: 1, -2: 2, 1e-7: 3, 1e300: 4}},
+		{"float32 string", map[float32]string{0.1: "a", 3: "b"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			encoded, err := Marshal(c.in)
+			if err != nil {
+				t.Fatalf("Marshal error: %v", err)
+			}
+			out := reflect.New(reflect.TypeOf(c.in))
+			if err := Unmarshal(encoded, out.Interface()); err != nil {
+				t.Fatalf("Unmarshal error: %v\n%s", err, encoded)
+			}
+			again, err := Marshal(out.Elem().Interface())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(again) != string(encoded) {
+				t.Errorf("round trip mismatch:\nfirst:  %s\nsecond: %s", encoded, again)
+			}
+		})
+	}
+
+	var m map[int]Person
+	if err := Unmarshal([]byte(`{42={name="Alice";age=30}}`), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m[42].Name != "Alice" || m[42].Age != 30 {
+		t.Errorf("unexpected map: %+v", m)
+	}
+
+	for doc, target := range map[string]interface{}{
+		`{abc="x"}`: &map[int]string{},
+		`{300=1}`:   &map[int8]int{},
+		`{-1=1}`:    &map[uint]int{},
+		`{1.5=1}`:   &map[struct{ A int }]int{},
+		`{yes=1}`:   &map[bool]int{},
+		`{1.5x=1}`:  &map[float64]int{},
+	} {
+		if err := Unmarshal([]byte(doc), target); err == nil {
+			t.Errorf("%s into %T: expected error", doc, target)
+		}
+	}
+}
+
+func TestBoolFloatKeyMaps(t *testing.T) {
+	data, err := Marshal(map[float64]bool{1.5: true, -2: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{-2=;1.5=true}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+
+	var m map[bool]float32
+	if err := Unmarshal([]byte(`{true=0.1;false=2}`), &m); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m, map[bool]float32{true: 0.1, false: 2}) {
+		t.Errorf("got %v", m)
+	}
+
+	for _, v := range []interface{}{
+		map[*int]int{new(int): 1},
+		map[struct{ A int }]int{{A: 1}: 1},
+	} {
+		if _, err := Marshal(v); err == nil || !strings.Contains(err.Error(), "unsupported map key type") {
+			t.Errorf("%T: expected unsupported key error, got %v", v, err)
+		}
+	}
+}
+
+func TestEmptyMapKey(t *testing.T) {
+	in := map[string]int{"": 42, "a": 1}
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{""=42;a=1}`; string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+	var out map[string]int
+	if err := Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("round trip = %v, want %v", out, in)
+	}
+
+	var generic interface{}
+	if err := Unmarshal([]byte(`{"" = "x";;b=1}`), &generic); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]interface{}{"": "x", "b": int64(1)}; !reflect.DeepEqual(generic, want) {
+		t.Errorf("generic = %v, want %v", generic, want)
+	}
+
+	for _, doc := range []string{`{=1}`, `{a=1; =2}`} {
+		if err := Unmarshal([]byte(doc), &out); err == nil {
+			t.Errorf("Unmarshal(%s) accepted a bare empty key", doc)
+		}
+	}
+}
+
+// yamlDoc has the shape yaml.v2 decodes documents into: maps with
+// interface keys at every level, and []interface{} for sequences.
+var yamlDoc = map[interface{}]interface{}{
+	"name":     "api",
+	"replicas": 3,
+	"env": map[interface{}]interface{}{
+		"DEBUG":   true,
+		"timeout": 1.5,
+	},
+	"ports": []interface{}{
+		map[interface{}]interface{}{"port": 80, "proto": "tcp"},
+		map[interface{}]interface{}{"port": 443},
+	},
+	200:          "ok",
+	"with space": "quoted",
+	false:        "no",
+}
+
+func TestMarshalInterfaceKeys(t *testing.T) {
+	data, err := Marshal(yamlDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{200="ok";env={DEBUG=true;timeout=1.5};false="no";name="api";ports=[{port=80;proto="tcp"},{port=443}];replicas=3;"with space"="quoted"}`
+	if string(data) != want {
+		t.Fatalf("got  %s\nwant %s", data, want)
+	}
+
+	var generic map[string]interface{}
+	if err := Unmarshal(data, &generic); err != nil {
+		t.Fatalf("output does not parse: %v", err)
+	}
+	if generic["with space"] != "quoted" || generic["200"] != "ok" {
+		t.Errorf("got %v", generic)
+	}
+
+	var same map[interface{}]interface{}
+	if err := Unmarshal(data, &same); err != nil {
+		t.Fatal(err)
+	}
+	if same["false"] != "no" || same["replicas"] != int64(3) {
+		t.Errorf("got %v", same)
+	}
+}
+
+func TestMarshalInterfaceKeyErrors(t *testing.T) {
+	for name, m := range map[string]map[interface{}]interface{}{
+		"ambiguous": {1: "a", "1": "b"},
+		"nil":       {nil: "a"},
+		"list":      {[2]int{1, 2}: "a"},
+	} {
+		if data, err := Marshal(m); err == nil {
+			t.Errorf("%s: expected error, got %s", name, data)
+		}
+	}
+	_, err := Marshal(map[interface{}]interface{}{1: "a", "1": "b"})
+	if err == nil || !strings.Contains(err.Error(), `are both written as "1"`) {
+		t.Errorf("ambiguous keys: got %v", err)
+	}
+}
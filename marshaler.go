@@ -0,0 +1,293 @@
+package god
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// GodMarshaler is implemented by types that encode themselves. MarshalGOD
+// returns a single GOD value, such as a quoted string or a list, which is
+// written in place of the value the type would otherwise encode as.
+type GodMarshaler interface {
+	MarshalGOD() ([]byte, error)
+}
+
+// AnnotatedMarshaler is a GodMarshaler that also names its type for
+// tooling. The annotation, such as @time, is written in front of the
+// value:
+//
+//	created=@time"2024-01-01T00:00:00Z"
+//
+// An annotation is '@' followed by letters, digits, '_', '.', '-' or '/'.
+// An empty annotation writes the value alone.
+type AnnotatedMarshaler interface {
+	MarshalGODAnnotated() (value, annotation []byte, err error)
+}
+
+// GodUnmarshaler is implemented by types that decode themselves.
+// UnmarshalGOD receives the text of one value, without any annotation, and
+// must copy it if it keeps it. Grounded values never reach UnmarshalGOD;
+// they leave the zero value as with any other type.
+type GodUnmarshaler interface {
+	UnmarshalGOD(data []byte) error
+}
+
+var (
+	godMarshalerType       = reflect.TypeOf((*GodMarshaler)(nil)).Elem()
+	annotatedMarshalerType = reflect.TypeOf((*AnnotatedMarshaler)(nil)).Elem()
+	godUnmarshalerType     = reflect.TypeOf((*GodUnmarshaler)(nil)).Elem()
+)
+
+var (
+	annotationsMu sync.RWMutex
+	annotations   = make(map[string]reflect.Type)
+	aliases       = make(map[reflect.Type]string)
+)
+
+// RegisterAnnotation makes the decoder use the type of v for values
+// annotated with annotation, such as "@time", when they are decoded into an
+// interface{}. v must be a pointer, whose element type is what is stored.
+// Typed targets decode as usual whatever their annotation says. Like
+// Publish, RegisterAnnotation panics if the annotation is already
+// registered or is not valid.
+func RegisterAnnotation(annotation string, v GodUnmarshaler) {
+	t := reflect.TypeOf(v)
+	if t.Kind() != reflect.Ptr {
+		panic("god: RegisterAnnotation needs a pointer, got " + t.String())
+	}
+	if !validAnnotation([]byte(annotation)) {
+		panic("god: invalid annotation " + annotation)
+	}
+	annotationsMu.Lock()
+	defer annotationsMu.Unlock()
+	if _, dup := annotations[annotation]; dup {
+		panic("god: reuse of annotation " + annotation)
+	}
+	annotations[annotation] = t.Elem()
+}
+
+// RegisterAlias names t in the format: values annotated @name decode to a
+// t when the target is an interface{}, and with MarshalOptions.UseAliases
+// values of type t are written with the annotation:
+//
+//	god.RegisterAlias("timestamp", reflect.TypeOf(Timestamp{}))
+//	// created=@timestamp"2024-01-01T00:00:00Z"
+//
+// The value is decoded by the UnmarshalGOD method of *t, so t must have
+// one; wrap types such as time.Time that do not. A type has at most one
+// alias, and an alias shares the names of RegisterAnnotation. Like
+// RegisterAnnotation, RegisterAlias panics if either is already taken or
+// name is not valid.
+func RegisterAlias(name string, t reflect.Type) {
+	if t == nil || !reflect.PtrTo(t).Implements(godUnmarshalerType) {
+		panic(fmt.Sprintf("god: RegisterAlias needs a type whose pointer implements GodUnmarshaler, got %v", t))
+	}
+	annotation := "@" + name
+	if !validAnnotation([]byte(annotation)) {
+		panic("god: invalid alias " + name)
+	}
+	annotationsMu.Lock()
+	defer annotationsMu.Unlock()
+	if _, dup := annotations[annotation]; dup {
+		panic("god: reuse of annotation " + annotation)
+	}
+	if a, dup := aliases[t]; dup {
+		panic("god: " + t.String() + " already has alias " + a)
+	}
+	annotations[annotation] = t
+	aliases[t] = annotation
+}
+
+// alias returns the annotation registered for t with RegisterAlias when
+// UseAliases is set, or "".
+func (b *encodeState) alias(t reflect.Type) string {
+	if !b.useAliases {
+		return ""
+	}
+	annotationsMu.RLock()
+	defer annotationsMu.RUnlock()
+	return aliases[t]
+}
+
+// writeAlias writes the alias of the type of v, which does not marshal
+// itself, when UseAliases is set. Numbers and booleans would run into the
+// annotation, so they are set apart by a space.
+func (b *encodeState) writeAlias(v reflect.Value) {
+	a := b.alias(v.Type())
+	if a == "" {
+		return
+	}
+	b.WriteString(a)
+	switch v.Kind() {
+	case reflect.String:
+		if isNumberType(v.Type()) {
+			b.WriteByte(' ')
+		}
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		b.WriteByte(' ')
+	}
+}
+
+func lookupAnnotation(annotation string) (reflect.Type, bool) {
+	annotationsMu.RLock()
+	defer annotationsMu.RUnlock()
+	t, ok := annotations[annotation]
+	return t, ok
+}
+
+func isAnnotationByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '_' || c == '.' || c == '-' || c == '/'
+}
+
+func validAnnotation(a []byte) bool {
+	if len(a) < 2 || a[0] != '@' {
+		return false
+	}
+	for _, c := range a[1:] {
+		if !isAnnotationByte(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// marshalerFor returns the value whose marshaling methods v has, which is
+// v or its address, or an invalid Value if it has none.
+func marshalerFor(v reflect.Value) reflect.Value {
+	t := v.Type()
+	if t.Implements(annotatedMarshalerType) || t.Implements(godMarshalerType) {
+		return v
+	}
+	if v.CanAddr() {
+		pt := reflect.PtrTo(t)
+		if pt.Implements(annotatedMarshalerType) || pt.Implements(godMarshalerType) {
+			return v.Addr()
+		}
+	}
+	return reflect.Value{}
+}
+
+// encodeMarshaler writes a value whose type marshals itself, given the
+// result of marshalerFor. A type that does not annotate itself gets its
+// alias, see MarshalOptions.UseAliases.
+func encodeMarshaler(b *encodeState, m reflect.Value) error {
+	var value, annotation []byte
+	var err error
+	if am, ok := m.Interface().(AnnotatedMarshaler); ok {
+		value, annotation, err = am.MarshalGODAnnotated()
+	} else {
+		value, err = m.Interface().(GodMarshaler).MarshalGOD()
+	}
+	if err != nil {
+		return fmt.Errorf("marshaling %v: %w", m.Type(), err)
+	}
+	if len(annotation) > 0 && !validAnnotation(annotation) {
+		return fmt.Errorf("marshaling %v: invalid annotation %q", m.Type(), annotation)
+	}
+	if err := checkMarshaled(value); err != nil {
+		return fmt.Errorf("marshaling %v: %w", m.Type(), err)
+	}
+	if len(annotation) == 0 {
+		annotation = []byte(b.alias(reflect.Indirect(m).Type()))
+	}
+	b.Write(annotation)
+	if len(annotation) > 0 && len(value) > 0 && isAnnotationByte(value[0]) {
+		b.WriteByte(' ') // keep a bare value from running into the annotation
+	}
+	b.Write(value)
+	return nil
+}
+
+// checkMarshaled makes sure the output of a marshaler is one value, so
+// that it cannot corrupt the surrounding document.
+func checkMarshaled(value []byte) error {
+	p := UnmarshalOptions{}.newParser(value)
+	p.skipSpaces()
+	if p.eof() {
+		return nil
+	}
+	if _, err := parseGenericValue(p); err != nil {
+		return fmt.Errorf("invalid value %q: %w", value, err)
+	}
+	p.skipSpaces()
+	if !p.eof() {
+		return fmt.Errorf("invalid value %q: trailing data", value)
+	}
+	return nil
+}
+
+// unmarshalerFor returns target's address if it has an UnmarshalGOD
+// method, or an invalid Value.
+func unmarshalerFor(target reflect.Value) reflect.Value {
+	if target.Kind() != reflect.Ptr && target.CanAddr() && reflect.PtrTo(target.Type()).Implements(godUnmarshalerType) {
+		return target.Addr()
+	}
+	return reflect.Value{}
+}
+
+// decodesWhole reports whether p decodes a table cell of type t as a
+// whole value: t decodes itself, or p reads it as JSON or in a time
+// layout.
+func (p *parser) decodesWhole(t reflect.Type) bool {
+	return decodesItself(t) || p.decodesJSONRaw(t) || p.decodesTime(t)
+}
+
+// decodesItself reports whether values of type t, or what t points to,
+// are decoded by UnmarshalGOD.
+func decodesItself(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return reflect.PtrTo(t).Implements(godUnmarshalerType)
+}
+
+// readAnnotation consumes an annotation at p, if there is one.
+func (p *parser) readAnnotation() string {
+	if p.peek() != '@' {
+		return ""
+	}
+	start := p.pos
+	p.pos++
+	for !p.eof() && isAnnotationByte(p.peek()) {
+		p.pos++
+	}
+	return string(p.src[start:p.pos])
+}
+
+// rawValue consumes one value and returns its text.
+func (p *parser) rawValue() ([]byte, error) {
+	p.skipSpaces()
+	start := p.pos
+	if _, err := parseGenericValue(p); err != nil {
+		return nil, err
+	}
+	return p.src[start:p.pos], nil
+}
+
+// decodeUnmarshaler hands the next value to the UnmarshalGOD method of u.
+func decodeUnmarshaler(p *parser, u reflect.Value) error {
+	raw, err := p.rawValue()
+	if err != nil {
+		return err
+	}
+	if err := u.Interface().(GodUnmarshaler).UnmarshalGOD(raw); err != nil {
+		return fmt.Errorf("unmarshaling %v: %w", u.Type().Elem(), err)
+	}
+	return nil
+}
+
+// decodeAnnotated decodes a value annotated with a registered annotation
+// for an interface{} target.
+func decodeAnnotated(p *parser, t reflect.Type) (interface{}, error) {
+	v := reflect.New(t)
+	if err := decodeUnmarshaler(p, v); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
+
+var errAnnotationWithoutValue = errors.New("annotation without a value")
@@ -0,0 +1,146 @@
+package god
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stamp is a time that marshals itself as an annotated RFC 3339 string.
+type stamp struct{ time.Time }
+
+func (s stamp) MarshalGODAnnotated() ([]byte, []byte, error) {
+	v, err := MarshalValue(s.Format(time.RFC3339))
+	return v, []byte("@time"), err
+}
+
+func (s *stamp) UnmarshalGOD(data []byte) error {
+	var text string
+	if err := UnmarshalValue(data, &text); err != nil {
+		return err
+	}
+	t, err := time.Parse(time.RFC3339, text)
+	s.Time = t
+	return err
+}
+
+// celsius marshals itself without an annotation.
+type celsius float64
+
+func (c celsius) MarshalGOD() ([]byte, error) {
+	return MarshalValue(struct {
+		Deg  float64 `god:"deg"`
+		Unit string  `god:"unit"`
+	}{float64(c), "C"})
+}
+
+func (c *celsius) UnmarshalGOD(data []byte) error {
+	var v struct {
+		Deg float64 `god:"deg"`
+	}
+	if err := UnmarshalValue(data, &v); err != nil {
+		return err
+	}
+	*c = celsius(v.Deg)
+	return nil
+}
+
+type reading struct {
+	At   stamp   `god:"at"`
+	Temp celsius `god:"temp"`
+	Note string  `god:"note"`
+}
+
+func init() {
+	RegisterAnnotation("@time", (*stamp)(nil))
+}
+
+var day = stamp{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+func TestMarshalerField(t *testing.T) {
+	in := reading{At: day, Temp: 21.5, Note: "ok"}
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{at=@time"2024-01-01T00:00:00Z";temp={deg=21.5;unit="C"};note="ok"}`
+	if string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+	var out reading
+	if err := Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !out.At.Equal(in.At.Time) || out.Temp != in.Temp || out.Note != in.Note {
+		t.Errorf("round trip = %+v, want %+v", out, in)
+	}
+}
+
+func TestMarshalerTableAndRoot(t *testing.T) {
+	in := []reading{{At: day, Note: "a,b"}, {Temp: -3}}
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []reading
+	if err := Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal(%s): %v", data, err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("table round trip = %+v, want %+v\n%s", out, in, data)
+	}
+
+	data, err = Marshal(day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{@time"2024-01-01T00:00:00Z"}` {
+		t.Errorf("Marshal(root) = %s", data)
+	}
+	var root stamp
+	if err := Unmarshal(data, &root); err != nil || !root.Equal(day.Time) {
+		t.Errorf("Unmarshal(root) = %v, %v", root, err)
+	}
+}
+
+func TestAnnotationSelectsUnmarshaler(t *testing.T) {
+	var v map[string]interface{}
+	src := `{at=@time"2024-01-01T00:00:00Z";other=@unknown"x";n=1}`
+	if err := Unmarshal([]byte(src), &v); err != nil {
+		t.Fatal(err)
+	}
+	if at, ok := v["at"].(stamp); !ok || !at.Equal(day.Time) {
+		t.Errorf("at = %#v, want a stamp", v["at"])
+	}
+	if v["other"] != "x" {
+		t.Errorf("unregistered annotation: other = %#v, want \"x\"", v["other"])
+	}
+
+	var s struct {
+		Other string `god:"other"`
+	}
+	if err := Unmarshal([]byte(src), &s); err != nil || s.Other != "x" {
+		t.Errorf("typed target: %q, %v", s.Other, err)
+	}
+}
+
+type badMarshaler struct{ V string }
+
+func (b badMarshaler) MarshalGOD() ([]byte, error) { return []byte(b.V), nil }
+
+func TestMarshalerErrors(t *testing.T) {
+	for _, v := range []string{`"a";b=1`, `[1,2`, `x y`} {
+		_, err := Marshal(map[string]badMarshaler{"k": {v}})
+		if err == nil || !strings.Contains(err.Error(), "badMarshaler") {
+			t.Errorf("Marshal(%q) error = %v", v, err)
+		}
+	}
+	var r reading
+	if err := Unmarshal([]byte(`{at=@time;note="x"}`), &r); err == nil {
+		t.Error("annotation without a value decoded")
+	}
+	if err := Unmarshal([]byte(`{at=@time"yesterday"}`), &r); err == nil {
+		t.Error("invalid time decoded")
+	}
+}
@@ -0,0 +1,136 @@
+package god
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+type wideRow struct {
+	ID       int
+	Name     string
+	Email    string
+	City     string
+	Country  string
+	Balance  float64
+	Verified bool
+}
+
+var wideRows = []wideRow{
+	{1, "Alice Liddell", "alice@example.com", "Oxford", "United Kingdom", 1520.5, true},
+	{22, "Bob", "bob@example.org", "Paris", "France", -3, false},
+	{333, "Carol", "c@x.io", "Oslo", "Norway", 0.25, true},
+}
+
+func checkWidth(t *testing.T, out []byte, width int) {
+	t.Helper()
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.Contains(line, "(id,") {
+			continue // headers are not wrapped
+		}
+		if n := utf8.RuneCountInString(line); n > width {
+			t.Errorf("line of %d characters exceeds %d: %q", n, width, line)
+		}
+	}
+}
+
+func TestMaxWidthTable(t *testing.T) {
+	doc := struct{ Rows []wideRow }{wideRows}
+	for _, o := range []MarshalOptions{
+		{Beautify: true, MaxWidth: 40},
+		{Beautify: true, MaxWidth: 40, AlignTableColumns: true},
+		{Beautify: true, MaxWidth: 40, AlignTableColumns: true, RightAlignNumbers: true},
+	} {
+		out, err := o.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkWidth(t, out, 40)
+		if !strings.Contains(string(out), ",\n      ") {
+			t.Errorf("rows were not wrapped:\n%s", out)
+		}
+		var back struct{ Rows []wideRow }
+		if err := Unmarshal(out, &back); err != nil {
+			t.Fatalf("%v in\n%s", err, out)
+		}
+		if !reflect.DeepEqual(back, doc) {
+			t.Errorf("got %+v, want %+v", back, doc)
+		}
+	}
+
+	// Rows that fit are written as before.
+	wide, err := MarshalOptions{Beautify: true, MaxWidth: 200}.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := MarshalBeautify(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(wide, plain) {
+		t.Errorf("got\n%s\nwant\n%s", wide, plain)
+	}
+}
+
+func TestMaxWidthEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetBeautify(true)
+	enc.SetMaxWidth(30)
+	if err := enc.Encode(wideRows); err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan wideRow, len(wideRows))
+	for _, r := range wideRows {
+		ch <- r
+	}
+	close(ch)
+	if err := enc.EncodeTableFrom(ch); err != nil {
+		t.Fatal(err)
+	}
+	checkWidth(t, buf.Bytes(), 30)
+
+	dec := NewDecoder(&buf)
+	var got []wideRow
+	if err := dec.Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, wideRows) {
+		t.Errorf("got %+v, want %+v", got, wideRows)
+	}
+	out := make(chan wideRow, len(wideRows))
+	if err := dec.DecodeTableTo(out); err != nil {
+		t.Fatal(err)
+	}
+	got = got[:0]
+	for r := range out {
+		got = append(got, r)
+	}
+	if !reflect.DeepEqual(got, wideRows) {
+		t.Errorf("got %+v, want %+v", got, wideRows)
+	}
+}
+
+func TestMaxWidthDocumentAndTranscode(t *testing.T) {
+	out, err := MarshalOptions{Beautify: true, MaxWidth: 30}.Marshal(struct{ Rows []wideRow }{wideRows})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := ParseDocument(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, _ := d.Root().Get("rows")
+	if rows.Len() != 3 || rows.Index(0).Len() != 7 {
+		t.Errorf("got %d rows of %d cells", rows.Len(), rows.Index(0).Len())
+	}
+	compact, err := Transcode(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := Marshal(struct{ Rows []wideRow }{wideRows}); !bytes.Equal(compact, want) {
+		t.Errorf("got  %s\nwant %s", compact, want)
+	}
+}
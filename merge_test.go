@@ -0,0 +1,87 @@
+package god
+
+import (
+	"reflect"
+	"testing"
+)
+
+type overlayDB struct {
+	Host string `god:"host"`
+	Port int    `god:"port"`
+}
+
+type overlayConfig struct {
+	Name     string            `god:"name"`
+	Debug    bool              `god:"debug"`
+	DB       overlayDB         `god:"db"`
+	Labels   map[string]string `god:"labels"`
+	Hosts    []string          `god:"hosts"`
+	Limit    *int              `god:"limit"`
+	Fallback *overlayDB        `god:"fallback"`
+}
+
+func TestUnmarshalOverlay(t *testing.T) {
+	base := []byte(`{name="app";debug=true;db={host="localhost";port=5432};labels={env="dev";team="core"};hosts=["a","b","c"];limit=10;fallback={host="backup";port=1}}`)
+	overlay := []byte(`{name="prod";db={host="db.internal"};labels={env="prod"};hosts=["x"];fallback={port=2}}`)
+
+	var cfg overlayConfig
+	if err := Unmarshal(base, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	limit, fallback := cfg.Limit, cfg.Fallback
+	if err := Unmarshal(overlay, &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	ten := 10
+	want := overlayConfig{
+		Name:     "prod",
+		Debug:    true,
+		DB:       overlayDB{Host: "db.internal", Port: 5432},
+		Labels:   map[string]string{"env": "prod", "team": "core"},
+		Hosts:    []string{"x"},
+		Limit:    &ten,
+		Fallback: &overlayDB{Host: "backup", Port: 2},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("got %+v, want %+v", cfg, want)
+	}
+	if cfg.Limit != limit || cfg.Fallback != fallback {
+		t.Error("existing pointers were replaced instead of decoded into")
+	}
+}
+
+func TestUnmarshalOverlayGrounds(t *testing.T) {
+	cfg := overlayConfig{Name: "app", Debug: true, Hosts: []string{"a"}}
+	if err := Unmarshal([]byte(`{debug=;hosts=}`), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "app" || cfg.Debug || cfg.Hosts != nil {
+		t.Errorf("got %+v, want name kept and debug and hosts grounded", cfg)
+	}
+}
+
+func TestUnmarshalMergeMapValues(t *testing.T) {
+	// Map values are decoded afresh, not merged, as with encoding/json.
+	m := map[string]overlayDB{"primary": {Host: "a", Port: 1}, "replica": {Host: "b", Port: 2}}
+	if err := Unmarshal([]byte(`{primary={port=9}}`), &m); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]overlayDB{"primary": {Port: 9}, "replica": {Host: "b", Port: 2}}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("got %+v, want %+v", m, want)
+	}
+}
+
+func TestUnmarshalIntoInterfacePointer(t *testing.T) {
+	db := &overlayDB{Host: "a", Port: 1}
+	v := struct {
+		DB interface{} `god:"db"`
+	}{DB: db}
+	if err := Unmarshal([]byte(`{db={port=2}}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.DB != db || *db != (overlayDB{Host: "a", Port: 2}) {
+		t.Errorf("got %#v, want the held pointer decoded into", v.DB)
+	}
+}
@@ -0,0 +1,83 @@
+package god
+
+import (
+	"reflect"
+	"testing"
+)
+
+type sparseAddr struct {
+	Street string
+	City   string
+}
+
+type sparseUser struct {
+	Name    string
+	Age     int
+	Admin   bool
+	Tags    []string
+	Addr    sparseAddr
+	Manager *sparseUser
+	Extra   map[string]int
+}
+
+type sparseDoc struct {
+	Owner sparseUser
+	Users []sparseUser
+	Count int
+}
+
+func TestMarshalMinimal(t *testing.T) {
+	in := sparseDoc{
+		Owner: sparseUser{Name: "root", Addr: sparseAddr{City: "Oslo"}, Extra: map[string]int{"a": 0, "b": 2}},
+		Users: []sparseUser{{Name: "a"}, {Name: "b", Age: 3}},
+	}
+	out, err := MarshalMinimal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{owner={name="root";addr={city="Oslo"};extra={a=;b=2}};users=(name,age:"a",;"b",3;)}`
+	if string(out) != want {
+		t.Errorf("got  %s\nwant %s", out, want)
+	}
+	full, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) >= len(full) {
+		t.Errorf("minimal output %s is not shorter than %s", out, full)
+	}
+
+	var back sparseDoc
+	if err := Unmarshal(out, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, in) {
+		t.Errorf("got %+v, want %+v", back, in)
+	}
+}
+
+func TestOmitZeroOption(t *testing.T) {
+	in := sparseUser{Name: "x", Manager: &sparseUser{}}
+	out, err := MarshalWith(in, OmitZero(), Lossless())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{name="x";manager={}}`; string(out) != want {
+		t.Errorf("got %s, want %s", out, want)
+	}
+	var back sparseUser
+	if err := Unmarshal(out, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, in) {
+		t.Errorf("got %+v, want %+v", back, in)
+	}
+
+	out, err = MarshalWith(sparseUser{Tags: []string{}}, OmitZero(), Lossless())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{tags=[]}`; string(out) != want {
+		t.Errorf("got %s, want %s", out, want)
+	}
+}
@@ -0,0 +1,86 @@
+package god
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrMixedObject is returned for an object that holds a naked value as
+// well as key=value pairs, such as {"John";age=12}. Rule 5 lets the root,
+// or any object, hold one or the other but not both.
+var ErrMixedObject = errors.New("object mixes a naked value with key=value pairs")
+
+// endNaked consumes the '}' that closes an object holding the naked value
+// p has just read. Anything after the value but a ';' is an error, and
+// key=value pairs are ErrMixedObject.
+func (p *parser) endNaked() error {
+	p.skipSpaces()
+	if p.peek() == ';' {
+		semi := p.pos
+		p.next()
+		p.skipSpaces()
+		if !p.eof() && p.peek() != '}' {
+			return fmt.Errorf("%w at pos %d", ErrMixedObject, semi)
+		}
+	}
+	if p.peek() != '}' {
+		return fmt.Errorf("expected '}' after naked value, got '%c' at pos %d", p.peek(), p.pos)
+	}
+	p.next()
+	return nil
+}
+
+// nakedInPairs returns ErrMixedObject if key, read from start where an
+// object key belongs and not followed by '=', is a naked value in an
+// object that has key=value pairs before or after it. Otherwise it
+// returns nil and the caller reports the missing '='.
+func (p *parser) nakedInPairs(key string, quoted bool, start int) error {
+	pos := p.pos
+	switch c := p.peek(); {
+	case key == "" && !quoted:
+		// A list, table or object where a key should be.
+		if c != '{' && c != '[' && c != '(' {
+			return nil
+		}
+		if skipValue(p) != nil {
+			p.pos = pos
+			return nil
+		}
+		p.skipSpaces()
+	case quoted || isNakedScalar(key):
+		if c != ';' && c != '}' && !p.eof() {
+			return nil
+		}
+	default:
+		return nil
+	}
+	before := start
+	for before > 0 && isSpace(p.src[before-1]) {
+		before--
+	}
+	mixed := before > 0 && p.src[before-1] != '{'
+	if !mixed && p.peek() == ';' {
+		p.next()
+		p.skipSpaces()
+		mixed = !p.eof() && p.peek() != '}'
+	}
+	p.pos = pos
+	if !mixed {
+		return nil
+	}
+	return fmt.Errorf("%w at pos %d", ErrMixedObject, start)
+}
+
+// isNakedScalar reports whether the bare token s is a value rather than a
+// key: a number, a boolean, \0 or an annotated value.
+func isNakedScalar(s string) bool {
+	switch {
+	case s == "true" || s == "false" || s == `\0`:
+		return true
+	case s != "" && s[0] == '@':
+		return s != includeDirective
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
@@ -0,0 +1,106 @@
+package god
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var mixedDocs = []string{
+	`{"John";age=12}`,
+	`{age=12;"John"}`,
+	`{12;age=12}`,
+	`{age=12;true}`,
+	`{[1,2];age=12}`,
+	`{age=12;(name:"a";)}`,
+	`{age=12;{x=1}}`,
+	`{a=1;"John";b=2}`,
+}
+
+func TestMixedRootRejected(t *testing.T) {
+	for _, doc := range mixedDocs {
+		targets := map[string]interface{}{
+			"map":       new(map[string]interface{}),
+			"interface": new(interface{}),
+			"struct":    new(struct{ Age int }),
+			"ordered":   new(OrderedMap),
+		}
+		for name, v := range targets {
+			if err := Unmarshal([]byte(doc), v); !errors.Is(err, ErrMixedObject) {
+				t.Errorf("%s into %s: got %v, want ErrMixedObject", doc, name, err)
+			}
+		}
+		if _, err := ParseDocument([]byte(doc)); !errors.Is(err, ErrMixedObject) {
+			t.Errorf("ParseDocument(%s): got %v, want ErrMixedObject", doc, err)
+		}
+		if _, err := Transcode([]byte(doc)); !errors.Is(err, ErrMixedObject) {
+			t.Errorf("Transcode(%s): got %v, want ErrMixedObject", doc, err)
+		}
+	}
+
+	var s string
+	if err := Unmarshal([]byte(`{"John";age=12}`), &s); !errors.Is(err, ErrMixedObject) {
+		t.Errorf("string target: got %v, want ErrMixedObject", err)
+	}
+	var rows []map[string]interface{}
+	if err := Unmarshal([]byte(`{(name:"a";);x=1}`), &rows); !errors.Is(err, ErrMixedObject) {
+		t.Errorf("table target: got %v, want ErrMixedObject", err)
+	}
+}
+
+func TestMixedNestedObjectRejected(t *testing.T) {
+	var m map[string]interface{}
+	if err := Unmarshal([]byte(`{a={"x";b=1}}`), &m); !errors.Is(err, ErrMixedObject) {
+		t.Errorf("got %v, want ErrMixedObject", err)
+	}
+}
+
+func TestNakedRootValid(t *testing.T) {
+	var s string
+	if err := Unmarshal([]byte(`{ "John" }`), &s); err != nil || s != "John" {
+		t.Errorf("got %q, %v", s, err)
+	}
+	if err := Unmarshal([]byte(`{"Jane"; }`), &s); err != nil || s != "Jane" {
+		t.Errorf("trailing ';': got %q, %v", s, err)
+	}
+	if got, err := Minify([]byte(`{"Jane"; }`)); err != nil || string(got) != `{"Jane"}` {
+		t.Errorf("Minify with trailing ';': got %s, %v", got, err)
+	}
+	var i interface{}
+	if err := Unmarshal([]byte(`{[1,2]}`), &i); err != nil || !reflect.DeepEqual(i, []interface{}{int64(1), int64(2)}) {
+		t.Errorf("got %v, %v", i, err)
+	}
+	d, err := ParseDocument([]byte(`{12}`))
+	if err != nil || d.Root().Kind() != KindNumber {
+		t.Errorf("got %v, %v", d, err)
+	}
+	out, err := Marshal("John")
+	if err != nil || string(out) != `{"John"}` {
+		t.Errorf("Marshal got %s, %v", out, err)
+	}
+}
+
+func TestKeyedRootValid(t *testing.T) {
+	var m map[string]interface{}
+	if err := Unmarshal([]byte(`{name="John";;age=12;}`), &m); err != nil || len(m) != 2 {
+		t.Errorf("got %v, %v", m, err)
+	}
+	// A key that is not followed by '=' is reported as such, not as a
+	// naked value.
+	if err := Unmarshal([]byte(`{a=1;b}`), &m); err == nil || errors.Is(err, ErrMixedObject) {
+		t.Errorf("got %v, want a missing '=' error", err)
+	}
+}
+
+type mixedMarshaler struct{ n int }
+
+func (mixedMarshaler) MarshalGOD() ([]byte, error) { return []byte(`{"x";a=1}`), nil }
+
+func TestMarshalNeverMixes(t *testing.T) {
+	if _, err := Marshal(mixedMarshaler{1}); err == nil {
+		t.Error("a marshaler writing a mixed object should be rejected")
+	}
+	if _, err := Marshal(map[string]RawMessage{"a": RawMessage(`{"x";a=1}`)}); err == nil {
+		t.Error("a RawMessage holding a mixed object should be rejected")
+	}
+}
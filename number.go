@@ -0,0 +1,104 @@
+package god
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// A Number is a GOD number kept as its literal text. It is produced when
+// decoding into interface{} with UnmarshalOptions.UseNumber, and is encoded
+// as a bare number.
+type Number string
+
+// String returns the literal text of the number.
+func (n Number) String() string { return string(n) }
+
+// Float64 returns the number as a float64.
+func (n Number) Float64() (float64, error) {
+	return strconv.ParseFloat(string(n), 64)
+}
+
+// Int64 returns the number as an int64.
+func (n Number) Int64() (int64, error) {
+	return strconv.ParseInt(string(n), 10, 64)
+}
+
+var (
+	numberType     = reflect.TypeOf(Number(""))
+	jsonNumberType = reflect.TypeOf(json.Number(""))
+)
+
+// isNumberType reports whether t holds a number as its literal text, as
+// Number and encoding/json's Number do. Both are written as bare numbers.
+func isNumberType(t reflect.Type) bool {
+	return t == numberType || t == jsonNumberType
+}
+
+// encodeNumber writes a Number as a bare token, rejecting text that would
+// not read back as a number.
+func encodeNumber(b *encodeState, n Number) error {
+	if _, err := n.Float64(); err != nil {
+		return fmt.Errorf("invalid number literal %q", string(n))
+	}
+	b.WriteString(string(n))
+	return nil
+}
+
+// decodeNumber reads a bare numeric token into a Number.
+func decodeNumber(p *parser, target reflect.Value) error {
+	token, err := p.readBareToken()
+	if err != nil {
+		return err
+	}
+	if _, err := strconv.ParseFloat(token, 64); err != nil {
+		return fmt.Errorf("invalid number %q at pos %d", token, p.pos)
+	}
+	target.SetString(token)
+	return nil
+}
+
+// NormalizeNumber returns the canonical form of a numeric token, so that
+// numbers written differently, such as 3, 3.0 and 3e0, normalize to the
+// same text. Integers that fit in an int64 are kept exactly; any other
+// number is read as a float64 and written as the shortest decimal that
+// reads back as the same value, without a fraction if it is integral.
+// Equal and Diff compare numbers by their canonical form.
+func NormalizeNumber(token string) (string, error) {
+	if i, err := strconv.ParseInt(token, 10, 64); err == nil {
+		return strconv.FormatInt(i, 10), nil
+	}
+	f, err := strconv.ParseFloat(token, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return "", fmt.Errorf("invalid number %q", token)
+	}
+	return canonicalFloat(f), nil
+}
+
+// canonicalFloat writes f as NormalizeNumber does.
+func canonicalFloat(f float64) string {
+	if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return formatFloat(f, 64)
+}
+
+// canonicalNumber returns the canonical form of a generically decoded
+// number, reporting false if v is not one.
+func canonicalNumber(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return "", false
+		}
+		return canonicalFloat(v), true
+	case Number:
+		s, err := NormalizeNumber(string(v))
+		return s, err == nil
+	}
+	return "", false
+}
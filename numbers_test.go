@@ -0,0 +1,71 @@
+package god
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeIntegers(t *testing.T) {
+	type ints struct {
+		I  int    `god:"i"`
+		I8 int8   `god:"i8"`
+		U  uint   `god:"u"`
+		U8 uint8  `god:"u8"`
+		I6 int64  `god:"i6"`
+		U6 uint64 `god:"u6"`
+	}
+	var v ints
+	if err := Unmarshal([]byte(`{i=1e3;i8=-128;u=2.0;u8=255;i6=-9223372036854775808;u6=18446744073709551615}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	want := ints{I: 1000, I8: -128, U: 2, U8: 255, I6: -9223372036854775808, U6: 18446744073709551615}
+	if v != want {
+		t.Errorf("got %+v, want %+v", v, want)
+	}
+
+	for _, tt := range []struct {
+		doc, want string
+	}{
+		{`{i=1.5}`, "invalid integer"},
+		{`{u=2.5}`, "invalid"},
+		{`{i8=128}`, "overflows int8"},
+		{`{i8=-129}`, "overflows int8"},
+		{`{u8=256}`, "overflows uint8"},
+		{`{u=-1}`, "invalid"},
+		{`{i6=9223372036854775808}`, "invalid integer"},
+	} {
+		var v ints
+		err := Unmarshal([]byte(tt.doc), &v)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want an error containing %q", tt.doc, err, tt.want)
+		}
+	}
+}
+
+func TestDecodeIntegerCells(t *testing.T) {
+	type row struct {
+		I  int   `god:"i"`
+		U8 uint8 `god:"u8"`
+	}
+	var rows []row
+	if err := Unmarshal([]byte(`{(i,u8:1e3,2.0;-5,255;)}`), &rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0] != (row{I: 1000, U8: 2}) || rows[1] != (row{I: -5, U8: 255}) {
+		t.Errorf("got %+v", rows)
+	}
+
+	for _, tt := range []struct {
+		doc, want string
+	}{
+		{`{(i:1.5;)}`, "invalid integer"},
+		{`{(u8:256;)}`, "overflows uint8"},
+		{`{(u8:-1;)}`, "invalid"},
+	} {
+		var rows []row
+		err := Unmarshal([]byte(tt.doc), &rows)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want an error containing %q", tt.doc, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package god
+
+import (
+	"reflect"
+	"testing"
+)
+
+type omitRow struct {
+	ID    int    `god:"id"`
+	Name  string `god:"name"`
+	Note  string `god:"note,omitempty"`
+	Score *int   `god:"score,omitempty"`
+}
+
+func TestOmitEmptyStructField(t *testing.T) {
+	type doc struct {
+		Name string `god:"name"`
+		Note string `god:"note,omitempty"`
+		Tags []int  `god:"tags,omitempty"`
+	}
+	data, err := Marshal(doc{Name: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{name="a"}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+
+	data, err = Marshal(doc{Name: "a", Note: "n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{name="a";note="n"}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+func TestOmitEmptyTableColumn(t *testing.T) {
+	type doc struct {
+		Rows []omitRow `god:"rows"`
+	}
+
+	// Note and Score are zero in every row, so both columns are dropped.
+	in := doc{Rows: []omitRow{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}}
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{rows=(id,name:1,"a";2,"b";)}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+
+	// The missing columns are grounded on decode, even over existing values.
+	score := 7
+	out := doc{Rows: []omitRow{{Note: "old", Score: &score}}}
+	if err := Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("got %+v, want %+v", out, in)
+	}
+}
+
+func TestOmitEmptyTableColumnKeptWhenAnyRowSet(t *testing.T) {
+	type doc struct {
+		Rows []omitRow `god:"rows"`
+	}
+
+	// One row sets Note, so the column is written for every row.
+	in := doc{Rows: []omitRow{{ID: 1, Name: "a"}, {ID: 2, Name: "b", Note: "x"}}}
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{rows=(id,name,note:1,"a",;2,"b","x";)}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+
+	var out doc
+	if err := Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("got %+v, want %+v", out, in)
+	}
+}
@@ -0,0 +1,307 @@
+package god
+
+import (
+	"io/fs"
+	"reflect"
+)
+
+// An Option configures MarshalWith, UnmarshalWith and UnmarshalContext.
+// Each sets a field of MarshalOptions or UnmarshalOptions, and options for
+// one direction are ignored by the other.
+type Option func(*options)
+
+type options struct {
+	marshal   MarshalOptions
+	unmarshal UnmarshalOptions
+}
+
+func newOptions(opts []Option) *options {
+	o := new(options)
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// MarshalWith returns the GOD encoding of v, configured by opts.
+func MarshalWith(v interface{}, opts ...Option) ([]byte, error) {
+	return newOptions(opts).marshal.Marshal(v)
+}
+
+// UnmarshalWith decodes the GOD document in data into v, configured by
+// opts.
+func UnmarshalWith(data []byte, v interface{}, opts ...Option) error {
+	return newOptions(opts).unmarshal.Unmarshal(data, v)
+}
+
+// Beautify writes indented output, as MarshalBeautify does.
+func Beautify() Option {
+	return func(o *options) {
+		o.marshal.Beautify = true
+	}
+}
+
+// Indent indents beautified output by unit at each level, see
+// MarshalOptions.Indent.
+func Indent(unit string) Option {
+	return func(o *options) {
+		o.marshal.Indent = unit
+	}
+}
+
+// OmitZero leaves out fields and table columns that would be empty, as
+// MarshalMinimal does.
+func OmitZero() Option {
+	return func(o *options) {
+		o.marshal.OmitZero = true
+	}
+}
+
+// AlignTableColumns lines up the columns of beautified tables, see
+// MarshalOptions.AlignTableColumns.
+func AlignTableColumns() Option {
+	return func(o *options) {
+		o.marshal.AlignTableColumns = true
+	}
+}
+
+// RightAlignNumbers right-aligns numbers in aligned table columns, see
+// MarshalOptions.RightAlignNumbers.
+func RightAlignNumbers() Option {
+	return func(o *options) {
+		o.marshal.RightAlignNumbers = true
+	}
+}
+
+// AlignAssignments lines up the '=' signs of beautified objects, see
+// MarshalOptions.AlignAssignments.
+func AlignAssignments() Option {
+	return func(o *options) {
+		o.marshal.AlignAssignments = true
+	}
+}
+
+// TrailingNewline ends the output with a newline, see
+// MarshalOptions.TrailingNewline.
+func TrailingNewline() Option {
+	return func(o *options) {
+		o.marshal.TrailingNewline = true
+	}
+}
+
+// EscapeUnicode writes non-ASCII text as escapes, see
+// MarshalOptions.EscapeUnicode.
+func EscapeUnicode() Option {
+	return func(o *options) {
+		o.marshal.EscapeUnicode = true
+	}
+}
+
+// Lossless rejects values that would not round-trip, see
+// MarshalOptions.Lossless.
+func Lossless() Option {
+	return func(o *options) {
+		o.marshal.Lossless = true
+	}
+}
+
+// UseAliases writes registered type aliases, see MarshalOptions.UseAliases.
+func UseAliases() Option {
+	return func(o *options) {
+		o.marshal.UseAliases = true
+	}
+}
+
+// EmitTypeComments comments beautified output with the types of structs,
+// see MarshalOptions.EmitTypeComments.
+func EmitTypeComments() Option {
+	return func(o *options) {
+		o.marshal.EmitTypeComments = true
+	}
+}
+
+// TypeAnnotations writes the type names of structs held in interfaces,
+// see MarshalOptions.TypeAnnotations.
+func TypeAnnotations() Option {
+	return func(o *options) {
+		o.marshal.TypeAnnotations = true
+	}
+}
+
+// WithTypeRegistry decodes objects with a @type key into the types in
+// registry, see UnmarshalOptions.TypeRegistry.
+func WithTypeRegistry(registry map[string]reflect.Type) Option {
+	return func(o *options) {
+		o.unmarshal.TypeRegistry = registry
+	}
+}
+
+// WithOrderedMaps decodes objects held in interfaces as *OrderedMap, see
+// UnmarshalOptions.OrderedMaps.
+func WithOrderedMaps() Option {
+	return func(o *options) {
+		o.unmarshal.OrderedMaps = true
+	}
+}
+
+// WithTimeFormat writes and reads time.Time values in layout, see
+// MarshalOptions.TimeFormat.
+func WithTimeFormat(layout string) Option {
+	return func(o *options) {
+		o.marshal.TimeFormat = layout
+		o.unmarshal.TimeFormat = layout
+	}
+}
+
+// WithFieldNameMapper names untagged struct fields by mapName when
+// encoding and decoding, see MarshalOptions.FieldNameMapper.
+func WithFieldNameMapper(mapName func(string) string) Option {
+	return func(o *options) {
+		o.marshal.FieldNameMapper = mapName
+		o.unmarshal.FieldNameMapper = mapName
+	}
+}
+
+// SkipValidation decodes without calling ValidateGOD methods, see
+// UnmarshalOptions.SkipValidation.
+func SkipValidation() Option {
+	return func(o *options) {
+		o.unmarshal.SkipValidation = true
+	}
+}
+
+// FlattenKeys decodes objects nested in url.Values and other maps of
+// string lists into dotted keys, see UnmarshalOptions.FlattenKeys.
+func FlattenKeys() Option {
+	return func(o *options) {
+		o.unmarshal.FlattenKeys = true
+	}
+}
+
+// OnColumnMismatch sets UnmarshalOptions.OnColumnMismatch to policy and,
+// for WarnColumns, UnmarshalOptions.ColumnMismatches to mismatches.
+func OnColumnMismatch(policy ColumnPolicy, mismatches *[]ColumnMismatch) Option {
+	return func(o *options) {
+		o.unmarshal.OnColumnMismatch = policy
+		o.unmarshal.ColumnMismatches = mismatches
+	}
+}
+
+// WithJSONRawMessages encodes and decodes json.RawMessage values as mode
+// says, see JSONRawMode.
+func WithJSONRawMessages(mode JSONRawMode) Option {
+	return func(o *options) {
+		o.marshal.JSONRawMessages = mode
+		o.unmarshal.JSONRawMessages = mode
+	}
+}
+
+// WithEncodeHook sets MarshalOptions.FieldHook.
+func WithEncodeHook(h FieldHook) Option {
+	return func(o *options) {
+		o.marshal.FieldHook = h
+	}
+}
+
+// WithDecodeHook sets UnmarshalOptions.FieldHook.
+func WithDecodeHook(h FieldHook) Option {
+	return func(o *options) {
+		o.unmarshal.FieldHook = h
+	}
+}
+
+// WithIncludeResolver resolves @include directives with resolve, see
+// UnmarshalOptions.IncludeResolver.
+func WithIncludeResolver(resolve func(path string) ([]byte, error)) Option {
+	return func(o *options) {
+		o.unmarshal.IncludeResolver = resolve
+	}
+}
+
+// WithIncludeFS resolves @include directives in fsys, see
+// UnmarshalOptions.IncludeFS.
+func WithIncludeFS(fsys fs.FS) Option {
+	return func(o *options) {
+		o.unmarshal.IncludeFS = fsys
+	}
+}
+
+// DisallowTrailingData rejects input with anything but whitespace after
+// the root object, see UnmarshalOptions.DisallowTrailingData.
+func DisallowTrailingData() Option {
+	return func(o *options) {
+		o.unmarshal.DisallowTrailingData = true
+	}
+}
+
+// UseNumber decodes numbers into interface{} values as a Number, see
+// UnmarshalOptions.UseNumber.
+func UseNumber() Option {
+	return func(o *options) {
+		o.unmarshal.UseNumber = true
+	}
+}
+
+// InternStrings shares the memory of repeated keys and short strings, see
+// UnmarshalOptions.InternStrings.
+func InternStrings() Option {
+	return func(o *options) {
+		o.unmarshal.InternStrings = true
+	}
+}
+
+// WithMaxTokenSize bounds the size of any single token, see
+// UnmarshalOptions.MaxTokenSize.
+func WithMaxTokenSize(n int) Option {
+	return func(o *options) {
+		o.unmarshal.MaxTokenSize = n
+	}
+}
+
+// WithProgress reports decoding progress to f, e.g. for a progress bar.
+// See UnmarshalOptions.Progress.
+func WithProgress(f func(bytesDone, total int64)) Option {
+	return func(o *options) {
+		o.unmarshal.Progress = f
+	}
+}
+
+// DisallowUnknownFields rejects keys and columns no struct field takes,
+// see UnmarshalOptions.DisallowUnknownFields.
+func DisallowUnknownFields() Option {
+	return func(o *options) {
+		o.unmarshal.DisallowUnknownFields = true
+	}
+}
+
+// DisallowDuplicateKeys rejects objects with a key twice, see
+// UnmarshalOptions.DisallowDuplicateKeys.
+func DisallowDuplicateKeys() Option {
+	return func(o *options) {
+		o.unmarshal.DisallowDuplicateKeys = true
+	}
+}
+
+// RequireFields rejects objects lacking a field not tagged omitempty, see
+// UnmarshalOptions.RequireFields.
+func RequireFields() Option {
+	return func(o *options) {
+		o.unmarshal.RequireFields = true
+	}
+}
+
+// WithMaxDepth bounds how deeply values may nest, see
+// UnmarshalOptions.MaxDepth.
+func WithMaxDepth(n int) Option {
+	return func(o *options) {
+		o.unmarshal.MaxDepth = n
+	}
+}
+
+// WithMaxInputSize bounds the length of a document, see
+// UnmarshalOptions.MaxInputSize.
+func WithMaxInputSize(n int) Option {
+	return func(o *options) {
+		o.unmarshal.MaxInputSize = n
+	}
+}
@@ -0,0 +1,69 @@
+package god
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMarshalWith(t *testing.T) {
+	p := Person{Name: "Ann", Age: 3}
+	data, err := MarshalWith(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := Marshal(p); string(data) != string(want) {
+		t.Errorf("no options: got %s, want %s", data, want)
+	}
+
+	data, err = MarshalWith(p, Beautify(), WithEncodeHook(func(path string, v reflect.Value) (interface{}, bool) {
+		return "***", path == "name"
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  name=\"***\";\n  age=3;\n  addr=;\n}"; string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+}
+
+func TestUnmarshalWith(t *testing.T) {
+	var v map[string]interface{}
+	if err := UnmarshalWith([]byte(`{n=1.50}`), &v, UseNumber()); err != nil {
+		t.Fatal(err)
+	}
+	if v["n"] != Number("1.50") {
+		t.Errorf("UseNumber: got %#v", v["n"])
+	}
+
+	if err := UnmarshalWith([]byte(`{n=1} x`), &v, DisallowTrailingData()); err == nil {
+		t.Error("DisallowTrailingData: expected an error")
+	}
+
+	long := `{s="` + strings.Repeat("a", 100) + `"}`
+	if err := UnmarshalWith([]byte(long), &v, WithMaxTokenSize(10)); !errors.Is(err, ErrTokenTooLarge) {
+		t.Errorf("WithMaxTokenSize: got %v", err)
+	}
+
+	fsys := fstest.MapFS{"base.god": {Data: []byte(`{port=80}`)}}
+	var cfg struct {
+		Port int    `god:"port"`
+		Name string `god:"name"`
+	}
+	if err := UnmarshalWith([]byte(`{@include "base.god";name="svc"}`), &cfg, WithIncludeFS(fsys)); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != 80 || cfg.Name != "svc" {
+		t.Errorf("WithIncludeFS: got %+v", cfg)
+	}
+
+	var p Person
+	err := UnmarshalWith([]byte(`{name="x"}`), &p, WithDecodeHook(func(path string, v reflect.Value) (interface{}, bool) {
+		return strings.ToUpper(v.String()), path == "name"
+	}), Beautify())
+	if err != nil || p.Name != "X" {
+		t.Errorf("WithDecodeHook: got %+v, %v", p, err)
+	}
+}
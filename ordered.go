@@ -0,0 +1,175 @@
+package god
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// An OrderedMap is an object that keeps its keys in order: the order they
+// were first set in, or the order of the document it was decoded from.
+// Marshal writes its keys in that order, where the keys of a map are
+// sorted, so that a document decoded into an OrderedMap, changed and
+// encoded again keeps its layout. The zero value is an empty map ready to
+// use.
+//
+// Values are decoded as into an interface{}, except that objects nested
+// in an OrderedMap are decoded as *OrderedMap too. Decoding into a
+// non-empty OrderedMap replaces the values of keys it already has, in
+// place, and appends the others. UnmarshalOptions.OrderedMaps decodes
+// every object held in an interface{} as an *OrderedMap.
+type OrderedMap struct {
+	pairs []orderedPair
+	index map[string]int
+}
+
+type orderedPair struct {
+	key   string
+	value interface{}
+}
+
+var orderedMapType = reflect.TypeOf(OrderedMap{})
+
+// Len returns the number of keys in m.
+func (m *OrderedMap) Len() int {
+	return len(m.pairs)
+}
+
+// Get returns the value of key and whether m has it.
+func (m *OrderedMap) Get(key string) (interface{}, bool) {
+	i, ok := m.index[key]
+	if !ok {
+		return nil, false
+	}
+	return m.pairs[i].value, true
+}
+
+// Set sets the value of key. A new key goes after the others; a key m
+// already has keeps its place.
+func (m *OrderedMap) Set(key string, value interface{}) {
+	if i, ok := m.index[key]; ok {
+		m.pairs[i].value = value
+		return
+	}
+	if m.index == nil {
+		m.index = make(map[string]int)
+	}
+	m.index[key] = len(m.pairs)
+	m.pairs = append(m.pairs, orderedPair{key, value})
+}
+
+// Delete removes key from m, if it is there.
+func (m *OrderedMap) Delete(key string) {
+	i, ok := m.index[key]
+	if !ok {
+		return
+	}
+	delete(m.index, key)
+	m.pairs = append(m.pairs[:i], m.pairs[i+1:]...)
+	for ; i < len(m.pairs); i++ {
+		m.index[m.pairs[i].key] = i
+	}
+}
+
+// Keys returns the keys of m in order.
+func (m *OrderedMap) Keys() []string {
+	keys := make([]string, len(m.pairs))
+	for i, pair := range m.pairs {
+		keys[i] = pair.key
+	}
+	return keys
+}
+
+// isEmptyOrderedMap reports whether the OrderedMap in v has no keys.
+func isEmptyOrderedMap(v reflect.Value) bool {
+	return v.FieldByName("pairs").Len() == 0
+}
+
+// encodeOrderedMap writes the OrderedMap v as an object.
+func encodeOrderedMap(b *encodeState, v reflect.Value, level int, compact bool) error {
+	if !v.CanInterface() {
+		return errors.New("cannot encode an unexported OrderedMap")
+	}
+	m := v.Interface().(OrderedMap)
+	return writeObject(b, m.Keys(), level, compact, "", func(i int) error {
+		mark := b.pushKey(m.pairs[i].key)
+		err := encodeValue(b, reflect.ValueOf(&m.pairs[i].value).Elem(), level+1, compact)
+		b.pop(mark)
+		return err
+	})
+}
+
+// decodeOrderedMap decodes an object into the OrderedMap target, with its
+// nested objects decoded as *OrderedMap.
+func decodeOrderedMap(p *parser, target reflect.Value) error {
+	if p.peek() != '{' {
+		return fmt.Errorf("expected '{' for OrderedMap, got '%c'", p.peek())
+	}
+	p.next() // consume '{'
+	p.skipSpaces()
+	p.depth++
+	defer func() { p.depth-- }()
+
+	ordered := p.orderedMaps
+	p.orderedMaps = true
+	defer func() { p.orderedMaps = ordered }()
+
+	m := target.Addr().Interface().(*OrderedMap)
+	for !p.eof() && p.peek() != '}' {
+		if err := p.checkpoint(); err != nil {
+			return err
+		}
+		start := p.pos
+		key, quoted, err := p.readKey()
+		if err != nil {
+			return err
+		}
+		p.skipSpaces()
+
+		// Skip stray semicolons. An empty key must be quoted, as "".
+		if key == "" && !quoted {
+			if p.peek() != ';' {
+				if err := p.nakedInPairs(key, quoted, start); err != nil {
+					return err
+				}
+				return fmt.Errorf("expected key at position %d, got '%c'", p.pos, p.peek())
+			}
+			p.next()
+			p.skipSpaces()
+			continue
+		}
+		if !quoted && key == includeDirective {
+			if err := p.include(target, decodeOrderedMap); err != nil {
+				return err
+			}
+			continue
+		}
+		if p.peek() != '=' {
+			if err := p.nakedInPairs(key, quoted, start); err != nil {
+				return err
+			}
+			return fmt.Errorf("expected '=' after key '%s', got '%c' at position %d", key, p.peek(), p.pos)
+		}
+		p.next() // consume '='
+
+		var value interface{}
+		mark := p.pushKey(key)
+		err = decodeValue(p, reflect.ValueOf(&value).Elem())
+		p.pop(mark)
+		if err != nil {
+			return err
+		}
+		m.Set(key, value)
+
+		p.skipSpaces()
+		if p.peek() == ';' {
+			p.next()
+		}
+		p.skipSpaces()
+	}
+	if p.peek() != '}' {
+		return errors.New("expected '}' at end of map")
+	}
+	p.next() // consume '}'
+	return nil
+}
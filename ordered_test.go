@@ -0,0 +1,92 @@
+package god
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOrderedMapMethods(t *testing.T) {
+	var m OrderedMap
+	m.Set("z", 1)
+	m.Set("a", 2)
+	m.Set("m", 3)
+	m.Set("z", 4)
+	if got := m.Keys(); !reflect.DeepEqual(got, []string{"z", "a", "m"}) {
+		t.Errorf("Keys() = %v", got)
+	}
+	if v, ok := m.Get("z"); !ok || v != 4 {
+		t.Errorf("Get(z) = %v, %v", v, ok)
+	}
+	m.Delete("a")
+	m.Delete("missing")
+	if v, ok := m.Get("m"); !ok || v != 3 || m.Len() != 2 {
+		t.Errorf("after Delete: Get(m) = %v, %v; Len() = %d", v, ok, m.Len())
+	}
+	if _, ok := m.Get("a"); ok {
+		t.Error("deleted key still there")
+	}
+}
+
+func TestOrderedMapRoundTrip(t *testing.T) {
+	doc := `{version=2;name="svc";zone="eu";limits={rps=100;burst=1.50;ips=["b","a"]};enabled=true;notes=;a={}}`
+	var m OrderedMap
+	if err := UnmarshalWith([]byte(doc), &m, UseNumber()); err != nil {
+		t.Fatal(err)
+	}
+	limits, _ := m.Get("limits")
+	limits.(*OrderedMap).Set("rps", 200)
+	m.Set("added", "x")
+
+	data, err := Marshal(&m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{version=2;name="svc";zone="eu";limits={rps=200;burst=1.50;ips=["b","a"]};enabled=true;notes=;a={};added="x"}`
+	if string(data) != want {
+		t.Errorf("got  %s\nwant %s", data, want)
+	}
+
+	// Beautified output keeps the order too.
+	pretty, err := MarshalBeautify(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again OrderedMap
+	if err := Unmarshal(pretty, &again); err != nil {
+		t.Fatal(err)
+	}
+	if got := again.Keys(); !reflect.DeepEqual(got, m.Keys()) {
+		t.Errorf("keys %v, want %v", got, m.Keys())
+	}
+}
+
+func TestWithOrderedMaps(t *testing.T) {
+	doc := []byte(`{b={y=1;x=2};a=[{d=1;c=2}]}`)
+	var v interface{}
+	if err := UnmarshalWith(doc, &v, WithOrderedMaps(), UseNumber()); err != nil {
+		t.Fatal(err)
+	}
+	root, ok := v.(*OrderedMap)
+	if !ok {
+		t.Fatalf("got %T, want *OrderedMap", v)
+	}
+	if got := root.Keys(); !reflect.DeepEqual(got, []string{"b", "a"}) {
+		t.Errorf("keys %v", got)
+	}
+	data, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(doc) {
+		t.Errorf("got %s, want %s", data, doc)
+	}
+
+	// A struct field of type OrderedMap keeps its order as well.
+	var s struct{ B OrderedMap }
+	if err := Unmarshal(doc, &s); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.B.Keys(); !reflect.DeepEqual(got, []string{"y", "x"}) {
+		t.Errorf("keys %v", got)
+	}
+}
@@ -0,0 +1,90 @@
+package god
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// UnmarshalFields decodes the values of the named top-level keys of the
+// GOD document in data into the matching fields of the struct v points
+// to, and leaves the other fields alone. fields are keys as written in the
+// document, a field's name or one of its aliases; a name that matches no
+// field of v is an error.
+//
+// Other keys are skipped without being decoded, and UnmarshalFields
+// returns as soon as every requested field has been set, without reading
+// the rest of the document, which is then not checked for errors. So that
+// the result does not depend on where decoding stops, each field is
+// decoded from the first key that names it and later duplicates are
+// skipped, whereas Unmarshal keeps the last. A requested key missing from
+// the document leaves its field as it is. @include directives are not
+// followed and are an error.
+func UnmarshalFields(data []byte, v interface{}, fields ...string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("UnmarshalFields target must be a non-nil pointer to a struct")
+	}
+	target := rv.Elem()
+	keys, err := decodeKeys(target.Type(), -1, -1, nil)
+	if err != nil {
+		return err
+	}
+	// pending holds the indexes of the fields still to be decoded.
+	pending := make(map[int]bool, len(fields))
+	for _, name := range fields {
+		i, ok := keys[name]
+		if !ok {
+			return fmt.Errorf("%v has no field for key %q", target.Type(), name)
+		}
+		pending[i] = true
+	}
+
+	p := UnmarshalOptions{}.newParser(data)
+	p.skipSpaces()
+	if p.peek() != '{' {
+		return fmt.Errorf("root must be an object '{...}', got '%c'", p.peek())
+	}
+	p.next()
+	p.depth++
+	for p.skipSpaces(); len(pending) > 0 && !p.eof() && p.peek() != '}'; p.skipSpaces() {
+		start := p.pos
+		key, quoted, err := p.readKey()
+		if err != nil {
+			return err
+		}
+		if !quoted && key == includeDirective {
+			return errors.New("UnmarshalFields does not follow @include")
+		}
+		p.skipSpaces()
+		if p.peek() != '=' {
+			if err := p.nakedInPairs(key, quoted, start); err != nil {
+				return err
+			}
+			return fmt.Errorf("expected '=' after key '%s'", key)
+		}
+		p.next() // consume '='
+
+		if i, ok := keys[key]; ok && pending[i] {
+			delete(pending, i)
+			mark := p.pushKey(key)
+			err = p.decodeField(target.Field(i), func(v reflect.Value) error {
+				return decodeValue(p, v)
+			})
+			p.pop(mark)
+		} else {
+			err = skipValue(p)
+		}
+		if err != nil {
+			return err
+		}
+		p.skipSpaces()
+		if p.peek() == ';' {
+			p.next()
+		}
+	}
+	if len(pending) > 0 && p.peek() != '}' {
+		return errors.New("expected '}' at end of struct")
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package god
+
+import (
+	"strings"
+	"testing"
+)
+
+type wideHeader struct {
+	Method string
+	Path   string `god:"path,alias=url"`
+	Status int
+	Body   string
+}
+
+func TestUnmarshalFields(t *testing.T) {
+	doc := []byte(`{method="GET";body={skipped=[1,"}"]};url="/x";status=200;method="POST"}`)
+	var h wideHeader
+	if err := UnmarshalFields(doc, &h, "method", "path"); err != nil {
+		t.Fatal(err)
+	}
+	// The first method wins, and status is left alone.
+	if h != (wideHeader{Method: "GET", Path: "/x"}) {
+		t.Errorf("got %+v", h)
+	}
+
+	// Decoding stops once the requested fields are set, so the broken
+	// rest of the document goes unread.
+	h = wideHeader{}
+	if err := UnmarshalFields([]byte(`{status=404;body=[oops`), &h, "status"); err != nil {
+		t.Fatal(err)
+	}
+	if h.Status != 404 {
+		t.Errorf("got %+v", h)
+	}
+
+	// A missing key leaves its field as it is.
+	h = wideHeader{Body: "kept"}
+	if err := UnmarshalFields([]byte(`{status=1}`), &h, "status", "body"); err != nil {
+		t.Fatal(err)
+	}
+	if h != (wideHeader{Status: 1, Body: "kept"}) {
+		t.Errorf("got %+v", h)
+	}
+}
+
+func TestUnmarshalFieldsErrors(t *testing.T) {
+	var h wideHeader
+	err := UnmarshalFields([]byte(`{}`), &h, "nope")
+	if err == nil || !strings.Contains(err.Error(), `"nope"`) {
+		t.Errorf("unknown field: got %v", err)
+	}
+	if err := UnmarshalFields([]byte(`{}`), h, "path"); err == nil {
+		t.Error("non-pointer target: want error")
+	}
+	if err := UnmarshalFields([]byte(`{status="x"}`), &h, "status"); err == nil {
+		t.Error("bad value: want error")
+	}
+}
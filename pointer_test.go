@@ -0,0 +1,195 @@
+package god
+
+import (
+	"reflect"
+	"testing"
+)
+
+type optionalAddr struct {
+	Name string  `god:"name"`
+	Addr *string `god:"addr"`
+}
+
+func TestDecodePointerNullability(t *testing.T) {
+	existing := "kept"
+
+	// "" allocates a pointer to the empty string.
+	v := optionalAddr{Addr: &existing}
+	if err := Unmarshal([]byte(`{name="a";addr=""}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Addr == nil || *v.Addr != "" {
+		t.Errorf(`addr="": expected pointer to "", got %v`, v.Addr)
+	}
+
+	// \0 grounds the pointer to nil.
+	existing = "kept"
+	v = optionalAddr{Addr: &existing}
+	if err := Unmarshal([]byte(`{name="a";addr=\0}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Addr != nil {
+		t.Errorf(`addr=\0: expected nil, got %q`, *v.Addr)
+	}
+
+	// An absent value leaves the pointer untouched.
+	v = optionalAddr{Addr: &existing}
+	if err := Unmarshal([]byte(`{name="a";addr=;}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Addr != &existing {
+		t.Errorf("addr=: expected pointer to be left as-is, got %v", v.Addr)
+	}
+	v = optionalAddr{}
+	if err := Unmarshal([]byte(`{addr=}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Addr != nil {
+		t.Errorf("addr=: expected nil pointer to stay nil, got %q", *v.Addr)
+	}
+}
+
+type optionalScalars struct {
+	Enabled *bool   `god:"enabled"`
+	Count   *int    `god:"count"`
+	Label   *string `god:"label"`
+}
+
+func TestPointerScalarsRoundTrip(t *testing.T) {
+	f, zero, empty := false, 0, ""
+	yes, n, s := true, 7, "x"
+	cases := []struct {
+		name string
+		in   optionalScalars
+		want string
+	}{
+		{"nil", optionalScalars{}, `{enabled=\0;count=\0;label=\0}`},
+		{"zero", optionalScalars{&f, &zero, &empty}, `{enabled=false;count=0;label=""}`},
+		{"set", optionalScalars{&yes, &n, &s}, `{enabled=true;count=7;label="x"}`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			encoded, err := Marshal(c.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(encoded) != c.want {
+				t.Errorf("expected %s, got %s", c.want, encoded)
+			}
+			var out optionalScalars
+			if err := Unmarshal(encoded, &out); err != nil {
+				t.Fatal(err)
+			}
+			// DeepEqual compares what the pointers point to, and nil-ness.
+			if !reflect.DeepEqual(out, c.in) {
+				t.Errorf("round trip mismatch: %s decoded to %+v", encoded, out)
+			}
+		})
+	}
+}
+
+func TestPointerScalarsInTables(t *testing.T) {
+	zero, n := 0, 3
+	in := []optionalScalars{{Count: &zero}, {Count: &n}, {}}
+	encoded, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{(enabled,count,label:\0,0,\0;\0,3,\0;\0,\0,\0;)}`
+	if string(encoded) != want {
+		t.Errorf("expected %s, got %s", want, encoded)
+	}
+	var out []optionalScalars
+	if err := Unmarshal(encoded, &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 3 || out[0].Count == nil || *out[0].Count != 0 || *out[1].Count != 3 || out[2].Count != nil || out[0].Label != nil {
+		t.Errorf("unexpected rows: %+v", out)
+	}
+}
+
+type generatedMessage struct {
+	Labels *map[string]int `god:"labels"`
+	Names  *[]string       `god:"names"`
+	IDs    **[]int         `god:"ids"`
+}
+
+func TestPointerToCollections(t *testing.T) {
+	labels := map[string]int{"a": 1, "b": 2}
+	names := []string{"x", "y"}
+	emptyLabels := map[string]int{}
+	emptyNames := []string{}
+	ids := &[]int{7}
+	cases := []struct {
+		name string
+		v    generatedMessage
+		doc  string
+	}{
+		{"nil", generatedMessage{}, `{labels=\0;names=\0;ids=\0}`},
+		{"set", generatedMessage{Labels: &labels, Names: &names, IDs: &ids}, `{labels={a=1;b=2};names=["x","y"];ids=[7]}`},
+		{"empty", generatedMessage{Labels: &emptyLabels, Names: &emptyNames}, `{labels={};names=[];ids=\0}`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			data, err := Marshal(c.v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != c.doc {
+				t.Errorf("Marshal = %s, want %s", data, c.doc)
+			}
+			var out generatedMessage
+			if err := Unmarshal(data, &out); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(out, c.v) {
+				t.Errorf("round trip = %+v, want %+v", out, c.v)
+			}
+
+			// The same holds in table cells.
+			data, err = Marshal([]generatedMessage{c.v})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var rows []generatedMessage
+			if err := Unmarshal(data, &rows); err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != 1 || !reflect.DeepEqual(rows[0], c.v) {
+				t.Errorf("table round trip of %s = %+v, want %+v", data, rows, c.v)
+			}
+		})
+	}
+}
+
+func TestDecodeGroundedPointerRoot(t *testing.T) {
+	type T struct {
+		A int `god:"a"`
+	}
+	x := 5
+	q := &x
+	if err := Unmarshal([]byte(`{\0}`), &q); err != nil || q != nil {
+		t.Errorf("*int: got %v, %v, want nil", q, err)
+	}
+	var pp **T
+	if err := Unmarshal([]byte(`{ \0 }`), &pp); err != nil || pp != nil {
+		t.Errorf("**T: got %v, %v, want nil", pp, err)
+	}
+	p := &T{A: 1}
+	if err := Unmarshal([]byte(`{\0;}`), &p); err != nil || p != nil {
+		t.Errorf("*T: got %v, %v, want nil", p, err)
+	}
+	if err := Unmarshal([]byte(`{\0;a=1}`), &p); err == nil {
+		t.Error("expected an error for a grounded root with pairs")
+	}
+
+	// A nil pointer marshals to a grounded root and back.
+	data, err := Marshal((*T)(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p = &T{}
+	if err := Unmarshal(data, &p); err != nil || p != nil {
+		t.Errorf("round trip of nil via %s: got %v, %v", data, p, err)
+	}
+}
@@ -0,0 +1,100 @@
+package god
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Struct values held in interfaces, such as the elements of a []Shape,
+// lose their type when encoded. With MarshalOptions.TypeAnnotations each
+// is written with its type name under the key @type, as in
+// {@type="Circle";radius=5}, and UnmarshalOptions.TypeRegistry maps the
+// name back to the type when decoding into an interface.
+
+// typeKey is the key that holds the type name of an annotated struct.
+const typeKey = "@type"
+
+// annotatedType returns the name to write under typeKey for the value
+// held in the interface v, or "" if it gets none: TypeAnnotations is off,
+// or the value is not a named struct encoded field by field.
+func (b *encodeState) annotatedType(v reflect.Value) string {
+	if !b.typeAnnotations || v.Kind() != reflect.Interface || v.IsNil() {
+		return ""
+	}
+	t := v.Elem().Type()
+	if t.Kind() == reflect.Ptr {
+		if v.Elem().IsNil() {
+			return ""
+		}
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t.Name() == "" || t == tableType || marshalsItself(t) {
+		return ""
+	}
+	return t.Name()
+}
+
+// registeredType returns the type that TypeRegistry holds for the object
+// at p, which must start with '{', if its first key is @type. It leaves p
+// where it was.
+func (p *parser) registeredType() (reflect.Type, error) {
+	if len(p.typeRegistry) == 0 {
+		return nil, nil
+	}
+	start := p.pos
+	var stats UnmarshalStats
+	if p.stats != nil {
+		stats = *p.stats
+	}
+	defer func() {
+		p.pos = start
+		if p.stats != nil {
+			*p.stats = stats
+		}
+	}()
+
+	p.next() // consume '{'
+	if key, _, err := p.readKey(); err != nil || key != typeKey {
+		return nil, nil
+	}
+	p.skipSpaces()
+	if p.peek() != '=' {
+		return nil, nil
+	}
+	p.next()
+	p.skipSpaces()
+	name, err := parseStringValue(p)
+	if err != nil {
+		return nil, err
+	}
+	t, ok := p.typeRegistry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown %s %q at pos %d", typeKey, name, start)
+	}
+	return t, nil
+}
+
+// decodeRegistered decodes the object at p into a new value of type t.
+func decodeRegistered(p *parser, t reflect.Type) (interface{}, error) {
+	v := reflect.New(t).Elem()
+	if err := decodeValue(p, v); err != nil {
+		return nil, err
+	}
+	return v.Interface(), nil
+}
+
+// assignInterface stores val in the interface target, or a pointer to it
+// if only the pointer implements the interface.
+func assignInterface(target reflect.Value, val interface{}) error {
+	rv := reflect.ValueOf(val)
+	if !rv.Type().AssignableTo(target.Type()) {
+		if !reflect.PtrTo(rv.Type()).AssignableTo(target.Type()) {
+			return fmt.Errorf("cannot decode %v into %v", rv.Type(), target.Type())
+		}
+		ptr := reflect.New(rv.Type())
+		ptr.Elem().Set(rv)
+		rv = ptr
+	}
+	target.Set(rv)
+	return nil
+}
@@ -0,0 +1,80 @@
+package god
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type shape interface{ Area() float64 }
+
+type circle struct{ Radius float64 }
+
+func (c circle) Area() float64 { return math.Pi * c.Radius * c.Radius }
+
+// square implements shape on its pointer, so it decodes as a *square.
+type square struct{ Side float64 }
+
+func (s *square) Area() float64 { return s.Side * s.Side }
+
+type drawing struct {
+	Title  string
+	Shapes []shape
+	Main   shape
+}
+
+var shapeRegistry = map[string]reflect.Type{
+	"circle": reflect.TypeOf(circle{}),
+	"square": reflect.TypeOf(square{}),
+}
+
+func TestTypeAnnotations(t *testing.T) {
+	in := drawing{Title: "d", Shapes: []shape{circle{5}, &square{3}, circle{}}, Main: &square{1}}
+	data, err := MarshalOptions{TypeAnnotations: true}.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{title="d";shapes=[{@type="circle";radius=5},{@type="square";side=3},{@type="circle";radius=}];main={@type="square";side=1}}`
+	if string(data) != want {
+		t.Fatalf("got %s, want %s", data, want)
+	}
+
+	var out drawing
+	if err := (UnmarshalOptions{TypeRegistry: shapeRegistry}).Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("got %#v, want %#v", out, in)
+	}
+
+	// interface{} targets get the registered types too.
+	var generic map[string]interface{}
+	if err := UnmarshalWith(data, &generic, WithTypeRegistry(shapeRegistry)); err != nil {
+		t.Fatal(err)
+	}
+	if got := generic["shapes"].([]interface{})[0]; got != (circle{5}) {
+		t.Errorf("got %#v", got)
+	}
+
+	// Without a registry @type is an ordinary key.
+	if err := Unmarshal(data, &generic); err != nil {
+		t.Fatal(err)
+	}
+	if got := generic["main"].(map[string]interface{})["@type"]; got != "square" {
+		t.Errorf("got %#v", got)
+	}
+}
+
+func TestTypeRegistryErrors(t *testing.T) {
+	o := UnmarshalOptions{TypeRegistry: shapeRegistry}
+	var out drawing
+	err := o.Unmarshal([]byte(`{main={@type="hexagon";side=1}}`), &out)
+	if err == nil || !strings.Contains(err.Error(), `"hexagon"`) {
+		t.Errorf("unknown type: got %v", err)
+	}
+	o.TypeRegistry = map[string]reflect.Type{"circle": reflect.TypeOf(drawing{})}
+	if err := o.Unmarshal([]byte(`{main={@type="circle"}}`), &out); err == nil {
+		t.Error("type not implementing the interface: want error")
+	}
+}
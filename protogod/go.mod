@@ -0,0 +1,7 @@
+module github.com/vinayakgupta29/god/protogod
+
+go 1.21
+
+require github.com/vinayakgupta29/god v0.1.0
+
+require google.golang.org/protobuf v1.34.2
@@ -0,0 +1,341 @@
+// Package protogod encodes protobuf messages as GOD by walking their
+// protoreflect descriptors instead of the generated Go structs, whose
+// internal state and oneof wrappers the reflection encoder cannot follow.
+//
+// Fields are keyed by their proto names and mapped as follows:
+//
+//   - scalars become the matching GOD numbers, strings and booleans, and
+//     bytes become base64 strings
+//   - enums are written as their value names
+//   - repeated message fields become tables, other repeated fields lists
+//   - map fields become objects
+//   - unset fields are grounded to their zero value, except scalars with
+//     explicit presence (optional fields and oneof members), whose unset
+//     state is written as \0
+//
+// It lives in its own module so that the core god package does not depend
+// on protobuf.
+package protogod
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/vinayakgupta29/god"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Marshal returns the compact GOD encoding of m.
+func Marshal(m proto.Message) ([]byte, error) {
+	return marshal(m, god.Marshal)
+}
+
+// MarshalBeautify returns the indented GOD encoding of m.
+func MarshalBeautify(m proto.Message) ([]byte, error) {
+	return marshal(m, god.MarshalBeautify)
+}
+
+// Unmarshal decodes the GOD document in data into m, which is reset first.
+func Unmarshal(data []byte, m proto.Message) error {
+	msg := m.ProtoReflect()
+	t, err := shapeOf(msg.Descriptor())
+	if err != nil {
+		return err
+	}
+	v := reflect.New(t)
+	if err := god.Unmarshal(data, v.Interface()); err != nil {
+		return err
+	}
+	proto.Reset(m)
+	return fromGo(v.Elem(), msg)
+}
+
+func marshal(m proto.Message, encode func(interface{}) ([]byte, error)) ([]byte, error) {
+	msg := m.ProtoReflect()
+	t, err := shapeOf(msg.Descriptor())
+	if err != nil {
+		return nil, err
+	}
+	v, err := toGo(msg, t)
+	if err != nil {
+		return nil, err
+	}
+	return encode(v.Interface())
+}
+
+// shapes caches the struct type built for each message descriptor.
+var shapes sync.Map // protoreflect.MessageDescriptor -> reflect.Type
+
+// shapeOf returns a struct type mirroring md, with one field per proto field
+// in declaration order, tagged with the proto field name.
+func shapeOf(md protoreflect.MessageDescriptor) (reflect.Type, error) {
+	return buildShape(md, map[protoreflect.FullName]bool{})
+}
+
+func buildShape(md protoreflect.MessageDescriptor, visiting map[protoreflect.FullName]bool) (reflect.Type, error) {
+	if t, ok := shapes.Load(md); ok {
+		return t.(reflect.Type), nil
+	}
+	if visiting[md.FullName()] {
+		return nil, fmt.Errorf("protogod: recursive message %s is not supported", md.FullName())
+	}
+	visiting[md.FullName()] = true
+	defer delete(visiting, md.FullName())
+
+	fields := md.Fields()
+	sf := make([]reflect.StructField, fields.Len())
+	for i := range sf {
+		fd := fields.Get(i)
+		t, err := fieldType(fd, visiting)
+		if err != nil {
+			return nil, err
+		}
+		sf[i] = reflect.StructField{
+			Name: fmt.Sprintf("F%d", i),
+			Type: t,
+			Tag:  reflect.StructTag(fmt.Sprintf(`god:%q`, fd.Name())),
+		}
+	}
+	t := reflect.StructOf(sf)
+	shapes.Store(md, t)
+	return t, nil
+}
+
+func fieldType(fd protoreflect.FieldDescriptor, visiting map[protoreflect.FullName]bool) (reflect.Type, error) {
+	switch {
+	case fd.IsMap():
+		k, err := singularType(fd.MapKey(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		v, err := singularType(fd.MapValue(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return reflect.MapOf(k, v), nil
+	case fd.IsList():
+		t, err := singularType(fd, visiting)
+		if err != nil {
+			return nil, err
+		}
+		return reflect.SliceOf(t), nil
+	}
+	t, err := singularType(fd, visiting)
+	if err != nil {
+		return nil, err
+	}
+	if fd.HasPresence() && fd.Message() == nil {
+		return reflect.PtrTo(t), nil
+	}
+	return t, nil
+}
+
+func singularType(fd protoreflect.FieldDescriptor, visiting map[protoreflect.FullName]bool) (reflect.Type, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return reflect.TypeOf(false), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return reflect.TypeOf(int32(0)), nil
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return reflect.TypeOf(int64(0)), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return reflect.TypeOf(uint32(0)), nil
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return reflect.TypeOf(uint64(0)), nil
+	case protoreflect.FloatKind:
+		return reflect.TypeOf(float32(0)), nil
+	case protoreflect.DoubleKind:
+		return reflect.TypeOf(float64(0)), nil
+	case protoreflect.StringKind, protoreflect.BytesKind, protoreflect.EnumKind:
+		return reflect.TypeOf(""), nil
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return buildShape(fd.Message(), visiting)
+	}
+	return nil, fmt.Errorf("protogod: unsupported field kind %v for %s", fd.Kind(), fd.FullName())
+}
+
+// toGo copies the fields set in m into a new value of its shape t.
+func toGo(m protoreflect.Message, t reflect.Type) (reflect.Value, error) {
+	v := reflect.New(t).Elem()
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if !m.Has(fd) {
+			continue
+		}
+		f := v.Field(i)
+		pv := m.Get(fd)
+		switch {
+		case fd.IsMap():
+			f.Set(reflect.MakeMap(f.Type()))
+			var err error
+			pv.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+				var gk, gv reflect.Value
+				if gk, err = goValue(fd.MapKey(), k.Value(), f.Type().Key()); err != nil {
+					return false
+				}
+				if gv, err = goValue(fd.MapValue(), mv, f.Type().Elem()); err != nil {
+					return false
+				}
+				f.SetMapIndex(gk, gv)
+				return true
+			})
+			if err != nil {
+				return v, err
+			}
+		case fd.IsList():
+			list := pv.List()
+			f.Set(reflect.MakeSlice(f.Type(), list.Len(), list.Len()))
+			for j := 0; j < list.Len(); j++ {
+				ev, err := goValue(fd, list.Get(j), f.Type().Elem())
+				if err != nil {
+					return v, err
+				}
+				f.Index(j).Set(ev)
+			}
+		case f.Kind() == reflect.Ptr:
+			ev, err := goValue(fd, pv, f.Type().Elem())
+			if err != nil {
+				return v, err
+			}
+			f.Set(reflect.New(f.Type().Elem()))
+			f.Elem().Set(ev)
+		default:
+			ev, err := goValue(fd, pv, f.Type())
+			if err != nil {
+				return v, err
+			}
+			f.Set(ev)
+		}
+	}
+	return v, nil
+}
+
+// goValue converts a singular proto value of field fd to type t.
+func goValue(fd protoreflect.FieldDescriptor, pv protoreflect.Value, t reflect.Type) (reflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		// The zero enum value is grounded like any other zero value.
+		n := pv.Enum()
+		if n == 0 {
+			return reflect.Zero(t), nil
+		}
+		if ev := fd.Enum().Values().ByNumber(n); ev != nil {
+			return reflect.ValueOf(string(ev.Name())), nil
+		}
+		return reflect.ValueOf(strconv.Itoa(int(n))), nil
+	case protoreflect.BytesKind:
+		return reflect.ValueOf(base64.StdEncoding.EncodeToString(pv.Bytes())), nil
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return toGo(pv.Message(), t)
+	}
+	return reflect.ValueOf(pv.Interface()).Convert(t), nil
+}
+
+// fromGo sets the fields of m from v, a value of m's shape. Zero values
+// leave fields unset.
+func fromGo(v reflect.Value, m protoreflect.Message) error {
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		f := v.Field(i)
+		if f.IsZero() {
+			continue
+		}
+		switch {
+		case fd.IsMap():
+			mm := m.Mutable(fd).Map()
+			iter := f.MapRange()
+			for iter.Next() {
+				k, err := protoValue(fd.MapKey(), iter.Key(), protoreflect.Value{})
+				if err != nil {
+					return err
+				}
+				var val protoreflect.Value
+				if fd.MapValue().Message() != nil {
+					val = mm.NewValue()
+				}
+				if val, err = protoValue(fd.MapValue(), iter.Value(), val); err != nil {
+					return err
+				}
+				mm.Set(k.MapKey(), val)
+			}
+		case fd.IsList():
+			list := m.Mutable(fd).List()
+			for j := 0; j < f.Len(); j++ {
+				var elem protoreflect.Value
+				if fd.Message() != nil {
+					elem = list.NewElement()
+				}
+				elem, err := protoValue(fd, f.Index(j), elem)
+				if err != nil {
+					return err
+				}
+				list.Append(elem)
+			}
+		case fd.Message() != nil:
+			if err := fromGo(f, m.Mutable(fd).Message()); err != nil {
+				return err
+			}
+		default:
+			if f.Kind() == reflect.Ptr {
+				f = f.Elem()
+			}
+			pv, err := protoValue(fd, f, protoreflect.Value{})
+			if err != nil {
+				return err
+			}
+			m.Set(fd, pv)
+		}
+	}
+	return nil
+}
+
+// protoValue converts f to a singular proto value of field fd. For message
+// fields, msg is a new message value to fill in.
+func protoValue(fd protoreflect.FieldDescriptor, f reflect.Value, msg protoreflect.Value) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(f.Bool()), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.ValueOfInt32(int32(f.Int())), nil
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.ValueOfInt64(f.Int()), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return protoreflect.ValueOfUint32(uint32(f.Uint())), nil
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return protoreflect.ValueOfUint64(f.Uint()), nil
+	case protoreflect.FloatKind:
+		return protoreflect.ValueOfFloat32(float32(f.Float())), nil
+	case protoreflect.DoubleKind:
+		return protoreflect.ValueOfFloat64(f.Float()), nil
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(f.String()), nil
+	case protoreflect.BytesKind:
+		b, err := base64.StdEncoding.DecodeString(f.String())
+		if err != nil {
+			return msg, fmt.Errorf("protogod: %s: %v", fd.FullName(), err)
+		}
+		return protoreflect.ValueOfBytes(b), nil
+	case protoreflect.EnumKind:
+		name := f.String()
+		if name == "" {
+			return protoreflect.ValueOfEnum(0), nil
+		}
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(name)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		n, err := strconv.ParseInt(name, 10, 32)
+		if err != nil {
+			return msg, fmt.Errorf("protogod: %s: unknown enum value %q", fd.FullName(), name)
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), nil
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return msg, fromGo(f, msg.Message())
+	}
+	return msg, fmt.Errorf("protogod: unsupported field kind %v for %s", fd.Kind(), fd.FullName())
+}
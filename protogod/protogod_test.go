@@ -0,0 +1,209 @@
+package protogod
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// companyFile builds the descriptor of testdata/company.proto.
+func companyFile(t *testing.T) protoreflect.FileDescriptor {
+	t.Helper()
+	field := func(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(num),
+			Type:     typ.Enum(),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	repeated := func(f *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+		f.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		return f
+	}
+	inOneof := func(f *descriptorpb.FieldDescriptorProto, i int32) *descriptorpb.FieldDescriptorProto {
+		f.OneofIndex = proto.Int32(i)
+		return f
+	}
+	const (
+		str   = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		i32   = descriptorpb.FieldDescriptorProto_TYPE_INT32
+		i64   = descriptorpb.FieldDescriptorProto_TYPE_INT64
+		msg   = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+		enum  = descriptorpb.FieldDescriptorProto_TYPE_ENUM
+		bytes = descriptorpb.FieldDescriptorProto_TYPE_BYTES
+	)
+	floors := inOneof(field("floors", 7, i32, ""), 1)
+	floors.Proto3Optional = proto.Bool(true)
+
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("company.proto"),
+		Package: proto.String("protogod.test"),
+		Syntax:  proto.String("proto3"),
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Role"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("ROLE_UNSPECIFIED"), Number: proto.Int32(0)},
+				{Name: proto.String("ROLE_ENGINEER"), Number: proto.Int32(1)},
+				{Name: proto.String("ROLE_MANAGER"), Number: proto.Int32(2)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Employee"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("name", 1, str, ""),
+					field("age", 2, i32, ""),
+					field("role", 3, enum, ".protogod.test.Role"),
+				},
+			},
+			{
+				Name: proto.String("Address"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("city", 1, str, ""),
+					field("zip", 2, str, ""),
+				},
+			},
+			{
+				Name: proto.String("Company"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("name", 1, str, ""),
+					field("founded", 2, i64, ""),
+					repeated(field("employees", 3, msg, ".protogod.test.Employee")),
+					repeated(field("budgets", 4, msg, ".protogod.test.Company.BudgetsEntry")),
+					repeated(field("tags", 5, str, "")),
+					field("hq", 6, msg, ".protogod.test.Address"),
+					floors,
+					field("logo", 8, bytes, ""),
+					inOneof(field("email", 9, str, ""), 0),
+					inOneof(field("phone", 10, str, ""), 0),
+				},
+				NestedType: []*descriptorpb.DescriptorProto{{
+					Name: proto.String("BudgetsEntry"),
+					Field: []*descriptorpb.FieldDescriptorProto{
+						field("key", 1, str, ""),
+						field("value", 2, i32, ""),
+					},
+					Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+				}},
+				OneofDecl: []*descriptorpb.OneofDescriptorProto{
+					{Name: proto.String("contact")},
+					{Name: proto.String("_floors")},
+				},
+			},
+		},
+	}
+	fd, err := protodesc.NewFile(fdp, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fd
+}
+
+func newCompany(t *testing.T) *dynamicpb.Message {
+	t.Helper()
+	fd := companyFile(t)
+	company := dynamicpb.NewMessage(fd.Messages().ByName("Company"))
+	desc := company.Descriptor()
+	get := func(name string) protoreflect.FieldDescriptor { return desc.Fields().ByName(protoreflect.Name(name)) }
+
+	company.Set(get("name"), protoreflect.ValueOfString("TechCorp"))
+	company.Set(get("founded"), protoreflect.ValueOfInt64(2020))
+
+	employees := company.Mutable(get("employees")).List()
+	for _, e := range []struct {
+		name string
+		age  int32
+		role protoreflect.EnumNumber
+	}{{"Alice", 30, 2}, {"Bob", 25, 0}} {
+		v := employees.NewElement()
+		m := v.Message()
+		ef := m.Descriptor().Fields()
+		m.Set(ef.ByName("name"), protoreflect.ValueOfString(e.name))
+		m.Set(ef.ByName("age"), protoreflect.ValueOfInt32(e.age))
+		m.Set(ef.ByName("role"), protoreflect.ValueOfEnum(e.role))
+		employees.Append(v)
+	}
+
+	budgets := company.Mutable(get("budgets")).Map()
+	budgets.Set(protoreflect.ValueOfString("eng").MapKey(), protoreflect.ValueOfInt32(100))
+	budgets.Set(protoreflect.ValueOfString("ops").MapKey(), protoreflect.ValueOfInt32(40))
+
+	tags := company.Mutable(get("tags")).List()
+	tags.Append(protoreflect.ValueOfString("b2b"))
+	tags.Append(protoreflect.ValueOfString("saas"))
+
+	hq := company.Mutable(get("hq")).Message()
+	hq.Set(hq.Descriptor().Fields().ByName("city"), protoreflect.ValueOfString("Boston"))
+
+	company.Set(get("floors"), protoreflect.ValueOfInt32(0))
+	company.Set(get("logo"), protoreflect.ValueOfBytes([]byte{0xde, 0xad}))
+	company.Set(get("phone"), protoreflect.ValueOfString("555-0100"))
+	return company
+}
+
+func TestMarshal(t *testing.T) {
+	data, err := Marshal(newCompany(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{name="TechCorp";founded=2020;employees=(name,age,role:"Alice",30,"ROLE_MANAGER";"Bob",25,;);` +
+		`budgets={eng=100;ops=40};tags=["b2b","saas"];hq={city="Boston";zip=};floors=0;logo="3q0=";email=\0;phone="555-0100"}`
+	if string(data) != want {
+		t.Errorf("unexpected encoding:\nwant %s\ngot  %s", want, data)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	in := newCompany(t)
+	for _, marshal := range []func(proto.Message) ([]byte, error){Marshal, MarshalBeautify} {
+		data, err := marshal(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := dynamicpb.NewMessage(in.Descriptor())
+		if err := Unmarshal(data, out); err != nil {
+			t.Fatalf("Unmarshal error: %v\n%s", err, data)
+		}
+		if !proto.Equal(in, out) {
+			t.Errorf("round trip mismatch:\n%s\nin:  %v\nout: %v", data, in, out)
+		}
+	}
+}
+
+func TestUnsetOptional(t *testing.T) {
+	fd := companyFile(t)
+	in := dynamicpb.NewMessage(fd.Messages().ByName("Company"))
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{name=;founded=;employees=;budgets=;tags=;hq=;floors=\0;logo=;email=\0;phone=\0}`
+	if string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+	out := dynamicpb.NewMessage(in.Descriptor())
+	if err := Unmarshal(data, out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Has(in.Descriptor().Fields().ByName("floors")) {
+		t.Error("expected floors to stay unset")
+	}
+}
+
+func TestUnknownEnum(t *testing.T) {
+	fd := companyFile(t)
+	e := dynamicpb.NewMessage(fd.Messages().ByName("Employee"))
+	if err := Unmarshal([]byte(`{role="ROLE_CEO"}`), e); err == nil {
+		t.Error("expected error for unknown enum name")
+	}
+}
@@ -0,0 +1,84 @@
+// Package redis maps Go structs onto Redis hashes, one hash field per
+// struct field, with each value encoded as GOD:
+//
+//	HSET user:1 name '"Alice"' age 30 tags '["admin","ops"]'
+//
+// Since every field stands alone, HGET reads a single field and HSET
+// updates one without rewriting the rest. The package does not talk to
+// Redis itself; ToHash produces the field map for HSET and FromHash
+// consumes the result of HGETALL, whichever client is in use.
+//
+// Field names follow the god struct tags, as in god.Marshal. An empty hash
+// value is the grounded zero value, so fields that were never set decode
+// to zero.
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/vinayakgupta29/god"
+)
+
+// ToHash encodes each exported field of the struct v, or of the struct it
+// points to, as a separate hash field.
+func ToHash(v interface{}) (map[string]string, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("redis: ToHash needs a struct, got %v", rv.Kind())
+	}
+
+	t := rv.Type()
+	hash := make(map[string]string, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := god.FieldName(field)
+		data, err := god.MarshalValue(rv.Field(i).Interface())
+		if err != nil {
+			return nil, fmt.Errorf("redis: field %s: %w", name, err)
+		}
+		hash[name] = string(data)
+	}
+	return hash, nil
+}
+
+// FromHash decodes the hash fields in m into the struct that target points
+// to. Hash fields that match no struct field are ignored, and struct
+// fields missing from m are left as they are, so FromHash also applies the
+// result of HMGET for a subset of fields.
+func FromHash(m map[string]string, target interface{}) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("redis: FromHash target must be a non-nil pointer to a struct")
+	}
+
+	rv = rv.Elem()
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := god.FieldName(field)
+		s, ok := m[name]
+		if !ok {
+			continue
+		}
+		fv := rv.Field(i)
+		if s == "" {
+			fv.Set(reflect.Zero(fv.Type()))
+			continue
+		}
+		if err := god.UnmarshalValue([]byte(s), fv.Addr().Interface()); err != nil {
+			return fmt.Errorf("redis: field %s: %w", name, err)
+		}
+	}
+	return nil
+}
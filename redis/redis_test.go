@@ -0,0 +1,74 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+)
+
+type user struct {
+	Name    string   `god:"name"`
+	Age     int      `god:"age"`
+	Tags    []string `god:"tags"`
+	Manager *user    `god:"manager"`
+	Email   string
+	secret  string
+}
+
+func TestToHash(t *testing.T) {
+	h, err := ToHash(&user{Name: "Alice", Age: 30, Tags: []string{"admin", "ops"}, secret: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"name":    `"Alice"`,
+		"age":     "30",
+		"tags":    `["admin","ops"]`,
+		"manager": `\0`,
+		"email":   "",
+	}
+	if !reflect.DeepEqual(h, want) {
+		t.Errorf("got %q, want %q", h, want)
+	}
+
+	if _, err := ToHash(3); err == nil {
+		t.Error("expected an error for a non-struct")
+	}
+}
+
+func TestFromHash(t *testing.T) {
+	in := user{Name: "Alice", Age: 30, Tags: []string{"admin"}, Manager: &user{Name: "Bob"}}
+	h, err := ToHash(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out user
+	if err := FromHash(h, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("got %+v, want %+v", out, in)
+	}
+
+	// A partial update touches only the fields present.
+	if err := FromHash(map[string]string{"age": "31", "unknown": "1"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Age != 31 || out.Name != "Alice" {
+		t.Errorf("partial update: got %+v", out)
+	}
+
+	// An empty value grounds the field.
+	if err := FromHash(map[string]string{"name": ""}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "" {
+		t.Errorf("empty value: got name %q", out.Name)
+	}
+
+	if err := FromHash(map[string]string{"age": `"x"`}, &out); err == nil {
+		t.Error("expected an error for a mistyped field")
+	}
+	if err := FromHash(h, out); err == nil {
+		t.Error("expected an error for a non-pointer target")
+	}
+}
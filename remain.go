@@ -0,0 +1,102 @@
+package god
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// RawMessage is the text of a single encoded GOD value, such as `"Bob"`,
+// `[1,2]` or `@time"2024-01-01T00:00:00Z"`. It is decoded without being
+// interpreted and encoded as it is, which delays decoding or keeps values
+// a program does not understand. An empty RawMessage is the grounded
+// value.
+type RawMessage []byte
+
+var rawMessageType = reflect.TypeOf(RawMessage(nil))
+
+// MarshalGOD returns m as the encoding of m.
+func (m RawMessage) MarshalGOD() ([]byte, error) {
+	return m, nil
+}
+
+// UnmarshalGOD sets *m to a copy of data.
+func (m *RawMessage) UnmarshalGOD(data []byte) error {
+	*m = append((*m)[:0], data...)
+	return nil
+}
+
+// readRawValue consumes one value, including any annotation, and returns
+// its text. An absent value is empty; \0 is kept as it is.
+func (p *parser) readRawValue() ([]byte, error) {
+	p.skipSpaces()
+	if p.eof() || strings.IndexByte(";},])", p.peek()) >= 0 {
+		return nil, nil
+	}
+	start := p.pos
+	if _, err := parseGenericValue(p); err != nil {
+		return nil, err
+	}
+	return p.src[start:p.pos], nil
+}
+
+// setRemain stores the raw value of an unknown key or column in the remain
+// map m, allocating it if it is nil.
+func setRemain(m reflect.Value, key string, raw []byte) {
+	if m.IsNil() {
+		m.Set(reflect.MakeMap(m.Type()))
+	}
+	var val reflect.Value
+	if m.Type().Elem() == rawMessageType {
+		val = reflect.ValueOf(append(RawMessage(nil), raw...))
+	} else {
+		val = reflect.ValueOf(string(raw)).Convert(m.Type().Elem())
+	}
+	m.SetMapIndex(reflect.ValueOf(key).Convert(m.Type().Key()), val)
+}
+
+// remainValue returns a value of a remain map as a RawMessage, which
+// encodes as the raw text it holds.
+func remainValue(v reflect.Value) reflect.Value {
+	if v.Type() == rawMessageType {
+		return v
+	}
+	return reflect.ValueOf(RawMessage(v.String()))
+}
+
+// writeRawCell writes the raw text of a table cell kept in a remain map,
+// making sure it is one cell.
+func writeRawCell(b *encodeState, raw []byte) error {
+	p := UnmarshalOptions{}.newParser(raw)
+	if _, _, err := p.readCell(); err != nil || !p.eof() {
+		return fmt.Errorf("invalid table cell %q", raw)
+	}
+	b.Write(raw)
+	return nil
+}
+
+// extraColumns returns the sorted keys of the remain or flatten maps at
+// field i of the rows of table v, leaving out those in known.
+func extraColumns(v reflect.Value, i int, known []string) ([]string, error) {
+	seen := make(map[string]bool, len(known))
+	for _, h := range known {
+		seen[h] = true
+	}
+	var cols []string
+	for r := 0; r < v.Len(); r++ {
+		iter := v.Index(r).Field(i).MapRange()
+		for iter.Next() {
+			k, err := mapKeyString(iter.Key())
+			if err != nil {
+				return nil, err
+			}
+			if !seen[k] {
+				seen[k] = true
+				cols = append(cols, k)
+			}
+		}
+	}
+	sort.Strings(cols)
+	return cols, nil
+}
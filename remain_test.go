@@ -0,0 +1,111 @@
+package god
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type configV1 struct {
+	Name  string                `god:"name"`
+	Port  int                   `god:"port"`
+	Extra map[string]RawMessage `god:",remain"`
+}
+
+type userV1 struct {
+	Name  string            `god:"name"`
+	Age   int               `god:"age"`
+	Extra map[string]string `god:",remain"`
+}
+
+func TestRemainPreservesUnknownKeys(t *testing.T) {
+	v2 := `{name="api";port=8080;tls={cert="a.pem";key="a.key"};zones=["eu","us"];since=@time"2024-01-01T00:00:00Z";owner=\0;note=}`
+	var cfg configV1
+	if err := Unmarshal([]byte(v2), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "api" || cfg.Port != 8080 {
+		t.Errorf("known fields = %+v", cfg)
+	}
+	if got := string(cfg.Extra["tls"]); got != `{cert="a.pem";key="a.key"}` {
+		t.Errorf("tls = %s", got)
+	}
+
+	out, err := Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{name="api";port=8080;note=;owner=\0;since=@time"2024-01-01T00:00:00Z";tls={cert="a.pem";key="a.key"};zones=["eu","us"]}`
+	if string(out) != want {
+		t.Errorf("Marshal = %s\nwant      %s", out, want)
+	}
+	if ok, err := (EqualOptions{Strict: true}).Equal([]byte(v2), out); err != nil || !ok {
+		t.Errorf("round trip is not equal to the v2 document: %v", err)
+	}
+}
+
+func TestRemainTableColumns(t *testing.T) {
+	v2 := `{(name,age,email,role:"Alice",30,"a@x.io",admin;"Bob",,,"ops, on call")}`
+	var users []userV1
+	if err := Unmarshal([]byte(v2), &users); err != nil {
+		t.Fatal(err)
+	}
+	want := []userV1{
+		{Name: "Alice", Age: 30, Extra: map[string]string{"email": `"a@x.io"`, "role": "admin"}},
+		{Name: "Bob", Extra: map[string]string{"email": "", "role": `"ops, on call"`}},
+	}
+	if !reflect.DeepEqual(users, want) {
+		t.Errorf("got %+v\nwant %+v", users, want)
+	}
+
+	out, err := Marshal(users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(out), "{(name,age,email,role:") {
+		t.Errorf("Marshal = %s", out)
+	}
+	var again []userV1
+	if err := Unmarshal(out, &again); err != nil || !reflect.DeepEqual(again, want) {
+		t.Errorf("round trip = %+v, %v\n%s", again, err, out)
+	}
+}
+
+func TestRemainFieldErrors(t *testing.T) {
+	var bad struct {
+		Extra map[string]int `god:",remain"`
+	}
+	if err := Unmarshal([]byte(`{x=1}`), &bad); err == nil {
+		t.Error("remain map of int accepted")
+	}
+	var both struct {
+		Flat  map[string]interface{} `god:",flatten"`
+		Extra map[string]RawMessage  `god:",remain"`
+	}
+	if _, err := Marshal(both); err == nil {
+		t.Error("remain and flatten together accepted")
+	}
+	var cfg configV1
+	if _, err := Marshal(configV1{Extra: map[string]RawMessage{"x": RawMessage("a b")}}); err == nil {
+		t.Error("invalid raw value encoded")
+	}
+	if err := Unmarshal([]byte(`{x=[1,2}`), &cfg); err == nil {
+		t.Error("invalid unknown value decoded")
+	}
+}
+
+func TestRawMessageField(t *testing.T) {
+	var v struct {
+		Payload RawMessage `god:"payload"`
+	}
+	if err := Unmarshal([]byte(`{payload={a=[1,2];b="}"}}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if string(v.Payload) != `{a=[1,2];b="}"}` {
+		t.Errorf("payload = %s", v.Payload)
+	}
+	out, err := Marshal(v)
+	if err != nil || string(out) != `{payload={a=[1,2];b="}"}}` {
+		t.Errorf("Marshal = %s, %v", out, err)
+	}
+}
@@ -0,0 +1,240 @@
+package god
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema describes the shape a GOD document must have. It validates the
+// generic form of a document, as decoded into an interface{}.
+//
+// Because GOD is grounded, an empty value (key=) is accepted wherever a
+// scalar is expected and checked as that type's zero value, and wherever
+// an array is expected as an empty one. Tables, which decode to slices of
+// objects, are arrays.
+type Schema struct {
+	// Type is one of "object", "array", "string", "number", "integer" or
+	// "boolean". An empty Type accepts any value.
+	Type string
+
+	// Properties constrains the keys of an object; Required lists the keys
+	// that must be present and Strict rejects keys not in Properties.
+	Properties map[string]*Schema
+	Required   []string
+	Strict     bool
+
+	// Items constrains every element of an array.
+	Items *Schema
+
+	// Enum, if non-empty, lists the only values allowed.
+	Enum []interface{}
+
+	// Minimum and Maximum bound numbers; MinLength and MaxLength bound the
+	// length of strings in characters.
+	Minimum   *float64
+	Maximum   *float64
+	MinLength *int
+	MaxLength *int
+
+	// Warnings records anything that was dropped while building the schema
+	// from another format, such as unsupported JSON Schema keywords.
+	Warnings []string
+}
+
+// SchemaError reports a value that does not conform to a Schema.
+type SchemaError struct {
+	Path   string // location of the value, e.g. "users[2].name"
+	Reason string
+}
+
+func (e *SchemaError) Error() string {
+	if e.Path == "" {
+		return "schema: " + e.Reason
+	}
+	return "schema: " + e.Path + ": " + e.Reason
+}
+
+// Validate decodes data and checks it against s.
+func (s *Schema) Validate(data []byte) error {
+	var v interface{}
+	if err := Unmarshal(data, &v); err != nil {
+		return err
+	}
+	return s.ValidateValue(v)
+}
+
+// ValidateValue checks a generically decoded value against s.
+func (s *Schema) ValidateValue(v interface{}) error {
+	return s.validate("", v)
+}
+
+func (s *Schema) validate(path string, v interface{}) error {
+	fail := func(format string, args ...interface{}) error {
+		return &SchemaError{Path: path, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	// An empty scalar is the grounded zero value of whatever is expected.
+	if str, ok := v.(string); ok && str == "" {
+		switch s.Type {
+		case "number", "integer":
+			v = int64(0)
+		case "boolean":
+			v = false
+		case "array":
+			v = []interface{}{}
+		}
+	}
+
+	switch s.Type {
+	case "":
+	case "object":
+		if _, ok := v.(map[string]interface{}); !ok {
+			return fail("expected object, got %s", typeName(v))
+		}
+	case "array":
+		if !isArray(v) {
+			return fail("expected array, got %s", typeName(v))
+		}
+	case "string":
+		if _, ok := v.(string); !ok {
+			return fail("expected string, got %s", typeName(v))
+		}
+	case "number":
+		if _, ok := toFloat(v); !ok {
+			return fail("expected number, got %s", typeName(v))
+		}
+	case "integer":
+		f, ok := toFloat(v)
+		if !ok || f != math.Trunc(f) {
+			return fail("expected integer, got %s", typeName(v))
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fail("expected boolean, got %s", typeName(v))
+		}
+	default:
+		return fail("unknown schema type %q", s.Type)
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if schemaEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fail("value %v is not one of %v", v, s.Enum)
+		}
+	}
+
+	if f, ok := toFloat(v); ok {
+		if s.Minimum != nil && f < *s.Minimum {
+			return fail("%v is less than minimum %v", f, *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			return fail("%v is greater than maximum %v", f, *s.Maximum)
+		}
+	}
+
+	if str, ok := v.(string); ok {
+		n := utf8.RuneCountInString(str)
+		if s.MinLength != nil && n < *s.MinLength {
+			return fail("length %d is less than minLength %d", n, *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			return fail("length %d is greater than maxLength %d", n, *s.MaxLength)
+		}
+	}
+
+	if m, ok := v.(map[string]interface{}); ok {
+		for _, key := range s.Required {
+			if _, ok := m[key]; !ok {
+				return fail("missing required key %q", key)
+			}
+		}
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			prop, ok := s.Properties[key]
+			if !ok {
+				if s.Strict {
+					return fail("unknown key %q", key)
+				}
+				continue
+			}
+			if err := prop.validate(joinPath(path, key), m[key]); err != nil {
+				return err
+			}
+		}
+	}
+
+	if isArray(v) && s.Items != nil {
+		list := reflect.ValueOf(v)
+		for i := 0; i < list.Len(); i++ {
+			if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), list.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isArray reports whether v is a list or a table: any slice, since tables
+// decode to []map[string]interface{}.
+func isArray(v interface{}) bool {
+	return v != nil && reflect.TypeOf(v).Kind() == reflect.Slice
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// schemaEqual compares enum members, treating all numbers alike.
+func schemaEqual(a, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case string:
+		return "string"
+	case int64, float64, int:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	if isArray(v) {
+		return "array"
+	}
+	return strings.TrimPrefix(fmt.Sprintf("%T", v), "*")
+}
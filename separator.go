@@ -0,0 +1,85 @@
+package god
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A table may separate its cells, and the columns of its header, with '|'
+// rather than ',', for consumers that read commas as decimal separators:
+//
+//	(name|age|addr:"Alice"|30|"NYC";)
+//
+// The encoder uses it for a slice field tagged sep=|. The header gives the
+// separator away, as one with a '|' and no ',', so decoders need no
+// option. Lists, objects and tables nested in cells keep their own
+// separators.
+const pipeSeparator = '|'
+
+// headerSeparator returns the cell separator of a table whose header text,
+// between '(' and ':', is header.
+func headerSeparator(header string) byte {
+	if strings.IndexByte(header, pipeSeparator) >= 0 && strings.IndexByte(header, ',') < 0 {
+		return pipeSeparator
+	}
+	return ','
+}
+
+// setSeparator sets the separator of the next table encoded to the one a
+// sep tag option names, if any.
+func (b *encodeState) setSeparator(sep string) error {
+	switch sep {
+	case "":
+	case ",", string(pipeSeparator):
+		b.tableSep = sep[0]
+	default:
+		return fmt.Errorf("unsupported table separator %q: use ',' or '|'", sep)
+	}
+	return nil
+}
+
+// takeSeparator returns the separator of the table about to be encoded
+// and clears it, so that tables nested in its cells use ','.
+func (b *encodeState) takeSeparator() byte {
+	sep := b.tableSep
+	b.tableSep = 0
+	if sep == 0 {
+		return ','
+	}
+	return sep
+}
+
+// setCellSeparator makes sep the cell separator of p until the returned
+// function restores the one before, so that nested tables keep their own.
+func (p *parser) setCellSeparator(sep byte) (restore func()) {
+	prev := p.cellSep
+	p.cellSep = 0
+	if sep != ',' {
+		p.cellSep = sep
+	}
+	return func() { p.cellSep = prev }
+}
+
+// nestCells clears the cell separator of p while it decodes the object or
+// list at p, if any, until the returned function restores it, so that the
+// values nested in a cell stop only at the delimiters of their own.
+func (p *parser) nestCells() (restore func()) {
+	if p.cellSep == 0 || p.eof() || (p.peek() != '{' && p.peek() != '[') {
+		return func() {}
+	}
+	return p.setCellSeparator(',')
+}
+
+// cellSeparators returns the characters that end a bare table cell.
+func (p *parser) cellSeparators() string {
+	if p.cellSep == 0 {
+		return ",;)"
+	}
+	return string(p.cellSep) + ";)"
+}
+
+// atCellSeparator reports whether p is at the separator of a table whose
+// cells are not separated by ','.
+func (p *parser) atCellSeparator() bool {
+	return p.cellSep != 0 && !p.eof() && p.peek() == p.cellSep
+}
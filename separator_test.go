@@ -0,0 +1,161 @@
+package god
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type pipeRow struct {
+	Name string
+	Age  int
+	Addr string
+}
+
+type pipeBook struct {
+	People []pipeRow `god:"people,sep=|"`
+	Others []pipeRow `god:"others"`
+}
+
+func TestPipeSeparatorEncode(t *testing.T) {
+	book := pipeBook{
+		People: []pipeRow{{"Alice", 30, "NYC"}, {"Bob", 25, "1,5 Main St"}},
+		Others: []pipeRow{{"Carol", 41, "LA"}},
+	}
+	out, err := Marshal(book)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{people=(name|age|addr:"Alice"|30|"NYC";"Bob"|25|"1,5 Main St";);others=(name,age,addr:"Carol",41,"LA";)}`
+	if string(out) != want {
+		t.Errorf("got  %s\nwant %s", out, want)
+	}
+
+	var back pipeBook
+	if err := Unmarshal(out, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, book) {
+		t.Errorf("got %+v, want %+v", back, book)
+	}
+}
+
+func TestPipeSeparatorBeautify(t *testing.T) {
+	book := pipeBook{People: []pipeRow{{"Alice", 30, "NYC"}, {"Bob", 5, "LA"}}}
+	for _, opts := range [][]Option{{Beautify()}, {Beautify(), AlignTableColumns()}} {
+		out, err := MarshalWith(book, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(out), "(name|age|addr:") || strings.Contains(string(out), `"Alice",`) {
+			t.Errorf("got %s", out)
+		}
+		var back pipeBook
+		if err := Unmarshal(out, &back); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(back.People, book.People) {
+			t.Errorf("got %+v, want %+v", back.People, book.People)
+		}
+	}
+}
+
+type pipeNested struct {
+	Name  string
+	Tags  []string
+	Attrs map[string]string
+	Rows  []pipeRow
+}
+
+func TestPipeSeparatorNestedCells(t *testing.T) {
+	in := struct {
+		Items []pipeNested `god:"items,sep=|"`
+	}{Items: []pipeNested{{
+		Name:  "a",
+		Tags:  []string{"x", "y"},
+		Attrs: map[string]string{"k|v": "1"},
+		Rows:  []pipeRow{{"Dan", 3, ""}},
+	}}}
+	out, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), `["x","y"]`) || !strings.Contains(string(out), `(name,age,addr:`) {
+		t.Errorf("nested values should keep ',': %s", out)
+	}
+	back := in
+	back.Items = nil
+	if err := Unmarshal(out, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, in) {
+		t.Errorf("got %+v, want %+v", back, in)
+	}
+}
+
+func TestPipeSeparatorDecode(t *testing.T) {
+	doc := `{(name | age | addr: Alice | 30 | "NYC"; Bob|25; "Carol"||LA)}`
+	var rows []pipeRow
+	if err := Unmarshal([]byte(doc), &rows); err != nil {
+		t.Fatal(err)
+	}
+	want := []pipeRow{{"Alice", 30, "NYC"}, {"Bob", 25, ""}, {"Carol", 0, "LA"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("got %+v, want %+v", rows, want)
+	}
+
+	var generic []map[string]interface{}
+	if err := Unmarshal([]byte(doc), &generic); err != nil {
+		t.Fatal(err)
+	}
+	if len(generic) != 3 || generic[0]["addr"] != "NYC" || generic[2]["addr"] != "LA" {
+		t.Errorf("got %v", generic)
+	}
+
+	d, err := ParseDocument([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Interface(); !reflect.DeepEqual(got, d.Root().Interface()) || d.Root().Index(0).Len() != 3 {
+		t.Errorf("document got %v", got)
+	}
+
+	out, err := Transcode([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{(name|age|addr:Alice|30|"NYC";Bob|25;"Carol"||LA;)}`; string(out) != want {
+		t.Errorf("transcode got  %s\nwant %s", out, want)
+	}
+}
+
+func TestPipeSeparatorTableReader(t *testing.T) {
+	tr, err := NewTableReader(strings.NewReader(`{(name|age|addr:"Alice"|30|"A, B";"Bob"|25;)}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []pipeRow
+	for tr.Next() {
+		var r pipeRow
+		if err := tr.ScanInto(&r); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, r)
+	}
+	if err := tr.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []pipeRow{{"Alice", 30, "A, B"}, {"Bob", 25, ""}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestPipeSeparatorInvalid(t *testing.T) {
+	in := struct {
+		Rows []pipeRow `god:"rows,sep=;"`
+	}{Rows: []pipeRow{{"Alice", 30, "NYC"}}}
+	if _, err := Marshal(in); err == nil || !strings.Contains(err.Error(), "unsupported table separator") {
+		t.Errorf("got %v, want an unsupported separator error", err)
+	}
+}
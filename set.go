@@ -0,0 +1,109 @@
+package god
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Sets, Go's map[T]struct{}, are encoded as a list of their keys in sorted
+// order, e.g. tags=["admin","dev"], and decoded from such a list.
+
+func isSetType(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && t.Elem().Kind() == reflect.Struct && t.Elem().NumField() == 0
+}
+
+func encodeSet(b *encodeState, v reflect.Value, level int, compact bool) error {
+	b.WriteByte('[')
+	for i, key := range sortedKeys(v) {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if err := encodeValue(b, key, level, compact); err != nil {
+			return err
+		}
+	}
+	b.WriteByte(']')
+	return nil
+}
+
+func decodeSet(p *parser, target reflect.Value) error {
+	p.next() // consume '['
+	p.skipSpaces()
+
+	if target.IsNil() {
+		target.Set(reflect.MakeMap(target.Type()))
+	}
+	member := reflect.Zero(target.Type().Elem())
+
+	for !p.eof() && p.peek() != ']' {
+		start := p.pos
+		key := reflect.New(target.Type().Key()).Elem()
+		if err := decodeValue(p, key); err != nil {
+			return err
+		}
+		if p.pos == start {
+			return fmt.Errorf("unexpected '%c' in set at pos %d", p.peek(), p.pos)
+		}
+		target.SetMapIndex(key, member)
+
+		p.skipSpaces()
+		if p.peek() == ',' {
+			p.next()
+			p.skipSpaces()
+		} else if p.peek() != ']' {
+			break
+		}
+	}
+
+	if p.peek() != ']' {
+		return errors.New("expected ']' at end of set")
+	}
+	p.next() // consume ']'
+	return nil
+}
+
+// sortedKeys returns the keys of map v in a deterministic order: numerically
+// for numeric keys, by their string form otherwise.
+func sortedKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		switch a.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return a.Int() < b.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return a.Uint() < b.Uint()
+		case reflect.Float32, reflect.Float64:
+			return a.Float() < b.Float()
+		case reflect.String:
+			return a.String() < b.String()
+		}
+		return fmt.Sprint(a.Interface()) < fmt.Sprint(b.Interface())
+	})
+	return keys
+}
+
+// sortInterfaceKeys sorts the keys of a map with interface keys, such as
+// the map[interface{}]interface{} of YAML decoders, by the names they are
+// written under. Keys of different types, such as 1 and "1", can share a
+// name, which would make the object ambiguous.
+func sortInterfaceKeys(keys []reflect.Value, names []string) error {
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return names[order[i]] < names[order[j]] })
+	sortedKeys := make([]reflect.Value, len(keys))
+	sortedNames := make([]string, len(names))
+	for i, k := range order {
+		sortedKeys[i], sortedNames[i] = keys[k], names[k]
+		if i > 0 && sortedNames[i] == sortedNames[i-1] {
+			return fmt.Errorf("map keys %#v and %#v are both written as %q", sortedKeys[i-1].Interface(), keys[k].Interface(), names[k])
+		}
+	}
+	copy(keys, sortedKeys)
+	copy(names, sortedNames)
+	return nil
+}
@@ -0,0 +1,65 @@
+package god
+
+import (
+	"reflect"
+	"testing"
+)
+
+type permissions struct {
+	User  string              `god:"user"`
+	Tags  map[string]struct{} `god:"tags"`
+	Ports map[int]struct{}    `god:"ports"`
+}
+
+func TestSetEncode(t *testing.T) {
+	v := permissions{
+		User:  "alice",
+		Tags:  map[string]struct{}{"write": {}, "admin": {}, "read": {}},
+		Ports: map[int]struct{}{443: {}, 80: {}, 8080: {}},
+	}
+	encoded, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	want := `{user="alice";tags=["admin","read","write"];ports=[80,443,8080]}`
+	if string(encoded) != want {
+		t.Errorf("expected %s, got %s", want, encoded)
+	}
+
+	var decoded permissions
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, v) {
+		t.Errorf("round trip mismatch: expected %+v, got %+v", v, decoded)
+	}
+}
+
+func TestSetRoot(t *testing.T) {
+	set := map[string]struct{}{"b": {}, "a": {}}
+	encoded, err := Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(encoded) != `{["a","b"]}` {
+		t.Errorf(`expected {["a","b"]}, got %s`, encoded)
+	}
+	var decoded map[string]struct{}
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, set) {
+		t.Errorf("expected %v, got %v", set, decoded)
+	}
+}
+
+func TestSetMalformed(t *testing.T) {
+	for _, doc := range []string{`{a=[)]}`, `{a=[}]}`, `{a=["x" "y"]}`} {
+		var v struct {
+			A map[string]struct{} `god:"a"`
+		}
+		if err := Unmarshal([]byte(doc), &v); err == nil {
+			t.Errorf("%s: decoded without an error", doc)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package god
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type shortRow struct {
+	Name string
+	Age  int
+	Addr string
+	Tags []string
+	Nick *string
+}
+
+func TestTableShortRows(t *testing.T) {
+	doc := `{(name,age,addr,tags,nick:"Alice",30;"Bob",25,"x",["q"],"b";"Carol";"Dan",,"y")}`
+	var rows []shortRow
+	if err := Unmarshal([]byte(doc), &rows); err != nil {
+		t.Fatal(err)
+	}
+	nick := "b"
+	want := []shortRow{
+		{Name: "Alice", Age: 30},
+		{Name: "Bob", Age: 25, Addr: "x", Tags: []string{"q"}, Nick: &nick},
+		{Name: "Carol"},
+		{Name: "Dan", Addr: "y"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("got  %+v\nwant %+v", rows, want)
+	}
+
+	var generic []map[string]interface{}
+	if err := Unmarshal([]byte(doc), &generic); err != nil {
+		t.Fatal(err)
+	}
+	if got := generic[2]; got["addr"] != "" || got["tags"] != "" || len(got) != 5 {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestTableLongRows(t *testing.T) {
+	docs := []string{
+		`{(name,age:"Alice",30,"extra";)}`,
+		`{(name,age:"Alice",30;"Bob",25,["x"])}`,
+	}
+	for _, doc := range docs {
+		var rows []shortRow
+		err := Unmarshal([]byte(doc), &rows)
+		if err == nil || !strings.Contains(err.Error(), "more cells") {
+			t.Errorf("%s: got %v, want an error", doc, err)
+		}
+		var generic interface{}
+		if err := Unmarshal([]byte(doc), &generic); err == nil {
+			t.Errorf("%s: generic decode wants an error", doc)
+		}
+	}
+
+	// Rows are numbered from 0, as in validation paths.
+	var rows []shortRow
+	err := Unmarshal([]byte(docs[1]), &rows)
+	if err == nil || !strings.Contains(err.Error(), "table row 1 has more cells") {
+		t.Errorf("got %v, want row 1", err)
+	}
+}
+
+func TestTableReaderShortRows(t *testing.T) {
+	tr, err := NewTableReader(strings.NewReader(`{(name,age,addr,nick:"Bob",25,"x","b";"Carol";"Dan",,"";"Eve",1,2,3,4)}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One struct is reused for every row; nothing may carry over.
+	var row shortRow
+	var got []shortRow
+	for tr.Next() {
+		if err := tr.ScanInto(&row); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, row)
+	}
+	if err := tr.Err(); err == nil || !strings.Contains(err.Error(), "more cells") {
+		t.Errorf("Err() = %v, want an error for the long row", err)
+	}
+	if len(got) != 3 || !reflect.DeepEqual(got[1], shortRow{Name: "Carol"}) ||
+		got[2].Addr != "" || got[2].Age != 0 || got[2].Nick != nil {
+		t.Errorf("got %+v", got)
+	}
+}
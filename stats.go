@@ -0,0 +1,88 @@
+package god
+
+import "time"
+
+// MarshalStats describes one call to MarshalWithStats.
+type MarshalStats struct {
+	// Bytes is the size of the encoded document.
+	Bytes int
+
+	// Fields counts the key-value pairs written, of structs and maps at
+	// any depth. Table cells are not fields.
+	Fields int
+
+	// TableRows counts the rows of every table written.
+	TableRows int
+
+	// MaxDepth is the deepest nesting of objects and tables; a document
+	// with only scalars at the root has depth 1.
+	MaxDepth int
+
+	// Duration is the time spent encoding.
+	Duration time.Duration
+}
+
+// UnmarshalStats describes one call to UnmarshalWithStats.
+type UnmarshalStats struct {
+	// Tokens counts the keys, strings and bare values read, including
+	// those of skipped values.
+	Tokens int
+
+	// UnknownKeys counts the object keys that matched no struct field and
+	// were skipped.
+	UnknownKeys int
+
+	// Rows counts the table rows decoded.
+	Rows int
+
+	// Duration is the time spent decoding.
+	Duration time.Duration
+}
+
+// MarshalWithStats is MarshalWith that also reports statistics about the
+// encoded document. Statistics are only collected by this function, so the
+// other encoding functions pay nothing for them.
+func MarshalWithStats(v interface{}, opts ...Option) ([]byte, MarshalStats, error) {
+	var stats MarshalStats
+	start := time.Now()
+	data, err := newOptions(opts).marshal.marshal(v, &stats)
+	stats.Duration = time.Since(start)
+	stats.Bytes = len(data)
+	return data, stats, err
+}
+
+// UnmarshalWithStats is UnmarshalWith that also reports statistics about
+// the decoded document.
+func UnmarshalWithStats(data []byte, v interface{}, opts ...Option) (UnmarshalStats, error) {
+	var stats UnmarshalStats
+	start := time.Now()
+	o := newOptions(opts).unmarshal
+	p := o.newParser(data)
+	p.stats = &stats
+	err := o.unmarshal(p, v)
+	stats.Duration = time.Since(start)
+	return stats, err
+}
+
+// noteDepth records that an object or table is being written at level.
+func (b *encodeState) noteDepth(level int) {
+	if b.stats != nil && level > b.stats.MaxDepth {
+		b.stats.MaxDepth = level
+	}
+}
+
+// countToken records that a token ending at p.pos was read, when
+// statistics are being collected. Tokens read again after the parser backs
+// up, as lookahead does, end no further than the last one counted and are
+// not counted twice.
+func (p *parser) countToken() {
+	p.countTokens(1)
+}
+
+// countTokens records that n tokens ending at p.pos were read.
+func (p *parser) countTokens(n int) {
+	if p.stats != nil && p.pos > p.tokenEnd {
+		p.stats.Tokens += n
+		p.tokenEnd = p.pos
+	}
+}
@@ -0,0 +1,86 @@
+package god
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestMarshalWithStats(t *testing.T) {
+	v := Response{
+		Status: 200,
+		Data: map[string]interface{}{
+			"company": benchCompany(3),
+			"tags":    []string{"a", "b"},
+		},
+	}
+	data, stats, err := MarshalWithStats(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(want) {
+		t.Errorf("MarshalWithStats output differs from Marshal:\n%s\n%s", data, want)
+	}
+	if stats.Bytes != len(data) {
+		t.Errorf("Bytes = %d, want %d", stats.Bytes, len(data))
+	}
+	// 5 response fields, 2 data entries and 3 company fields.
+	if stats.Fields != 10 {
+		t.Errorf("Fields = %d, want 10", stats.Fields)
+	}
+	if stats.TableRows != 3 {
+		t.Errorf("TableRows = %d, want 3", stats.TableRows)
+	}
+	// response, data, company, employees
+	if stats.MaxDepth != 4 {
+		t.Errorf("MaxDepth = %d, want 4", stats.MaxDepth)
+	}
+
+	_, stats, err = MarshalWithStats("naked", Beautify())
+	if err != nil || stats.MaxDepth != 1 || stats.Fields != 0 {
+		t.Errorf("naked root: %+v, %v", stats, err)
+	}
+}
+
+func TestUnmarshalWithStats(t *testing.T) {
+	doc := `{name="TechCorp";founded=2020;extra=[1,2];employees=(name,age,addr:"a",1,"x";"b",2,"y";)}`
+	var c Company
+	stats, err := UnmarshalWithStats([]byte(doc), &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "TechCorp" || len(c.Employees) != 2 {
+		t.Fatalf("decoded %+v", c)
+	}
+	// 4 keys, 2 values, 3 column names and 6 cells.
+	if stats.Tokens != 15 {
+		t.Errorf("Tokens = %d, want 15", stats.Tokens)
+	}
+	if stats.UnknownKeys != 1 {
+		t.Errorf("UnknownKeys = %d, want 1", stats.UnknownKeys)
+	}
+	if stats.Rows != 2 {
+		t.Errorf("Rows = %d, want 2", stats.Rows)
+	}
+
+	// Generic decoding counts each key once.
+	var generic interface{}
+	stats, err = UnmarshalWithStats([]byte(`{a={b="x"};c=1}`), &generic)
+	if err != nil || stats.Tokens != 5 {
+		t.Errorf("generic: %+v, %v", stats, err)
+	}
+
+	// Included files count too: the directive, its path and 6 more.
+	fsys := fstest.MapFS{"base.god": {Data: []byte(`{a=1;b=2}`)}}
+	stats, err = UnmarshalWithStats([]byte(`{@include "base.god";c=3}`), &generic, WithIncludeFS(fsys))
+	if err != nil || stats.Tokens != 8 {
+		t.Errorf("include: %+v, %v", stats, err)
+	}
+
+	if _, err := UnmarshalWithStats([]byte(`{a=`), &generic); err == nil {
+		t.Error("expected an error for an invalid document")
+	}
+}
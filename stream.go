@@ -0,0 +1,27 @@
+package god
+
+import (
+	"bufio"
+	"io"
+)
+
+// MarshalReader returns a reader that yields the compact GOD encoding of v.
+// The value is encoded on demand as the reader is consumed, so large values
+// never need to be held in memory as a whole; this makes it suitable as an
+// http.Request body. Encoding errors are returned from Read.
+//
+// v must not be modified until the reader has been drained. A caller that
+// stops reading early should close the reader to release the encoding
+// goroutine.
+func MarshalReader(v interface{}) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		bw := bufio.NewWriter(pw)
+		err := encodeRoot(&encodeState{writer: bw}, v, true)
+		if err == nil {
+			err = bw.Flush()
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
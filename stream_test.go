@@ -0,0 +1,76 @@
+package god
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMarshalReader(t *testing.T) {
+	for _, v := range []interface{}{
+		Person{Name: "Alice", Age: 30, Address: "NYC"},
+		benchPeople(5000),
+		map[string]interface{}{"key": "value"},
+		"raw",
+	} {
+		want, err := Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(MarshalReader(v))
+		if err != nil {
+			t.Fatalf("ReadAll error: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("MarshalReader output differs from Marshal:\nwant %.80s\ngot  %.80s", want, got)
+		}
+	}
+}
+
+func TestMarshalReaderError(t *testing.T) {
+	_, err := io.ReadAll(MarshalReader(map[string]interface{}{"ch": make(chan int)}))
+	if err == nil {
+		t.Error("expected encoding error from Read")
+	}
+}
+
+func TestMarshalReaderClose(t *testing.T) {
+	r := MarshalReader(benchPeople(10000))
+	buf := make([]byte, 16)
+	if _, err := r.Read(buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Read(buf); err != io.ErrClosedPipe {
+		t.Errorf("expected io.ErrClosedPipe after Close, got %v", err)
+	}
+}
+
+func TestMarshalReaderHTTPBody(t *testing.T) {
+	people := benchPeople(100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var got []Person
+		data, _ := io.ReadAll(r.Body)
+		if err := Unmarshal(data, &got); err != nil || len(got) != len(people) {
+			http.Error(w, "bad body", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL, MarshalReader(people))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("server rejected streamed body: %s", resp.Status)
+	}
+}
@@ -0,0 +1,156 @@
+package god
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// ErrInputTooLarge is returned when a document is longer than
+// UnmarshalOptions.MaxInputSize.
+var ErrInputTooLarge = errors.New("input exceeds maximum size")
+
+// ErrTooDeep is returned when values nest more deeply than
+// UnmarshalOptions.MaxDepth.
+var ErrTooDeep = errors.New("values nest too deeply")
+
+// The limits Decoder.Strict sets.
+const (
+	StrictMaxDepth     = 100
+	StrictMaxTokenSize = 1 << 20
+	StrictMaxInputSize = 10 << 20
+)
+
+// Strict turns on every check the decoder has, for documents from
+// untrusted sources: DisallowUnknownFields, DisallowDuplicateKeys and
+// RequireFields, with a MaxDepth of StrictMaxDepth, a MaxTokenSize of
+// StrictMaxTokenSize and a MaxInputSize of StrictMaxInputSize.
+// ValidateGOD methods are called. Numbers that overflow their target are
+// an error whatever the mode.
+func (d *Decoder) Strict() {
+	d.opts.DisallowUnknownFields = true
+	d.opts.DisallowDuplicateKeys = true
+	d.opts.RequireFields = true
+	d.opts.SkipValidation = false
+	d.opts.MaxDepth = StrictMaxDepth
+	d.opts.MaxTokenSize = StrictMaxTokenSize
+	d.opts.MaxInputSize = StrictMaxInputSize
+}
+
+// Lenient turns off every check that can be, for reading legacy data:
+// the options Strict sets are cleared, tokens and documents may be of any
+// size, and ValidateGOD methods are not called.
+func (d *Decoder) Lenient() {
+	d.opts.DisallowUnknownFields = false
+	d.opts.DisallowDuplicateKeys = false
+	d.opts.RequireFields = false
+	d.opts.OnColumnMismatch = IgnoreColumns
+	d.opts.SkipValidation = true
+	d.opts.MaxDepth = 0
+	d.opts.MaxTokenSize = -1
+	d.opts.MaxInputSize = 0
+}
+
+// enter counts a value being decoded against MaxDepth; leave must follow.
+func (p *parser) enter() error {
+	p.nesting++
+	if p.nesting > p.maxDepth {
+		return fmt.Errorf("%w: more than %d levels at pos %d", ErrTooDeep, p.maxDepth, p.pos)
+	}
+	return nil
+}
+
+func (p *parser) leave() {
+	p.nesting--
+}
+
+// objectKeys records the keys of an object, and the struct fields they
+// set, for DisallowDuplicateKeys and RequireFields. A nil *objectKeys,
+// as newObjectKeys returns when neither is set, records nothing.
+type objectKeys struct {
+	duplicates bool
+	keys       map[string]bool
+	fields     map[int]bool
+
+	// included is set once an @include directive has spliced keys in,
+	// which are not recorded.
+	included bool
+}
+
+func (p *parser) newObjectKeys() *objectKeys {
+	if !p.disallowDuplicates && !p.requireFields {
+		return nil
+	}
+	return &objectKeys{duplicates: p.disallowDuplicates, keys: make(map[string]bool), fields: make(map[int]bool)}
+}
+
+// add records key, which sets struct field i, or none if i is negative.
+// A key, or a field under another of its names, seen before is an error
+// with DisallowDuplicateKeys.
+func (k *objectKeys) add(key string, i int) error {
+	if k == nil {
+		return nil
+	}
+	if k.duplicates && (k.keys[key] || i >= 0 && k.fields[i]) {
+		return fmt.Errorf("duplicate key %q", key)
+	}
+	k.keys[key] = true
+	if i >= 0 {
+		k.fields[i] = true
+	}
+	return nil
+}
+
+func (k *objectKeys) include() {
+	if k != nil {
+		k.included = true
+	}
+}
+
+// checkRequired reports the first exported field of struct type t not
+// tagged omitempty that no key of the object set, with RequireFields.
+// Objects with @include directives are not checked.
+func (p *parser) checkRequired(k *objectKeys, t reflect.Type, flatIdx, remainIdx int) error {
+	if !p.requireFields || k.included {
+		return nil
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || i == flatIdx || i == remainIdx || k.fields[i] {
+			continue
+		}
+		if tag := parseFieldTag(field, p.fieldNameMapper); !tag.omitEmpty {
+			return fmt.Errorf("%v: missing key %q", t, tag.name)
+		}
+	}
+	return nil
+}
+
+// checkStrictColumns applies DisallowUnknownFields and RequireFields to
+// the header of a table of structs of type t, as checkColumns does its
+// ColumnPolicy.
+func (p *parser) checkStrictColumns(t reflect.Type, headers []string, fieldMap map[string]int, flatIdx, remainIdx int) error {
+	if !p.disallowUnknown && !p.requireFields {
+		return nil
+	}
+	k := &objectKeys{keys: make(map[string]bool), fields: make(map[int]bool)}
+	for _, h := range headers {
+		i, ok := fieldMap[h]
+		if !ok && p.disallowUnknown && flatIdx < 0 && remainIdx < 0 {
+			return fmt.Errorf("%v: unknown column %q", t, h)
+		}
+		if ok {
+			k.fields[i] = true
+		}
+	}
+	return p.checkRequired(k, t, flatIdx, remainIdx)
+}
+
+// overflowsFloat32 reports whether the finite val rounds to an infinity
+// as a float32, where target is one. Unlike OverflowFloat, it allows
+// values that round down to math.MaxFloat32, as its shortest decimal form
+// does, like strconv.ParseFloat with a bit size of 32.
+func overflowsFloat32(target reflect.Value, val float64) bool {
+	return target.Kind() == reflect.Float32 && !math.IsInf(val, 0) && math.IsInf(float64(float32(val)), 0)
+}
@@ -0,0 +1,136 @@
+package god
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type strictConfig struct {
+	Name    string `god:"name,alias=title"`
+	Port    int    `god:"port"`
+	Comment string `god:"comment,omitempty"`
+}
+
+func decodeStrict(doc string, v interface{}) error {
+	d := NewDecoder(strings.NewReader(doc))
+	d.Strict()
+	return d.Decode(v)
+}
+
+func TestStrictAccepts(t *testing.T) {
+	var c strictConfig
+	if err := decodeStrict(`{name="a";port=80}`, &c); err != nil {
+		t.Fatal(err)
+	}
+	if c != (strictConfig{Name: "a", Port: 80}) {
+		t.Errorf("got %+v", c)
+	}
+	var rows []strictConfig
+	if err := decodeStrict(`{(name,port:"a",1;"b",2;)}`, &rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[1].Port != 2 {
+		t.Errorf("got %+v", rows)
+	}
+}
+
+func TestStrictRejects(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want string
+	}{
+		{`{name="a";port=80;extra=1}`, `unknown key "extra"`},
+		{`{name="a";port=80;port=81}`, `duplicate key "port"`},
+		{`{name="a";title="b";port=80}`, `duplicate key "title"`},
+		{`{name="a"}`, `missing key "port"`},
+		{`{name="a";port=80;comment={}}`, `string`},
+	}
+	for _, tt := range tests {
+		var c strictConfig
+		err := decodeStrict(tt.doc, &c)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want an error containing %q", tt.doc, err, tt.want)
+		}
+	}
+
+	var rows []strictConfig
+	if err := decodeStrict(`{(name:"a";)}`, &rows); err == nil {
+		t.Error("table lacking a column was accepted")
+	}
+	if err := decodeStrict(`{(name,port,fax:"a",1,2;)}`, &rows); err == nil {
+		t.Error("table with an extra column was accepted")
+	}
+	var small struct {
+		N int8 `god:"n"`
+	}
+	if err := decodeStrict(`{n=300}`, &small); err == nil || !strings.Contains(err.Error(), "overflows") {
+		t.Errorf("int8: got %v", err)
+	}
+	var m map[string]int
+	if err := decodeStrict(`{a=1;a=2}`, &m); err == nil || !strings.Contains(err.Error(), `duplicate key "a"`) {
+		t.Errorf("map: got %v", err)
+	}
+}
+
+func TestStrictLimits(t *testing.T) {
+	deep := strings.Repeat("[", StrictMaxDepth+1) + strings.Repeat("]", StrictMaxDepth+1)
+	var v interface{}
+	if err := decodeStrict(`{x=`+deep+`}`, &v); !errors.Is(err, ErrTooDeep) {
+		t.Errorf("got %v, want ErrTooDeep", err)
+	}
+	ok := strings.Repeat("[", StrictMaxDepth-1) + strings.Repeat("]", StrictMaxDepth-1)
+	if err := decodeStrict(`{x=`+ok+`}`, &v); err != nil {
+		t.Errorf("depth %d: %v", StrictMaxDepth, err)
+	}
+
+	big := `{s="` + strings.Repeat("a", StrictMaxTokenSize+1) + `"}`
+	if err := decodeStrict(big, &v); !errors.Is(err, ErrTokenTooLarge) {
+		t.Errorf("got %v, want ErrTokenTooLarge", err)
+	}
+
+	var m map[string]int
+	err := UnmarshalWith([]byte(`{a=1;b=2}`), &m, WithMaxInputSize(8))
+	if !errors.Is(err, ErrInputTooLarge) {
+		t.Errorf("got %v, want ErrInputTooLarge", err)
+	}
+	d := NewDecoder(strings.NewReader(`{a=1}{a=1;b=2;c=3}`))
+	d.opts.MaxInputSize = 8
+	if err := d.Decode(&m); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Decode(&m); !errors.Is(err, ErrInputTooLarge) {
+		t.Errorf("got %v, want ErrInputTooLarge", err)
+	}
+}
+
+func TestLenient(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`{name="a";extra=1}{name="c";name="d"}`))
+	d.Strict()
+	d.Lenient()
+	var c strictConfig
+	if err := d.Decode(&c); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Decode(&c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "d" {
+		t.Errorf("got %+v, want the last name", c)
+	}
+}
+
+func TestFloatOverflow(t *testing.T) {
+	var v struct {
+		F float32 `god:"f"`
+	}
+	if err := Unmarshal([]byte(`{f=1e39}`), &v); err == nil {
+		t.Error("1e39 decoded into a float32")
+	}
+	var rows []struct {
+		F float32 `god:"f"`
+	}
+	if err := Unmarshal([]byte(`{(f:1e39;)}`), &rows); err == nil {
+		t.Error("1e39 decoded into a float32 cell")
+	}
+}
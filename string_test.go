@@ -0,0 +1,109 @@
+package god
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTripleQuotedRoot(t *testing.T) {
+	note := "Meeting notes\n- ship \"v2\" } on Friday\n- {review} = done\n"
+	docs := []string{
+		`{"""` + note + `"""}`,
+		"{\n  \"\"\"" + note + "\"\"\"\n}",
+	}
+	for _, doc := range docs {
+		var s string
+		if err := Unmarshal([]byte(doc), &s); err != nil {
+			t.Fatalf("Unmarshal(%q): %v", doc, err)
+		}
+		if s != note {
+			t.Errorf("Unmarshal(%q) = %q, want %q", doc, s, note)
+		}
+
+		var ptr *string
+		if err := Unmarshal([]byte(doc), &ptr); err != nil || ptr == nil || *ptr != note {
+			t.Errorf("Unmarshal(%q) into *string = %v, %v", doc, ptr, err)
+		}
+
+		var v interface{}
+		if err := Unmarshal([]byte(doc), &v); err != nil || v != note {
+			t.Errorf("Unmarshal(%q) into interface{} = %q, %v", doc, v, err)
+		}
+	}
+
+	for _, marshal := range []func(interface{}) ([]byte, error){Marshal, MarshalBeautify} {
+		encoded, err := marshal(note)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(encoded), `"""`) {
+			t.Errorf("multi-line root not triple-quoted: %s", encoded)
+		}
+		var s string
+		if err := Unmarshal(encoded, &s); err != nil || s != note {
+			t.Errorf("round trip of %s = %q, %v", encoded, s, err)
+		}
+	}
+}
+
+func TestTripleQuotedRootStream(t *testing.T) {
+	d := NewDecoder(strings.NewReader("{\"\"\"a } b\nc\"\"\"}\n{\"\"\"d\"\"\"}"))
+	for _, want := range []string{"a } b\nc", "d"} {
+		var s string
+		if err := d.Decode(&s); err != nil {
+			t.Fatal(err)
+		}
+		if s != want {
+			t.Errorf("Decode = %q, want %q", s, want)
+		}
+	}
+}
+
+func TestEscapeUnicode(t *testing.T) {
+	type doc struct {
+		Text  string            `god:"text"`
+		Lines string            `god:"lines"`
+		Tags  map[string]string `god:"tags"`
+	}
+	in := doc{Text: "héllo, 世界 🌍\x01", Lines: "первая\nвторая", Tags: map[string]string{"clé 1": "ok"}}
+
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{text=\"héllo, 世界 🌍\\x01\";lines=\"\"\"первая\nвторая\"\"\";tags={\"clé 1\"=\"ok\"}}"
+	if string(data) != want {
+		t.Errorf("default output:\ngot  %s\nwant %s", data, want)
+	}
+
+	escaped, err := MarshalWith(in, EscapeUnicode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = `{text="h\u00e9llo, \u4e16\u754c \U0001f30d\x01";lines="\u043f\u0435\u0440\u0432\u0430\u044f\n\u0432\u0442\u043e\u0440\u0430\u044f";tags={"cl\u00e9 1"="ok"}}`
+	if string(escaped) != want {
+		t.Errorf("escaped output:\ngot  %s\nwant %s", escaped, want)
+	}
+
+	for _, data := range [][]byte{data, escaped} {
+		var out doc
+		if err := Unmarshal(data, &out); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(out, in) {
+			t.Errorf("round trip of %s: got %+v", data, out)
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetEscapeUnicode(true)
+	if err := enc.Encode(map[string]string{"ключ": "значение"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\"\\u043a\\u043b\\u044e\\u0447\"=\"\\u0437\\u043d\\u0430\\u0447\\u0435\\u043d\\u0438\\u0435\"}\n"; buf.String() != want {
+		t.Errorf("Encoder: got %q, want %q", buf.String(), want)
+	}
+}
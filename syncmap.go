@@ -0,0 +1,59 @@
+package god
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var syncMapType = reflect.TypeOf((*sync.Map)(nil)).Elem()
+
+// syncMapOf returns the *sync.Map held in v, copying v first if it is not
+// addressable.
+func syncMapOf(v reflect.Value) *sync.Map {
+	if !v.CanAddr() {
+		tmp := reflect.New(syncMapType)
+		tmp.Elem().Set(v)
+		v = tmp.Elem()
+	}
+	return v.Addr().Interface().(*sync.Map)
+}
+
+// syncMapAsMap takes a snapshot of the entries of the sync.Map in v as a
+// map[string]interface{}, which encodes as an object. Keys are formatted
+// with fmt.Sprint, so keys that format the same collide and one of them
+// wins. Entries stored during the snapshot may or may not be included, as
+// with sync.Map.Range.
+func syncMapAsMap(v reflect.Value) reflect.Value {
+	m := make(map[string]interface{})
+	syncMapOf(v).Range(func(key, value interface{}) bool {
+		m[fmt.Sprint(key)] = value
+		return true
+	})
+	return reflect.ValueOf(m)
+}
+
+// isEmptySyncMap reports whether the sync.Map in v has no entries.
+func isEmptySyncMap(v reflect.Value) bool {
+	empty := true
+	syncMapOf(v).Range(func(key, value interface{}) bool {
+		empty = false
+		return false
+	})
+	return empty
+}
+
+// decodeSyncMap decodes an object into the sync.Map target, storing each
+// entry under its string key with a generically decoded value. Existing
+// entries with other keys are kept, as with maps.
+func decodeSyncMap(p *parser, target reflect.Value) error {
+	var m map[string]interface{}
+	if err := decodeMap(p, reflect.ValueOf(&m).Elem()); err != nil {
+		return err
+	}
+	sm := target.Addr().Interface().(*sync.Map)
+	for k, v := range m {
+		sm.Store(k, v)
+	}
+	return nil
+}
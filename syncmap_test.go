@@ -0,0 +1,108 @@
+package god
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+type cacheSnapshot struct {
+	Name    string    `god:"name"`
+	Entries *sync.Map `god:"entries"`
+}
+
+func TestSyncMapEncode(t *testing.T) {
+	var m sync.Map
+	m.Store("b", 2)
+	m.Store("a", "x")
+	m.Store(3, []string{"p"})
+
+	data, err := Marshal(&m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{3=["p"];a="x";b=2}`; string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+
+	data, err = Marshal(cacheSnapshot{Name: "c", Entries: &m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{name="c";entries={3=["p"];a="x";b=2}}`; string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+
+	var empty sync.Map
+	data, err = Marshal(cacheSnapshot{Name: "c", Entries: &empty})
+	if err != nil || string(data) != `{name="c";entries={}}` {
+		t.Errorf("Marshal(empty) = %s, %v", data, err)
+	}
+}
+
+func TestSyncMapDecode(t *testing.T) {
+	var snap cacheSnapshot
+	if err := Unmarshal([]byte(`{name="c";entries={a="x";b=2;c=[1]}}`), &snap); err != nil {
+		t.Fatal(err)
+	}
+	if snap.Entries == nil {
+		t.Fatal("Entries was not allocated")
+	}
+	for key, want := range map[string]string{"a": "x", "b": "2", "c": "[1]"} {
+		v, ok := snap.Entries.Load(key)
+		if !ok || fmt.Sprint(v) != want {
+			t.Errorf("Load(%q) = %v, %v; want %s", key, v, ok, want)
+		}
+	}
+
+	var root sync.Map
+	root.Store("keep", true)
+	if err := Unmarshal([]byte(`{n=1}`), &root); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := root.Load("n"); v != int64(1) {
+		t.Errorf("root n = %#v", v)
+	}
+	if _, ok := root.Load("keep"); !ok {
+		t.Error("existing entry was dropped")
+	}
+}
+
+func TestSyncMapConcurrentEncode(t *testing.T) {
+	var m sync.Map
+	for i := 0; i < 100; i++ {
+		m.Store(fmt.Sprint("k", i), i)
+	}
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				m.Load(fmt.Sprint("k", i%100))
+				m.Store(fmt.Sprint("w", w), i)
+			}
+		}(w)
+	}
+	for i := 0; i < 50; i++ {
+		data, err := Marshal(&m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var back map[string]interface{}
+		if err := Unmarshal(data, &back); err != nil {
+			t.Fatal(err)
+		}
+		if len(back) < 100 {
+			t.Fatalf("snapshot has %d entries, want at least 100", len(back))
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
@@ -0,0 +1,250 @@
+package god
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Table represents the key = (header:rows;...) syntax without a struct type.
+// Each row holds the text of its cells in header order: the content of
+// quoted strings, other values as written, and "" for empty and \0 cells.
+//
+// Tables decode from and encode to the table syntax, as the root of a
+// document or as a field. When encoding, numeric and boolean text is written
+// bare and anything else is quoted.
+//
+// The accessors below give a quick, dataframe-like view of a decoded table,
+// such as one column of an export as a typed slice.
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+var (
+	tableType = reflect.TypeOf(Table{})
+	rowType   = reflect.TypeOf([]string{})
+)
+
+func decodeTableValue(p *parser, target reflect.Value) error {
+	var rows [][]string
+	header, err := decodeTableHeader(p, reflect.ValueOf(&rows).Elem())
+	if err != nil {
+		return err
+	}
+	target.Set(reflect.ValueOf(Table{Header: header, Rows: rows}))
+	return nil
+}
+
+func encodeTable(b *encodeState, t Table, level int, compact bool) error {
+	if b.stats != nil {
+		b.noteDepth(level)
+		b.stats.TableRows += len(t.Rows)
+	}
+	sep := b.takeSeparator()
+	b.WriteByte('(')
+	for i, h := range t.Header {
+		if i > 0 {
+			b.WriteByte(sep)
+		}
+		b.WriteString(h)
+	}
+	b.WriteByte(':')
+	if !compact {
+		b.WriteByte('\n')
+	}
+	for _, row := range t.Rows {
+		if !compact {
+			b.WriteString(b.indent(level))
+		}
+		for i := range t.Header {
+			if i > 0 {
+				b.WriteByte(sep)
+			}
+			if i < len(row) {
+				encodeTableText(b, row[i])
+			}
+		}
+		b.WriteByte(';')
+		if !compact {
+			b.WriteByte('\n')
+		}
+	}
+	if !compact {
+		b.WriteString(b.indent(level - 1))
+	}
+	b.WriteByte(')')
+	return nil
+}
+
+func encodeTableText(b *encodeState, s string) {
+	if s == "" {
+		return
+	}
+	if s == "true" || s == "false" {
+		b.WriteString(s)
+		return
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		b.WriteString(s)
+		return
+	}
+	b.WriteString(b.quote(s))
+}
+
+// Column returns the index of the named column, or -1.
+func (t *Table) Column(name string) int {
+	for i, h := range t.Header {
+		if h == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// cells returns the text of the named column. Rows shorter than the header
+// are grounded.
+func (t *Table) cells(col string) ([]string, error) {
+	idx := t.Column(col)
+	if idx < 0 {
+		return nil, fmt.Errorf("unknown column %q", col)
+	}
+	cells := make([]string, len(t.Rows))
+	for i, row := range t.Rows {
+		if idx < len(row) {
+			cells[i] = row[idx]
+		}
+	}
+	return cells, nil
+}
+
+func cellError(col string, row int, cell, want string) error {
+	return fmt.Errorf("column %q row %d: cannot convert %q to %s", col, row, cell, want)
+}
+
+// Ints returns the named column as integers. Empty cells are 0.
+func (t *Table) Ints(col string) ([]int64, error) {
+	cells, err := t.cells(col)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]int64, len(cells))
+	for i, c := range cells {
+		if c == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(c, 10, 64)
+		if err != nil {
+			return nil, cellError(col, i, c, "int")
+		}
+		out[i] = n
+	}
+	return out, nil
+}
+
+// Floats returns the named column as floats. Empty cells are 0.
+func (t *Table) Floats(col string) ([]float64, error) {
+	cells, err := t.cells(col)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]float64, len(cells))
+	for i, c := range cells {
+		if c == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(c, 64)
+		if err != nil {
+			return nil, cellError(col, i, c, "float")
+		}
+		out[i] = f
+	}
+	return out, nil
+}
+
+// Strings returns the text of the named column.
+func (t *Table) Strings(col string) ([]string, error) {
+	return t.cells(col)
+}
+
+// Bools returns the named column as booleans. Empty cells are false.
+func (t *Table) Bools(col string) ([]bool, error) {
+	cells, err := t.cells(col)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]bool, len(cells))
+	for i, c := range cells {
+		switch c {
+		case "", "false":
+		case "true":
+			out[i] = true
+		default:
+			return nil, cellError(col, i, c, "bool")
+		}
+	}
+	return out, nil
+}
+
+// Select returns a new table with only the named columns, in the order
+// given. A column the table does not have is grounded in every row.
+func (t *Table) Select(cols ...string) *Table {
+	idx := make([]int, len(cols))
+	for i, c := range cols {
+		idx[i] = t.Column(c)
+	}
+	out := &Table{Header: append([]string(nil), cols...), Rows: make([][]string, len(t.Rows))}
+	for r, row := range t.Rows {
+		cells := make([]string, len(cols))
+		for i, j := range idx {
+			if j >= 0 && j < len(row) {
+				cells[i] = row[j]
+			}
+		}
+		out.Rows[r] = cells
+	}
+	return out
+}
+
+// SortBy sorts the rows by the named column, keeping the order of equal
+// rows. Empty cells sort first. Cells that are both numbers compare
+// numerically, so "9" sorts before "10"; numbers sort before other text,
+// which compares bytewise.
+func (t *Table) SortBy(col string, desc bool) error {
+	idx := t.Column(col)
+	if idx < 0 {
+		return fmt.Errorf("unknown column %q", col)
+	}
+	cell := func(row []string) string {
+		if idx < len(row) {
+			return row[idx]
+		}
+		return ""
+	}
+	sort.SliceStable(t.Rows, func(i, j int) bool {
+		a, b := cell(t.Rows[i]), cell(t.Rows[j])
+		if desc {
+			a, b = b, a
+		}
+		return lessCell(a, b)
+	})
+	return nil
+}
+
+func lessCell(a, b string) bool {
+	if a == "" || b == "" {
+		return a == "" && b != ""
+	}
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+	switch {
+	case errA == nil && errB == nil:
+		return fa < fb
+	case errA == nil:
+		return true
+	case errB == nil:
+		return false
+	}
+	return a < b
+}
@@ -0,0 +1,194 @@
+package god
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const salesDoc = `{(region,units,price,active,note:
+	"north",12,2.5,true,"ok";
+	"south",\0,10,false,;
+	"east",9,1e1,,"x, y";
+	"west",100,0.25,true,"";
+)}`
+
+func TestTableDecode(t *testing.T) {
+	var tbl Table
+	if err := Unmarshal([]byte(salesDoc), &tbl); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"region", "units", "price", "active", "note"}; !reflect.DeepEqual(tbl.Header, want) {
+		t.Errorf("header: expected %v, got %v", want, tbl.Header)
+	}
+	if len(tbl.Rows) != 4 {
+		t.Fatalf("expected 4 rows, got %d", len(tbl.Rows))
+	}
+	if want := []string{"south", "", "10", "false", ""}; !reflect.DeepEqual(tbl.Rows[1], want) {
+		t.Errorf("row 1: expected %q, got %q", want, tbl.Rows[1])
+	}
+
+	// A root table round-trips.
+	encoded, err := Marshal(tbl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again Table
+	if err := Unmarshal(encoded, &again); err != nil || !reflect.DeepEqual(again, tbl) {
+		t.Errorf("root round trip mismatch: %s decoded to %+v (%v)", encoded, again, err)
+	}
+
+	// As a field, and back again.
+	var doc struct {
+		Sales Table `god:"sales"`
+	}
+	if err := Unmarshal([]byte(`{sales=(a,b:1,"x";2,;)}`), &doc); err != nil {
+		t.Fatal(err)
+	}
+	encoded, err = Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{sales=(a,b:1,"x";2,;)}`; string(encoded) != want {
+		t.Errorf("expected %s, got %s", want, encoded)
+	}
+}
+
+func TestTableColumns(t *testing.T) {
+	var tbl Table
+	if err := Unmarshal([]byte(salesDoc), &tbl); err != nil {
+		t.Fatal(err)
+	}
+
+	units, err := tbl.Ints("units")
+	if err != nil || !reflect.DeepEqual(units, []int64{12, 0, 9, 100}) {
+		t.Errorf("Ints: %v, %v", units, err)
+	}
+	prices, err := tbl.Floats("price")
+	if err != nil || !reflect.DeepEqual(prices, []float64{2.5, 10, 10, 0.25}) {
+		t.Errorf("Floats: %v, %v", prices, err)
+	}
+	active, err := tbl.Bools("active")
+	if err != nil || !reflect.DeepEqual(active, []bool{true, false, false, true}) {
+		t.Errorf("Bools: %v, %v", active, err)
+	}
+	notes, err := tbl.Strings("note")
+	if err != nil || !reflect.DeepEqual(notes, []string{"ok", "", "x, y", ""}) {
+		t.Errorf("Strings: %q, %v", notes, err)
+	}
+
+	// Mixed-type columns report the offending row and text.
+	if _, err := tbl.Ints("price"); err == nil || !strings.Contains(err.Error(), `row 0: cannot convert "2.5"`) {
+		t.Errorf("expected conversion error for row 0, got %v", err)
+	}
+	if _, err := tbl.Bools("region"); err == nil || !strings.Contains(err.Error(), `"north"`) {
+		t.Errorf("expected conversion error, got %v", err)
+	}
+	if _, err := tbl.Floats("missing"); err == nil {
+		t.Error("expected error for unknown column")
+	}
+}
+
+func TestTableSelectAndSort(t *testing.T) {
+	var tbl Table
+	if err := Unmarshal([]byte(salesDoc), &tbl); err != nil {
+		t.Fatal(err)
+	}
+
+	sel := tbl.Select("units", "region", "missing")
+	if want := []string{"12", "north", ""}; !reflect.DeepEqual(sel.Rows[0], want) {
+		t.Errorf("Select: expected %q, got %q", want, sel.Rows[0])
+	}
+
+	if err := sel.SortBy("units", false); err != nil {
+		t.Fatal(err)
+	}
+	regions, _ := sel.Strings("region")
+	if want := []string{"south", "east", "north", "west"}; !reflect.DeepEqual(regions, want) {
+		t.Errorf("SortBy asc: expected %v, got %v", want, regions)
+	}
+	if err := sel.SortBy("units", true); err != nil {
+		t.Fatal(err)
+	}
+	regions, _ = sel.Strings("region")
+	if want := []string{"west", "north", "east", "south"}; !reflect.DeepEqual(regions, want) {
+		t.Errorf("SortBy desc: expected %v, got %v", want, regions)
+	}
+	if err := sel.SortBy("nope", false); err == nil {
+		t.Error("expected error for unknown column")
+	}
+
+	// The original table is untouched by sorting the projection.
+	if tbl.Rows[0][0] != "north" {
+		t.Errorf("Select must copy rows, got %q", tbl.Rows[0])
+	}
+}
+
+func TestTableQuotedCellWithSeparator(t *testing.T) {
+	type greeting struct {
+		N    int
+		Text string
+	}
+	docs := []string{
+		`{(n,text:1,"hello, world";)}`,
+		`{(n,text:1,  "hello, world"  ;)}`,
+		"{(n,text:\n\t1,\n\t\"hello, world\";)}",
+		"{(n,text:1, // note\n \"hello, world\";)}",
+		`{(n,text:1, @str "hello, world";)}`,
+	}
+	want := []greeting{{1, "hello, world"}}
+	for _, doc := range docs {
+		var rows []greeting
+		if err := Unmarshal([]byte(doc), &rows); err != nil {
+			t.Errorf("%q: %v", doc, err)
+		} else if !reflect.DeepEqual(rows, want) {
+			t.Errorf("%q: got %+v, want %+v", doc, rows, want)
+		}
+
+		var generic []map[string]interface{}
+		if err := Unmarshal([]byte(doc), &generic); err != nil || generic[0]["text"] != "hello, world" {
+			t.Errorf("%q: generic got %v, %v", doc, generic, err)
+		}
+
+		d, err := ParseDocument([]byte(doc))
+		if err != nil {
+			t.Errorf("%q: %v", doc, err)
+		} else if got := d.Root().Index(0).Index(1).Text(); got != "hello, world" {
+			t.Errorf("%q: document got %q", doc, got)
+		}
+
+		tr, err := NewTableReader(strings.NewReader(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for tr.Next() {
+			if got := tr.Row(); len(got) != 2 || got[1] != "hello, world" {
+				t.Errorf("%q: table reader got %q", doc, got)
+			}
+		}
+		if err := tr.Err(); err != nil {
+			t.Errorf("%q: %v", doc, err)
+		}
+	}
+}
+
+func TestStructTableEnds(t *testing.T) {
+	type row struct {
+		ID int `god:"id"`
+	}
+	for _, doc := range []string{`{(id:1;`, `{(id:1;2`, `{(id:`} {
+		var rows []row
+		if err := Unmarshal([]byte(doc), &rows); err != errUnterminatedTable {
+			t.Errorf("%s: got %v, want %v", doc, err, errUnterminatedTable)
+		}
+	}
+	for _, doc := range []string{`{()}`, `{(id)}`, `{(id:)}`} {
+		var rows []row
+		if err := Unmarshal([]byte(doc), &rows); err != nil {
+			t.Fatalf("%s: %v", doc, err)
+		}
+		if rows == nil || len(rows) != 0 {
+			t.Errorf("%s: got %#v, want an empty slice", doc, rows)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package god
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// A TableReader reads the rows of a GOD table one at a time, like a
+// sql.Rows cursor. Only the current row is held in memory, so tables of
+// any length can be read in constant space.
+//
+//	tr, err := god.NewTableReader(f)
+//	if err != nil {
+//		return err
+//	}
+//	for tr.Next() {
+//		var u User
+//		if err := tr.ScanInto(&u); err != nil {
+//			return err
+//		}
+//	}
+//	return tr.Err()
+type TableReader struct {
+	d       *Decoder
+	header  []string
+	columns []int // stream column of each Row entry, or nil for all
+	cells   []string
+	quoted  []bool
+	row     []string
+	done    bool
+	err     error
+
+	// rooted is set when the table is the raw value of a root object.
+	rooted bool
+
+	// sep is the cell separator the header gives away.
+	sep byte
+
+	// The field index of each stream column for the last ScanInto type.
+	scanType   reflect.Type
+	scanFields []int
+}
+
+// NewTableReader reads the table header from r and returns a reader
+// positioned before the first row. The stream must start with the table,
+// either bare, as in (a,b:...), or as the raw value of a root object, as in
+// {(a,b:...)}.
+//
+// If headers are given, Row returns only those columns, in that order, and
+// it is an error for the stream to lack any of them.
+func NewTableReader(r io.Reader, headers ...string) (*TableReader, error) {
+	t := &TableReader{d: NewDecoder(r)}
+	if err := t.readHeader(); err != nil {
+		return nil, err
+	}
+	if len(headers) == 0 {
+		return t, nil
+	}
+	for _, h := range headers {
+		i := indexOf(t.header, h)
+		if i < 0 {
+			return nil, fmt.Errorf("table has no column %q", h)
+		}
+		t.columns = append(t.columns, i)
+	}
+	return t, nil
+}
+
+// Header returns the columns of the table as written in the stream.
+func (t *TableReader) Header() []string {
+	return t.header
+}
+
+// Next advances to the next row, returning false at the end of the table
+// or on error. Err distinguishes the two.
+func (t *TableReader) Next() bool {
+	if t.done || t.err != nil {
+		return false
+	}
+	last, err := t.readRow()
+	if err != nil {
+		t.err = err
+		return false
+	}
+	if last {
+		t.done = true
+		if len(strings.TrimSpace(string(t.d.buf))) == 0 {
+			return false
+		}
+	}
+	if err := t.splitRow(); err != nil {
+		t.err = err
+		return false
+	}
+	return true
+}
+
+// Row returns the cells of the current row as text, as in Table. Missing
+// and \0 cells are "". The slice is reused by the next call to Next.
+func (t *TableReader) Row() []string {
+	t.row = t.row[:0]
+	if t.columns == nil {
+		for i := range t.header {
+			t.row = append(t.row, t.cellText(i))
+		}
+		return t.row
+	}
+	for _, i := range t.columns {
+		t.row = append(t.row, t.cellText(i))
+	}
+	return t.row
+}
+
+// ScanInto decodes the current row into the struct pointed to by v,
+// matching columns to fields by name as Unmarshal does for tables. Empty
+// and missing cells ground their fields, so a struct reused for every row
+// keeps nothing from the rows before.
+func (t *TableReader) ScanInto(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("ScanInto target must be a non-nil pointer to a struct")
+	}
+	target := rv.Elem()
+	if target.Type() != t.scanType {
+		t.scanType = target.Type()
+		t.scanFields = t.scanFields[:0]
+		for _, h := range t.header {
+			idx := -1
+			for i := 0; i < t.scanType.NumField(); i++ {
+				field := t.scanType.Field(i)
+				if field.IsExported() && parseFieldTag(field, t.d.opts.FieldNameMapper).name == h {
+					idx = i
+					break
+				}
+			}
+			t.scanFields = append(t.scanFields, idx)
+		}
+	}
+	for col, idx := range t.scanFields {
+		if idx < 0 {
+			continue
+		}
+		// A row cut short leaves its last cells empty.
+		cell, quoted := "", false
+		if col < len(t.cells) {
+			cell, quoted = t.cells[col], t.quoted[col]
+		}
+		field := target.Field(idx)
+		field.Set(reflect.Zero(field.Type()))
+		if err := setCell(field, cell, quoted); err != nil {
+			return fmt.Errorf("column %q: %w", t.header[col], err)
+		}
+	}
+	return nil
+}
+
+// Err returns the error, if any, that stopped Next.
+func (t *TableReader) Err() error {
+	return t.err
+}
+
+// cellText returns the text of stream column i of the current row.
+func (t *TableReader) cellText(i int) string {
+	if i >= len(t.cells) || (!t.quoted[i] && t.cells[i] == `\0`) {
+		return ""
+	}
+	return t.cells[i]
+}
+
+// readHeader consumes the opening of the table through the ':' that ends
+// its header.
+func (t *TableReader) readHeader() error {
+	c, err := t.skipSpaces()
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+	if c == '{' {
+		t.rooted = true
+		if c, err = t.skipSpaces(); err != nil {
+			return unexpectedEOF(err)
+		}
+	}
+	if c != '(' {
+		return fmt.Errorf("expected '(' for table, got '%c'", c)
+	}
+
+	var header strings.Builder
+	for {
+		c, err := t.d.r.ReadByte()
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		if c == ':' || c == ')' {
+			t.done = c == ')' // an empty table
+			break
+		}
+		header.WriteByte(c)
+	}
+	t.sep = headerSeparator(header.String())
+	for _, h := range strings.Split(header.String(), string(t.sep)) {
+		if h = strings.TrimSpace(h); h != "" {
+			t.header = append(t.header, h)
+		}
+	}
+	return nil
+}
+
+// skipSpaces returns the next byte that is not whitespace or part of a
+// comment.
+func (t *TableReader) skipSpaces() (byte, error) {
+	for {
+		c, err := t.d.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if c == '/' && t.d.atComment() {
+			if _, err := t.d.r.ReadBytes('\n'); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if c != ' ' && c != '\n' && c != '\r' && c != '\t' {
+			return c, nil
+		}
+	}
+}
+
+// readRow buffers the text of the next row, up to the ';' that ends it or
+// the ')' that ends the table, and reports whether it was the last.
+func (t *TableReader) readRow() (last bool, err error) {
+	d := t.d
+	d.buf = d.buf[:0]
+	depth := 0
+	for {
+		c, err := d.r.ReadByte()
+		if err != nil {
+			return false, unexpectedEOF(err)
+		}
+		switch c {
+		case ';':
+			if depth == 0 {
+				return false, nil
+			}
+		case ')':
+			if depth == 0 {
+				return true, nil
+			}
+			depth--
+		case '(', '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		}
+		d.buf = append(d.buf, c)
+		if c == '"' {
+			if err := d.readString(); err != nil {
+				return false, unexpectedEOF(err)
+			}
+		}
+	}
+}
+
+// splitRow parses the buffered row into its cells.
+func (t *TableReader) splitRow() error {
+	t.cells, t.quoted = t.cells[:0], t.quoted[:0]
+	p := t.d.opts.newParser(t.d.buf)
+	p.setCellSeparator(t.sep)
+	p.skipSpaces()
+	for !p.eof() {
+		cell, quoted, err := p.readCell()
+		if err != nil {
+			return err
+		}
+		if len(t.cells) == len(t.header) {
+			return fmt.Errorf("table row has more cells than its %d columns", len(t.header))
+		}
+		t.cells = append(t.cells, cell)
+		t.quoted = append(t.quoted, quoted)
+		p.skipSpaces()
+		if p.peek() == t.sep {
+			p.next()
+			p.skipSpaces()
+		} else if !p.eof() {
+			return fmt.Errorf("expected '%c' between table cells, got '%c'", t.sep, p.peek())
+		}
+	}
+	return nil
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
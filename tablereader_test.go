@@ -0,0 +1,142 @@
+package god
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTableReader(t *testing.T) {
+	src := `{(name,age,email:
+  "Alice",30,"a@x.org";
+  "Bob, Jr.",\0,;
+  "Carol",41,"c;(x)"
+)}`
+	tr, err := NewTableReader(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"name", "age", "email"}; !reflect.DeepEqual(tr.Header(), want) {
+		t.Errorf("header: got %v, want %v", tr.Header(), want)
+	}
+
+	var rows [][]string
+	var people []Person
+	for tr.Next() {
+		rows = append(rows, append([]string(nil), tr.Row()...))
+		var p Person
+		if err := tr.ScanInto(&p); err != nil {
+			t.Fatal(err)
+		}
+		people = append(people, p)
+	}
+	if err := tr.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	wantRows := [][]string{
+		{"Alice", "30", "a@x.org"},
+		{"Bob, Jr.", "", ""},
+		{"Carol", "41", "c;(x)"},
+	}
+	if !reflect.DeepEqual(rows, wantRows) {
+		t.Errorf("rows: got %q, want %q", rows, wantRows)
+	}
+	wantPeople := []Person{{Name: "Alice", Age: 30}, {Name: "Bob, Jr."}, {Name: "Carol", Age: 41}}
+	if !reflect.DeepEqual(people, wantPeople) {
+		t.Errorf("people: got %+v, want %+v", people, wantPeople)
+	}
+}
+
+func TestTableReaderColumns(t *testing.T) {
+	tr, err := NewTableReader(strings.NewReader(`(a,b,c:1,2,3;4,5,6;)`), "c", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got [][]string
+	for tr.Next() {
+		got = append(got, append([]string(nil), tr.Row()...))
+	}
+	if want := [][]string{{"3", "1"}, {"6", "4"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := NewTableReader(strings.NewReader(`(a,b:)`), "z"); err == nil {
+		t.Error("expected an error for a missing column")
+	}
+}
+
+func TestTableReaderEmptyAndTruncated(t *testing.T) {
+	tr, err := NewTableReader(strings.NewReader(`{()}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.Next() || tr.Err() != nil {
+		t.Errorf("empty table: expected no rows, got err %v", tr.Err())
+	}
+
+	tr, err = NewTableReader(strings.NewReader(`(a:1;2`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for tr.Next() {
+	}
+	if tr.Err() != io.ErrUnexpectedEOF {
+		t.Errorf("truncated table: expected io.ErrUnexpectedEOF, got %v", tr.Err())
+	}
+}
+
+// rowSource produces an endless-looking table without holding it in memory.
+type rowSource struct {
+	n, rows int
+	pending []byte
+}
+
+func (s *rowSource) Read(b []byte) (int, error) {
+	if len(s.pending) == 0 {
+		switch {
+		case s.n == 0:
+			s.pending = []byte("(id,name:")
+		case s.n > s.rows:
+			return 0, io.EOF
+		case s.n == s.rows:
+			s.pending = []byte(")")
+		default:
+			s.pending = []byte(fmt.Sprintf("%d,\"row %d\";", s.n, s.n))
+		}
+		s.n++
+	}
+	n := copy(b, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+func TestTableReaderStreams(t *testing.T) {
+	const rows = 100000
+	tr, err := NewTableReader(&rowSource{rows: rows})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var row struct {
+		ID   int    `god:"id"`
+		Name string `god:"name"`
+	}
+	count := 0
+	for tr.Next() {
+		if err := tr.ScanInto(&row); err != nil {
+			t.Fatal(err)
+		}
+		count++
+		if row.ID != count {
+			t.Fatalf("row %d: got id %d", count, row.ID)
+		}
+	}
+	if err := tr.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if count != rows-1 {
+		t.Errorf("expected %d rows, got %d", rows-1, count)
+	}
+}
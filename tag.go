@@ -0,0 +1,145 @@
+package god
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// fieldTag is a parsed `god:"name,option,..."` struct tag.
+type fieldTag struct {
+	name string
+
+	// omitEmpty drops the key when the field is zero, and a table column
+	// when the field is zero in every row.
+	omitEmpty bool
+
+	// flatten merges the entries of a map field into the parent object
+	// instead of writing them under the field's own key. In a table each
+	// entry is a column, and columns that match no other field are
+	// decoded into the map of their row.
+	flatten bool
+
+	// redact writes the field as Redacted, see FieldHook.
+	redact bool
+
+	// remain collects the raw values of keys and table columns that match
+	// no other field, so that they survive a round trip.
+	remain bool
+
+	// sep is the cell separator of the table the field holds, given as a
+	// sep=| option. See separator.go.
+	sep string
+
+	// aliases are other keys and column names the field is decoded from,
+	// given as alias=name options. The field is encoded under name alone.
+	aliases []string
+}
+
+// parseFieldTag returns the tag of f. Its name defaults to the field name
+// mapped by mapName or, if mapName is nil, lowercased.
+func parseFieldTag(f reflect.StructField, mapName func(string) string) fieldTag {
+	name, opts, _ := strings.Cut(f.Tag.Get("god"), ",")
+	tag := fieldTag{name: name}
+	if tag.name == "" && mapName != nil {
+		tag.name = mapName(f.Name)
+	} else if tag.name == "" {
+		tag.name = strings.ToLower(f.Name)
+	}
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		switch opt {
+		case "omitempty":
+			tag.omitEmpty = true
+		case "flatten":
+			tag.flatten = true
+		case "redact":
+			tag.redact = true
+		case "remain":
+			tag.remain = true
+		default:
+			if alias, ok := strings.CutPrefix(opt, "alias="); ok && alias != "" {
+				tag.aliases = append(tag.aliases, alias)
+			} else if sep, ok := strings.CutPrefix(opt, "sep="); ok {
+				tag.sep = sep
+			}
+		}
+	}
+	return tag
+}
+
+// duplicateKeyError reports that fields i and j of struct type t are both
+// encoded under key, so that neither could be decoded reliably.
+func duplicateKeyError(t reflect.Type, i, j int, key string) error {
+	return fmt.Errorf("%v: fields %s and %s both have the key %q", t, t.Field(i).Name, t.Field(j).Name, key)
+}
+
+// decodeKeys maps the keys that decode into the fields of struct type t,
+// their names and aliases, to the field indexes. The flatten and remain
+// fields at flatIdx and remainIdx, which take the keys no field does, are
+// left out. Untagged fields are named by mapName, as in parseFieldTag.
+func decodeKeys(t reflect.Type, flatIdx, remainIdx int, mapName func(string) string) (map[string]int, error) {
+	keys := make(map[string]int)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || i == flatIdx || i == remainIdx {
+			continue
+		}
+		tag := parseFieldTag(field, mapName)
+		for _, key := range append([]string{tag.name}, tag.aliases...) {
+			if j, dup := keys[key]; dup && j != i {
+				return nil, duplicateKeyError(t, j, i, key)
+			}
+			keys[key] = i
+		}
+	}
+	return keys, nil
+}
+
+// FieldName returns the key under which the struct field f is encoded:
+// the name in its god tag, or else its lowercased field name. Packages
+// that map structs onto other key-value stores can use it to agree with
+// Marshal and Unmarshal.
+func FieldName(f reflect.StructField) string {
+	return parseFieldTag(f, nil).name
+}
+
+// flattenField returns the index of the first flatten-tagged field of
+// struct type t, or -1 if there is none. The field must be a map.
+func flattenField(t reflect.Type) (int, error) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || !parseFieldTag(field, nil).flatten {
+			continue
+		}
+		if field.Type.Kind() != reflect.Map {
+			return -1, fmt.Errorf("flatten field %s must be a map, got %v", field.Name, field.Type)
+		}
+		return i, nil
+	}
+	return -1, nil
+}
+
+// remainField returns the index of the first remain-tagged field of struct
+// type t, or -1 if there is none. The field must be a map from string to
+// RawMessage or string, and t must not also have a flatten field, which
+// would compete for the same keys.
+func remainField(t reflect.Type) (int, error) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || !parseFieldTag(field, nil).remain {
+			continue
+		}
+		ft := field.Type
+		if ft.Kind() != reflect.Map || ft.Key().Kind() != reflect.String ||
+			(ft.Elem() != rawMessageType && ft.Elem().Kind() != reflect.String) {
+			return -1, fmt.Errorf("remain field %s must be a map[string]RawMessage or map[string]string, got %v", field.Name, ft)
+		}
+		if flatIdx, _ := flattenField(t); flatIdx >= 0 {
+			return -1, fmt.Errorf("remain field %s cannot be combined with flatten field %s", field.Name, t.Field(flatIdx).Name)
+		}
+		return i, nil
+	}
+	return -1, nil
+}
@@ -0,0 +1,60 @@
+package god
+
+import (
+	"strings"
+	"testing"
+)
+
+type account struct {
+	UserName string `god:"user_name,alias=username,alias=uname"`
+	Age      int
+}
+
+func TestTagAliasTableColumns(t *testing.T) {
+	for _, col := range []string{"user_name", "username", "uname"} {
+		var out struct{ Accounts []account }
+		doc := `{accounts=(` + col + `,age:"ann",30;"bob",41;)}`
+		if err := Unmarshal([]byte(doc), &out); err != nil {
+			t.Fatalf("%s: %v", col, err)
+		}
+		if len(out.Accounts) != 2 || out.Accounts[0].UserName != "ann" || out.Accounts[1].Age != 41 {
+			t.Errorf("%s: got %+v", col, out.Accounts)
+		}
+	}
+}
+
+func TestTagAliasObjectKeys(t *testing.T) {
+	var out account
+	if err := Unmarshal([]byte(`{uname="ann";age=30}`), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.UserName != "ann" || out.Age != 30 {
+		t.Errorf("got %+v", out)
+	}
+
+	data, err := Marshal(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{user_name="ann";age=30}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+	data, err = Marshal(map[string][]account{"a": {out}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{a=(user_name,age:"ann",30;)}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+func TestTagAliasCollision(t *testing.T) {
+	var out struct {
+		Name  string `god:"name,alias=id"`
+		Ident string `god:"id"`
+	}
+	err := Unmarshal([]byte(`{name="x"}`), &out)
+	if err == nil || !strings.Contains(err.Error(), `both have the key "id"`) {
+		t.Errorf("got %v, want duplicate key error", err)
+	}
+}
@@ -0,0 +1,13 @@
+module github.com/vinayakgupta29/god/testify
+
+go 1.21
+
+require github.com/vinayakgupta29/god v0.1.0
+
+require github.com/stretchr/testify v1.10.0
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
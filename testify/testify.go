@@ -0,0 +1,73 @@
+// Package testify provides GOD-aware assertions that plug into
+// github.com/stretchr/testify.
+//
+// Documents are compared by meaning rather than by bytes: key order,
+// whitespace, optional semicolons and compact versus beautified layout do
+// not matter. Failures list the paths that differ:
+//
+//	GOD documents are not semantically equal:
+//	  employees[1].age: expected 25, got 26
+//	  founded: missing in actual
+package testify
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vinayakgupta29/god"
+)
+
+type tHelper interface {
+	Helper()
+}
+
+// AssertGODEqual asserts that two GOD documents are semantically equal.
+func AssertGODEqual(t assert.TestingT, expected, actual []byte, msgAndArgs ...interface{}) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	diffs, err := god.Diff(expected, actual)
+	if err != nil {
+		// Diff says which document it could not decode, want or got.
+		name, doc := "Actual", actual
+		if strings.HasPrefix(err.Error(), "want: ") {
+			name, doc = "Expected", expected
+		}
+		return assert.Fail(t, fmt.Sprintf("%s is not a valid GOD document: %v\n%s", name, errors.Unwrap(err), doc), msgAndArgs...)
+	}
+	if len(diffs) == 0 {
+		return true
+	}
+	lines := make([]string, len(diffs))
+	for i, d := range diffs {
+		lines[i] = d.String()
+	}
+	return assert.Fail(t, "GOD documents are not semantically equal:\n  "+strings.Join(lines, "\n  "), msgAndArgs...)
+}
+
+// RequireGODEqual is like AssertGODEqual but stops the test on failure.
+func RequireGODEqual(t require.TestingT, expected, actual []byte, msgAndArgs ...interface{}) {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	if !AssertGODEqual(t, expected, actual, msgAndArgs...) {
+		t.FailNow()
+	}
+}
+
+// AssertGODMatchesStruct decodes godBytes into a new value of expected's
+// type and asserts that it is reflect.DeepEqual to expected.
+func AssertGODMatchesStruct(t assert.TestingT, expected interface{}, godBytes []byte, msgAndArgs ...interface{}) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	actual := reflect.New(reflect.TypeOf(expected))
+	if err := god.Unmarshal(godBytes, actual.Interface()); err != nil {
+		return assert.Fail(t, fmt.Sprintf("Cannot decode GOD document into %T: %v\n%s", expected, err, godBytes), msgAndArgs...)
+	}
+	return assert.Equal(t, expected, actual.Elem().Interface(), msgAndArgs...)
+}
@@ -0,0 +1,94 @@
+package testify
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type person struct {
+	Name string `god:"name"`
+	Age  int    `god:"age"`
+}
+
+// recorder is a TestingT that captures failures instead of failing.
+type recorder struct {
+	messages []string
+	fatal    bool
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.messages = append(r.messages, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) FailNow() { r.fatal = true }
+
+func TestAssertGODEqualSemantic(t *testing.T) {
+	compact := []byte(`{name="TechCorp";founded=2020;employees=(name,age:"Alice",30;"Bob",25;)}`)
+	pretty := []byte("{\n  employees=(name,age:\n    \"Alice\",30;\n    \"Bob\",25;\n  )\n  founded=2020\n  name=\"TechCorp\"\n}")
+	AssertGODEqual(t, compact, pretty)
+	RequireGODEqual(t, pretty, compact)
+}
+
+func TestAssertGODEqualDiff(t *testing.T) {
+	r := &recorder{}
+	ok := AssertGODEqual(r,
+		[]byte(`{name="TechCorp";founded=2020;employees=(name,age:"Alice",30;"Bob",25;);tags=["a","b"]}`),
+		[]byte(`{name="TechCorp";employees=(name,age:"Alice",30;"Bob",26;);tags=["a"];extra=1}`),
+	)
+	if ok || len(r.messages) != 1 {
+		t.Fatalf("expected a single failure, got ok=%v messages=%v", ok, r.messages)
+	}
+	for _, want := range []string{
+		"GOD documents are not semantically equal",
+		"employees[1].age: expected 25, got 26",
+		"founded: missing in actual",
+		"extra: unexpected key with value 1",
+		"tags: expected 2 elements, got 1",
+	} {
+		if !strings.Contains(r.messages[0], want) {
+			t.Errorf("expected failure to contain %q, got:\n%s", want, r.messages[0])
+		}
+	}
+	if r.fatal {
+		t.Error("AssertGODEqual must not stop the test")
+	}
+}
+
+func TestRequireGODEqualFailsNow(t *testing.T) {
+	r := &recorder{}
+	RequireGODEqual(r, []byte(`{a=1}`), []byte(`{a=2}`))
+	if !r.fatal {
+		t.Error("expected RequireGODEqual to call FailNow")
+	}
+}
+
+func TestAssertGODEqualInvalid(t *testing.T) {
+	r := &recorder{}
+	if AssertGODEqual(r, []byte(`{a=1}`), []byte(`a=1`)) {
+		t.Fatal("expected failure for invalid document")
+	}
+	if !strings.Contains(r.messages[0], "Actual is not a valid GOD document") {
+		t.Errorf("unexpected message: %s", r.messages[0])
+	}
+
+	r = &recorder{}
+	if AssertGODEqual(r, []byte(`a=1`), []byte(`{a=1}`)) {
+		t.Fatal("expected failure for invalid document")
+	}
+	if !strings.Contains(r.messages[0], "Expected is not a valid GOD document") {
+		t.Errorf("unexpected message: %s", r.messages[0])
+	}
+}
+
+func TestAssertGODMatchesStruct(t *testing.T) {
+	AssertGODMatchesStruct(t, person{Name: "Alice", Age: 30}, []byte(`{name="Alice";age=30}`))
+
+	r := &recorder{}
+	if AssertGODMatchesStruct(r, person{Name: "Alice", Age: 30}, []byte(`{name="Alice";age=31}`)) {
+		t.Fatal("expected mismatch")
+	}
+	if !strings.Contains(r.messages[0], "Age") {
+		t.Errorf("expected diff to mention the differing field, got:\n%s", r.messages[0])
+	}
+}
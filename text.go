@@ -0,0 +1,76 @@
+package god
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+)
+
+// Types that implement encoding.TextMarshaler and TextUnmarshaler, such as
+// time.Time and net.IP, are written as quoted strings holding their text.
+// The GOD methods, MarshalGOD and UnmarshalGOD, take precedence over them.
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// textMarshalerFor returns v or its address, whichever implements
+// encoding.TextMarshaler, or an invalid Value if neither does.
+func textMarshalerFor(v reflect.Value) reflect.Value {
+	if v.Type().Implements(textMarshalerType) {
+		return v
+	}
+	if v.CanAddr() && reflect.PtrTo(v.Type()).Implements(textMarshalerType) {
+		return v.Addr()
+	}
+	return reflect.Value{}
+}
+
+// marshalsItself reports whether values of type t are written by their
+// own methods rather than field by field.
+func marshalsItself(t reflect.Type) bool {
+	pt := reflect.PtrTo(t)
+	for _, m := range []reflect.Type{godMarshalerType, annotatedMarshalerType, textMarshalerType} {
+		if t.Implements(m) || pt.Implements(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeText writes the text of m, the result of textMarshalerFor, as a
+// quoted string.
+func encodeText(b *encodeState, m reflect.Value) error {
+	text, err := m.Interface().(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return fmt.Errorf("marshaling %v: %w", m.Type(), err)
+	}
+	b.WriteString(b.quote(string(text)))
+	return nil
+}
+
+// textUnmarshalerFor returns the address of target if it implements
+// encoding.TextUnmarshaler, or an invalid Value.
+func textUnmarshalerFor(target reflect.Value) reflect.Value {
+	if target.Kind() != reflect.Ptr && target.CanAddr() && reflect.PtrTo(target.Type()).Implements(textUnmarshalerType) {
+		return target.Addr()
+	}
+	return reflect.Value{}
+}
+
+// decodeText reads a string and hands it to the UnmarshalText method of u.
+func decodeText(p *parser, u reflect.Value) error {
+	s, err := parseStringValue(p)
+	if err != nil {
+		return err
+	}
+	return unmarshalText(u, s)
+}
+
+func unmarshalText(u reflect.Value, s string) error {
+	if err := u.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+		return fmt.Errorf("unmarshaling %v: %w", u.Type().Elem(), err)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package god
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// severity is an enum that marshals itself as text.
+type severity int
+
+func (l severity) MarshalText() ([]byte, error) {
+	switch l {
+	case 1:
+		return []byte("warn"), nil
+	case 2:
+		return []byte("error"), nil
+	}
+	return nil, errors.New("unknown level")
+}
+
+func (l *severity) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "warn":
+		*l = 1
+	case "error":
+		*l = 2
+	default:
+		return errors.New("unknown level " + string(text))
+	}
+	return nil
+}
+
+type event struct {
+	At    time.Time `god:"at"`
+	Addr  net.IP    `god:"addr"`
+	Level severity  `god:"level"`
+}
+
+var eventTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func TestTextMarshaler(t *testing.T) {
+	v := event{At: eventTime, Addr: net.ParseIP("10.0.0.1"), Level: 2}
+	data, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{at="2024-01-02T03:04:05Z";addr="10.0.0.1";level="error"}`; string(data) != want {
+		t.Fatalf("got %s, want %s", data, want)
+	}
+	var back event
+	if err := Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !back.At.Equal(eventTime) || !back.Addr.Equal(v.Addr) || back.Level != 2 {
+		t.Errorf("got %+v, want %+v", back, v)
+	}
+
+	// Zero values are grounded like any other.
+	data, err = Marshal(event{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{at=;addr=;level=}`; string(data) != want {
+		t.Errorf("zero event: got %s, want %s", data, want)
+	}
+}
+
+func TestTextMarshalerTable(t *testing.T) {
+	rows := []event{
+		{At: eventTime, Addr: net.ParseIP("10.0.0.1"), Level: 1},
+		{At: eventTime.Add(time.Hour), Level: 2},
+	}
+	data, err := Marshal(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{(at,addr,level:"2024-01-02T03:04:05Z","10.0.0.1","warn";"2024-01-02T04:04:05Z",,"error";)}`
+	if string(data) != want {
+		t.Fatalf("got %s, want %s", data, want)
+	}
+	var back []event
+	if err := Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if len(back) != 2 || !back[1].At.Equal(rows[1].At) || back[1].Addr != nil || back[0].Level != 1 {
+		t.Errorf("got %+v", back)
+	}
+}
+
+func TestTextMarshalerRootAndList(t *testing.T) {
+	data, err := Marshal(eventTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"2024-01-02T03:04:05Z"}`; string(data) != want {
+		t.Errorf("root: got %s, want %s", data, want)
+	}
+	var at time.Time
+	if err := Unmarshal(data, &at); err != nil || !at.Equal(eventTime) {
+		t.Errorf("root decode = %v, %v", at, err)
+	}
+
+	times := []time.Time{eventTime, eventTime.Add(time.Minute)}
+	data, err = Marshal(map[string]interface{}{"times": times})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{times=["2024-01-02T03:04:05Z","2024-01-02T03:05:05Z"]}`; string(data) != want {
+		t.Errorf("list: got %s, want %s", data, want)
+	}
+	var back struct {
+		Times []time.Time `god:"times"`
+	}
+	if err := Unmarshal(data, &back); err != nil || !reflect.DeepEqual(back.Times, times) {
+		t.Errorf("list decode = %v, %v", back.Times, err)
+	}
+}
+
+func TestTextMarshalerErrors(t *testing.T) {
+	if _, err := Marshal(event{Level: 7}); err == nil || !strings.Contains(err.Error(), "unknown level") {
+		t.Errorf("marshal: got %v", err)
+	}
+	var v event
+	if err := Unmarshal([]byte(`{level="info"}`), &v); err == nil || !strings.Contains(err.Error(), "unknown level info") {
+		t.Errorf("unmarshal: got %v", err)
+	}
+	var rows []event
+	if err := Unmarshal([]byte(`{(level:"info";)}`), &rows); err == nil {
+		t.Error("unmarshal table: expected error")
+	}
+}
+
+// godText has both kinds of methods; the GOD ones win.
+type godText struct{ s string }
+
+func (g godText) MarshalText() ([]byte, error)    { return []byte("text"), nil }
+func (g godText) MarshalGOD() ([]byte, error)     { return []byte(`"god"`), nil }
+func (g *godText) UnmarshalText(b []byte) error   { g.s = "text"; return nil }
+func (g *godText) UnmarshalGOD(data []byte) error { g.s = "god"; return nil }
+
+func TestGODMethodsBeforeText(t *testing.T) {
+	data, err := Marshal(map[string]godText{"v": {"x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{v="god"}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+	var back map[string]godText
+	if err := Unmarshal(data, &back); err != nil || back["v"].s != "god" {
+		t.Errorf("got %+v, %v", back, err)
+	}
+}
@@ -0,0 +1,71 @@
+package god
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Layouts for MarshalOptions.TimeFormat and UnmarshalOptions.TimeFormat
+// that write a time.Time as a bare integer, the seconds or milliseconds
+// since the Unix epoch, rather than a quoted string. Times decoded from
+// them are in UTC.
+const (
+	TimeUnix      = "unix"
+	TimeUnixMilli = "unixmilli"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// encodeTime writes t in layout, a time.Format layout or one of TimeUnix
+// and TimeUnixMilli.
+func encodeTime(b *encodeState, t time.Time, layout string) error {
+	switch layout {
+	case TimeUnix:
+		b.WriteString(strconv.FormatInt(t.Unix(), 10))
+	case TimeUnixMilli:
+		b.WriteString(strconv.FormatInt(t.UnixMilli(), 10))
+	default:
+		b.WriteString(b.quote(t.Format(layout)))
+	}
+	return nil
+}
+
+// decodeTime reads a time written in layout into target, a time.Time.
+func decodeTime(p *parser, target reflect.Value, layout string) error {
+	if layout != TimeUnix && layout != TimeUnixMilli {
+		start := p.pos
+		s, err := parseStringValue(p)
+		if err != nil {
+			return err
+		}
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			return fmt.Errorf("invalid time at pos %d: %w", start, err)
+		}
+		target.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	start := p.pos
+	n, err := parseInt(p)
+	if err != nil {
+		return fmt.Errorf("invalid %s time at pos %d: %w", layout, start, err)
+	}
+	t := time.Unix(n, 0)
+	if layout == TimeUnixMilli {
+		t = time.UnixMilli(n)
+	}
+	target.Set(reflect.ValueOf(t.UTC()))
+	return nil
+}
+
+// decodesTime reports whether p decodes values of type t, or what t
+// points to, with a configured time layout.
+func (p *parser) decodesTime(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return p.timeFormat != "" && t == timeType
+}
@@ -0,0 +1,77 @@
+package god
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+type deploy struct {
+	Name string
+	At   time.Time
+	Done *time.Time
+}
+
+func TestTimeFormat(t *testing.T) {
+	at := time.Date(2024, 3, 9, 14, 30, 5, 0, time.UTC)
+	done := at.Add(90 * time.Second)
+	in := []deploy{{Name: "api", At: at, Done: &done}, {Name: "web", At: at}}
+
+	tests := []struct {
+		layout string
+		want   string
+		back   time.Time // At as it decodes, where the layout drops detail
+	}{
+		{"", `{(name,at,done:"api","2024-03-09T14:30:05Z","2024-03-09T14:31:35Z";"web","2024-03-09T14:30:05Z",\0;)}`, at},
+		{time.DateOnly, `{(name,at,done:"api","2024-03-09","2024-03-09";"web","2024-03-09",\0;)}`, time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC)},
+		{TimeUnix, `{(name,at,done:"api",1709994605,1709994695;"web",1709994605,\0;)}`, at},
+		{TimeUnixMilli, `{(name,at,done:"api",1709994605000,1709994695000;"web",1709994605000,\0;)}`, at},
+	}
+	for _, tt := range tests {
+		data, err := MarshalWith(in, WithTimeFormat(tt.layout))
+		if err != nil {
+			t.Fatalf("%q: %v", tt.layout, err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.layout, data, tt.want)
+		}
+		var out []deploy
+		if err := UnmarshalWith(data, &out, WithTimeFormat(tt.layout)); err != nil {
+			t.Fatalf("%q: %v", tt.layout, err)
+		}
+		if len(out) != 2 || !out[0].At.Equal(tt.back) || out[0].Done == nil || out[1].Done != nil {
+			t.Errorf("%q: got %+v", tt.layout, out)
+		}
+	}
+
+	var obj deploy
+	if err := UnmarshalWith([]byte(`{at=1709994605}`), &obj, WithTimeFormat(TimeUnix)); err != nil || !obj.At.Equal(at) {
+		t.Errorf("object: got %v, %v", obj.At, err)
+	}
+	if err := UnmarshalWith([]byte(`{at="yesterday"}`), &obj, WithTimeFormat(time.DateOnly)); err == nil {
+		t.Error("want error for a time in the wrong layout")
+	}
+}
+
+func TestStreamTimeFormat(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetTimeFormat(TimeUnixMilli)
+	at := time.UnixMilli(1700000000123).UTC()
+	if err := enc.Encode(deploy{Name: "x", At: at}); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); !strings.Contains(got, "at=1700000000123") {
+		t.Fatalf("got %s", got)
+	}
+	dec := NewDecoder(&buf)
+	dec.SetTimeFormat(TimeUnixMilli)
+	var out deploy
+	if err := dec.Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !out.At.Equal(at) {
+		t.Errorf("got %v, want %v", out.At, at)
+	}
+}
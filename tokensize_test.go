@@ -0,0 +1,61 @@
+package god
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMaxTokenSize(t *testing.T) {
+	long := strings.Repeat("a", 100)
+	opts := UnmarshalOptions{MaxTokenSize: 64}
+	cases := []struct {
+		name   string
+		doc    string
+		target interface{}
+	}{
+		{"bare value", `{age=` + strings.Repeat("1", 100) + `}`, &Person{}},
+		{"string", `{name="` + long + `"}`, &Person{}},
+		{"triple string", `{name="""` + long + "\n" + `"""}`, &Person{}},
+		{"key", `{` + long + `=1}`, &map[string]int{}},
+		{"quoted key", `{"` + long + `"=1}`, &map[string]int{}},
+		{"table cell", `{(age:` + strings.Repeat("1", 100) + `;)}`, &[]Person{}},
+		{"generic", `{x=` + strings.Repeat("1", 100) + `}`, new(interface{})},
+		{"skipped field", `{unknown=` + strings.Repeat("1", 100) + `}`, &Person{}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := opts.Unmarshal([]byte(c.doc), c.target)
+			if !errors.Is(err, ErrTokenTooLarge) {
+				t.Fatalf("expected ErrTokenTooLarge, got %v", err)
+			}
+		})
+	}
+
+	// Tokens within the limit, and any token with the limit disabled, decode.
+	var p Person
+	if err := opts.Unmarshal([]byte(`{name="`+long[:64]+`"}`), &p); err != nil {
+		t.Errorf("token at the limit: %v", err)
+	}
+	if err := (UnmarshalOptions{MaxTokenSize: -1}).Unmarshal([]byte(`{name="`+long+`"}`), &p); err != nil || p.Name != long {
+		t.Errorf("unlimited: %v", err)
+	}
+
+	// The default limit applies to plain Unmarshal.
+	huge := `{name="` + strings.Repeat("x", DefaultMaxTokenSize+1) + `"}`
+	if err := Unmarshal([]byte(huge), &p); !errors.Is(err, ErrTokenTooLarge) {
+		t.Errorf("expected default limit to apply, got %v", err)
+	}
+}
+
+func TestDecoderMaxTokenSize(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`{name="short"}{name="` + strings.Repeat("a", 100) + `"}`))
+	d.SetMaxTokenSize(64)
+	var p Person
+	if err := d.Decode(&p); err != nil || p.Name != "short" {
+		t.Fatalf("first document: %+v, %v", p, err)
+	}
+	if err := d.Decode(&p); !errors.Is(err, ErrTokenTooLarge) {
+		t.Errorf("expected ErrTokenTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package god
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDisallowTrailingData(t *testing.T) {
+	strict := UnmarshalOptions{DisallowTrailingData: true}
+	cases := []struct {
+		name string
+		doc  string
+		ok   bool
+	}{
+		{"clean", `{name="x";age=1}`, true},
+		{"trailing whitespace", "{name=\"x\"}\n\t ", true},
+		{"garbage", `{name="x"} trailing garbage`, false},
+		{"concatenated", `{name="x"}{name="y"}`, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var p Person
+			err := strict.Unmarshal([]byte(c.doc), &p)
+			if c.ok && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !c.ok {
+				if err == nil || !strings.Contains(err.Error(), "trailing data") {
+					t.Fatalf("expected trailing data error, got %v", err)
+				}
+			}
+			if p.Name != "x" {
+				t.Errorf("expected name to be decoded, got %+v", p)
+			}
+		})
+	}
+
+	// Other root kinds are checked too.
+	var s string
+	if err := strict.Unmarshal([]byte(`{"x"} junk`), &s); err == nil {
+		t.Error("expected error for raw root with trailing data")
+	}
+	var people []Person
+	if err := strict.Unmarshal([]byte(`{(name:"a";)} junk`), &people); err == nil {
+		t.Error("expected error for table root with trailing data")
+	}
+
+	// The default stays lenient.
+	var p Person
+	if err := Unmarshal([]byte(`{name="x"} trailing garbage`), &p); err != nil {
+		t.Errorf("default Unmarshal should ignore trailing data, got %v", err)
+	}
+}
+
+func TestTrailingNewline(t *testing.T) {
+	p := Person{Name: "x", Age: 1}
+	for _, beautify := range []bool{false, true} {
+		data, err := MarshalOptions{Beautify: beautify, TrailingNewline: true}.Marshal(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(string(data), "}\n") {
+			t.Errorf("beautify=%v: expected exactly one trailing newline, got %q", beautify, data)
+		}
+	}
+
+	// The raw API is unchanged.
+	data, err := MarshalBeautify(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.HasSuffix(string(data), "\n") {
+		t.Errorf("MarshalBeautify should not add a newline, got %q", data)
+	}
+
+	// Any amount of trailing whitespace is accepted, even when trailing
+	// data is not.
+	strict := UnmarshalOptions{DisallowTrailingData: true}
+	for _, doc := range []string{"{name=\"x\"}\n", "{name=\"x\"}\n\n\r\n \t\n"} {
+		var got Person
+		if err := strict.Unmarshal([]byte(doc), &got); err != nil || got.Name != "x" {
+			t.Errorf("%q: got %+v, %v", doc, got, err)
+		}
+	}
+	var s string
+	if err := strict.Unmarshal([]byte("{\"x\"}\n\n"), &s); err != nil || s != "x" {
+		t.Errorf("raw root: got %q, %v", s, err)
+	}
+	var people []Person
+	if err := strict.Unmarshal([]byte("{(name:\"x\";)}\n"), &people); err != nil || len(people) != 1 {
+		t.Errorf("table root: got %+v, %v", people, err)
+	}
+}
+
+// TestFormatIdempotent reformats documents the way a formatter would, by
+// decoding them generically and writing them beautified with a trailing
+// newline. Formatting the output again must not change it.
+func TestFormatIdempotent(t *testing.T) {
+	format := func(src []byte) []byte {
+		t.Helper()
+		var v interface{}
+		if err := Unmarshal(src, &v); err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+		out, err := MarshalWith(v, Beautify(), TrailingNewline())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	docs := []string{
+		`{name="x";tags=["a","b"];nested={k=1}}`,
+		"{\n  name = \"x\";\n}\n\n\n",
+		`{(id,name:1,"a";2,"b";)}`,
+		`{"hello"}`,
+	}
+	for _, doc := range docs {
+		once := format([]byte(doc))
+		twice := format(once)
+		if string(once) != string(twice) {
+			t.Errorf("%s: formatting is not idempotent:\n%s\nthen\n%s", doc, once, twice)
+		}
+		if !strings.HasSuffix(string(once), "}\n") || strings.HasSuffix(string(once), "\n\n") {
+			t.Errorf("%s: expected one trailing newline, got %q", doc, once)
+		}
+	}
+}
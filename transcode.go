@@ -0,0 +1,459 @@
+package god
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Transcode rewrites the GOD document in src in the layout Marshal would
+// use, without decoding it into a Go value: compact by default, or indented
+// with Beautify(). TrailingNewline() and Indent() are honoured too; other
+// options are ignored.
+//
+// Transcode works on tokens alone. Strings, including triple-quoted ones,
+// bare values, grounded nulls, annotations and @include directives are
+// copied byte for byte; only the whitespace between tokens changes, and a
+// missing ';' after the last table row is added. Keys keep their order.
+// Comments are kept in beautified output, on their own lines or after the
+// entry or row they follow, and dropped in compact output, where a line
+// comment would swallow the rest of the document.
+func Transcode(src []byte, opts ...Option) ([]byte, error) {
+	o := newOptions(opts).marshal
+	if err := checkIndent(o.Indent); err != nil {
+		return nil, err
+	}
+	t := &transcoder{p: UnmarshalOptions{}.newParser(src), compact: !o.Beautify, indentUnit: o.Indent}
+	t.skip()
+	if t.p.peek() != '{' {
+		return nil, fmt.Errorf("expected '{' at pos %d", t.p.pos)
+	}
+	t.flush(0)
+	if err := t.value(1); err != nil {
+		return nil, err
+	}
+	t.skip()
+	if !t.p.eof() {
+		return nil, fmt.Errorf("unexpected data after document at pos %d", t.p.pos)
+	}
+	t.trailing()
+	for _, c := range t.comments {
+		t.out.WriteString("\n" + c.text)
+	}
+	if o.TrailingNewline {
+		t.out.WriteByte('\n')
+	}
+	return []byte(t.out.String()), nil
+}
+
+// Minify rewrites the GOD document in src in compact form, dropping its
+// comments. It is Transcode(src) under a name that reads well at call
+// sites.
+func Minify(src []byte) ([]byte, error) {
+	return Transcode(src)
+}
+
+// A transcoder copies the tokens of a document from p to out, laying them
+// out as the encoder does. Levels follow the encoder's: the keys of an
+// object at level n are indented n times.
+type transcoder struct {
+	p          *parser
+	out        strings.Builder
+	compact    bool
+	indentUnit string
+
+	// comments holds the comments read but not yet written.
+	comments []comment
+}
+
+// A comment is a // comment of the source, up to the end of its line.
+// ownLine reports whether it started a line rather than following a token.
+type comment struct {
+	text    string
+	ownLine bool
+}
+
+func (t *transcoder) indent(level int) string {
+	return indentBy(t.indentUnit, level)
+}
+
+// skip consumes whitespace and comments, keeping the comments for
+// beautified output.
+func (t *transcoder) skip() {
+	p := t.p
+	if t.compact {
+		p.skipSpaces()
+		return
+	}
+	ownLine := p.pos == 0
+	for !p.eof() {
+		switch p.peek() {
+		case '\n':
+			ownLine = true
+			p.pos++
+		case ' ', '\r', '\t':
+			p.pos++
+		case '/':
+			if p.pos+1 == len(p.src) || p.src[p.pos+1] != '/' {
+				return
+			}
+			end := len(p.src)
+			if i := bytes.IndexByte(p.src[p.pos:], '\n'); i >= 0 {
+				end = p.pos + i
+			}
+			text := strings.TrimRight(string(p.src[p.pos:end]), " \r\t")
+			t.comments = append(t.comments, comment{text: text, ownLine: ownLine})
+			p.pos = end
+		default:
+			return
+		}
+	}
+}
+
+// trailing writes the first pending comment at the end of the line just
+// written, if it followed a token in the source.
+func (t *transcoder) trailing() {
+	if len(t.comments) > 0 && !t.comments[0].ownLine {
+		t.out.WriteString(" " + t.comments[0].text)
+		t.comments = t.comments[1:]
+	}
+}
+
+// flush writes the pending comments on lines of their own, indented for
+// level.
+func (t *transcoder) flush(level int) {
+	for _, c := range t.comments {
+		t.out.WriteString(t.indent(level) + c.text + "\n")
+	}
+	t.comments = t.comments[:0]
+}
+
+// inline writes the pending comments where the output does not break
+// lines, each ending its line, and indents the next token for level.
+func (t *transcoder) inline(level int) {
+	for _, c := range t.comments {
+		t.out.WriteString(" " + c.text + "\n" + t.indent(level))
+	}
+	t.comments = t.comments[:0]
+}
+
+func (t *transcoder) value(level int) error {
+	p := t.p
+	t.skip()
+	t.inline(level)
+	if a := p.readAnnotation(); a != "" {
+		t.out.WriteString(a)
+		spaced := p.pos
+		t.skip()
+		// Keep a space that stops the annotation running into the value.
+		if p.pos > spaced && !p.eof() && isAnnotationByte(p.peek()) {
+			t.out.WriteByte(' ')
+		}
+	}
+	switch p.peek() {
+	case '{':
+		return t.object(level)
+	case '[':
+		return t.list(level)
+	case '(':
+		return t.table(level)
+	case '"':
+		start := p.pos
+		if _, err := parseStringValue(p); err != nil {
+			return err
+		}
+		t.out.Write(p.src[start:p.pos])
+		return nil
+	}
+	start := p.pos
+	tok, err := p.scanBareToken()
+	if err != nil {
+		return err
+	}
+	if len(tok) == 0 {
+		if p.eof() {
+			return fmt.Errorf("unexpected end of document at pos %d", start)
+		}
+		return fmt.Errorf("unexpected '%c' at pos %d", p.peek(), start)
+	}
+	t.out.Write(tok)
+	return nil
+}
+
+func (t *transcoder) newline(level int) {
+	if !t.compact {
+		t.out.WriteString("\n" + t.indent(level))
+	}
+}
+
+// expect consumes c after any whitespace.
+func (t *transcoder) expect(c byte) error {
+	t.skip()
+	if t.p.eof() {
+		return fmt.Errorf("expected '%c' at end of document", c)
+	}
+	if got := t.p.next(); got != c {
+		return fmt.Errorf("expected '%c', got '%c' at pos %d", c, got, t.p.pos-1)
+	}
+	return nil
+}
+
+func (t *transcoder) object(level int) error {
+	p := t.p
+	p.next() // consume '{'
+	t.skip()
+	t.out.WriteByte('{')
+	if p.peek() == '}' {
+		p.next()
+		if !t.compact {
+			t.trailing()
+			t.out.WriteByte('\n')
+			t.flush(level)
+		}
+		t.out.WriteString(t.closing(level) + "}")
+		return nil
+	}
+
+	// Rule 5: an object holds key-value pairs or one naked value.
+	saved := p.pos
+	key, quoted, _ := p.readKey()
+	p.skipSpaces()
+	isMap := p.peek() == '=' || !quoted && key == includeDirective
+	p.pos = saved
+	if !isMap {
+		if !t.compact {
+			t.trailing()
+			t.out.WriteByte('\n')
+			t.flush(level)
+			t.out.WriteString(t.indent(level))
+		}
+		if err := t.value(level + 1); err != nil {
+			return err
+		}
+		t.skip()
+		if err := p.endNaked(); err != nil {
+			return err
+		}
+		if !t.compact {
+			t.trailing()
+			t.out.WriteByte('\n')
+			t.flush(level)
+		}
+		t.out.WriteString(t.closing(level) + "}")
+		return nil
+	}
+
+	if !t.compact {
+		t.trailing()
+		t.out.WriteByte('\n')
+	}
+	for n := 0; ; n++ {
+		t.skip()
+		if p.eof() {
+			return fmt.Errorf("unterminated object at pos %d", saved-1)
+		}
+		if !t.compact {
+			t.flush(level)
+		}
+		if p.peek() == '}' {
+			p.next()
+			break
+		}
+		if n > 0 && t.compact {
+			t.out.WriteByte(';')
+		}
+		if !t.compact {
+			t.out.WriteString(t.indent(level))
+		}
+		start := p.pos
+		key, quoted, err := p.readKey()
+		if err != nil {
+			return err
+		}
+		t.out.Write(p.src[start:p.pos])
+		t.skip()
+		if !quoted && key == includeDirective {
+			// The directive is copied, not resolved.
+			if p.peek() != '"' {
+				return fmt.Errorf("%s: expected a string at pos %d", includeDirective, p.pos)
+			}
+			t.out.WriteByte(' ')
+			if err := t.value(level + 1); err != nil {
+				return err
+			}
+		} else {
+			if p.peek() != '=' {
+				if err := p.nakedInPairs(key, quoted, start); err != nil {
+					return err
+				}
+			}
+			if err := t.expect('='); err != nil {
+				return err
+			}
+			t.out.WriteByte('=')
+			t.skip()
+			if c := p.peek(); c != ';' && c != '}' {
+				if err := t.value(level + 1); err != nil {
+					return err
+				}
+			}
+		}
+		t.skip()
+		if p.peek() == ';' {
+			p.next()
+			t.skip()
+		} else if p.peek() != '}' {
+			return fmt.Errorf("expected ';' or '}' at pos %d", p.pos)
+		}
+		if !t.compact {
+			t.out.WriteByte(';')
+			t.trailing()
+			t.out.WriteByte('\n')
+		}
+	}
+	t.out.WriteString(t.closing(level) + "}")
+	return nil
+}
+
+// closing returns the indentation of the bracket that closes a value at
+// level.
+func (t *transcoder) closing(level int) string {
+	if t.compact {
+		return ""
+	}
+	return t.indent(level - 1)
+}
+
+func (t *transcoder) list(level int) error {
+	p := t.p
+	start := p.pos
+	p.next() // consume '['
+	t.out.WriteByte('[')
+	for {
+		t.skip()
+		if p.eof() {
+			return fmt.Errorf("unterminated list at pos %d", start)
+		}
+		if c := p.peek(); c != ',' && c != ']' {
+			if err := t.value(level); err != nil {
+				return err
+			}
+			t.skip()
+		}
+		if p.eof() {
+			return fmt.Errorf("unterminated list at pos %d", start)
+		}
+		t.inline(level)
+		switch c := p.next(); c {
+		case ',':
+			t.out.WriteByte(',')
+		case ']':
+			t.out.WriteByte(']')
+			return nil
+		default:
+			return fmt.Errorf("expected ',' or ']', got '%c' at pos %d", c, p.pos-1)
+		}
+	}
+}
+
+func (t *transcoder) table(level int) error {
+	p := t.p
+	start := p.pos
+	p.next() // consume '('
+	header, err := p.readUntilAny(":)")
+	if err != nil {
+		return err
+	}
+	sep := headerSeparator(header)
+	names := strings.Split(header, string(sep))
+	for i := range names {
+		names[i] = strings.TrimSpace(names[i])
+	}
+	t.out.WriteString("(" + strings.Join(names, string(sep)))
+	if p.eof() {
+		return fmt.Errorf("unterminated table at pos %d", start)
+	}
+	if p.next() == ')' {
+		t.out.WriteByte(')')
+		return nil
+	}
+	t.out.WriteByte(':')
+	defer p.setCellSeparator(sep)()
+	if !t.compact {
+		t.skip()
+		t.trailing()
+		t.out.WriteByte('\n')
+	}
+	for {
+		t.skip()
+		if p.eof() {
+			return fmt.Errorf("unterminated table at pos %d", start)
+		}
+		if !t.compact {
+			t.flush(level)
+		}
+		if p.peek() == ')' {
+			p.next()
+			break
+		}
+		if !t.compact {
+			t.out.WriteString(t.indent(level))
+		}
+		if err := t.row(level, sep); err != nil {
+			return err
+		}
+		if !t.compact {
+			t.skip()
+			t.trailing()
+			t.out.WriteByte('\n')
+		}
+	}
+	t.out.WriteString(t.closing(level) + ")")
+	return nil
+}
+
+// row copies one table row and its closing ';'. A row closed by the ')'
+// of the table gets a ';' too; the ')' is left for table.
+func (t *transcoder) row(level int, sep byte) error {
+	p := t.p
+	for {
+		if err := t.cell(level + 1); err != nil {
+			return err
+		}
+		t.skip()
+		if p.eof() {
+			return fmt.Errorf("unterminated table row at pos %d", p.pos)
+		}
+		switch p.peek() {
+		case sep:
+			p.next()
+			t.out.WriteByte(sep)
+		case ';':
+			p.next()
+			t.out.WriteByte(';')
+			return nil
+		case ')':
+			t.out.WriteByte(';')
+			return nil
+		default:
+			return fmt.Errorf("expected '%c', ';' or ')' at pos %d", sep, p.pos)
+		}
+	}
+}
+
+// cell copies one table cell. Bare cells are read as the decoder reads
+// them, up to the next separator, so they may hold spaces.
+func (t *transcoder) cell(level int) error {
+	p := t.p
+	t.skip()
+	if c := p.peek(); c == '"' || c == '@' || isNestedStart(c) {
+		defer p.nestCells()()
+		return t.value(level)
+	}
+	text, err := p.readUntilAny(p.cellSeparators())
+	if err != nil {
+		return err
+	}
+	t.out.WriteString(strings.TrimSpace(text))
+	return nil
+}
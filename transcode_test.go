@@ -0,0 +1,156 @@
+package god
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type transcodeRow struct {
+	ID   int    `god:"id"`
+	Name string `god:"name"`
+}
+
+func TestTranscodeMatchesMarshal(t *testing.T) {
+	values := []interface{}{
+		map[string]interface{}{
+			"empty": "",
+			"text":  "a\nb",
+			"obj": map[string]interface{}{
+				"x": 1,
+				"y": []interface{}{1, "s", map[string]interface{}{"k": 2}, nil},
+			},
+			"rows": []transcodeRow{{1, "a"}, {2, "b, c"}},
+		},
+		Table{Header: []string{"a", "b"}, Rows: [][]string{{"1", "x"}}},
+		[]int{1, 2},
+		map[string]interface{}{},
+	}
+	for _, v := range values {
+		compact, err := Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pretty, err := MarshalBeautify(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Transcode(compact, Beautify())
+		if err != nil {
+			t.Fatalf("beautifying %s: %v", compact, err)
+		}
+		if string(got) != string(pretty) {
+			t.Errorf("Transcode(%s, Beautify()):\ngot  %s\nwant %s", compact, got, pretty)
+		}
+		got, err = Minify(pretty)
+		if err != nil {
+			t.Fatalf("minifying %s: %v", pretty, err)
+		}
+		if string(got) != string(compact) {
+			t.Errorf("Minify:\ngot  %s\nwant %s", got, compact)
+		}
+	}
+}
+
+func TestTranscodePreservesTokens(t *testing.T) {
+	src := "{ doc = \"\"\"line one\n  line two; {x}\"\"\" ;\n" +
+		"  nil = \\0 ; at = @time \"2024-01-01\" ;\n" +
+		"  t = ( a , b : 1 , Alice Smith ; \\0 , [ 1 , 2 ] ) ;\n" +
+		"  \"odd key\" = 1e3 }"
+	got, err := Minify([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{doc=\"\"\"line one\n  line two; {x}\"\"\";nil=\\0;at=@time\"2024-01-01\";" +
+		"t=(a,b:1,Alice Smith;\\0,[1,2];);\"odd key\"=1e3}"
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	pretty, err := Transcode(got, Beautify(), TrailingNewline())
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := Minify(pretty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != want {
+		t.Errorf("round trip through %s\ngot  %s\nwant %s", pretty, again, want)
+	}
+}
+
+func TestTranscodeErrors(t *testing.T) {
+	for _, src := range []string{
+		``,
+		`[1]`,
+		`{a=1`,
+		`{a=1}x`,
+		`{a 1}`,
+		`{a=[1,2}`,
+		`{a=(x:1,2`,
+		`{a="open}`,
+	} {
+		if got, err := Minify([]byte(src)); err == nil {
+			t.Errorf("%q: expected error, got %s", src, got)
+		}
+	}
+}
+
+func TestTranscodeInclude(t *testing.T) {
+	src := "{ @include  \"base.god\" ;\n  name = \"x\" }"
+	got, err := Minify([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{@include "base.god";name="x"}`; string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	pretty, err := Transcode([]byte(`{@include "base.god"}`), Beautify())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  @include \"base.god\";\n}"; string(pretty) != want {
+		t.Errorf("got  %q\nwant %q", pretty, want)
+	}
+}
+
+func TestTranscodeComments(t *testing.T) {
+	got, err := Transcode([]byte("{a=1; // note\n b=2}"), Beautify())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  a=1; // note\n  b=2;\n}"; string(got) != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+
+	src := "// head\n{ // open\n  // own\n  a = [1, // one\n 2 ] ;\n" +
+		"  t=(x,y: // hdr\n 1,2; // r1\n // between\n 3,4;);\n" +
+		"  n={ 5 // five\n}; e={ // empty\n}\n // last\n} // tail"
+	pretty, err := Transcode([]byte(src), Beautify())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []string{"head", "open", "own", "one", "hdr", "r1", "between", "five", "empty", "last", "tail"} {
+		if !strings.Contains(string(pretty), "// "+c) {
+			t.Errorf("comment %q dropped from\n%s", c, pretty)
+		}
+	}
+	var want, have interface{}
+	if err := Unmarshal([]byte(src), &want); err != nil {
+		t.Fatal(err)
+	}
+	if err := Unmarshal(pretty, &have); err != nil {
+		t.Fatalf("%v in\n%s", err, pretty)
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("got %v, want %v from\n%s", have, want, pretty)
+	}
+
+	minified, err := Minify([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{a=[1,2];t=(x,y:1,2;3,4;);n={5};e={}}"; string(minified) != want {
+		t.Errorf("got  %s\nwant %s", minified, want)
+	}
+}
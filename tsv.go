@@ -0,0 +1,98 @@
+package god
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// TableToTSV exports the table at key in a GOD document as tab-separated
+// values: a header row, then one line per row. An empty key selects a table
+// at the root, as in {(a,b:1,2;)}.
+//
+// TSV has no quoting, so backslashes, tabs, newlines and carriage returns in
+// cells are written as \\, \t, \n and \r. Cells are written as Table holds
+// them: strings without their quotes, grounded cells empty and any other
+// value as it appears in the document.
+func TableToTSV(data []byte, key string) ([]byte, error) {
+	var t Table
+	if key == "" {
+		if err := Unmarshal(data, &t); err != nil {
+			return nil, err
+		}
+	} else {
+		var doc map[string]RawMessage
+		if err := Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		raw, ok := doc[key]
+		if !ok {
+			return nil, fmt.Errorf("no table at key %q", key)
+		}
+		if err := UnmarshalValue(raw, &t); err != nil {
+			return nil, fmt.Errorf("key %q: %w", key, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	writeTSVLine(&buf, t.Header)
+	for _, row := range t.Rows {
+		writeTSVLine(&buf, row)
+	}
+	return buf.Bytes(), nil
+}
+
+// TSVToTable imports tab-separated values, whose first line is the header,
+// as a GOD document holding one root table. Escapes written by TableToTSV
+// are undone, and cells that read as numbers or booleans are written bare
+// while everything else is quoted. A row may have fewer cells than the
+// header, but not more.
+func TSVToTable(data []byte) ([]byte, error) {
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) == 0 || lines[0] == "" {
+		return nil, fmt.Errorf("tsv: missing header row")
+	}
+	var t Table
+	for i, line := range lines {
+		cells := strings.Split(strings.TrimSuffix(line, "\r"), "\t")
+		for j, cell := range cells {
+			cells[j] = unescapeTSV(cell)
+		}
+		if i == 0 {
+			for _, h := range cells {
+				if strings.TrimSpace(h) == "" || strings.ContainsAny(h, ",:;()\"\n") {
+					return nil, fmt.Errorf("tsv: invalid column name %q", h)
+				}
+			}
+			t.Header = cells
+			continue
+		}
+		if len(cells) > len(t.Header) {
+			return nil, fmt.Errorf("tsv: line %d has %d cells for %d columns", i+1, len(cells), len(t.Header))
+		}
+		t.Rows = append(t.Rows, cells)
+	}
+	return Marshal(t)
+}
+
+var (
+	tsvEscaper   = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+	tsvUnescaper = strings.NewReplacer(`\\`, `\`, `\t`, "\t", `\n`, "\n", `\r`, "\r")
+)
+
+func writeTSVLine(buf *bytes.Buffer, cells []string) {
+	for i, cell := range cells {
+		if i > 0 {
+			buf.WriteByte('\t')
+		}
+		buf.WriteString(tsvEscaper.Replace(cell))
+	}
+	buf.WriteByte('\n')
+}
+
+func unescapeTSV(s string) string {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s
+	}
+	return tsvUnescaper.Replace(s)
+}
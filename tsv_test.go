@@ -0,0 +1,82 @@
+package god
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTableToTSV(t *testing.T) {
+	doc := []byte(`{people=(name,age,note:"Alice",30,"a\tb";"Bob",25,"line1\nline2";"Carol",\0,"back\\slash";);title="staff"}`)
+	got, err := TableToTSV(doc, "people")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "name\tage\tnote\nAlice\t30\ta\\tb\nBob\t25\tline1\\nline2\nCarol\t\tback\\\\slash\n"
+	if string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if _, err := TableToTSV(doc, "missing"); err == nil {
+		t.Error("expected error for missing key")
+	}
+	if _, err := TableToTSV(doc, "title"); err == nil {
+		t.Error("expected error for a key that is not a table")
+	}
+}
+
+func TestTableToTSVRoot(t *testing.T) {
+	got, err := TableToTSV([]byte(`{(a,b:1,2;3,4;)}`), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a\tb\n1\t2\n3\t4\n"; string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTSVToTable(t *testing.T) {
+	got, err := TSVToTable([]byte("name\tage\tok\r\nAlice\t30\ttrue\r\nBob\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{(name,age,ok:"Alice",30,true;"Bob",,;)}`; string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestTSVRoundTrip(t *testing.T) {
+	tsv := "id\ttext\n1\ttab\\there\n2\tnew\\nline and back\\\\slash\n"
+	doc, err := TSVToTable([]byte(tsv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tbl Table
+	if err := Unmarshal(doc, &tbl); err != nil {
+		t.Fatalf("decoding %s: %v", doc, err)
+	}
+	if tbl.Rows[0][1] != "tab\there" || tbl.Rows[1][1] != "new\nline and back\\slash" {
+		t.Errorf("cells not unescaped: %q", tbl.Rows)
+	}
+	back, err := TableToTSV(doc, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(back) != tsv {
+		t.Errorf("round trip: got %q, want %q", back, tsv)
+	}
+}
+
+func TestTSVToTableErrors(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"a\tb\n1\t2\t3\n",
+		"a,b\tc\n1\t2\n",
+		"a\t\n1\t2\n",
+	} {
+		if _, err := TSVToTable([]byte(in)); err == nil {
+			t.Errorf("%q: expected error", in)
+		} else if !strings.HasPrefix(err.Error(), "tsv: ") {
+			t.Errorf("%q: error %q lacks tsv prefix", in, err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package god
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+var (
+	unitsMu sync.RWMutex
+	units   = make(map[reflect.Type]func(string) (reflect.Value, error))
+)
+
+// RegisterUnit makes the decoder parse numbers with a unit suffix, such as
+// timeout=30s or size=10MB, with parse when they are decoded into a T:
+//
+//	god.RegisterUnit(time.ParseDuration)
+//	god.RegisterUnit(ParseByteSize) // func(string) (ByteSize, error)
+//
+// parse is given the whole bare token or table cell, only when it is not a
+// plain number, which decodes into T as usual; quoted strings are not
+// offered to it. T must be an integer or float type. Encoding is not
+// affected, so a T is written as a plain number unless it marshals itself.
+// Like RegisterAnnotation, RegisterUnit panics if T already has a parser.
+func RegisterUnit[T any](parse func(s string) (T, error)) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if !isNumericKind(t.Kind()) {
+		panic("god: RegisterUnit needs an integer or float type, got " + t.String())
+	}
+	unitsMu.Lock()
+	defer unitsMu.Unlock()
+	if _, dup := units[t]; dup {
+		panic("god: reuse of unit parser for " + t.String())
+	}
+	units[t] = func(s string) (reflect.Value, error) {
+		v, err := parse(s)
+		return reflect.ValueOf(v), err
+	}
+}
+
+func isNumericKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// setUnit stores s in field with the unit parser registered for the type
+// of field, if there is one and s is not a plain number. It reports
+// whether it handled s.
+func setUnit(field reflect.Value, s string) (bool, error) {
+	if !isNumericKind(field.Kind()) {
+		return false, nil
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return false, nil
+	}
+	unitsMu.RLock()
+	parse := units[field.Type()]
+	unitsMu.RUnlock()
+	if parse == nil {
+		return false, nil
+	}
+	v, err := parse(s)
+	if err != nil {
+		return true, fmt.Errorf("invalid %v %q: %w", field.Type(), s, err)
+	}
+	field.Set(v)
+	return true, nil
+}
+
+// decodeUnit decodes a bare token with a unit suffix into target, see
+// setUnit. If it does not handle the value it leaves p where it was.
+func (p *parser) decodeUnit(target reflect.Value) (bool, error) {
+	if !isNumericKind(target.Kind()) || p.peek() == '"' {
+		return false, nil
+	}
+	start := p.pos
+	token, err := p.readBareToken()
+	if err != nil {
+		return false, err
+	}
+	ok := false
+	if token != "" {
+		ok, err = setUnit(target, token)
+	}
+	if !ok {
+		// The token is read again by the default decoding.
+		p.pos = start
+	}
+	return ok, err
+}
@@ -0,0 +1,101 @@
+package god
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type unitSize uint64
+
+func parseUnitSize(s string) (unitSize, error) {
+	for i, suffix := range []string{"KB", "MB", "GB"} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.ParseUint(n, 10, 64)
+			return unitSize(v) << (10 * (i + 1)), err
+		}
+	}
+	return 0, errors.New("unknown unit")
+}
+
+type unitTimeout time.Duration
+
+func init() {
+	RegisterUnit(parseUnitSize)
+	RegisterUnit(func(s string) (unitTimeout, error) {
+		d, err := time.ParseDuration(s)
+		return unitTimeout(d), err
+	})
+}
+
+type unitConfig struct {
+	Timeout unitTimeout
+	Size    unitSize
+	Limit   *unitSize
+	Plain   int
+}
+
+func TestUnitSuffix(t *testing.T) {
+	var c unitConfig
+	if err := Unmarshal([]byte(`{timeout=1m30s;size=10MB;limit=2KB;plain=3}`), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Timeout != unitTimeout(90*time.Second) || c.Size != 10<<20 || c.Limit == nil || *c.Limit != 2<<10 || c.Plain != 3 {
+		t.Errorf("got %+v", c)
+	}
+
+	// Plain numbers decode as usual.
+	if err := Unmarshal([]byte(`{timeout=5;size=1024}`), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Timeout != 5 || c.Size != 1024 {
+		t.Errorf("got %+v", c)
+	}
+}
+
+func TestUnitSuffixTable(t *testing.T) {
+	var rows []unitConfig
+	if err := Unmarshal([]byte(`{(timeout,size,limit:250ms,1GB,;2s,7,\0;)}`), &rows); err != nil {
+		t.Fatal(err)
+	}
+	want := []unitConfig{
+		{Timeout: unitTimeout(250 * time.Millisecond), Size: 1 << 30},
+		{Timeout: unitTimeout(2 * time.Second), Size: 7},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("got %+v, want %+v", rows, want)
+	}
+}
+
+func TestUnitSuffixErrors(t *testing.T) {
+	var c unitConfig
+	err := Unmarshal([]byte(`{size=10XB}`), &c)
+	if err == nil || !strings.Contains(err.Error(), "unknown unit") {
+		t.Errorf("got %v, want the parser's error", err)
+	}
+	if err := Unmarshal([]byte(`{size="10MB"}`), &c); err == nil {
+		t.Error("quoted strings should not be offered to unit parsers")
+	}
+	if err := Unmarshal([]byte(`{plain=10MB}`), &c); err == nil {
+		t.Error("types without a unit parser should reject suffixes")
+	}
+}
+
+func TestRegisterUnitPanics(t *testing.T) {
+	for name, register := range map[string]func(){
+		"duplicate":   func() { RegisterUnit(parseUnitSize) },
+		"non-numeric": func() { RegisterUnit(func(string) (string, error) { return "", nil }) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected a panic", name)
+				}
+			}()
+			register()
+		}()
+	}
+}
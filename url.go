@@ -0,0 +1,68 @@
+package god
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// MarshalURL returns the compact encoding of v with every byte outside the
+// RFC 3986 unreserved set (A-Z a-z 0-9 - . _ ~) percent-encoded, so the result
+// can be placed in a query parameter, path segment or header unchanged.
+func MarshalURL(v interface{}) (string, error) {
+	data, err := Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.Grow(len(data) * 2)
+	for _, c := range data {
+		if isUnreserved(c) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String(), nil
+}
+
+// MarshalURLBase64 returns the compact encoding of v wrapped in unpadded
+// base64url. It is usually shorter than MarshalURL for string-heavy documents.
+func MarshalURLBase64(v interface{}) (string, error) {
+	data, err := Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// UnmarshalURL decodes a string produced by MarshalURL or MarshalURLBase64
+// into v. The forms are told apart by their first character: a
+// percent-encoded document always starts with "%7B" for the root '{'. A
+// document starting with '{' has already been unescaped, as by
+// url.ParseQuery, and is decoded as it is.
+func UnmarshalURL(s string, v interface{}) error {
+	var data []byte
+	if strings.HasPrefix(s, "{") {
+		data = []byte(s)
+	} else if strings.HasPrefix(s, "%") {
+		unescaped, err := url.PathUnescape(s)
+		if err != nil {
+			return err
+		}
+		data = []byte(unescaped)
+	} else {
+		decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+		if err != nil {
+			return err
+		}
+		data = decoded
+	}
+	return Unmarshal(data, v)
+}
+
+func isUnreserved(c byte) bool {
+	return 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
@@ -0,0 +1,89 @@
+package god
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"testing"
+)
+
+func TestMarshalURLRoundTrip(t *testing.T) {
+	people := []Person{
+		{Name: "Alice & Bob", Age: 30, Address: "1 Main St; Apt {2}"},
+		{Name: "Zoë", Age: 25, Address: "a+b=c?d#e/f"},
+	}
+	for name, marshal := range map[string]func(interface{}) (string, error){
+		"percent": MarshalURL,
+		"base64":  MarshalURLBase64,
+	} {
+		s, err := marshal(people)
+		if err != nil {
+			t.Fatalf("%s: marshal error: %v", name, err)
+		}
+		for _, c := range []byte(s) {
+			if !isUnreserved(c) && c != '%' {
+				t.Errorf("%s: output contains reserved character %q: %s", name, c, s)
+			}
+		}
+
+		q, err := url.ParseQuery("d=" + s + "&other=1")
+		if err != nil {
+			t.Fatalf("%s: ParseQuery error: %v", name, err)
+		}
+		var got []Person
+		if err := UnmarshalURL(q.Get("d"), &got); err != nil {
+			t.Fatalf("%s: UnmarshalURL error: %v", name, err)
+		}
+		if len(got) != len(people) || got[0] != people[0] || got[1] != people[1] {
+			t.Errorf("%s: expected %+v, got %+v", name, people, got)
+		}
+	}
+}
+
+func TestMarshalURLSize(t *testing.T) {
+	type row struct {
+		ID    int    `god:"id" json:"id"`
+		Name  string `god:"name" json:"name"`
+		Admin bool   `god:"admin" json:"admin"`
+	}
+	rows := []row{{1, "alice", true}, {2, "bob", false}, {3, "carol", false}, {4, "dave", true}}
+
+	js, err := json.Marshal(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonB64 := base64.RawURLEncoding.EncodeToString(js)
+	percent, err := MarshalURL(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64, err := MarshalURLBase64(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("json+base64=%d god+percent=%d god+base64=%d", len(jsonB64), len(percent), len(b64))
+	if len(b64) >= len(jsonB64) {
+		t.Errorf("expected base64url GOD (%d) to be shorter than base64url JSON (%d)", len(b64), len(jsonB64))
+	}
+}
+
+func TestUnmarshalURLLiteralPercent(t *testing.T) {
+	want := Person{Name: "100%", Address: "x%41y"}
+	s, err := MarshalURL(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := url.ParseQuery("d=" + s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, in := range []string{s, q.Get("d")} {
+		var got Person
+		if err := UnmarshalURL(in, &got); err != nil {
+			t.Fatalf("%s: %v", in, err)
+		}
+		if got != want {
+			t.Errorf("%s: got %+v, want %+v", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package god
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+)
+
+// A Validator checks a value once it has been decoded. Unmarshal calls
+// the ValidateGOD method of every struct it decodes that implements
+// Validator, the root, fields, list elements, map values and table rows
+// alike, once the struct is fully populated, so that nested structs are
+// validated before the structs that hold them. An error stops decoding
+// and is returned as a *ValidationError. UnmarshalOptions.SkipValidation
+// turns the calls off.
+type Validator interface {
+	ValidateGOD() error
+}
+
+var validatorType = reflect.TypeOf((*Validator)(nil)).Elem()
+
+// ValidationError is returned by Unmarshal when the ValidateGOD method of
+// a decoded struct fails.
+type ValidationError struct {
+	// Path locates the struct, as in "employees[3]"; it is empty for the
+	// root.
+	Path string
+
+	// Err is the error ValidateGOD returned.
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// validate calls the ValidateGOD method of the decoded struct v, if it has
+// one. The path of the error is filled in by atKey and atIndex as it is
+// returned through the values that hold v.
+func (p *parser) validate(v reflect.Value) error {
+	if p.skipValidation {
+		return nil
+	}
+	var val Validator
+	if v.CanAddr() && reflect.PtrTo(v.Type()).Implements(validatorType) {
+		val = v.Addr().Interface().(Validator)
+	} else if v.Type().Implements(validatorType) && v.CanInterface() {
+		val = v.Interface().(Validator)
+	} else {
+		return nil
+	}
+	if err := val.ValidateGOD(); err != nil {
+		return &ValidationError{Err: err}
+	}
+	return nil
+}
+
+// atKey prefixes the path of the *ValidationError in err, if any, with
+// the object key or table column key.
+func atKey(err error, key string) error {
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		if ve.Path != "" && ve.Path[0] != '[' {
+			key += "."
+		}
+		ve.Path = key + ve.Path
+	}
+	return err
+}
+
+// atIndex prefixes the path of the *ValidationError in err, if any, with
+// the list or table row index i.
+func atIndex(err error, i int) error {
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		index := "[" + strconv.Itoa(i) + "]"
+		if ve.Path != "" && ve.Path[0] != '[' {
+			index += "."
+		}
+		ve.Path = index + ve.Path
+	}
+	return err
+}
@@ -0,0 +1,109 @@
+package god
+
+import (
+	"errors"
+	"testing"
+)
+
+var errAgePositive = errors.New("age must be positive")
+
+type staffMember struct {
+	Name string
+	Age  int
+}
+
+func (s staffMember) ValidateGOD() error {
+	if s.Age <= 0 {
+		return errAgePositive
+	}
+	return nil
+}
+
+type staffTeam struct {
+	Lead    *staffMember
+	Members []staffMember
+}
+
+func (t *staffTeam) ValidateGOD() error {
+	if t.Lead == nil {
+		return errors.New("team needs a lead")
+	}
+	return nil
+}
+
+type staffCompany struct {
+	Employees []staffMember
+	Teams     map[string]staffTeam
+	Boss      staffMember
+}
+
+func TestValidateGOD(t *testing.T) {
+	ok := `{employees=(name,age:"a",30;"b",41;);teams={core={lead={name="c";age=50};members=[{name="d";age=2}]}};boss={name="e";age=60}}`
+	var c staffCompany
+	if err := Unmarshal([]byte(ok), &c); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		doc, path, msg string
+	}{
+		{`{employees=(name,age:"a",30;"b",0;"c",-1;)}`, "employees[1]", "age must be positive"},
+		{`{boss={name="e"}}`, "boss", "age must be positive"},
+		{`{teams={core={lead={name="c";age=50};members=[{name="d";age=2},{name="x"}]}}}`, "teams.core.members[1]", "age must be positive"},
+		{`{teams={core={lead={name="c";age=-5}}}}`, "teams.core.lead", "age must be positive"},
+		{`{teams={core={members=[]}}}`, "teams.core", "team needs a lead"},
+	}
+	for _, tt := range tests {
+		var c staffCompany
+		err := Unmarshal([]byte(tt.doc), &c)
+		var ve *ValidationError
+		if !errors.As(err, &ve) {
+			t.Errorf("%s: got %v, want a *ValidationError", tt.doc, err)
+			continue
+		}
+		if ve.Path != tt.path || ve.Err.Error() != tt.msg {
+			t.Errorf("%s: got path %q error %q, want %q %q", tt.doc, ve.Path, ve.Err, tt.path, tt.msg)
+		}
+		if want := tt.path + ": " + tt.msg; err.Error() != want {
+			t.Errorf("got %q, want %q", err, want)
+		}
+	}
+
+	var c2 staffCompany
+	err := Unmarshal([]byte(`{employees=(name,age:"a",30;"b",0;)}`), &c2)
+	if !errors.Is(err, errAgePositive) {
+		t.Errorf("got %v, want it to wrap errAgePositive", err)
+	}
+}
+
+func TestValidateGODRootAndPointers(t *testing.T) {
+	var m staffMember
+	err := Unmarshal([]byte(`{name="a";age=0}`), &m)
+	var ve *ValidationError
+	if !errors.As(err, &ve) || ve.Path != "" || err.Error() != "age must be positive" {
+		t.Errorf("got %v", err)
+	}
+
+	var rows []*staffMember
+	err = Unmarshal([]byte(`{[{name="a";age=1},{name="b"}]}`), &rows)
+	if !errors.As(err, &ve) || ve.Path != "[1]" {
+		t.Errorf("got %v", err)
+	}
+
+	var table []staffMember
+	err = Unmarshal([]byte(`{(name,age:"a",1;"b",2;"c",;)}`), &table)
+	if err == nil || err.Error() != "[2]: age must be positive" {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestSkipValidation(t *testing.T) {
+	doc := []byte(`{employees=(name,age:"a",0;);boss={name="e"}}`)
+	var c staffCompany
+	if err := UnmarshalWith(doc, &c, SkipValidation()); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Employees) != 1 || c.Boss.Name != "e" {
+		t.Errorf("got %+v", c)
+	}
+}
@@ -0,0 +1,70 @@
+package god
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshalValue(t *testing.T) {
+	var people []Person
+	if err := UnmarshalValue([]byte(`(name,age:"a",1;"b",2;)`), &people); err != nil {
+		t.Fatal(err)
+	}
+	if want := []Person{{Name: "a", Age: 1}, {Name: "b", Age: 2}}; !reflect.DeepEqual(people, want) {
+		t.Errorf("table: expected %+v, got %+v", want, people)
+	}
+
+	var nums []int
+	if err := UnmarshalValue([]byte(" [1,2,3]\n"), &nums); err != nil || !reflect.DeepEqual(nums, []int{1, 2, 3}) {
+		t.Errorf("list: %v, %v", nums, err)
+	}
+
+	var s string
+	if err := UnmarshalValue([]byte(`"""a
+b"""`), &s); err != nil || s != "a\nb" {
+		t.Errorf("string: %q, %v", s, err)
+	}
+
+	var p Person
+	if err := UnmarshalValue([]byte(`{name="x";age=3}`), &p); err != nil || p.Name != "x" || p.Age != 3 {
+		t.Errorf("object: %+v, %v", p, err)
+	}
+
+	var generic interface{}
+	if err := UnmarshalValue([]byte(`[1,"two",true]`), &generic); err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{int64(1), "two", true}; !reflect.DeepEqual(generic, want) {
+		t.Errorf("generic: expected %#v, got %#v", want, generic)
+	}
+
+	for _, doc := range []string{``, `[1,2] [3]`, `"a",1`} {
+		var v interface{}
+		if err := UnmarshalValue([]byte(doc), &v); err == nil {
+			t.Errorf("%q: expected error, got %#v", doc, v)
+		}
+	}
+}
+
+func TestMarshalValue(t *testing.T) {
+	for _, v := range []interface{}{"a\nb", []int{1, 2}, []Person{{Name: "a", Age: 1}}, map[string]int{"x": 1}, 2.5} {
+		data, err := MarshalValue(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := reflect.New(reflect.TypeOf(v))
+		if err := UnmarshalValue(data, got.Interface()); err != nil {
+			t.Fatalf("%s: %v", data, err)
+		}
+		if !reflect.DeepEqual(got.Elem().Interface(), v) {
+			t.Errorf("%s: got %#v, want %#v", data, got.Elem().Interface(), v)
+		}
+	}
+}
+
+func TestMarshalValueNil(t *testing.T) {
+	data, err := MarshalValue(nil)
+	if err != nil || len(data) != 0 {
+		t.Errorf("expected no output, got %q, %v", data, err)
+	}
+}
@@ -0,0 +1,154 @@
+package god
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+)
+
+// Maps from strings to lists of strings, such as url.Values and
+// http.Header, hold flat key-value data with more than one value per key.
+// They decode from an object whose values are scalars, each becoming a
+// list of one, or lists of scalars, each element in its string form:
+//
+//	{q="go";page=2;tag=["a","b"]}  // q=go&page=2&tag=a&tag=b
+//
+// Numbers keep their text and booleans are "true" or "false". Nested
+// objects are an error unless UnmarshalOptions.FlattenKeys is set, and
+// tables always are. url.Values is written the same way, with a list only
+// for keys with more than one value.
+
+var urlValuesType = reflect.TypeOf(url.Values(nil))
+
+// isValuesMap reports whether t is a map from strings to lists of strings.
+func isValuesMap(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && t.Key().Kind() == reflect.String &&
+		t.Elem().Kind() == reflect.Slice && t.Elem().Elem().Kind() == reflect.String &&
+		!isNumberType(t.Elem().Elem())
+}
+
+// urlValuesAsMap returns the url.Values in v as a map whose keys with a
+// single value hold that value rather than a list of one.
+func urlValuesAsMap(v reflect.Value) reflect.Value {
+	m := make(map[string]interface{}, v.Len())
+	for key, vals := range v.Interface().(url.Values) {
+		if len(vals) == 1 {
+			m[key] = vals[0]
+		} else {
+			m[key] = vals
+		}
+	}
+	return reflect.ValueOf(m)
+}
+
+// decodeValuesEntry decodes the value of key into the values map target,
+// see isValuesMap. A grounded value stores a nil list.
+func (p *parser) decodeValuesEntry(target reflect.Value, key string) error {
+	p.skipSpaces()
+	if p.readAnnotation() != "" {
+		p.skipSpaces()
+	}
+	list := reflect.Zero(target.Type().Elem())
+	switch c := p.peek(); c {
+	case ';', '}':
+	case '{':
+		if !p.flattenKeys {
+			return fmt.Errorf("cannot decode an object into %v without FlattenKeys", target.Type())
+		}
+		return p.decodeValuesObject(target, key)
+	case '(':
+		return fmt.Errorf("cannot decode a table into %v", target.Type())
+	case '[':
+		p.next() // consume '['
+		list = reflect.MakeSlice(target.Type().Elem(), 0, 0)
+		for p.skipSpaces(); p.peek() != ']'; p.skipSpaces() {
+			if p.eof() {
+				return errors.New("expected ']' at end of list")
+			}
+			s, err := p.readValuesScalar()
+			if err != nil {
+				return err
+			}
+			list = reflect.Append(list, reflect.ValueOf(s).Convert(list.Type().Elem()))
+			p.skipSpaces()
+			if p.peek() == ',' {
+				p.next()
+			} else if p.peek() != ']' {
+				return fmt.Errorf("expected ',' or ']' at pos %d, got '%c'", p.pos, p.peek())
+			}
+		}
+		p.next() // consume ']'
+	default:
+		if c == '\\' && p.peekAhead(2) == `\0` {
+			p.pos += 2
+			break
+		}
+		s, err := p.readValuesScalar()
+		if err != nil {
+			return err
+		}
+		list = reflect.MakeSlice(target.Type().Elem(), 1, 1)
+		list.Index(0).SetString(s)
+	}
+	target.SetMapIndex(reflect.ValueOf(key).Convert(target.Type().Key()), list)
+	return nil
+}
+
+// decodeValuesObject decodes a nested object into the values map target
+// under keys prefixed by prefix and a dot.
+func (p *parser) decodeValuesObject(target reflect.Value, prefix string) error {
+	p.next() // consume '{'
+	p.depth++
+	defer func() { p.depth-- }()
+	for p.skipSpaces(); p.peek() != '}'; p.skipSpaces() {
+		if p.eof() {
+			return errors.New("expected '}' at end of object")
+		}
+		if p.peek() == ';' {
+			p.next()
+			continue
+		}
+		key, _, err := p.readKey()
+		if err != nil {
+			return err
+		}
+		p.skipSpaces()
+		if p.peek() != '=' {
+			return fmt.Errorf("expected '=' after key '%s', got '%c' at position %d", key, p.peek(), p.pos)
+		}
+		p.next() // consume '='
+		if err := p.decodeValuesEntry(target, prefix+"."+key); err != nil {
+			return err
+		}
+	}
+	p.next() // consume '}'
+	return nil
+}
+
+// readValuesScalar reads a string, number, boolean or \0, which is "", and
+// returns its string form.
+func (p *parser) readValuesScalar() (string, error) {
+	p.skipSpaces()
+	if p.readAnnotation() != "" {
+		p.skipSpaces()
+	}
+	switch c := p.peek(); c {
+	case '"':
+		return parseStringValue(p)
+	case '{', '[', '(':
+		return "", fmt.Errorf("cannot decode a nested '%c' as a string at pos %d", c, p.pos)
+	}
+	token, err := p.readBareToken()
+	if err != nil {
+		return "", err
+	}
+	if token == `\0` {
+		return "", nil
+	}
+	if _, err := strconv.ParseFloat(token, 64); err != nil && token != "true" && token != "false" {
+		return "", fmt.Errorf("invalid value %q", token)
+	}
+	return token, nil
+}
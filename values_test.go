@@ -0,0 +1,106 @@
+package god
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const formDoc = `{q="go lang";page=2;tags=["a","b",3];debug=true;empty=;none=\0}`
+
+func TestUnmarshalURLValues(t *testing.T) {
+	var v url.Values
+	if err := Unmarshal([]byte(formDoc), &v); err != nil {
+		t.Fatal(err)
+	}
+	want := url.Values{
+		"q":     {"go lang"},
+		"page":  {"2"},
+		"tags":  {"a", "b", "3"},
+		"debug": {"true"},
+		"empty": nil,
+		"none":  nil,
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("got %v, want %v", v, want)
+	}
+
+	var m map[string][]string
+	if err := Unmarshal([]byte(formDoc), &m); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(url.Values(m), want) {
+		t.Errorf("map[string][]string got %v, want %v", m, want)
+	}
+
+	var h http.Header
+	if err := Unmarshal([]byte(`{Accept=["text/plain","text/html"];"X-Id"=7}`), &h); err != nil {
+		t.Fatal(err)
+	}
+	if h["Accept"][1] != "text/html" || h["X-Id"][0] != "7" {
+		t.Errorf("got %v", h)
+	}
+}
+
+func TestURLValuesRoundTrip(t *testing.T) {
+	in := url.Values{"q": {"go"}, "tag": {"a", "b"}, "page": {"2"}}
+	out, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{page="2";q="go";tag=["a","b"]}`; string(out) != want {
+		t.Errorf("got %s, want %s", out, want)
+	}
+	var back url.Values
+	if err := Unmarshal(out, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, in) || back.Encode() != in.Encode() {
+		t.Errorf("got %v, want %v", back, in)
+	}
+
+	// Nested, and pretty.
+	wrapped := struct{ Form url.Values }{in}
+	out, err = MarshalBeautify(wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var backWrapped struct{ Form url.Values }
+	if err := Unmarshal(out, &backWrapped); err != nil || !reflect.DeepEqual(backWrapped, wrapped) {
+		t.Errorf("got %v, %v from\n%s", backWrapped, err, out)
+	}
+}
+
+func TestURLValuesNested(t *testing.T) {
+	doc := []byte(`{user={name="Ann";roles=["x","y"];addr={city="Oslo"}};id=1}`)
+	var v url.Values
+	err := Unmarshal(doc, &v)
+	if err == nil || !strings.Contains(err.Error(), "FlattenKeys") {
+		t.Errorf("got %v, want an error naming FlattenKeys", err)
+	}
+
+	v = nil
+	if err := UnmarshalWith(doc, &v, FlattenKeys()); err != nil {
+		t.Fatal(err)
+	}
+	want := url.Values{"user.name": {"Ann"}, "user.roles": {"x", "y"}, "user.addr.city": {"Oslo"}, "id": {"1"}}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("got %v, want %v", v, want)
+	}
+}
+
+func TestURLValuesInvalid(t *testing.T) {
+	for _, doc := range []string{
+		`{a=[[1]]}`,
+		`{a=[{b=1}]}`,
+		`{a=(x:1;)}`,
+		`{a=bare}`,
+	} {
+		var v url.Values
+		if err := UnmarshalWith([]byte(doc), &v, FlattenKeys()); err == nil {
+			t.Errorf("%s: expected an error, got %v", doc, v)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package god
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshalSurroundingWhitespace(t *testing.T) {
+	for _, pad := range []string{"", "\n", " \t\r\n  ", "\n\n\t"} {
+		var p Person
+		if err := Unmarshal([]byte(pad+`{name="Alice";age=30}`+pad), &p); err != nil {
+			t.Errorf("struct %q: %v", pad, err)
+		} else if p.Name != "Alice" || p.Age != 30 {
+			t.Errorf("struct %q: got %+v", pad, p)
+		}
+
+		var m map[string]int
+		if err := Unmarshal([]byte(pad+`{a=1;b=2}`+pad), &m); err != nil {
+			t.Errorf("map %q: %v", pad, err)
+		} else if !reflect.DeepEqual(m, map[string]int{"a": 1, "b": 2}) {
+			t.Errorf("map %q: got %v", pad, m)
+		}
+
+		var people []Person
+		if err := Unmarshal([]byte(pad+`{ (name,age:"a",1;) }`+pad), &people); err != nil {
+			t.Errorf("table %q: %v", pad, err)
+		} else if !reflect.DeepEqual(people, []Person{{Name: "a", Age: 1}}) {
+			t.Errorf("table %q: got %+v", pad, people)
+		}
+
+		var iv interface{}
+		if err := Unmarshal([]byte(pad+`{ "x" }`+pad), &iv); err != nil {
+			t.Errorf("interface %q: %v", pad, err)
+		} else if iv != "x" {
+			t.Errorf("interface %q: got %#v", pad, iv)
+		}
+
+		strict := UnmarshalOptions{DisallowTrailingData: true}
+		if err := strict.Unmarshal([]byte(pad+`{name="Bob"}`+pad), &p); err != nil {
+			t.Errorf("strict %q: %v", pad, err)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+// Package wireguard converts WireGuard configuration files to and from GOD.
+//
+// The [Interface] section becomes a nested object and the repeated [Peer]
+// sections become a table, so a typical config looks like:
+//
+//	{
+//	  interface={
+//	    privateKey="yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=";
+//	    listenPort=51820;
+//	    address="10.0.0.1/24";
+//	  };
+//	  peers=(publicKey,presharedKey,allowedIPs,endpoint,persistentKeepalive:
+//	    "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=",,"10.0.0.2/32",,25;
+//	  );
+//	}
+package wireguard
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/vinayakgupta29/god"
+)
+
+// Config is a parsed WireGuard configuration.
+type Config struct {
+	Interface Interface `god:"interface"`
+	Peers     []Peer    `god:"peers"`
+}
+
+// Interface holds the keys of the [Interface] section, including the
+// wg-quick extensions.
+type Interface struct {
+	PrivateKey string   `god:"privateKey" wg:"PrivateKey"`
+	ListenPort int      `god:"listenPort" wg:"ListenPort"`
+	FwMark     string   `god:"fwMark" wg:"FwMark"`
+	Address    string   `god:"address" wg:"Address,list"`
+	DNS        string   `god:"dns" wg:"DNS,list"`
+	MTU        int      `god:"mtu" wg:"MTU"`
+	Table      string   `god:"table" wg:"Table"`
+	PreUp      []string `god:"preUp" wg:"PreUp"`
+	PostUp     []string `god:"postUp" wg:"PostUp"`
+	PreDown    []string `god:"preDown" wg:"PreDown"`
+	PostDown   []string `god:"postDown" wg:"PostDown"`
+	SaveConfig bool     `god:"saveConfig" wg:"SaveConfig"`
+}
+
+// Peer holds the keys of a [Peer] section.
+type Peer struct {
+	PublicKey           string `god:"publicKey" wg:"PublicKey"`
+	PresharedKey        string `god:"presharedKey" wg:"PresharedKey"`
+	AllowedIPs          string `god:"allowedIPs" wg:"AllowedIPs,list"`
+	Endpoint            string `god:"endpoint" wg:"Endpoint"`
+	PersistentKeepalive int    `god:"persistentKeepalive" wg:"PersistentKeepalive"`
+}
+
+// FromWireguard parses a WireGuard configuration file and returns it as a
+// beautified GOD document.
+func FromWireguard(data []byte) ([]byte, error) {
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return god.MarshalBeautify(cfg)
+}
+
+// ToWireguard converts a GOD document produced by FromWireguard (or written
+// by hand in the same shape) back into a WireGuard configuration file.
+func ToWireguard(data []byte) ([]byte, error) {
+	var cfg Config
+	if err := god.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	return Format(&cfg), nil
+}
+
+// Parse reads a WireGuard configuration file. Comments start with '#' and
+// run to the end of the line; section and key names are case-insensitive.
+func Parse(data []byte) (*Config, error) {
+	cfg := &Config{}
+	var section reflect.Value
+	seenInterface := false
+	seen := make(map[string]bool)
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; sc.Scan(); lineNo++ {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			seen = make(map[string]bool)
+			switch name := strings.TrimSpace(line[1 : len(line)-1]); strings.ToLower(name) {
+			case "interface":
+				if seenInterface {
+					return nil, fmt.Errorf("line %d: duplicate [Interface] section", lineNo)
+				}
+				seenInterface = true
+				section = reflect.ValueOf(&cfg.Interface).Elem()
+			case "peer":
+				cfg.Peers = append(cfg.Peers, Peer{})
+				section = reflect.ValueOf(&cfg.Peers[len(cfg.Peers)-1]).Elem()
+			default:
+				return nil, fmt.Errorf("line %d: unknown section [%s]", lineNo, name)
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected 'Key = Value'", lineNo)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if !section.IsValid() {
+			return nil, fmt.Errorf("line %d: key %q outside of a section", lineNo, key)
+		}
+		if err := setKey(section, key, value, seen); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func setKey(section reflect.Value, key, value string, seen map[string]bool) error {
+	t := section.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, list := wgTag(t.Field(i))
+		if !strings.EqualFold(name, key) {
+			continue
+		}
+		field := section.Field(i)
+		switch field.Kind() {
+		case reflect.Slice:
+			field.Set(reflect.Append(field, reflect.ValueOf(value)))
+			return nil
+		case reflect.String:
+			if seen[name] && list {
+				field.SetString(field.String() + ", " + value)
+				return nil
+			}
+		}
+		if seen[name] {
+			return fmt.Errorf("duplicate key %s", name)
+		}
+		seen[name] = true
+
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Int:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			field.SetInt(int64(n))
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			field.SetBool(b)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown key %s", key)
+}
+
+// Format writes cfg in WireGuard configuration syntax. Zero-valued keys are
+// omitted.
+func Format(cfg *Config) []byte {
+	var b bytes.Buffer
+	b.WriteString("[Interface]\n")
+	formatSection(&b, reflect.ValueOf(cfg.Interface))
+	for _, peer := range cfg.Peers {
+		b.WriteString("\n[Peer]\n")
+		formatSection(&b, reflect.ValueOf(peer))
+	}
+	return b.Bytes()
+}
+
+func formatSection(b *bytes.Buffer, section reflect.Value) {
+	t := section.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _ := wgTag(t.Field(i))
+		field := section.Field(i)
+		switch field.Kind() {
+		case reflect.Slice:
+			for j := 0; j < field.Len(); j++ {
+				fmt.Fprintf(b, "%s = %s\n", name, field.Index(j).String())
+			}
+		case reflect.String:
+			if field.String() != "" {
+				fmt.Fprintf(b, "%s = %s\n", name, field.String())
+			}
+		case reflect.Int:
+			if field.Int() != 0 {
+				fmt.Fprintf(b, "%s = %d\n", name, field.Int())
+			}
+		case reflect.Bool:
+			if field.Bool() {
+				fmt.Fprintf(b, "%s = true\n", name)
+			}
+		}
+	}
+}
+
+func wgTag(f reflect.StructField) (name string, list bool) {
+	name, opt, _ := strings.Cut(f.Tag.Get("wg"), ",")
+	return name, opt == "list"
+}
@@ -0,0 +1,99 @@
+package wireguard
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sampleConfig = `# wg0 on the hub
+[Interface]
+PrivateKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=
+ListenPort = 51820
+Address = 10.0.0.1/24
+Address = fd00::1/64
+PostUp = iptables -A FORWARD -i %i -j ACCEPT
+PostUp = iptables -t nat -A POSTROUTING -o eth0 -j MASQUERADE
+
+[Peer] # laptop
+PublicKey = xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=
+AllowedIPs = 10.0.0.2/32
+
+[peer]
+publickey = TrMvSoP4jYQlY6RIzBgbssQqY3vxI2Pi+y71lOWWXX0=
+PresharedKey = /UwcSPg38hW/D9Y3tcS1FOV0K1wuURMbS0sesJEP5ak=
+AllowedIPs = 10.0.0.3/32, 10.0.0.4/32
+Endpoint = 192.95.5.69:51820
+PersistentKeepalive = 25
+`
+
+var sampleParsed = &Config{
+	Interface: Interface{
+		PrivateKey: "yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=",
+		ListenPort: 51820,
+		Address:    "10.0.0.1/24, fd00::1/64",
+		PostUp: []string{
+			"iptables -A FORWARD -i %i -j ACCEPT",
+			"iptables -t nat -A POSTROUTING -o eth0 -j MASQUERADE",
+		},
+	},
+	Peers: []Peer{
+		{PublicKey: "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=", AllowedIPs: "10.0.0.2/32"},
+		{
+			PublicKey:           "TrMvSoP4jYQlY6RIzBgbssQqY3vxI2Pi+y71lOWWXX0=",
+			PresharedKey:        "/UwcSPg38hW/D9Y3tcS1FOV0K1wuURMbS0sesJEP5ak=",
+			AllowedIPs:          "10.0.0.3/32, 10.0.0.4/32",
+			Endpoint:            "192.95.5.69:51820",
+			PersistentKeepalive: 25,
+		},
+	},
+}
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse([]byte(sampleConfig))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, sampleParsed) {
+		t.Errorf("expected %+v, got %+v", sampleParsed, cfg)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	doc, err := FromWireguard([]byte(sampleConfig))
+	if err != nil {
+		t.Fatalf("FromWireguard error: %v", err)
+	}
+	if !strings.Contains(string(doc), "peers=(publicKey,presharedKey,allowedIPs,endpoint,persistentKeepalive:") {
+		t.Errorf("expected peers to be encoded as a table, got:\n%s", doc)
+	}
+
+	wg, err := ToWireguard(doc)
+	if err != nil {
+		t.Fatalf("ToWireguard error: %v\n%s", err, doc)
+	}
+	cfg, err := Parse(wg)
+	if err != nil {
+		t.Fatalf("Parse of regenerated config error: %v\n%s", err, wg)
+	}
+	if !reflect.DeepEqual(cfg, sampleParsed) {
+		t.Errorf("round trip mismatch:\n%s\nexpected %+v\ngot      %+v", wg, sampleParsed, cfg)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := map[string]string{
+		"outside section":   "PrivateKey = abc\n",
+		"unknown section":   "[Server]\n",
+		"unknown key":       "[Interface]\nColour = blue\n",
+		"duplicate key":     "[Interface]\nListenPort = 1\nListenPort = 2\n",
+		"duplicate section": "[Interface]\n[Interface]\n",
+		"bad number":        "[Interface]\nListenPort = many\n",
+		"missing equals":    "[Interface]\nListenPort\n",
+	}
+	for name, input := range cases {
+		if _, err := Parse([]byte(input)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package god
+
+import (
+	"testing"
+)
+
+type contact struct {
+	Owner   Person `god:"owner"`
+	Backup  Person `god:"backup"`
+	Comment string `god:"comment"`
+}
+
+func TestZeroStructIsGrounded(t *testing.T) {
+	v := contact{Owner: Person{Name: "Alice"}}
+	encoded, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{owner={name="Alice";age=;addr=};backup=;comment=}`
+	if string(encoded) != want {
+		t.Errorf("expected %s, got %s", want, encoded)
+	}
+
+	decoded := contact{Backup: Person{Name: "stale"}}
+	if err := Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if decoded != v {
+		t.Errorf("expected %+v, got %+v", v, decoded)
+	}
+}
+
+func TestZeroStructInMap(t *testing.T) {
+	encoded, err := Marshal(map[string]Person{"empty": {}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(encoded) != `{empty=}` {
+		t.Errorf("expected {empty=}, got %s", encoded)
+	}
+}
+
+func TestZeroRootIsStillAnObject(t *testing.T) {
+	for _, v := range []interface{}{Person{}, map[string]int{}} {
+		encoded, err := Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if encoded[0] != '{' || encoded[len(encoded)-1] != '}' {
+			t.Errorf("%T: expected an object root, got %q", v, encoded)
+		}
+	}
+}